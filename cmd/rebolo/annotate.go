@@ -0,0 +1,327 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/inflect"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/schema"
+)
+
+const (
+	annotationStart = "// == Schema Information"
+	annotationEnd   = "// == End Schema Information"
+)
+
+// runAnnotate writes schema comments into the model files in dir.
+// With check set, files are left untouched and an error is returned if any is stale.
+func runAnnotate(dir string, check bool) error {
+	database, config, err := connectDatabase()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	inspector, err := schema.NewInspector(database.DB().(*sql.DB), config.Database.Driver)
+	if err != nil {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no model files found in %s", dir)
+	}
+
+	ctx := context.Background()
+	stale := 0
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		out, tables, err := annotateSource(ctx, inspector, src)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		if bytes.Equal(src, out) {
+			fmt.Printf("   ✓ %s is up to date\n", file)
+			continue
+		}
+
+		stale++
+		if check {
+			fmt.Printf("   ❌ %s is out of date\n", file)
+			continue
+		}
+
+		if err := os.WriteFile(file, out, 0644); err != nil {
+			return err
+		}
+		if len(tables) == 0 {
+			fmt.Printf("   ✓ %s annotation removed\n", file)
+		} else {
+			fmt.Printf("   ✓ %s annotated (%s)\n", file, strings.Join(tables, ", "))
+		}
+	}
+
+	if check && stale > 0 {
+		return fmt.Errorf("%d model file(s) out of date, run 'rebolo annotate'", stale)
+	}
+	return nil
+}
+
+// annotateSource removes existing schema blocks and adds a fresh one above
+// every struct that maps to a table. It returns the new source and the tables annotated.
+func annotateSource(ctx context.Context, inspector *schema.Inspector, src []byte) ([]byte, []string, error) {
+	lines := stripAnnotations(strings.Split(string(src), "\n"))
+	stripped := []byte(strings.Join(lines, "\n"))
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", stripped, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existing, err := inspector.Tables(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	known := make(map[string]bool, len(existing))
+	for _, t := range existing {
+		known[t] = true
+	}
+
+	tableNames := modelTableNames(file)
+
+	type insertion struct {
+		line  int // 1-based line the block goes above
+		block []string
+	}
+	var inserts []insertion
+	var annotated []string
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if _, ok := ts.Type.(*ast.StructType); !ok {
+				continue
+			}
+
+			table := ""
+			for _, candidate := range tableCandidates(ts.Name.Name, tableNames) {
+				if known[candidate] {
+					table = candidate
+					break
+				}
+			}
+			if table == "" {
+				continue
+			}
+
+			info, err := inspector.Table(ctx, table)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			// Grouped declarations carry the doc on the spec
+			pos, doc := gen.Pos(), gen.Doc
+			if gen.Lparen.IsValid() {
+				pos, doc = ts.Pos(), ts.Doc
+			}
+
+			line := fset.Position(pos).Line
+			indent := leadingSpace(lines[line-1])
+			block := formatAnnotation(info, indent)
+			if doc != nil {
+				block = append([]string{indent + "//"}, block...)
+			}
+
+			inserts = append(inserts, insertion{line: line, block: block})
+			annotated = append(annotated, table)
+		}
+	}
+
+	// Insert from the bottom so earlier line numbers stay valid
+	for i := len(inserts) - 1; i >= 0; i-- {
+		at := inserts[i].line - 1
+		rest := append([]string{}, lines[at:]...)
+		lines = append(append(lines[:at], inserts[i].block...), rest...)
+	}
+
+	out, err := format.Source([]byte(strings.Join(lines, "\n")))
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, annotated, nil
+}
+
+// stripAnnotations removes schema blocks, including the separator line
+// that joins a block to a doc comment above it
+func stripAnnotations(lines []string) []string {
+	result := make([]string, 0, len(lines))
+	inBlock := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == annotationStart:
+			inBlock = true
+			if n := len(result); n > 0 && strings.TrimSpace(result[n-1]) == "//" {
+				result = result[:n-1]
+			}
+		case trimmed == annotationEnd:
+			inBlock = false
+		case !inBlock:
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// formatAnnotation renders the schema comment block for a table
+func formatAnnotation(table *schema.Table, indent string) []string {
+	foreign := make(map[string]bool, len(table.ForeignKeys))
+	for _, fk := range table.ForeignKeys {
+		foreign[fk.Column] = true
+	}
+
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Column\tType\tNull\tDefault\tKey")
+	for _, col := range table.Columns {
+		null := "NO"
+		if col.Nullable {
+			null = "YES"
+		}
+		var keys []string
+		if col.PrimaryKey {
+			keys = append(keys, "PK")
+		}
+		if foreign[col.Name] {
+			keys = append(keys, "FK")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", col.Name, col.Type, null, col.Default.String, strings.Join(keys, ","))
+	}
+	tw.Flush()
+
+	block := []string{annotationStart, "//", "// Table: " + table.Name, "//"}
+	block = append(block, codeLines(buf.String())...)
+
+	if len(table.Indexes) > 0 {
+		buf.Reset()
+		tw = tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		for _, idx := range table.Indexes {
+			var flags []string
+			if idx.Primary {
+				flags = append(flags, "PRIMARY")
+			} else if idx.Unique {
+				flags = append(flags, "UNIQUE")
+			}
+			fmt.Fprintf(tw, "%s\t(%s)\t%s\n", idx.Name, strings.Join(idx.Columns, ", "), strings.Join(flags, " "))
+		}
+		tw.Flush()
+		block = append(block, "//", "// Indexes:", "//")
+		block = append(block, codeLines(buf.String())...)
+	}
+
+	if len(table.ForeignKeys) > 0 {
+		block = append(block, "//", "// Foreign keys:", "//")
+		for _, fk := range table.ForeignKeys {
+			ref := fk.RefTable
+			if fk.RefColumn != "" {
+				ref += "(" + fk.RefColumn + ")"
+			}
+			block = append(block, "//\t"+fk.Column+" -> "+ref)
+		}
+	}
+
+	block = append(block, "//", annotationEnd)
+
+	for i := range block {
+		block[i] = indent + block[i]
+	}
+	return block
+}
+
+// codeLines turns tabwriter output into indented comment lines
+func codeLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		lines = append(lines, "//\t"+strings.TrimRight(line, " "))
+	}
+	return lines
+}
+
+// modelTableNames collects explicit table names from TableName() methods
+// that return a string literal
+func modelTableNames(file *ast.File) map[string]string {
+	names := make(map[string]string)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != "TableName" || fn.Recv == nil || len(fn.Recv.List) == 0 || fn.Body == nil {
+			continue
+		}
+		if len(fn.Body.List) != 1 {
+			continue
+		}
+		ret, ok := fn.Body.List[0].(*ast.ReturnStmt)
+		if !ok || len(ret.Results) != 1 {
+			continue
+		}
+		lit, ok := ret.Results[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			continue
+		}
+		value, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			continue
+		}
+
+		recv := fn.Recv.List[0].Type
+		if star, ok := recv.(*ast.StarExpr); ok {
+			recv = star.X
+		}
+		if ident, ok := recv.(*ast.Ident); ok {
+			names[ident.Name] = value
+		}
+	}
+	return names
+}
+
+// tableCandidates lists the table names a model may map to, most specific first
+func tableCandidates(model string, explicit map[string]string) []string {
+	if name, ok := explicit[model]; ok {
+		return []string{name}
+	}
+
+	snake := inflect.Underscore(model)
+	lower := strings.ToLower(model)
+	return []string{inflect.Pluralize(snake), inflect.Pluralize(lower), snake, lower}
+}
+
+func leadingSpace(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " \t"))]
+}
@@ -4,34 +4,49 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/adapters"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/tasks"
 )
 
 func buildForProduction() {
-	fmt.Println("🏗️  Building assets with Bun.js...")
-	
-	// Check if package.json exists
-	if _, err := os.Stat("package.json"); os.IsNotExist(err) {
-		fmt.Println("❌ No package.json found. Run 'rebolo dev' first to set up assets.")
+	if err := buildProductionAssets(); err != nil {
+		fmt.Printf("❌ %v\n", err)
 		return
 	}
 	
-	// Install dependencies
-	fmt.Println("📦 Installing dependencies...")
-	if err := runBuildCommand("bun", "install"); err != nil {
-		fmt.Printf("❌ Failed to install dependencies: %v\n", err)
-		return
+	// Compressed copies of the assets, sent instead of compressing them
+	// on every request
+	if _, err := os.Stat("public"); err == nil {
+		fmt.Println("🗜️  Precompressing assets...")
+		count, err := adapters.Precompress("public")
+		if err != nil {
+			fmt.Printf("❌ Failed to precompress assets: %v\n", err)
+			return
+		}
+		fmt.Printf("   ✓ %d files compressed\n", count)
 	}
-	
-	// Build assets for production
-	fmt.Println("⚡ Building assets for production...")
-	if err := runBuildCommand("bun", "run", "build"); err != nil {
-		fmt.Printf("❌ Failed to build assets: %v\n", err)
-		return
+
+	// Check the views parse and record their checksums
+	if _, err := os.Stat("views"); err == nil {
+		fmt.Println("📝 Precompiling templates...")
+		manifest, err := adapters.BuildTemplateManifest("views")
+		if err != nil {
+			fmt.Printf("❌ Failed to compile templates: %v\n", err)
+			return
+		}
+		if err := adapters.WriteTemplateManifest(adapters.DefaultTemplateManifest, manifest); err != nil {
+			fmt.Printf("❌ Failed to write template manifest: %v\n", err)
+			return
+		}
+		fmt.Printf("   ✓ %d templates, checksums in %s\n", len(manifest), adapters.DefaultTemplateManifest)
 	}
 	
 	// Build Go binary
 	fmt.Println("🔨 Building Go application...")
-	if err := runBuildCommand("go", "build", "-o", "app", "main.go"); err != nil {
+	if err := runBuildCommand("go", "build", "-ldflags", versionLDFlags(), "-o", "app", "main.go"); err != nil {
 		fmt.Printf("❌ Failed to build Go application: %v\n", err)
 		return
 	}
@@ -49,6 +64,61 @@ func buildForProduction() {
 	fmt.Println("   5. Run: ./app")
 }
 
+// buildProductionAssets makes the production build of the asset pipeline, apps
+// without one (assets.tool: none) have nothing to build
+func buildProductionAssets() error {
+	tool := detectFrontendTool()
+	if tool.Name() == "none" {
+		return nil
+	}
+
+	fmt.Printf("🏗️  Building assets with %s...\n", tool.Name())
+	if err := tool.Setup(); err != nil {
+		return fmt.Errorf("failed to install dependencies: %w", err)
+	}
+
+	fmt.Println("⚡ Building assets for production...")
+	output, err := tool.Build(true)
+	if output != "" {
+		fmt.Println(output)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to build assets: %w", err)
+	}
+	return nil
+}
+
+// versionLDFlags stamps the app's version and commit from git, and the
+// build time, for rebolo.Version
+func versionLDFlags() string {
+	pkg := tasks.Module + "/pkg/rebolo"
+	built := time.Now().UTC().Format(time.RFC3339)
+	flags := []string{"-X", pkg + ".buildTime=" + built}
+
+	git := func(args ...string) string {
+		out, err := exec.Command("git", args...).Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(out))
+	}
+	version := git("describe", "--tags", "--always", "--dirty")
+	if version != "" {
+		flags = append(flags, "-X", pkg+".buildVersion="+version)
+	}
+	if commit := git("rev-parse", "HEAD"); commit != "" {
+		if strings.HasSuffix(version, "-dirty") {
+			commit += "-dirty"
+		}
+		flags = append(flags, "-X", pkg+".buildCommit="+commit)
+	}
+	if version == "" {
+		version = "unknown, not a git checkout"
+	}
+	fmt.Printf("   ✓ version %s, built %s\n", version, built)
+	return strings.Join(flags, " ")
+}
+
 func runBuildCommand(name string, args ...string) error {
 	cmd := exec.Command(name, args...)
 	cmd.Stdout = os.Stdout
@@ -0,0 +1,569 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/openapi"
+	"gopkg.in/yaml.v3"
+)
+
+// ClientData is what the client template renders: the Go types of the
+// spec's schemas and a method per operation
+type ClientData struct {
+	Package    string
+	Source     string
+	Title      string
+	UsesTime   bool
+	Types      []ClientType
+	Operations []ClientOperation
+}
+
+// ClientType is a named schema, a struct when it has Fields
+type ClientType struct {
+	Name   string
+	Doc    string
+	Fields []ClientField
+	Type   string // underlying type of a non-object schema
+}
+
+// ClientField is a property of an object schema
+type ClientField struct {
+	Name     string
+	Type     string
+	Tag      string
+	Doc      string
+	Optional bool
+}
+
+// ClientOperation is a method of the client
+type ClientOperation struct {
+	Name       string
+	Doc        string
+	Method     string
+	Path       string
+	PathParams []ClientParam
+	Query      []ClientParam // fields of the Params struct
+	Body       string        // Go type of the request body, "" without one
+	Result     string        // Go type of the response body, "" without one
+	ResultPtr  bool          // Return *Result, for structs
+}
+
+// ClientParam is a path or query parameter
+type ClientParam struct {
+	Name     string // Go identifier, a field name for query parameters
+	JSONName string
+	Type     string
+	Doc      string
+}
+
+// ErrorResult is what the method returns with an error
+func (op ClientOperation) ErrorResult() string {
+	if op.ResultPtr || strings.HasPrefix(op.Result, "[]") || strings.HasPrefix(op.Result, "map[") || op.Result == "interface{}" {
+		return "nil"
+	}
+	return "out"
+}
+
+// Params is the name of the struct holding the operation's query parameters
+func (op ClientOperation) Params() string {
+	return op.Name + "Params"
+}
+
+// GenerateClient writes a typed Go client of the OpenAPI document at from,
+// a file or a URL like a Rebolo app's /openapi.json, to out/client.go
+func (g *Generator) GenerateClient(from, name, out string) error {
+	doc, err := loadOpenAPI(from)
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		name = doc.Info.Title
+	}
+	pkg := packageName(name)
+	if out == "" {
+		out = filepath.Join("clients", pkg)
+	}
+
+	data := clientData(doc)
+	data.Package = pkg
+	data.Source = from
+
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return err
+	}
+	file := filepath.Join(out, "client.go")
+	if err := g.renderFile("client/client.go.tmpl", file, data); err != nil {
+		return err
+	}
+
+	// Templates leave blank lines and misaligned fields behind
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	formatted, err := format.Source(content)
+	if err != nil {
+		return fmt.Errorf("generated client in %s doesn't compile, is the template customized? %w", file, err)
+	}
+	if err := os.WriteFile(file, formatted, 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("📝 Created %s\n", file)
+	fmt.Printf("✅ Generated %s client: %d types, %d operations\n", pkg, len(data.Types), len(data.Operations))
+	fmt.Printf("💡 Use it with: %s.New(\"https://...\", httpclient.WithBearerToken(key))\n", pkg)
+	fmt.Printf("💡 Regenerate it when the API changes: rebolo generate client --from %s --name %s\n", from, pkg)
+	return nil
+}
+
+// loadOpenAPI reads an OpenAPI 3 document in YAML or JSON from a file or
+// an http(s) URL
+func loadOpenAPI(from string) (*openapi.Document, error) {
+	var raw []byte
+	var err error
+	if strings.HasPrefix(from, "http://") || strings.HasPrefix(from, "https://") {
+		client := &http.Client{Timeout: 30 * time.Second}
+		resp, err := client.Get(from)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", from, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch %s: %s", from, resp.Status)
+		}
+		raw, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+	} else if raw, err = os.ReadFile(from); err != nil {
+		return nil, err
+	}
+
+	// JSON is YAML, one parser reads both. Converted to JSON for the
+	// document's json tags.
+	var tree interface{}
+	if err := yaml.Unmarshal(raw, &tree); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", from, err)
+	}
+	converted, err := json.Marshal(stringKeys(tree))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", from, err)
+	}
+	var doc openapi.Document
+	if err := json.Unmarshal(converted, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", from, err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("%s isn't an OpenAPI 3 document", from)
+	}
+	return &doc, nil
+}
+
+// stringKeys turns YAML maps with keys like 200 into JSON objects
+func stringKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = stringKeys(value)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = stringKeys(value)
+		}
+		return m
+	case []interface{}:
+		for i, value := range v {
+			v[i] = stringKeys(value)
+		}
+	}
+	return v
+}
+
+var httpMethods = []string{"get", "post", "put", "patch", "delete", "head", "options"}
+
+// clientData builds the types and operations of doc
+func clientData(doc *openapi.Document) ClientData {
+	data := ClientData{Title: doc.Info.Title}
+	named := map[string]bool{}
+	types := &clientTypes{data: &data, named: named}
+
+	names := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		named[exportedName(name)] = true
+	}
+	for _, name := range names {
+		types.define(exportedName(name), doc.Components.Schemas[name])
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for _, method := range httpMethods {
+			op := doc.Paths[path][method]
+			if op == nil {
+				continue
+			}
+			data.Operations = append(data.Operations, types.operation(strings.ToUpper(method), path, op))
+		}
+	}
+
+	// Structs are returned by pointer, slices, maps and the like by value
+	structs := map[string]bool{"time.Time": true}
+	for _, typ := range data.Types {
+		structs[typ.Name] = len(typ.Fields) > 0
+	}
+	// Optional struct fields are pointers, omitempty leaves nil ones out
+	for _, typ := range data.Types {
+		for i, field := range typ.Fields {
+			if field.Optional && structs[field.Type] {
+				typ.Fields[i].Type = "*" + field.Type
+			}
+		}
+	}
+	for i := range data.Operations {
+		data.Operations[i].ResultPtr = structs[data.Operations[i].Result]
+	}
+	return data
+}
+
+// clientTypes maps schemas to Go types, defining the named ones
+type clientTypes struct {
+	data  *ClientData
+	named map[string]bool // type names taken
+	ops   map[string]bool // method names taken
+}
+
+func (t *clientTypes) define(name string, schema *openapi.Schema) {
+	typ := ClientType{Name: name, Doc: docComment(schema.Description, name+" is a schema of "+t.data.Title)}
+	if schema.Type == "object" || (schema.Type == "" && len(schema.Properties) > 0) {
+		if len(schema.Properties) == 0 {
+			typ.Type = t.goType(schema, name)
+		}
+		typ.Fields = t.fields(name, schema)
+	} else {
+		typ.Type = t.goType(schema, name)
+	}
+	t.data.Types = append(t.data.Types, typ)
+}
+
+func (t *clientTypes) fields(owner string, schema *openapi.Schema) []ClientField {
+	required := map[string]bool{}
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var fields []ClientField
+	for _, name := range names {
+		property := schema.Properties[name]
+		field := ClientField{Name: exportedName(name), Doc: docComment(property.Description, "")}
+		field.Type = t.goType(property, owner+field.Name)
+		if property.Nullable && property.Ref == "" && !strings.HasPrefix(field.Type, "[]") && !strings.HasPrefix(field.Type, "map[") && field.Type != "interface{}" {
+			field.Type = "*" + field.Type
+		}
+		field.Tag = fmt.Sprintf("`json:%q`", name)
+		if !required[name] {
+			field.Tag = fmt.Sprintf("`json:\"%s,omitempty\"`", name)
+			field.Optional = true
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// goType returns the Go type of schema, defining a type named hint for an
+// inline object
+func (t *clientTypes) goType(schema *openapi.Schema, hint string) string {
+	if schema == nil {
+		return "interface{}"
+	}
+	if schema.Ref != "" {
+		return exportedName(schema.Ref[strings.LastIndex(schema.Ref, "/")+1:])
+	}
+	switch schema.Type {
+	case "string":
+		switch schema.Format {
+		case "date-time":
+			t.data.UsesTime = true
+			return "time.Time"
+		case "byte":
+			return "[]byte"
+		}
+		return "string"
+	case "integer":
+		switch schema.Format {
+		case "int32":
+			return "int32"
+		case "int64":
+			return "int64"
+		}
+		return "int"
+	case "number":
+		if schema.Format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + t.goType(schema.Items, hint+"Item")
+	case "object", "":
+		if len(schema.Properties) > 0 {
+			name := t.unique(hint)
+			t.define(name, schema)
+			return name
+		}
+		if schema.AdditionalProperties != nil {
+			return "map[string]" + t.goType(schema.AdditionalProperties, hint+"Value")
+		}
+		if schema.Type == "object" {
+			return "map[string]interface{}"
+		}
+	}
+	return "interface{}"
+}
+
+func (t *clientTypes) unique(name string) string {
+	candidate := name
+	for i := 2; t.named[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	t.named[candidate] = true
+	return candidate
+}
+
+func (t *clientTypes) operation(method, path string, object *openapi.OperationObject) ClientOperation {
+	if t.ops == nil {
+		// Taken by the httpclient.Client the generated one embeds
+		t.ops = map[string]bool{"Do": true, "BaseURL": true, "HTTP": true, "Header": true, "Retries": true}
+	}
+	name := exportedName(object.OperationID)
+	if name == "" {
+		name = operationName(method, path)
+	}
+	for candidate, i := name, 2; ; i++ {
+		if !t.ops[candidate] {
+			name = candidate
+			break
+		}
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	t.ops[name] = true
+
+	op := ClientOperation{Name: name, Method: method, Path: path}
+	// The summary in the first paragraph, gofmt makes a lone line a heading
+	op.Doc = fmt.Sprintf("// %s calls %s %s", name, method, path)
+	if summary := strings.TrimSpace(object.Summary); summary != "" {
+		op.Doc += ".\n" + docComment(summary, "")
+	}
+	if description := strings.TrimSpace(object.Description); description != "" && description != strings.TrimSpace(object.Summary) {
+		op.Doc += "\n//\n" + docComment(description, "")
+	}
+
+	taken := map[string]bool{"ctx": true, "c": true, "params": true, "body": true, "out": true, "err": true}
+	for _, param := range object.Parameters {
+		p := ClientParam{JSONName: param.Name, Type: t.goType(param.Schema, name+exportedName(param.Name)), Doc: docComment(param.Description, "")}
+		if p.Type == "interface{}" {
+			p.Type = "string"
+		}
+		switch param.In {
+		case "path":
+			p.Name = paramName(param.Name, taken)
+			op.PathParams = append(op.PathParams, p)
+		case "query":
+			p.Name = exportedName(param.Name)
+			op.Query = append(op.Query, p)
+		}
+	}
+
+	if object.RequestBody != nil {
+		if media, ok := jsonMedia(object.RequestBody.Content); ok {
+			op.Body = t.goType(media.Schema, name+"Request")
+		}
+	}
+
+	codes := make([]string, 0, len(object.Responses))
+	for code := range object.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		if media, ok := jsonMedia(object.Responses[code].Content); ok && media.Schema != nil {
+			op.Result = t.goType(media.Schema, name+"Response")
+		}
+		break
+	}
+	return op
+}
+
+// docComment turns text into // lines, fallback when it's empty
+func docComment(text, fallback string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		text = fallback
+	}
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("// "+strings.TrimSpace(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// jsonMedia returns the JSON content of a body, application/json or a
+// variant like application/problem+json
+func jsonMedia(content map[string]openapi.MediaType) (openapi.MediaType, bool) {
+	if media, ok := content["application/json"]; ok {
+		return media, true
+	}
+	for kind, media := range content {
+		if strings.HasSuffix(kind, "+json") {
+			return media, true
+		}
+	}
+	return openapi.MediaType{}, false
+}
+
+// operationVerbs name operations without operationId by method
+var operationVerbs = map[string]string{"GET": "Get", "POST": "Create", "PUT": "Update", "PATCH": "Patch", "DELETE": "Delete"}
+
+// operationName names an operation without operationId from its method
+// and path: GET /api/v1/posts/{id}/comments is GetPostsComments, DELETE
+// /api/posts/{id} DeletePostsByID
+func operationName(method, path string) string {
+	name, ok := operationVerbs[method]
+	if !ok {
+		name = exportedName(strings.ToLower(method))
+	}
+	var params []string
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		switch {
+		case segment == "" || segment == "api" || isVersionSegment(segment):
+		case strings.HasPrefix(segment, "{"):
+			params = append(params, exportedName(segment))
+		default:
+			name += exportedName(segment)
+			params = nil // only those after the last segment
+		}
+	}
+	if len(params) > 0 {
+		name += "By" + strings.Join(params, "And")
+	}
+	return name
+}
+
+func isVersionSegment(segment string) bool {
+	return len(segment) > 1 && segment[0] == 'v' && strings.Trim(segment[1:], "0123456789") == ""
+}
+
+// initialisms are written in capitals in Go names, as golint wants
+var initialisms = map[string]bool{
+	"api": true, "html": true, "http": true, "https": true, "id": true, "ip": true, "json": true,
+	"sql": true, "uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+// exportedName turns names like created_at, post-id or listPosts into Go
+// identifiers like CreatedAt, PostID and ListPosts
+func exportedName(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	var b strings.Builder
+	for _, word := range words {
+		// listPosts and postID split in words too
+		start := 0
+		runes := []rune(word)
+		for i := 1; i <= len(runes); i++ {
+			if i < len(runes) && !(unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i-1])) {
+				continue
+			}
+			part := string(runes[start:i])
+			if initialisms[strings.ToLower(part)] {
+				b.WriteString(strings.ToUpper(part))
+			} else {
+				b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+			}
+			start = i
+		}
+	}
+	name := b.String()
+	if name != "" && unicode.IsDigit(rune(name[0])) {
+		name = "N" + name
+	}
+	return name
+}
+
+// paramName turns a path parameter into an argument name, not clashing
+// with keywords or the method's other arguments
+func paramName(s string, taken map[string]bool) string {
+	name := exportedName(s)
+	if name == "" {
+		name = "Param"
+	}
+	runes := []rune(name)
+	// ID is id, URLPath urlPath
+	i := 1
+	for i < len(runes) && unicode.IsUpper(runes[i]) && (i+1 == len(runes) || unicode.IsUpper(runes[i+1])) {
+		i++
+	}
+	name = strings.ToLower(string(runes[:i])) + string(runes[i:])
+	for taken[name] || reservedName(name) {
+		name += "Param"
+	}
+	taken[name] = true
+	return name
+}
+
+// reservedName reports whether s is a keyword or a package the client imports
+func reservedName(s string) bool {
+	switch s {
+	case "break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough", "for", "func",
+		"go", "goto", "if", "import", "interface", "map", "package", "range", "return", "select", "struct",
+		"switch", "type", "var", "httpclient", "context", "time":
+		return true
+	}
+	return false
+}
+
+// packageName turns a title like "Billing API" into a package name
+func packageName(s string) string {
+	var b bytes.Buffer
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) && r < unicode.MaxASCII || unicode.IsDigit(r) && b.Len() > 0 {
+			b.WriteRune(r)
+		}
+	}
+	name := strings.TrimSuffix(b.String(), "api")
+	if name == "" {
+		return "client"
+	}
+	return name
+}
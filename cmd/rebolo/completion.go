@@ -0,0 +1,92 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/tasks"
+	"github.com/spf13/cobra"
+)
+
+// Cobra adds the completion command, these complete the arguments and
+// flag values it can't know about
+func registerCompletions() {
+	fixed := func(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return values, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+
+	newCmd.RegisterFlagCompletionFunc("frontend", fixed("bun", "vite", "esbuild", "htmx", "none", "react", "svelte", "vue"))
+	newCmd.RegisterFlagCompletionFunc("db", fixed("sqlite", "postgres", "mysql"))
+	deployCmd.RegisterFlagCompletionFunc("target", fixed("systemd", "fly", "heroku"))
+	deployCmd.RegisterFlagCompletionFunc("env", fixed("production", "staging", "development"))
+	maintainCmd.RegisterFlagCompletionFunc("op", fixed("vacuum", "analyze", "optimize"))
+
+	destroyCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return []string{"resource", "model", "controller", "migration", "job", "mailer"}, cobra.ShellCompDirectiveNoFileComp
+		case 1:
+			return generatedNames(args[0]), cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	templatesCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		entries, _ := fs.ReadDir(templates, "templates")
+		var dirs []string
+		for _, entry := range entries {
+			if entry.IsDir() {
+				dirs = append(dirs, entry.Name())
+			}
+		}
+		return dirs, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	// The app's own tasks need it built, only the built-in ones complete
+	taskCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		tasks.DefaultTasks()
+		var names []string
+		for _, task := range tasks.List() {
+			names = append(names, task.Name+"\t"+task.Description)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// generatedNames lists what destroy can remove for kind, from the files
+// generators write in the app
+func generatedNames(kind string) []string {
+	var pattern, suffix string
+	switch kind {
+	case "resource", "scaffold", "controller":
+		pattern, suffix = filepath.Join("controllers", "*_controller.go"), "_controller.go"
+	case "model":
+		pattern, suffix = filepath.Join("models", "*.go"), ".go"
+	case "job":
+		pattern, suffix = filepath.Join("jobs", "*.go"), ".go"
+	case "mailer":
+		pattern, suffix = filepath.Join("mailers", "*_mailer.go"), ".go"
+	default:
+		return nil
+	}
+
+	files, _ := filepath.Glob(pattern)
+	var names []string
+	for _, file := range files {
+		name := filepath.Base(file)
+		if strings.HasSuffix(name, "_test.go") || (kind == "job" && name == "jobs.go") {
+			continue
+		}
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			names = append(names, strings.TrimSuffix(name, suffix))
+		}
+	}
+	return names
+}
@@ -55,6 +55,8 @@ func DefaultFieldTypeMapping() *FieldTypeMapping {
 			"float":    "float64",
 			"time":     "time.Time",
 			"datetime": "time.Time",
+			"point":    "geo.Point",
+			"polygon":  "geo.Polygon",
 		},
 		SQLTypes: map[string]string{
 			"string":   "VARCHAR(255)",
@@ -66,6 +68,8 @@ func DefaultFieldTypeMapping() *FieldTypeMapping {
 			"float":    "DECIMAL",
 			"time":     "TIMESTAMP",
 			"datetime": "TIMESTAMP",
+			"point":    "GEOMETRY", // PostGIS and SpatiaLite, see database.spatial
+			"polygon":  "GEOMETRY",
 		},
 		HTMLTypes: map[string]string{
 			"string":   "text",
@@ -77,6 +81,8 @@ func DefaultFieldTypeMapping() *FieldTypeMapping {
 			"float":    "number",
 			"time":     "datetime-local",
 			"datetime": "datetime-local",
+			"point":    "text",     // "lat, lng"
+			"polygon":  "textarea", // WKT
 		},
 	}
 }
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/adapters"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/ports"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/schema"
+	"github.com/go-sql-driver/mysql"
+)
+
+// connectDatabase connects to the database configured in the project's config.yml
+func connectDatabase() (adapters.DatabaseAdapter, ports.ConfigData, error) {
+	config, err := adapters.NewYAMLConfig().Load()
+	if err != nil {
+		return nil, config, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if config.Database.URL == "" {
+		return nil, config, fmt.Errorf("no database url configured in config.yml")
+	}
+	if config.Database.Driver == "" {
+		config.Database.Driver = "postgres"
+	}
+
+	database, err := adapters.NewDatabaseFactory().CreateDatabase(config.Database.Driver)
+	if err != nil {
+		return nil, config, err
+	}
+
+	if sqliteDB, ok := database.(*adapters.SQLiteDatabase); ok {
+		sqliteDB.SetOptions(config.Database.SQLite)
+	}
+	if err := adapters.ConfigureRetry(database, config.Database.Retry); err != nil {
+		return nil, config, err
+	}
+
+	if err := database.ConnectWithDSN(config.Database.URL, false); err != nil {
+		return nil, config, err
+	}
+
+	return database, config, nil
+}
+
+// ensureDatabase creates the configured database if the server does not have it yet.
+// SQLite files are created on connect, so only Postgres and MySQL need this.
+func ensureDatabase(config ports.ConfigData) error {
+	switch schema.NormalizeDriver(config.Database.Driver) {
+	case "postgres":
+		return ensurePostgresDatabase(config.Database.URL)
+	case "mysql":
+		return ensureMySQLDatabase(config.Database.URL)
+	}
+	return nil
+}
+
+func ensurePostgresDatabase(dsn string) error {
+	u, err := url.Parse(dsn)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		// Key/value DSNs are used as they are
+		return nil
+	}
+
+	name := strings.TrimPrefix(u.Path, "/")
+	if name == "" {
+		return nil
+	}
+
+	// Connect to the maintenance database to create the target one
+	u.Path = "/postgres"
+	db, err := sql.Open("postgres", u.String())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var exists bool
+	if err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", name).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check database %s: %w", name, err)
+	}
+	if exists {
+		return nil
+	}
+
+	if _, err := db.Exec(`CREATE DATABASE "` + strings.ReplaceAll(name, `"`, `""`) + `"`); err != nil {
+		return fmt.Errorf("failed to create database %s: %w", name, err)
+	}
+	fmt.Printf("✅ Created database %s\n", name)
+	return nil
+}
+
+func ensureMySQLDatabase(dsn string) error {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return err
+	}
+
+	name := cfg.DBName
+	if name == "" {
+		return nil
+	}
+
+	cfg.DBName = ""
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE DATABASE IF NOT EXISTS `" + strings.ReplaceAll(name, "`", "``") + "`"); err != nil {
+		return fmt.Errorf("failed to create database %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/inflect"
+)
+
+// runDestroy removes the files created by `rebolo generate <kind> <name>`
+// and the statements registering them in main.go, routes.go or, for jobs,
+// jobs/jobs.go
+func runDestroy(kind, name string) error {
+	g := NewGenerator()
+
+	var paths []string
+	var refs []string // selector expressions registered in main.go
+	files := routeFiles
+
+	switch kind {
+	case "resource", "scaffold":
+		data := g.resourceData(name, nil)
+		paths = append(paths,
+			filepath.Join("models", data.VarName+".go"),
+			filepath.Join("controllers", data.VarName+"_controller.go"),
+			filepath.Join("views", data.ViewPath),
+		)
+		paths = append(paths, globMigrations("create_"+data.TableName)...)
+		refs = []string{"controllers." + data.Name + "Controller", "controllers." + data.PluralName + "Resource"}
+	case "model":
+		data := g.resourceData(name, nil)
+		paths = append(paths, filepath.Join("models", data.VarName+".go"))
+		paths = append(paths, globMigrations("create_"+data.TableName)...)
+	case "controller":
+		path := inflect.Underscore(name)
+		paths = append(paths,
+			filepath.Join("controllers", path+"_controller.go"),
+			filepath.Join("views", path),
+		)
+		refs = []string{"controllers." + inflect.Camelize(name) + "Controller"}
+	case "migration":
+		paths = globMigrations(inflect.Underscore(name))
+	case "job":
+		handler := strings.TrimSuffix(inflect.Underscore(name), "_job")
+		paths = append(paths, filepath.Join("jobs", handler+".go"), filepath.Join("jobs", handler+"_test.go"))
+		job := inflect.Camelize(handler)
+		refs = []string{job + ".Register", "jobs." + job + "Job", "jobs." + job}
+		files = append([]string{filepath.Join("jobs", "jobs.go")}, routeFiles...)
+	case "mailer":
+		file := strings.TrimSuffix(inflect.Underscore(name), "_mailer") + "_mailer"
+		paths = append(paths,
+			filepath.Join("mailers", file+".go"),
+			filepath.Join("mailers", file+"_preview.go"),
+			filepath.Join("views", "mailers", file),
+		)
+		refs = []string{"mailers." + inflect.Camelize(file) + "Previews"}
+	default:
+		return fmt.Errorf("unknown generator %q (available: resource, model, controller, migration, job, mailer)", kind)
+	}
+
+	removed := 0
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		fmt.Printf("🗑️  Removed %s\n", path)
+		removed++
+
+		if strings.HasPrefix(path, migrationsDir) {
+			fmt.Println("⚠️  If this migration was already applied, revert its changes in the database manually")
+		}
+	}
+
+	for _, file := range files {
+		if len(refs) == 0 {
+			break
+		}
+		n, err := unregisterRoutes(file, refs)
+		if err != nil {
+			fmt.Printf("⚠️  Could not update %s, remove the routes by hand: %v\n", file, err)
+		} else if n > 0 {
+			fmt.Printf("🗑️  Removed %d statement(s) from %s\n", n, file)
+			removed++
+		}
+	}
+
+	if removed == 0 {
+		return fmt.Errorf("nothing to destroy for %s %s", kind, name)
+	}
+
+	fmt.Printf("✅ Destroyed %s: %s\n", kind, name)
+	return nil
+}
+
+// globMigrations finds migrations named <timestamp>_<name>.sql
+func globMigrations(name string) []string {
+	matches, _ := filepath.Glob(filepath.Join(migrationsDir, "*_"+name+".sql"))
+	return matches
+}
+
+// unregisterRoutes deletes the statements of file mentioning any of refs,
+// plus those using a variable assigned from one of them, e.g.
+//
+//	posts := &controllers.PostController{App: app}
+//	app.GET("/posts", posts.Index)
+//
+// It returns the number of statements removed.
+func unregisterRoutes(file string, refs []string) (int, error) {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, parser.ParseComments)
+	if err != nil {
+		return 0, err
+	}
+
+	mentions := func(stmt ast.Stmt, vars map[string]bool) bool {
+		found := false
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.SelectorExpr:
+				if pkg, ok := node.X.(*ast.Ident); ok {
+					for _, ref := range refs {
+						if pkg.Name+"."+node.Sel.Name == ref {
+							found = true
+						}
+					}
+				}
+			case *ast.Ident:
+				if vars[node.Name] {
+					found = true
+				}
+			}
+			return !found
+		})
+		return found
+	}
+
+	// Statements of every block, in source order
+	var stmts []ast.Stmt
+	ast.Inspect(f, func(n ast.Node) bool {
+		if block, ok := n.(*ast.BlockStmt); ok {
+			stmts = append(stmts, block.List...)
+		}
+		return true
+	})
+	sort.Slice(stmts, func(i, j int) bool { return stmts[i].Pos() < stmts[j].Pos() })
+
+	vars := make(map[string]bool)
+	remove := make(map[ast.Stmt]bool)
+	for _, stmt := range stmts {
+		switch stmt.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt,
+			*ast.SelectStmt, *ast.BlockStmt, *ast.CaseClause, *ast.CommClause, *ast.LabeledStmt:
+			// Only the simple statements inside compound ones are removed
+			continue
+		}
+		if !mentions(stmt, vars) {
+			continue
+		}
+		remove[stmt] = true
+		if assign, ok := stmt.(*ast.AssignStmt); ok {
+			for _, lhs := range assign.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok && ident.Name != "_" {
+					vars[ident.Name] = true
+				}
+			}
+		}
+	}
+
+	if len(remove) == 0 {
+		return 0, nil
+	}
+
+	// Cut whole lines, last statement first so offsets stay valid
+	type span struct{ start, end int }
+	var spans []span
+	for stmt := range remove {
+		start := fset.Position(stmt.Pos()).Offset
+		end := fset.Position(stmt.End()).Offset
+		for start > 0 && src[start-1] != '\n' {
+			start--
+		}
+		for end < len(src) && src[end] != '\n' {
+			end++
+		}
+		if end < len(src) {
+			end++
+		}
+		spans = append(spans, span{start, end})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start > spans[j].start })
+
+	out := append([]byte{}, src...)
+	last := len(src) + 1
+	for _, s := range spans {
+		if s.end > last {
+			continue // nested in a statement already removed
+		}
+		out = append(out[:s.start:s.start], out[s.end:]...)
+		last = s.start
+	}
+
+	out = removeUnusedImports(src, out)
+
+	formatted, err := format.Source(out)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(file, formatted, 0644); err != nil {
+		return 0, err
+	}
+	return len(remove), nil
+}
+
+// removeUnusedImports drops the imports that were used in before but are
+// no longer referenced in after, once statements have been removed
+func removeUnusedImports(before, after []byte) []byte {
+	usedBefore := usedPackages(before)
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", after, parser.ParseComments)
+	if err != nil {
+		return after
+	}
+	usedAfter := usedPackages(after)
+
+	changed := false
+	for _, imp := range append([]*ast.ImportSpec{}, f.Imports...) {
+		pkg := filepath.Base(strings.Trim(imp.Path.Value, `"`))
+		if imp.Name != nil {
+			pkg = imp.Name.Name
+		}
+		if usedBefore[pkg] && !usedAfter[pkg] && deleteImport(fset, f, imp) {
+			changed = true
+		}
+	}
+
+	if !changed {
+		return after
+	}
+
+	var b strings.Builder
+	if err := format.Node(&b, fset, f); err != nil {
+		return after
+	}
+	return []byte(b.String())
+}
+
+// usedPackages returns the identifiers used as selector qualifiers in src
+func usedPackages(src []byte) map[string]bool {
+	used := make(map[string]bool)
+
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return used
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if pkg, ok := sel.X.(*ast.Ident); ok {
+				used[pkg.Name] = true
+			}
+		}
+		return true
+	})
+	return used
+}
+
+func deleteImport(fset *token.FileSet, f *ast.File, imp *ast.ImportSpec) bool {
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		for i, spec := range gen.Specs {
+			if spec == imp {
+				gen.Specs = append(gen.Specs[:i], gen.Specs[i+1:]...)
+				// Fold the now empty line so no blank line is left in the group
+				if gen.Lparen.IsValid() {
+					fset.File(imp.Pos()).MergeLine(fset.Position(imp.Pos()).Line)
+				}
+				for j, fi := range f.Imports {
+					if fi == imp {
+						f.Imports = append(f.Imports[:j], f.Imports[j+1:]...)
+						break
+					}
+				}
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -1,24 +1,34 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"syscall"
 	"time"
 
-	"github.com/fsnotify/fsnotify"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/assets"
 )
 
 var devConfig = DefaultDevConfig()
 
+// DevOptions configure `rebolo dev`
+type DevOptions struct {
+	Port  int  // public port, the app's configured port when 0
+	Proxy bool // serve the Go and frontend dev servers behind Port
+}
+
 // startDevServer starts the development server with hot reload
-func startDevServer() {
+func startDevServer(opts DevOptions) {
 	fmt.Println("Starting ReboloLang development server...")
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -33,6 +43,22 @@ func startDevServer() {
 		cancel()
 	}()
 
+	if opts.Port == 0 {
+		opts.Port = devPort()
+	}
+
+	// Behind the proxy the Go server listens on a private port, so the
+	// proxy can keep answering while it restarts
+	appPort, frontendPort := 0, 0
+	if opts.Proxy {
+		var err error
+		if appPort, err = freePort(); err != nil {
+			log.Printf("⚠️  No port for the Go server, running without the proxy: %v", err)
+			opts.Proxy = false
+			appPort = 0
+		}
+	}
+
 	// Check if frontend exists
 	hasFrontend := false
 	if _, err := os.Stat("frontend"); err == nil {
@@ -43,51 +69,67 @@ func startDevServer() {
 	if hasFrontend {
 		// 1. Install frontend dependencies if needed
 		setupFrontendDependencies()
-		
-		// 2. Build frontend initially
-		buildFrontend()
-		
-		// 3. Watch frontend for changes
-		go watchAndCompileFrontend(ctx)
+
+		if opts.Proxy && isBunInstalled() && hasDevScript() {
+			// 2. Serve the frontend with its dev server (HMR) behind the proxy
+			frontendPort, _ = freePort()
+			go runFrontendDevServer(ctx, frontendPort)
+		} else {
+			// 2. Build frontend initially
+			buildFrontend()
+
+			// 3. Watch frontend for changes
+			go watchAndCompileFrontend(ctx)
+		}
+	} else if tool := detectFrontendTool(); tool.Name() == "none" {
+		// Created with --frontend=none/htmx or --api-only, there are no assets to build
+		fmt.Println("ℹ️  No asset pipeline, skipping the asset build")
 	} else {
-		// Traditional mode: Setup Bun.js and compile assets initially
-		setupBunAndAssets()
+		// Traditional mode: compile the assets in src/ initially
+		setupAssets(tool)
 		
-		// Start Bun watcher for assets (CSS/JS) in background
-		go watchAndCompileAssets(ctx)
+		// Recompile them (CSS/JS) in background as they change
+		go watchAndCompileAssets(ctx, tool)
+	}
+
+	if opts.Proxy {
+		go startDevProxy(ctx, opts.Port, newDevProxy(appPort, frontendPort))
 	}
 
 	// Start Go server with hot reload for .go files
-	startGoServerWithHotReload(ctx)
+	startGoServerWithHotReload(ctx, appPort)
 }
 
-// setupBunAndAssets sets up Bun.js and compiles assets initially
-func setupBunAndAssets() {
-	// Check if Bun is installed
-	if !isBunInstalled() {
-		fmt.Println("🔧 Bun.js not found. Trying to use it from ~/.bun/bin...")
-
-		// Try to use Bun from home directory
-		homeDir, _ := os.UserHomeDir()
-		bunPath := filepath.Join(homeDir, ".bun", "bin", "bun")
-		if _, err := os.Stat(bunPath); err == nil {
-			// Add to PATH temporarily
-			os.Setenv("PATH", filepath.Dir(bunPath)+":"+os.Getenv("PATH"))
-		} else {
-			// Install Bun
-			fmt.Println("📥 Installing Bun.js...")
-			if err := installBun(); err != nil {
-				log.Printf("⚠️  Bun.js installation failed: %v", err)
-				log.Println("📝 Using fallback assets (direct copy of CSS/JS)")
-				createFallbackAssets()
-				return
-			}
-		}
+// hasDevScript reports whether frontend/package.json has a "dev" script
+// to run its dev server
+func hasDevScript() bool {
+	data, err := os.ReadFile(filepath.Join("frontend", "package.json"))
+	if err != nil {
+		return false
 	}
 
-	// Build assets initially
-	fmt.Println("⚡ Building initial assets with Bun...")
-	if err := buildAssets(); err != nil {
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return false
+	}
+	_, ok := pkg.Scripts["dev"]
+	return ok
+}
+
+// setupAssets installs what the tool needs and compiles the assets
+// initially, copying them as they are when it can't
+func setupAssets(tool FrontendTool) {
+	if err := tool.Setup(); err != nil {
+		log.Printf("⚠️  %v", err)
+		log.Println("📝 Using fallback assets (direct copy of CSS/JS)")
+		createFallbackAssets()
+		return
+	}
+
+	fmt.Printf("⚡ Building initial assets with %s...\n", tool.Name())
+	if err := buildAssets(tool); err != nil {
 		log.Printf("⚠️  Asset build failed: %v", err)
 		createFallbackAssets()
 	} else {
@@ -95,31 +137,16 @@ func setupBunAndAssets() {
 	}
 }
 
-// watchAndCompileAssets watches for CSS/JS changes and recompiles with Bun
-func watchAndCompileAssets(ctx context.Context) {
-	watcher, err := fsnotify.NewWatcher()
+// watchAndCompileAssets watches for CSS/JS changes and recompiles them
+func watchAndCompileAssets(ctx context.Context, tool FrontendTool) {
+	watcher, err := newTreeWatcher("src", []string{".css", ".js", ".ts"}, nil)
 	if err != nil {
-		log.Printf("❌ Failed to create asset watcher: %v", err)
-		return
-	}
-	defer watcher.Close()
-
-	// Watch src directory
-	srcDir := "src"
-	if err := filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info == nil {
-			return err
-		}
-		if info.IsDir() {
-			return watcher.Add(path)
-		}
-		return nil
-	}); err != nil {
 		log.Printf("❌ Failed to watch src directory: %v", err)
 		return
 	}
+	defer watcher.Close()
 
-	fmt.Println("👀 Watching assets for changes (Bun.js)...")
+	fmt.Printf("👀 Watching assets for changes (%s)...\n", tool.Name())
 
 	debounce := time.NewTimer(300 * time.Millisecond)
 	debounce.Stop()
@@ -132,15 +159,13 @@ func watchAndCompileAssets(ctx context.Context) {
 			if !ok {
 				return
 			}
-			if event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
-				ext := filepath.Ext(event.Name)
-				if ext == ".css" || ext == ".js" || ext == ".ts" {
-					debounce.Reset(300 * time.Millisecond)
-				}
+			if watcher.handle(event) {
+				debounce.Reset(300 * time.Millisecond)
 			}
 		case <-debounce.C:
+			watcher.flush()
 			fmt.Println("⚡ Recompiling assets...")
-			if err := buildAssets(); err != nil {
+			if err := buildAssets(tool); err != nil {
 				log.Printf("❌ Asset compilation failed: %v", err)
 			} else {
 				fmt.Println("✅ Assets recompiled")
@@ -151,36 +176,42 @@ func watchAndCompileAssets(ctx context.Context) {
 	}
 }
 
-// startGoServerWithHotReload starts the Go server and restarts it when .go files change
-func startGoServerWithHotReload(ctx context.Context) {
+// startGoServerWithHotReload starts the Go server and restarts it when .go
+// files change. A port other than 0 overrides the app's configured one.
+func startGoServerWithHotReload(ctx context.Context, port int) {
 	fmt.Println("🔥 Starting Go server with hot reload...")
 
-	watcher, err := fsnotify.NewWatcher()
+	// Watch .go files recursively, including directories added later
+	watcher, err := newTreeWatcher(".", devConfig.GoWatchExtensions, devConfig.GoSkipDirs)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer watcher.Close()
 
-	// Watch .go files recursively
-	filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
-		if err != nil || info == nil {
-			return err
-		}
-		if info.IsDir() {
-			// Skip hidden directories, vendor, and node_modules
-			if strings.HasPrefix(info.Name(), ".") || info.Name() == "vendor" || info.Name() == "node_modules" {
-				return filepath.SkipDir
-			}
-			return watcher.Add(path)
-		}
-		return nil
-	})
-
 	var cmd *exec.Cmd
 	var serverStarted = make(chan bool, 1)
 
+	// The app is built to a binary and run directly, so killing it stops the
+	// server (go run would leave its child listening)
+	binary := filepath.Join(os.TempDir(), fmt.Sprintf("rebolo-dev-%d", os.Getpid()))
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	defer os.Remove(binary)
+
 	// Function to start/restart the server
 	startServer := func() {
+		// Keep the compiler output for the dev proxy's error overlay
+		var output bytes.Buffer
+		build := exec.Command("go", "build", "-o", binary, ".")
+		build.Stdout = io.MultiWriter(os.Stdout, &output)
+		build.Stderr = io.MultiWriter(os.Stderr, &output)
+		if err := build.Run(); err != nil {
+			log.Printf("❌ Build failed, fix the errors above: %v", err)
+			devBuild.failed(strings.TrimSpace(output.String()))
+			return
+		}
+
 		// Kill existing process
 		if cmd != nil && cmd.Process != nil {
 			fmt.Println("🔄 Restarting Go server...")
@@ -191,15 +222,20 @@ func startGoServerWithHotReload(ctx context.Context) {
 		}
 
 		// Start new process
-		cmd = exec.Command("go", "run", "main.go")
+		cmd = exec.Command(binary)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		cmd.Env = os.Environ()
+		if port != 0 {
+			cmd.Env = append(cmd.Env, fmt.Sprintf("PORT=%d", port))
+		}
 
 		if err := cmd.Start(); err != nil {
 			log.Printf("❌ Failed to start server: %v", err)
+			devBuild.failed(err.Error())
 			return
 		}
+		devBuild.restarted()
 
 		// Signal that server started
 		select {
@@ -227,11 +263,11 @@ func startGoServerWithHotReload(ctx context.Context) {
 				return
 			}
 			// Only restart on .go file changes
-			if event.Op&(fsnotify.Write|fsnotify.Create) != 0 && filepath.Ext(event.Name) == ".go" {
-				fmt.Printf("🔄 Code changed: %s\n", filepath.Base(event.Name))
+			if watcher.handle(event) {
 				debounce.Reset(500 * time.Millisecond)
 			}
 		case <-debounce.C:
+			fmt.Printf("🔄 Code changed: %s\n", strings.Join(watcher.flush(), ", "))
 			startServer()
 		case err := <-watcher.Errors:
 			log.Printf("❌ Watcher error: %v", err)
@@ -263,22 +299,20 @@ func installBun() error {
 	return nil
 }
 
-// buildAssets builds the frontend assets with Bun
-func buildAssets() error {
+// buildAssets builds the assets for development and records the outcome
+// for the app's hot reload overlay
+func buildAssets(tool FrontendTool) error {
 	if _, err := os.Stat("src/index.js"); os.IsNotExist(err) {
 		return fmt.Errorf("src/index.js not found")
 	}
 
-	os.MkdirAll("public", 0755)
-
-	// Build with Bun
-	cmd := exec.Command("bun", "build", "src/index.js", "--outdir", "public", "--target", "browser")
-	output, err := cmd.CombinedOutput()
-
+	output, err := tool.Build(false)
 	if err != nil {
-		return fmt.Errorf("build failed: %w\n%s", err, string(output))
+		recordAssetBuild(output)
+		return fmt.Errorf("build failed: %w\n%s", err, output)
 	}
 
+	recordAssetBuild("")
 	return nil
 }
 
@@ -345,35 +379,31 @@ func buildFrontend() {
 	if err != nil {
 		log.Printf("⚠️  Frontend build failed: %v", err)
 		log.Printf("   Output: %s", string(output))
+		recordAssetBuild(strings.TrimSpace(string(output)))
 		return
 	}
 	
+	recordAssetBuild("")
 	fmt.Println("✅ Frontend built successfully")
 }
 
+// recordAssetBuild tells the app how the last asset build went, so open
+// pages show failures on the hot reload overlay
+func recordAssetBuild(output string) {
+	if err := assets.WriteStatus(output); err != nil {
+		log.Printf("⚠️  Failed to record the asset build: %v", err)
+	}
+}
+
 // watchAndCompileFrontend watches frontend changes and rebuilds
 func watchAndCompileFrontend(ctx context.Context) {
-	watcher, err := fsnotify.NewWatcher()
+	extensions := []string{".tsx", ".ts", ".jsx", ".js", ".vue", ".svelte", ".css"}
+	watcher, err := newTreeWatcher(filepath.Join("frontend", "src"), extensions, nil)
 	if err != nil {
-		log.Printf("❌ Failed to create frontend watcher: %v", err)
-		return
-	}
-	defer watcher.Close()
-
-	// Watch frontend/src directory
-	frontendSrc := filepath.Join("frontend", "src")
-	if err := filepath.Walk(frontendSrc, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info == nil {
-			return err
-		}
-		if info.IsDir() {
-			return watcher.Add(path)
-		}
-		return nil
-	}); err != nil {
 		log.Printf("❌ Failed to watch frontend: %v", err)
 		return
 	}
+	defer watcher.Close()
 
 	fmt.Println("👀 Watching frontend for changes...")
 
@@ -388,15 +418,11 @@ func watchAndCompileFrontend(ctx context.Context) {
 			if !ok {
 				return
 			}
-			if event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
-				ext := filepath.Ext(event.Name)
-				if ext == ".tsx" || ext == ".ts" || ext == ".jsx" || ext == ".js" || 
-				   ext == ".vue" || ext == ".svelte" || ext == ".css" {
-					fmt.Printf("🔄 Frontend changed: %s\n", filepath.Base(event.Name))
-					debounce.Reset(500 * time.Millisecond)
-				}
+			if watcher.handle(event) {
+				debounce.Reset(500 * time.Millisecond)
 			}
 		case <-debounce.C:
+			fmt.Printf("🔄 Frontend changed: %s\n", strings.Join(watcher.flush(), ", "))
 			buildFrontend()
 		case err := <-watcher.Errors:
 			log.Printf("❌ Frontend watcher error: %v", err)
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/inflect"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/schema"
+)
+
+// modelColumn is a column a model struct expects
+type modelColumn struct {
+	Name    string
+	SQLType string
+}
+
+// modelTable is a model struct and the table it maps to
+type modelTable struct {
+	Model      string
+	Candidates []string // table names to look for, most specific first
+	Columns    []modelColumn
+}
+
+// runDiff compares the model structs in dir with the live schema and
+// writes a migration adding what is missing. With dryRun set, the SQL is
+// printed instead.
+func runDiff(name, dir string, dryRun bool) error {
+	database, config, err := connectDatabase()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	inspector, err := schema.NewInspector(database.DB().(*sql.DB), config.Database.Driver)
+	if err != nil {
+		return err
+	}
+
+	models, err := parseModels(dir, goSQLTypes(DefaultFieldTypeMapping()))
+	if err != nil {
+		return err
+	}
+
+	statements, err := diffModels(context.Background(), inspector, models)
+	if err != nil {
+		return err
+	}
+	if len(statements) == 0 {
+		fmt.Println("✅ Models match the database schema")
+		return nil
+	}
+
+	sqlText := strings.Join(statements, "\n") + "\n"
+	if dryRun {
+		fmt.Print(sqlText)
+		return nil
+	}
+
+	// Only commented out drops: nothing for a migration to run
+	pending := false
+	for _, stmt := range statements {
+		pending = pending || !strings.HasPrefix(stmt, "--")
+	}
+	if !pending {
+		fmt.Print(sqlText)
+		fmt.Println("✅ Models have every column, no migration written")
+		return nil
+	}
+
+	os.MkdirAll(migrationsDir, 0755)
+	path := filepath.Join(migrationsDir, time.Now().Format("20060102150405")+"_"+inflect.Underscore(name)+".sql")
+	if err := os.WriteFile(path, []byte(sqlText), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Generated migration: %s\n", path)
+	fmt.Println("   Review it, then run 'rebolo db migrate'")
+	return nil
+}
+
+// diffModels returns the statements that bring the schema in line with
+// the models: CREATE TABLE for missing tables, ADD COLUMN for missing
+// columns. Columns only the database has are dropped in comments, as
+// dropping loses data; type changes are left to hand-written migrations.
+func diffModels(ctx context.Context, inspector *schema.Inspector, models []modelTable) ([]string, error) {
+	existing, err := inspector.Tables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(existing))
+	for _, t := range existing {
+		known[t] = true
+	}
+
+	driver := inspector.Driver()
+	var statements []string
+	for _, m := range models {
+		table := ""
+		for _, candidate := range m.Candidates {
+			if known[candidate] {
+				table = candidate
+				break
+			}
+		}
+
+		if table == "" {
+			statements = append(statements, createTableSQL(driver, m.Candidates[0], m.Columns))
+			continue
+		}
+
+		info, err := inspector.Table(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		expected := make(map[string]bool, len(m.Columns))
+		for _, c := range m.Columns {
+			expected[c.Name] = true
+			if info.Column(c.Name) == nil {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table, c.Name, c.SQLType))
+			}
+		}
+		for _, c := range info.Columns {
+			if !expected[c.Name] {
+				statements = append(statements, fmt.Sprintf("-- %s.%s is not in %s, uncomment to drop it:\n-- ALTER TABLE %s DROP COLUMN %s;", table, c.Name, m.Model, table, c.Name))
+			}
+		}
+	}
+	return statements, nil
+}
+
+// createTableSQL creates a table, its id column as the dialect's auto
+// incrementing primary key
+func createTableSQL(driver, table string, columns []modelColumn) string {
+	lines := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if c.Name == "id" {
+			lines = append(lines, "    id "+primaryKeySQL(driver))
+			continue
+		}
+		lines = append(lines, "    "+c.Name+" "+c.SQLType)
+	}
+	return fmt.Sprintf("CREATE TABLE %s (\n%s\n);", table, strings.Join(lines, ",\n"))
+}
+
+func primaryKeySQL(driver string) string {
+	switch driver {
+	case "sqlite":
+		return "INTEGER PRIMARY KEY AUTOINCREMENT"
+	case "mysql":
+		return "BIGINT AUTO_INCREMENT PRIMARY KEY"
+	}
+	return "BIGSERIAL PRIMARY KEY"
+}
+
+// parseModels reads the structs of the model files in dir
+func parseModels(dir string, sqlTypes map[string]string) ([]modelTable, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no model files found in %s", dir)
+	}
+	sort.Strings(files)
+
+	var models []modelTable
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		tableNames := modelTableNames(file)
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok || !ts.Name.IsExported() {
+					continue
+				}
+				columns := structColumns(st, sqlTypes, path+": "+ts.Name.Name)
+				if len(columns) == 0 {
+					continue
+				}
+				models = append(models, modelTable{
+					Model:      ts.Name.Name,
+					Candidates: tableCandidates(ts.Name.Name, tableNames),
+					Columns:    columns,
+				})
+			}
+		}
+	}
+	return models, nil
+}
+
+// embeddedColumns are the columns of the model package's embeddable structs
+var embeddedColumns = map[string][]modelColumn{
+	"model.Timestamps":  {{Name: "created_at", SQLType: "TIMESTAMP DEFAULT CURRENT_TIMESTAMP"}, {Name: "updated_at", SQLType: "TIMESTAMP DEFAULT CURRENT_TIMESTAMP"}},
+	"model.SoftDeletes": {{Name: "deleted_at", SQLType: "TIMESTAMP"}},
+}
+
+// structColumns maps the fields of a struct to columns: the db tag, the
+// json tag or the field name in snake_case, "-" skips a field
+func structColumns(st *ast.StructType, sqlTypes map[string]string, where string) []modelColumn {
+	var columns []modelColumn
+	for _, field := range st.Fields.List {
+		goType := types.ExprString(field.Type)
+		if len(field.Names) == 0 {
+			columns = append(columns, embeddedColumns[goType]...)
+			continue
+		}
+
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			value, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(value)
+		}
+
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			name := ""
+			for _, key := range []string{"db", "json"} {
+				if n, _, _ := strings.Cut(tag.Get(key), ","); n != "" {
+					name = n
+					break
+				}
+			}
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = inflect.Underscore(ident.Name)
+			}
+
+			sqlType, ok := sqlTypes[strings.TrimPrefix(goType, "*")]
+			if !ok {
+				fmt.Printf("⚠️  %s.%s: no column type for %s, skipping\n", where, ident.Name, goType)
+				continue
+			}
+			columns = append(columns, modelColumn{Name: name, SQLType: sqlType})
+		}
+	}
+	return columns
+}
+
+// goSQLTypes maps Go types to the column types the generators use for
+// them, plus the database/sql null types
+func goSQLTypes(mapping *FieldTypeMapping) map[string]string {
+	types := map[string]string{
+		"int":             "BIGINT",
+		"int32":           "INTEGER",
+		"float32":         "REAL",
+		"sql.NullString":  "VARCHAR(255)",
+		"sql.NullInt64":   "BIGINT",
+		"sql.NullInt32":   "INTEGER",
+		"sql.NullBool":    "BOOLEAN",
+		"sql.NullFloat64": "DECIMAL",
+		"sql.NullTime":    "TIMESTAMP",
+	}
+
+	// Field types in alphabetical order, so "string" (VARCHAR) wins over
+	// "text" for string and "datetime" over "time" for time.Time
+	fieldTypes := make([]string, 0, len(mapping.GoTypes))
+	for fieldType := range mapping.GoTypes {
+		fieldTypes = append(fieldTypes, fieldType)
+	}
+	sort.Strings(fieldTypes)
+	for _, fieldType := range fieldTypes {
+		goType := mapping.GoTypes[fieldType]
+		if _, ok := types[goType]; !ok {
+			types[goType] = mapping.SQLTypes[fieldType]
+		}
+	}
+	return types
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/adapters"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/migrate"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/ports"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/session"
+)
+
+// checkStatus is how a doctor check went
+type checkStatus int
+
+const (
+	checkOK checkStatus = iota
+	checkWarning
+	checkFailed
+)
+
+// checkResult is the outcome of a check, Fix tells how to solve a
+// warning or failure
+type checkResult struct {
+	Name   string
+	Status checkStatus
+	Detail string
+	Fix    string
+}
+
+// runDoctor checks the project in the working directory and prints a fix
+// for each problem. It returns false when a check failed.
+func runDoctor() bool {
+	var results []checkResult
+	report := func(r checkResult) {
+		results = append(results, r)
+		icon := map[checkStatus]string{checkOK: "✅", checkWarning: "⚠️ ", checkFailed: "❌"}[r.Status]
+		fmt.Printf("%s %s: %s\n", icon, r.Name, r.Detail)
+		if r.Fix != "" {
+			fmt.Printf("   → %s\n", r.Fix)
+		}
+	}
+
+	if _, err := os.Stat("config.yml"); err != nil {
+		report(checkResult{"config", checkFailed, "no config.yml in this directory", "Run rebolo doctor in the app's directory, or create an app with rebolo new"})
+		return false
+	}
+	config, err := adapters.NewYAMLConfig().Load()
+	if err != nil {
+		report(checkResult{"config", checkFailed, err.Error(), "Fix the YAML syntax of config.yml, or of config.<env>.yml for REBOLO_ENV"})
+		return false
+	}
+	report(checkResult{Name: "config", Detail: fmt.Sprintf("config.yml parses (env: %s)", envOrDefault(config.App.Env))})
+
+	for _, r := range checkDatabase(config) {
+		report(r)
+	}
+	report(checkFrontendTool())
+	report(checkViews())
+	report(checkSessionSecret(config))
+	for _, r := range checkPorts(config) {
+		report(r)
+	}
+
+	failed, warnings := 0, 0
+	for _, r := range results {
+		switch r.Status {
+		case checkFailed:
+			failed++
+		case checkWarning:
+			warnings++
+		}
+	}
+	fmt.Println()
+	switch {
+	case failed > 0:
+		fmt.Printf("❌ %d problem(s), %d warning(s)\n", failed, warnings)
+	case warnings > 0:
+		fmt.Printf("⚠️  %d warning(s)\n", warnings)
+	default:
+		fmt.Println("✅ Everything looks good")
+	}
+	return failed == 0
+}
+
+func envOrDefault(env string) string {
+	if env == "" {
+		return "development"
+	}
+	return env
+}
+
+// checkDatabase connects to the database and looks for pending migrations
+func checkDatabase(config ports.ConfigData) []checkResult {
+	if config.Database.URL == "" {
+		return []checkResult{{"database", checkWarning, "no database.url in config.yml", "Set database.driver and database.url, unless the app has no database"}}
+	}
+
+	database, _, err := connectDatabase()
+	if err != nil {
+		fix := "Start the database server and check database.url, run rebolo db migrate to create the database"
+		if strings.Contains(err.Error(), "unknown") {
+			fix = "Set database.driver to postgres, sqlite, mysql, libsql or neon"
+		}
+		return []checkResult{{"database", checkFailed, err.Error(), fix}}
+	}
+	defer database.Close()
+	results := []checkResult{{Name: "database", Detail: "connected to " + config.Database.Driver}}
+
+	db, ok := database.DB().(*sql.DB)
+	if !ok {
+		return results
+	}
+	if _, err := os.Stat(migrationsDir); err != nil {
+		return results
+	}
+	migrator, err := migrate.New(db, config.Database.Driver, migrationsDir)
+	if err != nil {
+		return append(results, checkResult{"migrations", checkFailed, err.Error(), "Check the file names in " + migrationsDir})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	pending, err := migrator.Pending(ctx)
+	switch {
+	case err != nil:
+		return append(results, checkResult{"migrations", checkFailed, err.Error(), ""})
+	case len(pending) > 0:
+		names := make([]string, len(pending))
+		for i, mig := range pending {
+			names[i] = filepath.Base(mig.Path)
+		}
+		return append(results, checkResult{"migrations", checkWarning, fmt.Sprintf("%d pending: %s", len(pending), strings.Join(names, ", ")), "Run rebolo db migrate"})
+	}
+	return append(results, checkResult{Name: "migrations", Detail: "up to date"})
+}
+
+// checkFrontendTool checks the asset pipeline's tool is installed
+func checkFrontendTool() checkResult {
+	tool := detectFrontendTool()
+	command, install := "", ""
+	switch tool.Name() {
+	case "none":
+		return checkResult{Name: "assets", Detail: "no asset pipeline"}
+	case "bun":
+		command, install = "bun", "Install Bun: curl -fsSL https://bun.sh/install | bash"
+	default:
+		command, install = "npm", "Install Node.js and npm: https://nodejs.org"
+	}
+	if _, err := exec.LookPath(command); err != nil {
+		return checkResult{"assets", checkFailed, fmt.Sprintf("%s builds the assets but isn't installed", command), install}
+	}
+	if _, err := os.Stat("node_modules"); err != nil {
+		return checkResult{"assets", checkWarning, "dependencies aren't installed", fmt.Sprintf("Run %s install, or rebolo dev which installs them", command)}
+	}
+	return checkResult{Name: "assets", Detail: tool.Name() + " is installed"}
+}
+
+// checkViews parses the views, as production apps do at boot
+func checkViews() checkResult {
+	info, err := os.Stat("views")
+	if err != nil {
+		return checkResult{Name: "views", Detail: "no views directory, fine for an API"}
+	}
+	if !info.IsDir() {
+		return checkResult{"views", checkFailed, "views is a file", "Move it away, views/ holds the templates"}
+	}
+	manifest, err := adapters.BuildTemplateManifest("views")
+	if err != nil {
+		return checkResult{"views", checkFailed, err.Error(), "Fix the template syntax, production apps parse every view at boot"}
+	}
+	if len(manifest) == 0 {
+		return checkResult{"views", checkWarning, "views/ has no templates", "Add .html templates or remove the directory"}
+	}
+	return checkResult{Name: "views", Detail: fmt.Sprintf("%d templates parse", len(manifest))}
+}
+
+// checkSessionSecret warns about sessions signed with the public default
+func checkSessionSecret(config ports.ConfigData) checkResult {
+	secret := os.ExpandEnv(config.Session.Secret)
+	switch {
+	case config.Session.Secret != "" && secret == "":
+		return checkResult{"session", checkWarning, fmt.Sprintf("session.secret %s is empty in this environment", config.Session.Secret), "Export the variable, e.g. SESSION_SECRET=$(rebolo task secret)"}
+	case secret == "" || secret == session.DefaultSecret:
+		status := checkWarning
+		if config.App.Env == "production" {
+			status = checkFailed
+		}
+		return checkResult{"session", status, "sessions are signed with the default secret, anyone can forge them", `Set session.secret: "${SESSION_SECRET}" in config.yml and SESSION_SECRET=$(rebolo task secret)`}
+	case len(secret) < 32:
+		return checkResult{"session", checkWarning, fmt.Sprintf("session.secret is only %d characters", len(secret)), "Use a longer one: rebolo task secret"}
+	}
+	return checkResult{Name: "session", Detail: "session.secret is set"}
+}
+
+// checkPorts checks the ports the app listens on are free
+func checkPorts(config ports.ConfigData) []checkResult {
+	port := config.Server.Port
+	if port == "" {
+		port = "3000"
+	}
+	results := []checkResult{checkPort("server.port", port)}
+	if config.GRPC.Port != "" {
+		results = append(results, checkPort("grpc.port", config.GRPC.Port))
+	}
+	return results
+}
+
+func checkPort(setting, port string) checkResult {
+	l, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return checkResult{"ports", checkWarning, fmt.Sprintf("%s %s is in use: %v", setting, port, err), fmt.Sprintf("Stop what listens on it (lsof -i :%s) or change %s", port, setting)}
+	}
+	l.Close()
+	return checkResult{Name: "ports", Detail: fmt.Sprintf("%s %s is free", setting, port)}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/adapters"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/assets"
+)
+
+// FrontendTool builds the asset pipeline of an app, src/ into public/.
+// It's chosen with `rebolo new --frontend`, recorded as assets.tool in
+// config.yml and used by `rebolo dev` and `rebolo build`.
+type FrontendTool interface {
+	Name() string
+	// Files maps the files `rebolo new` writes for the tool to their templates
+	Files() map[string]string
+	// Setup makes the tool runnable, installing dependencies when missing
+	Setup() error
+	// Build compiles the assets once and returns the tool's output. Production
+	// builds fingerprint file names and write the manifest jsBundle reads.
+	Build(production bool) (string, error)
+}
+
+// frontendTools are the tools `rebolo new --frontend` accepts for the
+// asset pipeline
+var frontendTools = map[string]FrontendTool{
+	"bun":     bunTool{},
+	"vite":    viteTool{},
+	"esbuild": esbuildTool{},
+	"none":    noneTool{},
+}
+
+// detectFrontendTool returns the tool set as assets.tool in config.yml.
+// Apps created before it was recorded use Bun when they have a
+// package.json, and no pipeline otherwise.
+func detectFrontendTool() FrontendTool {
+	if config, err := adapters.NewYAMLConfig().Load(); err == nil && config.Assets.Tool != "" {
+		if tool, ok := frontendTools[config.Assets.Tool]; ok {
+			return tool
+		}
+		fmt.Printf("⚠️  Unknown assets.tool %q, valid options are bun, vite, esbuild and none\n", config.Assets.Tool)
+	}
+	if _, err := os.Stat("package.json"); err == nil {
+		return bunTool{}
+	}
+	return noneTool{}
+}
+
+// bunTool bundles with Bun, installed on first use when missing
+type bunTool struct{}
+
+func (bunTool) Name() string { return "bun" }
+
+func (bunTool) Files() map[string]string {
+	return map[string]string{"package.json": "app/package.json.tmpl"}
+}
+
+func (bunTool) Setup() error {
+	if !isBunInstalled() {
+		fmt.Println("🔧 Bun.js not found. Trying to use it from ~/.bun/bin...")
+
+		homeDir, _ := os.UserHomeDir()
+		bunPath := filepath.Join(homeDir, ".bun", "bin", "bun")
+		if _, err := os.Stat(bunPath); err == nil {
+			// Add to PATH temporarily
+			os.Setenv("PATH", filepath.Dir(bunPath)+":"+os.Getenv("PATH"))
+		} else {
+			fmt.Println("📥 Installing Bun.js...")
+			if err := installBun(); err != nil {
+				return fmt.Errorf("Bun.js installation failed: %w", err)
+			}
+		}
+	}
+	return installPackages("bun", "install")
+}
+
+func (bunTool) Build(production bool) (string, error) {
+	if production {
+		return runTool("bun", "run", "build")
+	}
+	// Development bundles keep their names, a manifest left by a production
+	// build would point the pages at stale files
+	os.Remove(assets.DefaultManifest)
+	return runTool("bun", "build", "src/index.js", "--outdir", "public", "--target", "browser")
+}
+
+// viteTool bundles with Vite through npm. Its manifest is
+// public/.vite/manifest.json, which config.yml points assets.manifest at.
+type viteTool struct{}
+
+const viteManifest = "public/.vite/manifest.json"
+
+func (viteTool) Name() string { return "vite" }
+
+func (viteTool) Files() map[string]string {
+	return map[string]string{
+		"package.json":   "app/package_vite.json.tmpl",
+		"vite.config.js": "app/vite.config.js.tmpl",
+	}
+}
+
+func (viteTool) Setup() error {
+	return installPackages("npm", "install")
+}
+
+func (viteTool) Build(production bool) (string, error) {
+	if production {
+		return runTool("npm", "run", "build")
+	}
+	os.Remove(viteManifest)
+	return runTool("npx", "vite", "build", "--mode", "development")
+}
+
+// esbuildTool bundles with esbuild through npm
+type esbuildTool struct{}
+
+func (esbuildTool) Name() string { return "esbuild" }
+
+func (esbuildTool) Files() map[string]string {
+	return map[string]string{"package.json": "app/package_esbuild.json.tmpl"}
+}
+
+func (esbuildTool) Setup() error {
+	return installPackages("npm", "install")
+}
+
+func (esbuildTool) Build(production bool) (string, error) {
+	if production {
+		return runTool("npm", "run", "build")
+	}
+	os.Remove(assets.DefaultManifest)
+	return runTool("npx", "esbuild", "src/index.js", "--bundle", "--format=esm", "--outdir=public", "--sourcemap")
+}
+
+// noneTool is for apps without an asset pipeline: APIs, htmx, or plain
+// files in public/
+type noneTool struct{}
+
+func (noneTool) Name() string                          { return "none" }
+func (noneTool) Files() map[string]string              { return nil }
+func (noneTool) Setup() error                          { return nil }
+func (noneTool) Build(production bool) (string, error) { return "", nil }
+
+// installPackages runs the package manager's install when node_modules is
+// missing
+func installPackages(name string, args ...string) error {
+	if _, err := os.Stat("node_modules"); err == nil {
+		return nil
+	}
+	if _, err := os.Stat("package.json"); os.IsNotExist(err) {
+		return nil
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return fmt.Errorf("%s not found, install it to build the assets", name)
+	}
+
+	fmt.Println("📦 Installing asset dependencies...")
+	if err := runBuildCommand(name, args...); err != nil {
+		return fmt.Errorf("%s %s failed: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// runTool runs a build command, returning its combined output
+func runTool(name string, args ...string) (string, error) {
+	os.MkdirAll("public", 0755)
+	output, err := exec.Command(name, args...).CombinedOutput()
+	return strings.TrimSpace(string(output)), err
+}
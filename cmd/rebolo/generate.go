@@ -0,0 +1,473 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/inflect"
+)
+
+// ControllerData is passed to the standalone controller templates
+type ControllerData struct {
+	Name      string
+	VarName   string
+	Module    string
+	ViewPath  string
+	RoutePath string
+	Actions   []Action
+}
+
+// Action is a controller method and the view it renders
+type Action struct {
+	Name   string
+	Method string
+}
+
+// MigrationData is passed to the standalone migration template
+type MigrationData struct {
+	Name      string
+	Kind      string // create, add, remove or empty
+	TableName string
+	Fields    []Field
+}
+
+// JobData is passed to the job template
+type JobData struct {
+	Name        string
+	HandlerName string
+}
+
+// MailerData is passed to the mailer templates
+type MailerData struct {
+	Name     string // UserMailer
+	FileName string // user_mailer
+	Actions  []MailerAction
+}
+
+// MailerAction is an email sent by a mailer
+type MailerAction struct {
+	Name    string // reset_password
+	Method  string // ResetPassword
+	Subject string // Reset password
+}
+
+// GraphQLData is passed to the graphql templates
+type GraphQLData struct {
+	Module string
+}
+
+// gqlgenModule is the gqlgen release added to apps by GenerateGraphQL
+const gqlgenModule = "github.com/99designs/gqlgen@v0.17.95"
+
+// GenerateModel creates a model and, unless skipMigration is set, the
+// migration creating its table
+func (g *Generator) GenerateModel(name string, fieldArgs []string, skipMigration bool) error {
+	data := g.resourceData(name, fieldArgs)
+
+	os.MkdirAll("models", 0755)
+	modelPath := filepath.Join("models", data.VarName+".go")
+	if err := g.createFile("resource/model.go.tmpl", modelPath, data); err != nil {
+		return err
+	}
+
+	existing, _ := filepath.Glob(filepath.Join(migrationsDir, "*_create_"+data.TableName+".sql"))
+	if !skipMigration && len(existing) > 0 {
+		fmt.Printf("⚠️  %s exists, skipping\n", existing[0])
+	} else if !skipMigration {
+		os.MkdirAll(migrationsDir, 0755)
+		migrationPath := filepath.Join(migrationsDir, data.Timestamp+"_create_"+data.TableName+".sql")
+		if err := g.createFile("resource/migration.sql.tmpl", migrationPath, data); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("✅ Generated model: %s\n", data.Name)
+	return nil
+}
+
+// GenerateAPIResource creates a JSON-only resource: model, migration and a
+// resource.Resource controller with request validation, without views
+func (g *Generator) GenerateAPIResource(name string, fieldArgs []string) error {
+	data := g.resourceData(name, fieldArgs)
+
+	os.MkdirAll("models", 0755)
+	os.MkdirAll("controllers", 0755)
+	os.MkdirAll(migrationsDir, 0755)
+
+	files := map[string]string{
+		filepath.Join("models", data.VarName+".go"):                                   "resource/model.go.tmpl",
+		filepath.Join("controllers", data.VarName+"_controller.go"):                   "api/controller.go.tmpl",
+		filepath.Join(migrationsDir, data.Timestamp+"_create_"+data.TableName+".sql"): "resource/migration.sql.tmpl",
+	}
+	for filePath, tmplName := range files {
+		if err := g.renderFile(tmplName, filePath, data); err != nil {
+			return fmt.Errorf("failed to generate %s: %w", filePath, err)
+		}
+	}
+
+	fmt.Printf("✅ Generated API resource: %s\n", name)
+	fmt.Printf("   - Model: models/%s.go\n", data.VarName)
+	fmt.Printf("   - Controller: controllers/%s_controller.go\n", data.VarName)
+	fmt.Printf("   - Migration: %s/%s_create_%s.sql\n", migrationsDir, data.Timestamp, data.TableName)
+
+	wireRoutes(func(app string) []string {
+		return []string{fmt.Sprintf("%s.RESTResource(\"/api/%s\", &controllers.%sResource{App: %s}, resource.Only(resource.List, resource.Show, resource.Create, resource.Update, resource.Destroy))",
+			app, data.RoutePath, data.PluralName, app)}
+	}, data.Module+"/controllers", "github.com/Palaciodiego008/rebololang/pkg/rebolo/resource")
+	return nil
+}
+
+// GenerateController creates a controller with one method and view per
+// action, index when none are given
+func (g *Generator) GenerateController(name string, actions []string) error {
+	if len(actions) == 0 {
+		actions = []string{"index"}
+	}
+
+	path := inflect.Underscore(name)
+	data := ControllerData{
+		Name:      inflect.Camelize(name),
+		VarName:   path,
+		Module:    g.getModuleName(),
+		ViewPath:  path,
+		RoutePath: path,
+	}
+	for _, action := range actions {
+		action = inflect.Underscore(action)
+		data.Actions = append(data.Actions, Action{Name: action, Method: inflect.Camelize(action)})
+	}
+
+	os.MkdirAll("controllers", 0755)
+	os.MkdirAll(filepath.Join("views", data.ViewPath), 0755)
+
+	controllerPath := filepath.Join("controllers", data.VarName+"_controller.go")
+	if err := g.createFile("controller/controller.go.tmpl", controllerPath, data); err != nil {
+		return err
+	}
+
+	for _, action := range data.Actions {
+		viewPath := filepath.Join("views", data.ViewPath, action.Name+".html")
+		viewData := map[string]string{
+			"Controller": data.Name,
+			"Action":     action.Name,
+			"ViewPath":   data.ViewPath,
+		}
+		if err := g.createFile("controller/view.html.tmpl", viewPath, viewData); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("✅ Generated controller: %sController\n", data.Name)
+
+	varName := strings.ToLower(data.Name[:1]) + data.Name[1:]
+	wireRoutes(func(app string) []string {
+		lines := []string{fmt.Sprintf("%s := &controllers.%sController{App: %s}", varName, data.Name, app)}
+		for _, action := range data.Actions {
+			lines = append(lines, fmt.Sprintf("%s.GET(\"/%s/%s\", %s.%s)", app, data.RoutePath, action.Name, varName, action.Method))
+		}
+		return lines
+	}, data.Module+"/controllers")
+	return nil
+}
+
+// GenerateMigration creates an empty migration, or one with the SQL
+// inferred from its name and fields:
+//
+//	create_posts title:string      CREATE TABLE posts (...)
+//	add_email_to_users email:string ALTER TABLE users ADD COLUMN email ...
+//	remove_age_from_users age:int   ALTER TABLE users DROP COLUMN age
+func (g *Generator) GenerateMigration(name string, fieldArgs []string) error {
+	name = inflect.Underscore(name)
+	timestamp := time.Now().Format("20060102150405")
+	filePath := filepath.Join(migrationsDir, timestamp+"_"+name+".sql")
+
+	os.MkdirAll(migrationsDir, 0755)
+
+	data := MigrationData{
+		Name:   name,
+		Kind:   "empty",
+		Fields: g.parseFields(fieldArgs),
+	}
+
+	switch {
+	case strings.HasPrefix(name, "create_"):
+		table := strings.TrimPrefix(name, "create_")
+		resource := ResourceData{TableName: table, Fields: data.Fields, Timestamps: g.Model.Timestamps, SoftDelete: g.Model.SoftDelete}
+		if err := g.createFile("resource/migration.sql.tmpl", filePath, resource); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Generated migration: %s\n", filePath)
+		return nil
+	case strings.HasPrefix(name, "add_") && strings.Contains(name, "_to_") && len(data.Fields) > 0:
+		data.Kind = "add"
+		data.TableName = name[strings.LastIndex(name, "_to_")+len("_to_"):]
+	case strings.HasPrefix(name, "remove_") && strings.Contains(name, "_from_") && len(data.Fields) > 0:
+		data.Kind = "remove"
+		data.TableName = name[strings.LastIndex(name, "_from_")+len("_from_"):]
+	}
+
+	if err := g.createFile("migration/migration.sql.tmpl", filePath, data); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Generated migration: %s\n", filePath)
+	return nil
+}
+
+// GenerateAPIKeys writes the migration creating the table of
+// pkg/rebolo/apikeys, for apps managing their schema with migrations
+func (g *Generator) GenerateAPIKeys() error {
+	existing, _ := filepath.Glob(filepath.Join(migrationsDir, "*_create_api_keys.sql"))
+	if len(existing) > 0 {
+		fmt.Printf("⚠️  Migration already exists: %s\n", existing[0])
+		return nil
+	}
+
+	os.MkdirAll(migrationsDir, 0755)
+	path := filepath.Join(migrationsDir, time.Now().Format("20060102150405")+"_create_api_keys.sql")
+	if err := g.createFile("migration/api_keys.sql.tmpl", path, nil); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Generated migration: %s\n", path)
+	fmt.Println("   Run 'rebolo db migrate', then keys := apikeys.Enable(app) and app.Use(keys.Middleware)")
+	return nil
+}
+
+// GenerateSettings writes the migration creating the table of
+// pkg/rebolo/settings, for apps managing their schema with migrations
+func (g *Generator) GenerateSettings() error {
+	existing, _ := filepath.Glob(filepath.Join(migrationsDir, "*_create_settings.sql"))
+	if len(existing) > 0 {
+		fmt.Printf("⚠️  Migration already exists: %s\n", existing[0])
+		return nil
+	}
+
+	os.MkdirAll(migrationsDir, 0755)
+	path := filepath.Join(migrationsDir, time.Now().Format("20060102150405")+"_create_settings.sql")
+	if err := g.createFile("migration/settings.sql.tmpl", path, nil); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Generated migration: %s\n", path)
+	fmt.Println("   Run 'rebolo db migrate', then s := settings.Enable(app) and s.Define your settings")
+	return nil
+}
+
+// GenerateJob creates a typed background job and its test in jobs/ and
+// registers it in jobs.Register, which main.go calls
+func (g *Generator) GenerateJob(name string) error {
+	handler := strings.TrimSuffix(inflect.Underscore(name), "_job")
+	data := JobData{
+		Name:        inflect.Camelize(handler),
+		HandlerName: handler,
+	}
+
+	os.MkdirAll("jobs", 0755)
+	registry := filepath.Join("jobs", "jobs.go")
+	if _, err := os.Stat(registry); os.IsNotExist(err) {
+		if err := g.createFile("job/jobs.go.tmpl", registry, data); err != nil {
+			return err
+		}
+	}
+	files := []struct{ tmpl, path string }{
+		{"job/job.go.tmpl", filepath.Join("jobs", handler+".go")},
+		{"job/job_test.go.tmpl", filepath.Join("jobs", handler+"_test.go")},
+	}
+	for _, f := range files {
+		if err := g.createFile(f.tmpl, f.path, data); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("✅ Generated job: %s\n", data.Name)
+
+	if _, err := registerIn([]string{registry}, func(app string) []string {
+		return []string{fmt.Sprintf("%s.Register(%s.RegisterWorkerContext)", data.Name, app)}
+	}); err != nil {
+		fmt.Printf("⚠️  Could not register the job in %s: %v\n", registry, err)
+		fmt.Printf("   Add it to Register: %s.Register(app.RegisterWorkerContext)\n", data.Name)
+	}
+	wireRoutes(func(app string) []string {
+		return []string{fmt.Sprintf("jobs.Register(%s)", app)}
+	}, g.getModuleName()+"/jobs")
+	fmt.Printf("💡 Enqueue it with: jobs.%s.Perform(app, jobs.%sArgs{})\n", data.Name, data.Name)
+	return nil
+}
+
+// GenerateMailer creates a mailer in mailers/ with a method per action,
+// their HTML and text templates in views/mailers/<mailer>/ and previews
+// of them registered in main.go
+func (g *Generator) GenerateMailer(name string, actions []string) error {
+	if len(actions) == 0 {
+		return fmt.Errorf("name at least one email, e.g. rebolo g mailer %s welcome", name)
+	}
+
+	file := strings.TrimSuffix(inflect.Underscore(name), "_mailer") + "_mailer"
+	data := MailerData{Name: inflect.Camelize(file), FileName: file}
+	for _, action := range actions {
+		action = inflect.Underscore(action)
+		data.Actions = append(data.Actions, MailerAction{
+			Name:    action,
+			Method:  inflect.Camelize(action),
+			Subject: inflect.Humanize(action),
+		})
+	}
+
+	viewsDir := filepath.Join("views", "mailers", file)
+	os.MkdirAll("mailers", 0755)
+	os.MkdirAll(viewsDir, 0755)
+
+	files := []struct{ tmpl, path string }{
+		{"mailer/mailer.go.tmpl", filepath.Join("mailers", file+".go")},
+		{"mailer/preview.go.tmpl", filepath.Join("mailers", file+"_preview.go")},
+	}
+	for _, f := range files {
+		if err := g.createFile(f.tmpl, f.path, data); err != nil {
+			return err
+		}
+	}
+
+	layout := filepath.Join("views", "mailers", "layout.html")
+	if _, err := os.Stat(layout); os.IsNotExist(err) {
+		if err := g.createFile("mailer/layout.html.tmpl", layout, nil); err != nil {
+			return err
+		}
+	}
+	for _, action := range data.Actions {
+		viewData := map[string]string{
+			"Mailer":  file,
+			"Action":  action.Name,
+			"Subject": action.Subject,
+		}
+		for _, ext := range []string{"html", "txt"} {
+			path := filepath.Join(viewsDir, action.Name+"."+ext)
+			if err := g.createFile("mailer/action."+ext+".tmpl", path, viewData); err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Printf("✅ Generated mailer: %s\n", data.Name)
+
+	wireRoutes(func(app string) []string {
+		return []string{fmt.Sprintf("mailers.%sPreviews(%s)", data.Name, app)}
+	}, g.getModuleName()+"/mailers")
+	fmt.Printf("💡 Send with: (&mailers.%s{App: app}).%s(user.Email, user)\n", data.Name, data.Actions[0].Method)
+	fmt.Println("   Preview the emails at /__rebolo__/mailers in development")
+	return nil
+}
+
+// GenerateGraphQL scaffolds a gqlgen server in graph/ with a schema file
+// and a resolver, runs gqlgen to generate the executable schema and the
+// resolver stubs and mounts the server with app.GraphQL
+func (g *Generator) GenerateGraphQL() error {
+	data := GraphQLData{Module: g.getModuleName()}
+
+	os.MkdirAll("graph", 0755)
+	files := []struct{ tmpl, path string }{
+		{"graphql/gqlgen.yml.tmpl", "gqlgen.yml"},
+		{"graphql/schema.graphqls.tmpl", filepath.Join("graph", "schema.graphqls")},
+		{"graphql/resolver.go.tmpl", filepath.Join("graph", "resolver.go")},
+		{"graphql/schema.resolvers.go.tmpl", filepath.Join("graph", "schema.resolvers.go")},
+	}
+	for _, f := range files {
+		if err := g.createFile(f.tmpl, f.path, data); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("📦 Adding gqlgen and generating graph/generated.go...\n")
+	if err := runBuildCommand("go", "get", "-tool", gqlgenModule); err != nil {
+		return fmt.Errorf("adding gqlgen: %w", err)
+	}
+	if err := runBuildCommand("go", "tool", "gqlgen", "generate"); err != nil {
+		fmt.Println("💡 Fix the schema and run: go generate ./graph")
+		return fmt.Errorf("gqlgen generate: %w", err)
+	}
+
+	// server.go needs the executable schema gqlgen just generated
+	if err := g.createFile("graphql/server.go.tmpl", filepath.Join("graph", "server.go"), data); err != nil {
+		return err
+	}
+	if err := runBuildCommand("go", "mod", "tidy"); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Generated GraphQL server in graph/\n")
+	fmt.Printf("💡 Edit graph/schema.graphqls, then run: go generate ./graph\n")
+
+	wireRoutes(func(app string) []string {
+		return []string{fmt.Sprintf("%s.GraphQL(graph.NewServer(%s))", app, app)}
+	}, data.Module+"/graph")
+	return nil
+}
+
+// CopyTemplates copies the embedded templates under dirs (all of them when
+// empty) to overrideDir so the project can customize them. Existing copies
+// are kept.
+func (g *Generator) CopyTemplates(dirs []string) error {
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	copied := 0
+	for _, dir := range dirs {
+		root := path.Join("templates", dir)
+		if _, err := fs.Stat(templates, root); err != nil {
+			return fmt.Errorf("no templates in %s", dir)
+		}
+
+		err := fs.WalkDir(templates, root, func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			target := filepath.Join(overrideDir, strings.TrimPrefix(name, "templates/"))
+			if _, err := os.Stat(target); err == nil {
+				fmt.Printf("⚠️  %s exists, skipping\n", target)
+				return nil
+			}
+
+			content, err := templates.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(target, content, 0644); err != nil {
+				return err
+			}
+			fmt.Printf("📝 Created %s\n", target)
+			copied++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("✅ Copied %d template(s) to %s, edit them to change what generators create\n", copied, overrideDir)
+	return nil
+}
+
+// createFile renders a template to filePath, leaving existing files alone
+func (g *Generator) createFile(tmplName, filePath string, data interface{}) error {
+	if _, err := os.Stat(filePath); err == nil {
+		fmt.Printf("⚠️  %s exists, skipping\n", filePath)
+		return nil
+	}
+
+	if err := g.renderFile(tmplName, filePath, data); err != nil {
+		return err
+	}
+
+	fmt.Printf("📝 Created %s\n", filePath)
+	return nil
+}
@@ -4,12 +4,12 @@ import (
 	"embed"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"text/template"
 	"time"
 
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/inflect"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
@@ -17,9 +17,30 @@ import (
 //go:embed templates
 var templates embed.FS
 
+// overrideDir holds project templates that replace the embedded ones,
+// e.g. .rebolo/templates/resource/controller.go.tmpl
+const overrideDir = ".rebolo/templates"
+
+// readTemplate returns a template relative to templates/, preferring the
+// project's copy in overrideDir
+func readTemplate(name string) ([]byte, error) {
+	if content, err := os.ReadFile(filepath.Join(overrideDir, name)); err == nil {
+		fmt.Printf("🎨 Using %s\n", filepath.Join(overrideDir, name))
+		return content, nil
+	}
+	return templates.ReadFile("templates/" + name)
+}
+
 type Generator struct {
-	templates   *template.Template
 	typeMapping *FieldTypeMapping
+	Model       ModelOptions
+	HTMX        bool // resources update their list in place with htmx partials
+}
+
+// ModelOptions are the conventional columns of generated models
+type ModelOptions struct {
+	Timestamps bool // created_at and updated_at, set by the generated controllers
+	SoftDelete bool // deleted_at, set instead of deleting the row
 }
 
 type AppData struct {
@@ -28,10 +49,27 @@ type AppData struct {
 	Framework        string
 	Title            string
 	FrontendFramework string
+	Database         string
+	APIOnly          bool
+	Assets           bool   // asset pipeline in src/
+	AssetTool        string // bun, vite or esbuild when Assets is set
+	HTMX             bool
+	FrameworkVersion string // required rebololang version, empty if unknown
+}
+
+// AppOptions are the choices made when creating an app with `rebolo new`
+type AppOptions struct {
+	Name     string
+	Frontend string // bun, vite, esbuild, none, htmx, react, svelte or vue
+	Database string // sqlite, postgres or mysql
+	APIOnly  bool
+	Module   string // Go module path, defaults to the app name
+	SkipTidy bool   // don't run go mod tidy, e.g. when offline
 }
 
 type ResourceData struct {
 	Name       string
+	PluralName string
 	VarName    string
 	Module     string
 	TableName  string
@@ -40,6 +78,22 @@ type ResourceData struct {
 	Fields     []Field
 	FirstField string
 	Timestamp  string
+	Timestamps bool // embeds model.Timestamps, see ModelOptions
+	SoftDelete bool // embeds model.SoftDeletes
+	HTMX       bool // controllers answer htmx requests with partials
+}
+
+// HasType reports whether one of the fields has one of the Go types,
+// for the imports of the templates
+func (d ResourceData) HasType(goTypes ...string) bool {
+	for _, f := range d.Fields {
+		for _, t := range goTypes {
+			if f.GoType == t {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 type Field struct {
@@ -51,70 +105,102 @@ type Field struct {
 	HTMLType string
 }
 
+// Spatial reports whether the field is a point or polygon, kept in a
+// geometry column
+func (f Field) Spatial() bool {
+	return strings.HasPrefix(f.GoType, "geo.")
+}
+
+// NewGenerator returns the generator behind `rebolo new`, `generate` and
+// `destroy`. Every file is rendered by renderFile from the embedded
+// templates, or their .rebolo/templates overrides.
 func NewGenerator() *Generator {
-	// Parse all template files recursively
-	tmpl := template.New("").Funcs(template.FuncMap{
-		"title": func(s string) string { return cases.Title(language.English).String(s) },
-		"lower": strings.ToLower,
-	})
-
-	// Parse templates manually to handle nested directories
-	tmpl = template.Must(tmpl.ParseFS(templates,
-		"templates/app/main.go.tmpl",
-		"templates/app/main_spa.go.tmpl",
-		"templates/app/package.json.tmpl",
-		"templates/app/src/index.js.tmpl",
-		"templates/app/src/styles.css.tmpl",
-		"templates/app/views/layouts/application.html.tmpl",
-		"templates/app/views/home/index.html.tmpl",
-		"templates/config/config.yml.tmpl",
-		"templates/resource/model.go.tmpl",
-		"templates/resource/controller.go.tmpl",
-		"templates/resource/migration.sql.tmpl",
-	))
+	// Custom inflections of the app, e.g. irregular plurals for table names
+	if err := inflect.LoadFile("inflections.yml"); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("⚠️  %v\n", err)
+	}
 
 	return &Generator{
-		templates:   tmpl,
 		typeMapping: DefaultFieldTypeMapping(),
+		Model:       ModelOptions{Timestamps: true},
 	}
 }
 
-func (g *Generator) GenerateApp(name string, frontendFramework string) error {
+func (g *Generator) GenerateApp(opts AppOptions) error {
+	name := opts.Name
+
 	// Validate frontend framework
 	validFrameworks := map[string]bool{
-		"react":  true,
-		"svelte": true,
-		"vue":    true,
-		"none":   true,
+		"bun":     true,
+		"vite":    true,
+		"esbuild": true,
+		"none":    true,
+		"htmx":    true,
+		"react":   true,
+		"svelte":  true,
+		"vue":     true,
 	}
-	
+
+	frontendFramework := opts.Frontend
 	if frontendFramework == "" {
+		frontendFramework = "bun"
+	}
+	if opts.APIOnly {
 		frontendFramework = "none"
 	}
-	
+
 	if !validFrameworks[frontendFramework] {
-		return fmt.Errorf("invalid frontend framework: %s. Valid options are: react, svelte, vue, none", frontendFramework)
+		return fmt.Errorf("invalid frontend: %s. Valid options are: bun, vite, esbuild, none, htmx, react, svelte, vue", frontendFramework)
+	}
+
+	database := opts.Database
+	if database == "" {
+		database = "sqlite"
+	}
+	if database != "sqlite" && database != "postgres" && database != "mysql" {
+		return fmt.Errorf("invalid database: %s. Valid options are: sqlite, postgres, mysql", database)
+	}
+
+	module := opts.Module
+	if module == "" {
+		module = name
 	}
 
 	data := AppData{
 		Name:             name,
-		Module:           fmt.Sprintf("github.com/Palaciodiego008/%s", name),
+		Module:           module,
 		Framework:        "ReboloLang",
 		Title:            fmt.Sprintf("Welcome to %s", name),
 		FrontendFramework: frontendFramework,
+		Database:         database,
+		APIOnly:          opts.APIOnly,
+		Assets:           frontendFramework == "bun" || frontendFramework == "vite" || frontendFramework == "esbuild",
+		HTMX:             frontendFramework == "htmx",
+		FrameworkVersion: frameworkVersion(),
 	}
+	if data.Assets {
+		data.AssetTool = frontendFramework
+	}
+	spa := frontendFramework == "react" || frontendFramework == "svelte" || frontendFramework == "vue"
 
 	// Create directory structure
 	dirs := []string{
 		name,
 		filepath.Join(name, "controllers"),
 		filepath.Join(name, "models"),
-		filepath.Join(name, "views", "home"),
-		filepath.Join(name, "views", "layouts"),
-		filepath.Join(name, "public"),
-		filepath.Join(name, "src"),
 		filepath.Join(name, "db", "migrations"),
 	}
+	if !opts.APIOnly {
+		dirs = append(dirs,
+			filepath.Join(name, "views", "home"),
+			filepath.Join(name, "views", "layouts"),
+			filepath.Join(name, "views", "errors"),
+			filepath.Join(name, "public"),
+		)
+	}
+	if data.Assets {
+		dirs = append(dirs, filepath.Join(name, "src"))
+	}
 
 	for _, dir := range dirs {
 		if err := os.MkdirAll(dir, 0755); err != nil {
@@ -124,51 +210,95 @@ func (g *Generator) GenerateApp(name string, frontendFramework string) error {
 
 	// Generate files from templates
 	files := map[string]string{
-		filepath.Join(name, "package.json"):                         "app/package.json.tmpl",
-		filepath.Join(name, "config.yml"):                           "config/config.yml.tmpl",
-		filepath.Join(name, "src", "index.js"):                      "app/src/index.js.tmpl",
-		filepath.Join(name, "src", "styles.css"):                    "app/src/styles.css.tmpl",
-		filepath.Join(name, "views", "layouts", "application.html"): "app/views/layouts/application.html.tmpl",
-		filepath.Join(name, "views", "home", "index.html"):          "app/views/home/index.html.tmpl",
+		filepath.Join(name, "go.mod"):          "app/go.mod.tmpl",
+		filepath.Join(name, "config.yml"):      "config/config.yml.tmpl",
+		filepath.Join(name, "config.test.yml"): "config/config.test.yml.tmpl",
 	}
-	
+	if data.Assets {
+		for file, tmplName := range frontendTools[data.AssetTool].Files() {
+			files[filepath.Join(name, file)] = tmplName
+		}
+		files[filepath.Join(name, "src", "index.js")] = "app/src/index.js.tmpl"
+		files[filepath.Join(name, "src", "styles.css")] = "app/src/styles.css.tmpl"
+	}
+	if !opts.APIOnly {
+		files[filepath.Join(name, "views", "layouts", "application.html")] = "app/views/layouts/application.html.tmpl"
+		files[filepath.Join(name, "views", "home", "index.html")] = "app/views/home/index.html.tmpl"
+	}
+
 	// Use different main.go template based on frontend
-	if frontendFramework != "none" {
+	switch {
+	case opts.APIOnly:
+		files[filepath.Join(name, "main.go")] = "app/main_api.go.tmpl"
+	case spa:
 		files[filepath.Join(name, "main.go")] = "app/main_spa.go.tmpl"
-	} else {
+	default:
 		files[filepath.Join(name, "main.go")] = "app/main.go.tmpl"
 	}
 
 	for filePath, tmplName := range files {
-		if err := g.renderTemplate(tmplName, filePath, data); err != nil {
+		if err := g.renderFile(tmplName, filePath, data); err != nil {
+			return fmt.Errorf("failed to generate %s: %w", filePath, err)
+		}
+	}
+
+	// Error pages are runtime templates, copy them verbatim
+	staticFiles := map[string]string{
+		filepath.Join(name, "views", "errors", "404.html"): "app/views/errors/404.html.tmpl",
+		filepath.Join(name, "views", "errors", "500.html"): "app/views/errors/500.html.tmpl",
+	}
+
+	if opts.APIOnly {
+		staticFiles = nil
+	}
+
+	for filePath, tmplPath := range staticFiles {
+		content, err := readTemplate(tmplPath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", tmplPath, err)
+		}
+		if err := os.WriteFile(filePath, content, 0644); err != nil {
 			return fmt.Errorf("failed to generate %s: %w", filePath, err)
 		}
 	}
 
-	// Initialize go.mod (like Buffalo does)
-	fmt.Printf("📦 Initializing Go module...\n")
-	cmd := exec.Command("go", "mod", "init", name)
-	cmd.Dir = name
-	if err := cmd.Run(); err != nil {
-		// If go.mod already exists or error, continue (user might have created it manually)
-		fmt.Printf("⚠️  Note: go mod init skipped (module may already exist)\n")
+	tidied := false
+	if !opts.SkipTidy {
+		if err := tidyModule(name, data.FrameworkVersion); err != nil {
+			// The app is generated, only its dependencies are missing (e.g. offline)
+			fmt.Printf("⚠️  go mod tidy failed, run it once online: %v\n", err)
+		} else {
+			tidied = true
+		}
 	}
 
 	// Generate frontend if framework is specified
-	if frontendFramework != "none" {
+	if spa {
 		if err := g.generateFrontend(name, frontendFramework, data); err != nil {
 			return fmt.Errorf("failed to generate frontend: %w", err)
 		}
 	}
 
 	fmt.Printf("✅ Generated app: %s\n", name)
-	if frontendFramework != "none" {
-		fmt.Printf("🎨 Frontend framework: %s\n", frontendFramework)
+	switch {
+	case opts.APIOnly:
+		fmt.Printf("🔌 API only (no views or assets)\n")
+	case frontendFramework != "bun":
+		fmt.Printf("🎨 Frontend: %s\n", frontendFramework)
 	}
+	fmt.Printf("🗄️  Database: %s\n", database)
 	fmt.Printf("💡 Next steps:\n")
 	fmt.Printf("   cd %s\n", name)
-	fmt.Printf("   go mod tidy\n")
-	if frontendFramework != "none" {
+	if !tidied {
+		fmt.Printf("   go mod tidy\n")
+	}
+	if database != "sqlite" {
+		fmt.Printf("   rebolo db migrate          # after starting %s\n", database)
+	}
+	if data.Assets && data.AssetTool != "bun" {
+		fmt.Printf("   npm install\n")
+	}
+	if spa {
 		fmt.Printf("   cd frontend && bun install\n")
 		fmt.Printf("   cd .. && rebolo dev\n")
 	} else {
@@ -178,22 +308,7 @@ func (g *Generator) GenerateApp(name string, frontendFramework string) error {
 }
 
 func (g *Generator) GenerateResource(name string, fieldArgs []string) error {
-	fields := g.parseFields(fieldArgs)
-
-	// Get module name from go.mod
-	moduleName := g.getModuleName()
-
-	data := ResourceData{
-		Name:       cases.Title(language.English).String(name),
-		VarName:    strings.ToLower(name),
-		Module:     moduleName,
-		TableName:  g.pluralize(strings.ToLower(name)),
-		ViewPath:   g.pluralize(strings.ToLower(name)),
-		RoutePath:  g.pluralize(strings.ToLower(name)),
-		Fields:     fields,
-		FirstField: g.getFirstStringField(fields),
-		Timestamp:  time.Now().Format("20060102150405"),
-	}
+	data := g.resourceData(name, fieldArgs)
 
 	// Create directories
 	os.MkdirAll("models", 0755)
@@ -209,7 +324,7 @@ func (g *Generator) GenerateResource(name string, fieldArgs []string) error {
 	}
 
 	for filePath, tmplName := range files {
-		if err := g.renderTemplate(tmplName, filePath, data); err != nil {
+		if err := g.renderFile(tmplName, filePath, data); err != nil {
 			return fmt.Errorf("failed to generate %s: %w", filePath, err)
 		}
 	}
@@ -225,21 +340,63 @@ func (g *Generator) GenerateResource(name string, fieldArgs []string) error {
 	fmt.Printf("   - Migration: db/migrations/%s_create_%s.sql\n", data.Timestamp, data.TableName)
 	fmt.Printf("   - Views: views/%s/\n", data.ViewPath)
 
+	wireRoutes(func(app string) []string {
+		return []string{fmt.Sprintf("%s.Resource(\"/%s\", &controllers.%sController{App: %s})", app, data.RoutePath, data.Name, app)}
+	}, data.Module+"/controllers")
+
 	return nil
 }
 
-func (g *Generator) renderTemplate(tmplName, filePath string, data interface{}) error {
+// resourceData builds the template data shared by the resource, model
+// and migration generators
+func (g *Generator) resourceData(name string, fieldArgs []string) ResourceData {
+	fields := g.parseFields(fieldArgs)
+	singular := inflect.Singularize(inflect.Underscore(name))
+	plural := inflect.Pluralize(singular)
+
+	return ResourceData{
+		Name:       inflect.Camelize(singular),
+		PluralName: inflect.Camelize(plural),
+		VarName:    singular,
+		Module:     g.getModuleName(),
+		TableName:  plural,
+		ViewPath:   plural,
+		RoutePath:  plural,
+		Fields:     fields,
+		FirstField: g.getFirstStringField(fields),
+		Timestamp:  time.Now().Format("20060102150405"),
+		Timestamps: g.Model.Timestamps,
+		SoftDelete: g.Model.SoftDelete,
+		HTMX:       g.HTMX,
+	}
+}
+
+// renderFile parses a single template on its own, so templates sharing a
+// file name in different directories don't clash, and writes it to filePath
+func (g *Generator) renderFile(tmplName, filePath string, data interface{}) error {
+	content, err := readTemplate(tmplName)
+	if err != nil {
+		return fmt.Errorf("failed to read template %s: %w", tmplName, err)
+	}
+
+	tmpl, err := template.New(filepath.Base(filePath)).Funcs(template.FuncMap{
+		"title": func(s string) string { return cases.Title(language.English).String(s) },
+		"lower": strings.ToLower,
+	}).Parse(string(content))
+	if err != nil {
+		return fmt.Errorf("failed to parse template %s: %w", tmplName, err)
+	}
+
 	file, err := os.Create(filePath)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to create file %s: %w", filePath, err)
 	}
 	defer file.Close()
 
-	// Extract just the filename from the template path
-	parts := strings.Split(tmplName, "/")
-	templateName := parts[len(parts)-1]
-
-	return g.templates.ExecuteTemplate(file, templateName, data)
+	if err := tmpl.Execute(file, data); err != nil {
+		return fmt.Errorf("failed to execute template %s: %w", tmplName, err)
+	}
+	return nil
 }
 
 func (g *Generator) parseFields(fieldArgs []string) []Field {
@@ -255,9 +412,9 @@ func (g *Generator) parseFields(fieldArgs []string) []Field {
 		fieldType := parts[1]
 
 		field := Field{
-			Name:     cases.Title(language.English).String(name),
-			DBName:   strings.ToLower(name),
-			FormName: strings.ToLower(name),
+			Name:     inflect.Camelize(name),
+			DBName:   inflect.Underscore(name),
+			FormName: inflect.Underscore(name),
 			GoType:   g.mapToGoType(fieldType),
 			SQLType:  g.mapToSQLType(fieldType),
 			HTMLType: g.mapToHTMLType(fieldType),
@@ -290,66 +447,25 @@ func (g *Generator) mapToHTMLType(goType string) string {
 	return "text" // default fallback
 }
 
-func (g *Generator) pluralize(word string) string {
-	// Enhanced pluralization rules
-	switch {
-	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"), strings.HasSuffix(word, "z"):
-		return word + "es"
-	case strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
-		return word + "es"
-	case strings.HasSuffix(word, "y"):
-		// Check if preceded by consonant
-		if len(word) > 1 && !isVowel(rune(word[len(word)-2])) {
-			return word[:len(word)-1] + "ies"
-		}
-		return word + "s"
-	case strings.HasSuffix(word, "f"):
-		return word[:len(word)-1] + "ves"
-	case strings.HasSuffix(word, "fe"):
-		return word[:len(word)-2] + "ves"
-	default:
-		return word + "s"
-	}
-}
-
-func isVowel(r rune) bool {
-	return strings.ContainsRune("aeiouAEIOU", r)
-}
-
 func (g *Generator) generateResourceViews(data ResourceData) error {
-	viewTemplates := map[string]string{
-		"index.html": "templates/resource/index.html.tmpl",
-		"show.html":  "templates/resource/show.html.tmpl",
-		"new.html":   "templates/resource/new.html.tmpl",
-		"edit.html":  "templates/resource/edit.html.tmpl",
-	}
-
-	for filename, tmplPath := range viewTemplates {
-		// Read template content
-		tmplContent, err := templates.ReadFile(tmplPath)
-		if err != nil {
-			return fmt.Errorf("failed to read %s: %w", tmplPath, err)
-		}
-
-		// Create new template instance for each view
-		tmpl, err := template.New(filename).Funcs(template.FuncMap{
-			"title": func(s string) string { return cases.Title(language.English).String(s) },
-			"lower": strings.ToLower,
-		}).Parse(string(tmplContent))
-		if err != nil {
-			return fmt.Errorf("failed to parse %s: %w", filename, err)
-		}
-
-		// Generate the view file
-		filePath := filepath.Join("views", data.ViewPath, filename)
-		file, err := os.Create(filePath)
-		if err != nil {
-			return fmt.Errorf("failed to create %s: %w", filePath, err)
-		}
-		defer file.Close()
-
-		if err := tmpl.Execute(file, data); err != nil {
-			return fmt.Errorf("failed to execute template for %s: %w", filename, err)
+	views := map[string]string{}
+	for _, view := range []string{"index.html", "show.html", "new.html", "edit.html"} {
+		views[view] = "resource/" + view + ".tmpl"
+	}
+	// htmx resources swap partials, named with a leading underscore, into
+	// the index page. The templates can't have it, go:embed skips such files.
+	if data.HTMX {
+		views["index.html"] = "resource/htmx/index.html.tmpl"
+		views["_list.html"] = "resource/htmx/list.html.tmpl"
+		views["_form.html"] = "resource/htmx/form.html.tmpl"
+		views["_"+data.VarName+".html"] = "resource/htmx/item.html.tmpl"
+	}
+
+	for view, tmplName := range views {
+		// Each view is parsed on its own to avoid name conflicts
+		filePath := filepath.Join("views", data.ViewPath, view)
+		if err := g.renderFile(tmplName, filePath, data); err != nil {
+			return err
 		}
 	}
 
@@ -438,26 +554,9 @@ func (g *Generator) generateFrontend(appName, framework string, data AppData) er
 
 	// Generate all frontend files from templates
 	for filePath, tmplName := range files {
-		tmplContent, err := templates.ReadFile("templates/" + tmplName)
-		if err != nil {
-			return fmt.Errorf("failed to read template %s: %w", tmplName, err)
-		}
-
-		tmpl, err := template.New(filepath.Base(filePath)).Parse(string(tmplContent))
-		if err != nil {
-			return fmt.Errorf("failed to parse template %s: %w", tmplName, err)
-		}
-
-		file, err := os.Create(filePath)
-		if err != nil {
-			return fmt.Errorf("failed to create file %s: %w", filePath, err)
-		}
-
-		if err := tmpl.Execute(file, data); err != nil {
-			file.Close()
-			return fmt.Errorf("failed to execute template %s: %w", tmplName, err)
+		if err := g.renderFile(tmplName, filePath, data); err != nil {
+			return err
 		}
-		file.Close()
 	}
 
 	// Create components directory
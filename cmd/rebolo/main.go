@@ -3,8 +3,8 @@ package main
 import (
 	"fmt"
 	"os"
+	"os/exec"
 
-	"github.com/Palaciodiego008/rebololang/pkg/rebolo/tasks"
 	"github.com/spf13/cobra"
 )
 
@@ -17,18 +17,31 @@ var rootCmd = &cobra.Command{
 var newCmd = &cobra.Command{
 	Use:   "new [app-name]",
 	Short: "Create a new ReboloLang application",
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		appName := args[0]
-		frontendFramework, _ := cmd.Flags().GetString("frontend")
-		
-		fmt.Printf("Creating new ReboloLang app: %s\n", appName)
-		if frontendFramework != "" && frontendFramework != "none" {
-			fmt.Printf("Frontend framework: %s\n", frontendFramework)
+		opts := AppOptions{}
+		opts.Frontend, _ = cmd.Flags().GetString("frontend")
+		opts.Database, _ = cmd.Flags().GetString("db")
+		opts.APIOnly, _ = cmd.Flags().GetBool("api-only")
+		opts.Module, _ = cmd.Flags().GetString("module")
+		opts.SkipTidy, _ = cmd.Flags().GetBool("skip-tidy")
+		interactive, _ := cmd.Flags().GetBool("interactive")
+		if len(args) > 0 {
+			opts.Name = args[0]
+		}
+
+		// Without a name there's nothing to create, ask for everything
+		if interactive || opts.Name == "" {
+			if err := promptAppOptions(&opts, os.Stdin); err != nil {
+				fmt.Printf("❌ Failed to read answers: %v\n", err)
+				os.Exit(1)
+			}
 		}
 
+		fmt.Printf("Creating new ReboloLang app: %s\n", opts.Name)
+
 		generator := NewGenerator()
-		if err := generator.GenerateApp(appName, frontendFramework); err != nil {
+		if err := generator.GenerateApp(opts); err != nil {
 			fmt.Printf("❌ Failed to generate app: %v\n", err)
 			os.Exit(1)
 		}
@@ -39,8 +52,9 @@ var devCmd = &cobra.Command{
 	Use:   "dev",
 	Short: "Start development server with hot reload",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Starting ReboloLang development server...")
-		startDevServer()
+		port, _ := cmd.Flags().GetInt("port")
+		noProxy, _ := cmd.Flags().GetBool("no-proxy")
+		startDevServer(DevOptions{Port: port, Proxy: !noProxy})
 	},
 }
 
@@ -69,45 +83,344 @@ var migrateCmd = &cobra.Command{
 	Short: "Run database migrations",
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("Running database migrations...")
-		runMigrations()
+		if err := runMigrations(); err != nil {
+			fmt.Printf("❌ Migration failed: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var maintainCmd = &cobra.Command{
+	Use:   "maintain",
+	Short: "Run database maintenance (VACUUM/ANALYZE, OPTIMIZE on MySQL)",
+	Run: func(cmd *cobra.Command, args []string) {
+		tables, _ := cmd.Flags().GetStringSlice("table")
+		operations, _ := cmd.Flags().GetStringSlice("op")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		fmt.Println("🧹 Running database maintenance...")
+		if err := runMaintenance(tables, operations, timeout); err != nil {
+			fmt.Printf("❌ Maintenance failed: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [name]",
+	Short: "Generate a migration adding the tables and columns models have but the database lacks",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := "sync_models"
+		if len(args) > 0 {
+			name = args[0]
+		}
+		dir, _ := cmd.Flags().GetString("dir")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		fmt.Println("🔍 Comparing models with the database schema...")
+		if err := runDiff(name, dir, dryRun); err != nil {
+			fmt.Printf("❌ Diff failed: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var annotateCmd = &cobra.Command{
+	Use:   "annotate",
+	Short: "Document table columns, indexes and constraints in model files",
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, _ := cmd.Flags().GetString("dir")
+		check, _ := cmd.Flags().GetBool("check")
+
+		fmt.Println("📝 Annotating models from the database schema...")
+		if err := runAnnotate(dir, check); err != nil {
+			fmt.Printf("❌ Annotate failed: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var upgradeAppCmd = &cobra.Command{
+	Use:   "upgrade-app",
+	Short: "Find deprecated framework APIs in the app and rewrite what is safe",
+	Run: func(cmd *cobra.Command, args []string) {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		fmt.Println("🔎 Scanning for deprecated APIs...")
+		if err := runUpgradeApp(".", dryRun); err != nil {
+			fmt.Printf("❌ Upgrade failed: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Install the latest rebolo release and report breaking changes concerning the app",
+	Run: func(cmd *cobra.Command, args []string) {
+		check, _ := cmd.Flags().GetBool("check")
+		report, _ := cmd.Flags().GetBool("report")
+
+		var err error
+		if report {
+			err = reportBreakingChanges()
+		} else {
+			err = runUpgrade(check)
+		}
+		if err != nil {
+			fmt.Printf("❌ Upgrade failed: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the project's config, database, tools, views and ports, and suggest fixes",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("🩺 Checking the project...")
+		if !runDoctor() {
+			os.Exit(1)
+		}
+	},
+}
+
+var testCmd = &cobra.Command{
+	Use:   "test [-- go test flags and packages]",
+	Short: "Create and migrate the test database, then run go test",
+	Long:  `Runs go test ./... with REBOLO_ENV=test, so config.test.yml is loaded. Arguments after -- are passed to go test.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		watch, _ := cmd.Flags().GetBool("watch")
+
+		if err := runTests(args, watch); err != nil {
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				os.Exit(exitErr.ExitCode())
+			}
+			fmt.Printf("❌ Tests failed: %v\n", err)
+			os.Exit(1)
+		}
 	},
 }
 
 var resourceCmd = &cobra.Command{
-	Use:   "resource [name] [fields...]",
-	Short: "Generate a complete resource (model, controller, views, routes)",
-	Args:  cobra.MinimumNArgs(1),
+	Use:     "resource [name] [fields...]",
+	Short:   "Generate a complete resource (model, controller, views, routes)",
+	Aliases: []string{"scaffold"},
+	Args:    cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		resourceName := args[0]
 		fields := args[1:]
+		api, _ := cmd.Flags().GetBool("api")
 		fmt.Printf("Generating resource: %s with fields: %v\n", resourceName, fields)
 
 		generator := NewGenerator()
-		if err := generator.GenerateResource(resourceName, fields); err != nil {
+		generator.Model = modelOptions(cmd)
+		generator.HTMX, _ = cmd.Flags().GetBool("htmx")
+		generate := generator.GenerateResource
+		if api {
+			generate = generator.GenerateAPIResource
+		}
+		if err := generate(resourceName, fields); err != nil {
 			fmt.Printf("❌ Failed to generate resource: %v\n", err)
 			os.Exit(1)
 		}
 	},
 }
 
+var modelCmd = &cobra.Command{
+	Use:   "model [name] [fields...]",
+	Short: "Generate a model and the migration creating its table",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		skipMigration, _ := cmd.Flags().GetBool("skip-migration")
+
+		generator := NewGenerator()
+		generator.Model = modelOptions(cmd)
+		if err := generator.GenerateModel(args[0], args[1:], skipMigration); err != nil {
+			fmt.Printf("❌ Failed to generate model: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var controllerCmd = &cobra.Command{
+	Use:   "controller [name] [actions...]",
+	Short: "Generate a controller with a view per action",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		generator := NewGenerator()
+		if err := generator.GenerateController(args[0], args[1:]); err != nil {
+			fmt.Printf("❌ Failed to generate controller: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var migrationCmd = &cobra.Command{
+	Use:   "migration [name] [fields...]",
+	Short: "Generate a migration (create_x, add_x_to_y and remove_x_from_y are filled in)",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		generator := NewGenerator()
+		if err := generator.GenerateMigration(args[0], args[1:]); err != nil {
+			fmt.Printf("❌ Failed to generate migration: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var jobCmd = &cobra.Command{
+	Use:   "job [name]",
+	Short: "Generate a background job handler",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		generator := NewGenerator()
+		if err := generator.GenerateJob(args[0]); err != nil {
+			fmt.Printf("❌ Failed to generate job: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var mailerCmd = &cobra.Command{
+	Use:   "mailer [name] [emails...]",
+	Short: "Generate a mailer with HTML and text templates per email, previewed in development",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		generator := NewGenerator()
+		if err := generator.GenerateMailer(args[0], args[1:]); err != nil {
+			fmt.Printf("❌ Failed to generate mailer: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var apikeysCmd = &cobra.Command{
+	Use:   "apikeys",
+	Short: "Generate the migration creating the API keys table",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		generator := NewGenerator()
+		if err := generator.GenerateAPIKeys(); err != nil {
+			fmt.Printf("❌ Failed to generate API keys migration: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var settingsCmd = &cobra.Command{
+	Use:   "settings",
+	Short: "Generate the migration creating the settings table",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		generator := NewGenerator()
+		if err := generator.GenerateSettings(); err != nil {
+			fmt.Printf("❌ Failed to generate settings migration: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var graphqlCmd = &cobra.Command{
+	Use:   "graphql",
+	Short: "Generate a gqlgen GraphQL server with a schema file and resolver stubs",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		generator := NewGenerator()
+		if err := generator.GenerateGraphQL(); err != nil {
+			fmt.Printf("❌ Failed to generate GraphQL server: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var clientCmd = &cobra.Command{
+	Use:   "client",
+	Short: "Generate a typed Go client of an API from its OpenAPI document",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		from, _ := cmd.Flags().GetString("from")
+		name, _ := cmd.Flags().GetString("name")
+		out, _ := cmd.Flags().GetString("out")
+		if err := NewGenerator().GenerateClient(from, name, out); err != nil {
+			fmt.Printf("❌ Failed to generate client: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates [resource|api|controller|migration|job|app|...]",
+	Short: "Copy the built-in generator templates to .rebolo/templates to customize them",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := NewGenerator().CopyTemplates(args); err != nil {
+			fmt.Printf("❌ Copying templates failed: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var destroyCmd = &cobra.Command{
+	Use:     "destroy [resource|model|controller|migration|job|mailer] [name]",
+	Short:   "Remove the files a generator created and their routes in main.go",
+	Aliases: []string{"d"},
+	Args:    cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runDestroy(args[0], args[1]); err != nil {
+			fmt.Printf("❌ Destroy failed: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var deployCmd = &cobra.Command{
+	Use:   "deploy",
+	Short: "Generate deployment descriptors (systemd, fly, heroku)",
+	Run: func(cmd *cobra.Command, args []string) {
+		target, _ := cmd.Flags().GetString("target")
+		env, _ := cmd.Flags().GetString("env")
+		force, _ := cmd.Flags().GetBool("force")
+
+		generator := NewGenerator()
+		if err := generator.GenerateDeploy(target, env, force); err != nil {
+			fmt.Printf("❌ Failed to generate deployment: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
 var taskCmd = &cobra.Command{
 	Use:   "task [task-name] [args...]",
 	Short: "Run a task (like Rake tasks)",
-	Long:  `Run a registered task. Use 'rebolo task' without arguments to see all available tasks.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Register default tasks
-		tasks.DefaultTasks()
+	Long: `Run a registered task. Use 'rebolo task' without arguments to see all available tasks.
 
-		if err := tasks.RunFromArgs(args); err != nil {
-			fmt.Printf("❌ Task failed: %v\n", err)
-			os.Exit(1)
+In an app's directory the app's own tasks, and the built-in ones using the app
+(routes, middleware, db:sessions:clear, cache:clear), run in the app: it is built and
+started as app task <name>.`,
+	// The task parses its own flags
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
+			cmd.Help()
+			return
 		}
+		os.Exit(runTask(args))
 	},
 }
 
 func init() {
 	// Add flags to new command
-	newCmd.Flags().StringP("frontend", "f", "none", "Frontend framework: react, svelte, vue, or none (default: none)")
+	newCmd.Flags().StringP("frontend", "f", "bun", "Frontend: bun (asset pipeline), vite or esbuild (asset pipeline with npm), htmx, none, react, svelte or vue")
+	newCmd.Flags().String("db", "sqlite", "Database: sqlite, postgres or mysql")
+	newCmd.Flags().Bool("api-only", false, "JSON API without views, assets or frontend")
+	newCmd.Flags().BoolP("interactive", "i", false, "Ask for the options interactively")
+	newCmd.Flags().String("module", "", "Go module path, e.g. github.com/you/myapp (default: the app name)")
+	newCmd.Flags().Bool("skip-tidy", false, "Don't run go mod tidy after generating the app")
+
+	devCmd.Flags().IntP("port", "p", 0, "Port to serve the app on (default: server.port from config.yml)")
+	devCmd.Flags().Bool("no-proxy", false, "Run the Go server directly on its port, without the dev proxy")
 	
 	rootCmd.AddCommand(newCmd)
 	rootCmd.AddCommand(devCmd)
@@ -115,9 +428,75 @@ func init() {
 	rootCmd.AddCommand(generateCmd)
 	rootCmd.AddCommand(dbCmd)
 	rootCmd.AddCommand(taskCmd)
+	rootCmd.AddCommand(annotateCmd)
+	rootCmd.AddCommand(upgradeAppCmd)
+	rootCmd.AddCommand(upgradeCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(testCmd)
+	rootCmd.AddCommand(destroyCmd)
 
 	generateCmd.AddCommand(resourceCmd)
+	generateCmd.AddCommand(modelCmd)
+	generateCmd.AddCommand(controllerCmd)
+	generateCmd.AddCommand(migrationCmd)
+	generateCmd.AddCommand(jobCmd)
+	generateCmd.AddCommand(mailerCmd)
+	generateCmd.AddCommand(graphqlCmd)
+	generateCmd.AddCommand(apikeysCmd)
+	generateCmd.AddCommand(settingsCmd)
+	generateCmd.AddCommand(clientCmd)
+	generateCmd.AddCommand(deployCmd)
+	generateCmd.AddCommand(templatesCmd)
 	dbCmd.AddCommand(migrateCmd)
+	dbCmd.AddCommand(maintainCmd)
+	dbCmd.AddCommand(diffCmd)
+
+	maintainCmd.Flags().StringSliceP("table", "t", nil, "Table to maintain (repeatable, default: all tables)")
+	maintainCmd.Flags().StringSlice("op", nil, "Operations to run: vacuum, analyze, optimize (default: driver defaults)")
+	maintainCmd.Flags().Duration("timeout", 0, "Stop starting new operations after this duration (e.g. 10m)")
+
+	clientCmd.Flags().String("from", "", "OpenAPI document, a YAML or JSON file or a URL like http://localhost:3000/openapi.json")
+	clientCmd.MarkFlagRequired("from")
+	clientCmd.Flags().String("name", "", "Package name (default: from the document's title)")
+	clientCmd.Flags().String("out", "", "Directory to write client.go to (default: clients/<name>)")
+
+	diffCmd.Flags().String("dir", "models", "Directory containing the model files")
+	diffCmd.Flags().Bool("dry-run", false, "Print the SQL instead of writing a migration")
+
+	annotateCmd.Flags().String("dir", "models", "Directory containing the model files")
+	annotateCmd.Flags().Bool("check", false, "Only report out of date files, exit non-zero if any")
+
+	upgradeAppCmd.Flags().Bool("dry-run", false, "Report without rewriting files")
+
+	upgradeCmd.Flags().Bool("check", false, "Only tell whether a newer release is available")
+	upgradeCmd.Flags().Bool("report", false, "Only report the breaking changes concerning the app")
+	upgradeCmd.Flags().MarkHidden("report")
+
+	testCmd.Flags().BoolP("watch", "w", false, "Re-run the tests when files change")
+
+	resourceCmd.Flags().Bool("api", false, "JSON-only resource: no views, request structs with validation")
+	resourceCmd.Flags().Bool("htmx", false, "Views with htmx partials: create, edit and delete update the list without reloading")
+
+	modelCmd.Flags().Bool("skip-migration", false, "Don't generate the create table migration")
+
+	for _, cmd := range []*cobra.Command{resourceCmd, modelCmd} {
+		cmd.Flags().Bool("timestamps", true, "Add created_at and updated_at columns")
+		cmd.Flags().Bool("soft-delete", false, "Add a deleted_at column: deleting sets it and lists hide those rows")
+	}
+
+	deployCmd.Flags().String("target", "", "Deployment target: systemd, fly, or heroku")
+	deployCmd.Flags().String("env", "production", "Environment whose config is used")
+	deployCmd.Flags().Bool("force", false, "Overwrite existing files")
+	deployCmd.MarkFlagRequired("target")
+
+	registerCompletions()
+}
+
+// modelOptions reads the --timestamps and --soft-delete flags
+func modelOptions(cmd *cobra.Command) ModelOptions {
+	timestamps, _ := cmd.Flags().GetBool("timestamps")
+	softDelete, _ := cmd.Flags().GetBool("soft-delete")
+	return ModelOptions{Timestamps: timestamps, SoftDelete: softDelete}
 }
 
 func main() {
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/maintenance"
+)
+
+func runMaintenance(tables, operations []string, timeout time.Duration) error {
+	database, config, err := connectDatabase()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	runner, err := maintenance.NewRunner(database.DB().(*sql.DB), config.Database.Driver)
+	if err != nil {
+		return err
+	}
+
+	results, err := runner.Run(context.Background(), maintenance.Options{
+		Tables:      tables,
+		Operations:  operations,
+		MaxDuration: timeout,
+	})
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, res := range results {
+		target := res.Table
+		if target == "" {
+			target = "(database)"
+		}
+		if res.Err != nil {
+			failed++
+			fmt.Printf("   ❌ %-9s %s: %v\n", res.Operation, target, res.Err)
+			continue
+		}
+		fmt.Printf("   ✓ %-9s %s (%v)\n", res.Operation, target, res.Duration.Round(time.Millisecond))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d operation(s) failed", failed)
+	}
+	fmt.Printf("✅ Maintenance completed (%d operations)\n", len(results))
+	return nil
+}
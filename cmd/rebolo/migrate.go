@@ -1,60 +1,42 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"io/fs"
-	"log"
-	"os"
 	"path/filepath"
-	"sort"
-	"strings"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/migrate"
 )
 
-func runMigrations() {
-	migrationsDir := "db/migrations"
-	
-	if _, err := os.Stat(migrationsDir); os.IsNotExist(err) {
-		fmt.Println("No migrations directory found")
-		return
+// migrationsDir holds the SQL migrations of a generated app
+const migrationsDir = "db/migrations"
+
+// runMigrations applies pending migrations to the configured database
+func runMigrations() error {
+	database, config, err := connectDatabase()
+	if err != nil {
+		return err
 	}
-	
-	// Get all migration files
-	var migrations []string
-	err := filepath.WalkDir(migrationsDir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		
-		if !d.IsDir() && strings.HasSuffix(path, ".sql") {
-			migrations = append(migrations, path)
-		}
-		
-		return nil
-	})
-	
+	defer database.Close()
+
+	migrator, err := migrate.New(database.DB().(*sql.DB), config.Database.Driver, migrationsDir)
 	if err != nil {
-		log.Printf("Error reading migrations: %v", err)
-		return
+		return err
 	}
-	
-	// Sort migrations by filename (timestamp)
-	sort.Strings(migrations)
-	
-	fmt.Printf("Found %d migration(s)\n", len(migrations))
-	
-	for _, migration := range migrations {
-		fmt.Printf("Running migration: %s\n", filepath.Base(migration))
-		
-		content, err := os.ReadFile(migration)
-		if err != nil {
-			log.Printf("Error reading migration %s: %v", migration, err)
-			continue
-		}
-		
-		// TODO: Execute SQL against database
-		// For now, just show what would be executed
-		fmt.Printf("SQL: %s\n", string(content))
+
+	applied, err := migrator.Up(context.Background())
+	for _, mig := range applied {
+		fmt.Printf("   ✓ %s\n", filepath.Base(mig.Path))
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		fmt.Println("✅ Database is up to date")
+	} else {
+		fmt.Printf("✅ Applied %d migration(s)\n", len(applied))
 	}
-	
-	fmt.Println("✅ Migrations completed")
+	return nil
 }
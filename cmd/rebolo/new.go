@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// promptAppOptions asks for the options of a new app, using the values
+// already in opts as defaults
+func promptAppOptions(opts *AppOptions, in io.Reader) error {
+	reader := bufio.NewReader(in)
+
+	ask := func(question, def string, choices ...string) (string, error) {
+		for {
+			if len(choices) > 0 {
+				fmt.Printf("%s (%s) [%s]: ", question, strings.Join(choices, "/"), def)
+			} else {
+				fmt.Printf("%s [%s]: ", question, def)
+			}
+
+			line, err := reader.ReadString('\n')
+			if err != nil && err != io.EOF {
+				return "", err
+			}
+			answer := strings.TrimSpace(line)
+			if answer == "" {
+				return def, nil
+			}
+			if len(choices) == 0 {
+				return answer, nil
+			}
+			answer = strings.ToLower(answer)
+			for _, choice := range choices {
+				if answer == choice {
+					return answer, nil
+				}
+			}
+			if err == io.EOF {
+				return "", fmt.Errorf("invalid answer %q", answer)
+			}
+			fmt.Printf("⚠️  Choose one of: %s\n", strings.Join(choices, ", "))
+		}
+	}
+
+	var err error
+	if opts.Name == "" {
+		for opts.Name == "" {
+			if opts.Name, err = ask("App name", ""); err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.Module, err = ask("Go module path", orDefault(opts.Module, opts.Name)); err != nil {
+		return err
+	}
+
+	if opts.Database, err = ask("Database", orDefault(opts.Database, "sqlite"), "sqlite", "postgres", "mysql"); err != nil {
+		return err
+	}
+
+	kind, err := ask("App type", map[bool]string{true: "api", false: "web"}[opts.APIOnly], "web", "api")
+	if err != nil {
+		return err
+	}
+	opts.APIOnly = kind == "api"
+
+	if !opts.APIOnly {
+		if opts.Frontend, err = ask("Frontend", orDefault(opts.Frontend, "bun"), "bun", "vite", "esbuild", "htmx", "none", "react", "svelte", "vue"); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println()
+	return nil
+}
+
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// pseudoVersion matches the versions Go stamps on builds from a checkout,
+// e.g. v0.0.0-20240101120000-abcdef123456+dirty
+var pseudoVersion = regexp.MustCompile(`\d{14}-[0-9a-f]{12}`)
+
+// frameworkVersion is the rebololang release this CLI was installed from,
+// or "" for development builds
+func frameworkVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	version := info.Main.Version
+	if !strings.HasPrefix(version, "v") || strings.Contains(version, "+") || pseudoVersion.MatchString(version) {
+		return ""
+	}
+	return version
+}
+
+// tidyModule resolves the dependencies of a new app and writes go.sum
+func tidyModule(dir, version string) error {
+	fmt.Printf("📦 Resolving Go dependencies...\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+
+	run := func(args ...string) error {
+		cmd := exec.CommandContext(ctx, "go", args...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("go %s: %w\n%s", strings.Join(args, " "), err, output)
+		}
+		return nil
+	}
+
+	// Development builds don't know their version, use the latest release
+	if version == "" {
+		if err := run("get", "github.com/Palaciodiego008/rebololang@latest"); err != nil {
+			return err
+		}
+	}
+	if err := run("mod", "tidy"); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Dependencies resolved (go.sum written)\n")
+	return nil
+}
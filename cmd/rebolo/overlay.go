@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/middleware"
+)
+
+// Hot reload endpoints of the app: pages poll hotReloadPath while their
+// WebSocket on hotReloadSocketPath is down
+const (
+	hotReloadPath       = "/__rebolo__/changes"
+	hotReloadSocketPath = "/__rebolo__/ws"
+)
+
+// devBuild is the state of the last Go server build, shared by the hot
+// reload loop and the dev proxy
+var devBuild buildState
+
+type buildState struct {
+	mu          sync.RWMutex
+	output      string // compiler output of the last build, if it failed
+	restartedAt time.Time
+	sockets     map[net.Conn]bool // hot reload WebSockets proxied to the app
+}
+
+// failed records the output of a build that didn't compile. Open pages
+// lose their hot reload socket and start polling, which reports the error.
+func (b *buildState) failed(output string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.output = output
+	for conn := range b.sockets {
+		conn.Close()
+	}
+	b.sockets = nil
+}
+
+// track remembers a proxied hot reload socket until it's closed
+func (b *buildState) track(conn net.Conn) net.Conn {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.sockets == nil {
+		b.sockets = make(map[net.Conn]bool)
+	}
+	b.sockets[conn] = true
+	return &trackedConn{Conn: conn, state: b}
+}
+
+type trackedConn struct {
+	net.Conn
+	state *buildState
+}
+
+func (c *trackedConn) Close() error {
+	c.state.mu.Lock()
+	delete(c.state.sockets, c.Conn)
+	c.state.mu.Unlock()
+	return c.Conn.Close()
+}
+
+// socketRecorder lets the reverse proxy hijack the connection of a hot
+// reload WebSocket and tracks it in devBuild
+type socketRecorder struct {
+	http.ResponseWriter
+}
+
+func (w socketRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	return devBuild.track(conn), rw, nil
+}
+
+// restarted records that a new build is serving requests
+func (b *buildState) restarted() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.output = ""
+	b.restartedAt = time.Now()
+}
+
+func (b *buildState) status() (string, time.Time) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.output, b.restartedAt
+}
+
+// serveBuildStatus answers hot reload polls and page loads from the proxy
+// while the last build failed, or just after a restart, and reports
+// whether it did
+func serveBuildStatus(w http.ResponseWriter, r *http.Request) bool {
+	output, restartedAt := devBuild.status()
+
+	// Keep pages polling while the build is broken
+	if r.URL.Path == hotReloadSocketPath && output != "" {
+		http.Error(w, "build failed", http.StatusServiceUnavailable)
+		return true
+	}
+
+	if r.URL.Path == hotReloadPath {
+		// The new server doesn't know the page is stale, tell it to reload
+		justRestarted := time.Since(restartedAt) < 2*time.Second
+		if output == "" && !justRestarted {
+			return false
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"changed":    output == "" && justRestarted,
+			"buildError": output,
+		})
+		return true
+	}
+
+	if output == "" || r.Method != http.MethodGet || !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		return false
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.WriteHeader(http.StatusInternalServerError)
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html>
+<head>
+    <title>Build failed</title>
+    <style>
+        body { font-family: system-ui, sans-serif; margin: 0; padding: 2rem; background: #1e1e1e; color: #eee; }
+        h1 { color: #ff6b6b; font-size: 1.5rem; }
+        pre { background: #111; padding: 1rem; border-radius: 4px; overflow: auto; line-height: 1.4; }
+    </style>
+</head>
+<body>
+    <h1>❌ Build failed</h1>
+    <pre>%s</pre>
+    <p>Fix the errors and save, this page reloads when the app compiles.</p>
+    <script>window.__reboloBuildError = true;</script>
+    %s
+</body>
+</html>`, html.EscapeString(output), middleware.HotReloadScript)
+	return true
+}
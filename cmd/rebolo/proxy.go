@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/adapters"
+)
+
+// appRestartTimeout is how long a request waits for the Go server to come
+// back before the restarting page is shown
+const appRestartTimeout = 15 * time.Second
+
+// devProxy serves `rebolo dev` on a single port. Requests go to the Go
+// server, except, when a frontend dev server runs, everything outside
+// /api/ and /__rebolo__/ which goes to it (pages, modules and HMR).
+type devProxy struct {
+	app      *httputil.ReverseProxy
+	frontend *httputil.ReverseProxy // nil without a frontend dev server
+}
+
+// newDevProxy proxies to the Go server on appPort and, if frontendPort
+// isn't 0, the Bun/Vite dev server
+func newDevProxy(appPort, frontendPort int) *devProxy {
+	p := &devProxy{app: reverseProxy(appPort)}
+	p.app.Transport = retryTransport{timeout: appRestartTimeout}
+	p.app.ErrorHandler = restartingPage
+
+	if frontendPort != 0 {
+		p.frontend = reverseProxy(frontendPort)
+		p.frontend.Transport = retryTransport{timeout: 5 * time.Second}
+		p.frontend.ErrorHandler = restartingPage
+	}
+	return p
+}
+
+func reverseProxy(port int) *httputil.ReverseProxy {
+	target := &url.URL{Scheme: "http", Host: "127.0.0.1:" + strconv.Itoa(port)}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	// Stream responses (SSE, HMR) instead of buffering them
+	proxy.FlushInterval = -1
+	return proxy
+}
+
+func (p *devProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Build errors and restarts are reported by the proxy itself
+	if serveBuildStatus(w, r) {
+		return
+	}
+	if r.URL.Path == hotReloadSocketPath {
+		w = socketRecorder{w}
+	}
+
+	if p.frontend != nil && !strings.HasPrefix(r.URL.Path, "/api/") && !strings.HasPrefix(r.URL.Path, "/__rebolo__/") {
+		p.frontend.ServeHTTP(w, r)
+		return
+	}
+	p.app.ServeHTTP(w, r)
+}
+
+// startDevProxy listens on port until ctx is done
+func startDevProxy(ctx context.Context, port int, proxy *devProxy) {
+	server := &http.Server{Addr: ":" + strconv.Itoa(port), Handler: proxy}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	fmt.Printf("🌐 Dev server: http://localhost:%d\n", port)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("❌ Dev proxy failed: %v", err)
+	}
+}
+
+// retryTransport retries requests without a body while the upstream server
+// refuses connections, i.e. while it is being rebuilt and restarted
+type retryTransport struct {
+	timeout time.Duration
+}
+
+func (t retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	deadline := time.Now().Add(t.timeout)
+	for {
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err == nil || !errors.Is(err, syscall.ECONNREFUSED) || (req.Body != nil && req.Body != http.NoBody) {
+			return resp, err
+		}
+		if time.Now().After(deadline) {
+			return nil, err
+		}
+
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// restartingPage answers for a server that isn't up yet. Browsers get a
+// page that reloads itself, other clients a 503.
+func restartingPage(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	w.Header().Set("Retry-After", "1")
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		http.Error(w, "server is restarting, retry shortly", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	fmt.Fprint(w, `<!DOCTYPE html>
+<html>
+<head>
+    <title>Restarting...</title>
+    <meta http-equiv="refresh" content="1">
+    <style>
+        body { font-family: system-ui, sans-serif; display: flex; align-items: center; justify-content: center; height: 100vh; margin: 0; color: #444; }
+    </style>
+</head>
+<body>
+    <p>🔄 The server is restarting, this page reloads when it's back...</p>
+</body>
+</html>`)
+}
+
+// devPort returns the port the app is configured to listen on
+func devPort() int {
+	if config, err := adapters.NewYAMLConfig().Load(); err == nil {
+		if port, err := strconv.Atoi(config.Server.Port); err == nil {
+			return port
+		}
+	}
+	return 3000
+}
+
+// freePort asks the OS for an unused local port
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// runFrontendDevServer runs the frontend's dev script (Vite through Bun) on
+// port until ctx is done
+func runFrontendDevServer(ctx context.Context, port int) {
+	cmd := exec.CommandContext(ctx, "bun", "run", "dev", "--port", strconv.Itoa(port), "--strictPort")
+	cmd.Dir = "frontend"
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	fmt.Println("🎨 Starting frontend dev server...")
+	if err := cmd.Run(); err != nil && ctx.Err() == nil {
+		log.Printf("❌ Frontend dev server stopped: %v", err)
+	}
+}
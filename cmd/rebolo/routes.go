@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// routeFiles are searched, in order, for the place to register generated code
+var routeFiles = []string{"routes.go", "main.go"}
+
+// wireRoutes registers the generated code with registerRoutes and prints
+// the statements to add by hand when that isn't possible
+func wireRoutes(lines func(app string) []string, imports ...string) {
+	file, err := registerRoutes(lines, imports...)
+	if err == nil {
+		fmt.Printf("📝 Registered routes in %s\n", file)
+		return
+	}
+
+	fmt.Printf("⚠️  Could not update routes automatically: %v\n", err)
+	fmt.Println("💡 Register them by hand:")
+	for _, line := range lines("app") {
+		fmt.Printf("   %s\n", line)
+	}
+}
+
+// registerRoutes inserts the statements built by lines into the app's route
+// setup and adds the imports they need. lines receives the name of the
+// *rebolo.Application variable. Statements already present are skipped, so
+// running a generator twice doesn't register anything twice.
+//
+// A routes.go with a func taking a *rebolo.Application is preferred, the
+// statements go at the end of it. Otherwise they go in main.go's main(),
+// before the static file handler or app.Start().
+func registerRoutes(lines func(app string) []string, imports ...string) (string, error) {
+	return registerIn(routeFiles, lines, imports...)
+}
+
+// registerIn is registerRoutes searching files instead of routeFiles
+func registerIn(files []string, lines func(app string) []string, imports ...string) (string, error) {
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, file, src, parser.ParseComments)
+		if err != nil {
+			return file, err
+		}
+
+		app, offset, ok := findRouteSetup(fset, f, src)
+		if !ok {
+			continue
+		}
+
+		var missing []string
+		for _, line := range lines(app) {
+			if !containsStatement(src, line) {
+				missing = append(missing, line)
+			}
+		}
+		if len(missing) == 0 {
+			return file, nil
+		}
+
+		indent := lineIndent(src, offset)
+		var block bytes.Buffer
+		for _, line := range missing {
+			block.WriteString(indent + line + "\n")
+		}
+		if rest := bytes.TrimLeft(src[offset:], " \t\n"); !bytes.HasPrefix(rest, []byte("}")) {
+			block.WriteString("\n")
+		}
+
+		out := make([]byte, 0, len(src)+block.Len())
+		out = append(out, src[:offset]...)
+		out = append(out, block.Bytes()...)
+		out = append(out, src[offset:]...)
+
+		out, err = addImports(out, imports)
+		if err != nil {
+			return file, err
+		}
+
+		formatted, err := format.Source(out)
+		if err != nil {
+			return file, err
+		}
+		return file, os.WriteFile(file, formatted, 0644)
+	}
+
+	return "", fmt.Errorf("no route setup found in %s", strings.Join(files, " or "))
+}
+
+// findRouteSetup returns the application variable and the offset, at the
+// start of a line, where new statements are inserted
+func findRouteSetup(fset *token.FileSet, f *ast.File, src []byte) (string, int, bool) {
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+
+		// func registerRoutes(app *rebolo.Application)
+		for _, field := range fn.Type.Params.List {
+			star, ok := field.Type.(*ast.StarExpr)
+			if !ok || len(field.Names) == 0 {
+				continue
+			}
+			if sel, ok := star.X.(*ast.SelectorExpr); ok && sel.Sel.Name == "Application" {
+				end := lineStart(src, fset.Position(fn.Body.Rbrace).Offset)
+				return field.Names[0].Name, end, true
+			}
+		}
+
+		if fn.Name.Name != "main" || fn.Recv != nil {
+			continue
+		}
+
+		// app := rebolo.New()
+		app := ""
+		for _, stmt := range fn.Body.List {
+			assign, ok := stmt.(*ast.AssignStmt)
+			if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+				continue
+			}
+			call, ok := assign.Rhs[0].(*ast.CallExpr)
+			if !ok {
+				continue
+			}
+			if sel, ok := call.Fun.(*ast.SelectorExpr); ok && strings.HasPrefix(sel.Sel.Name, "New") {
+				if ident, ok := assign.Lhs[0].(*ast.Ident); ok {
+					app = ident.Name
+					break
+				}
+			}
+		}
+		if app == "" {
+			return "", 0, false
+		}
+
+		for _, stmt := range fn.Body.List {
+			if callsMethod(stmt, app, "ServeStatic") || callsMethod(stmt, app, "Start") {
+				return app, lineStart(src, commentStart(fset, f, stmt)), true
+			}
+		}
+		return app, lineStart(src, fset.Position(fn.Body.Rbrace).Offset), true
+	}
+	return "", 0, false
+}
+
+// callsMethod reports whether stmt calls app.<method>
+func callsMethod(stmt ast.Stmt, app, method string) bool {
+	found := false
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok && sel.Sel.Name == method {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == app {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// commentStart returns the offset of the comment directly above stmt, so
+// inserted code doesn't separate a statement from its comment
+func commentStart(fset *token.FileSet, f *ast.File, stmt ast.Stmt) int {
+	pos := fset.Position(stmt.Pos())
+	for _, group := range f.Comments {
+		end := fset.Position(group.End())
+		if end.Line == pos.Line-1 {
+			return fset.Position(group.Pos()).Offset
+		}
+	}
+	return pos.Offset
+}
+
+func lineStart(src []byte, offset int) int {
+	for offset > 0 && src[offset-1] != '\n' {
+		offset--
+	}
+	return offset
+}
+
+func lineIndent(src []byte, offset int) string {
+	end := offset
+	for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	if end == offset || (end < len(src) && src[end] == '}') {
+		return "\t"
+	}
+	return string(src[offset:end])
+}
+
+// containsStatement reports whether src has stmt, ignoring whitespace
+func containsStatement(src []byte, stmt string) bool {
+	squash := func(s string) string { return strings.Join(strings.Fields(s), "") }
+	return strings.Contains(squash(string(src)), squash(stmt))
+}
+
+// addImports adds the import paths that src doesn't import yet
+func addImports(src []byte, paths []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	have := make(map[string]bool)
+	for _, imp := range f.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		have[path] = true
+	}
+
+	var missing []string
+	for _, path := range paths {
+		if !have[path] {
+			missing = append(missing, strconv.Quote(path))
+		}
+	}
+	if len(missing) == 0 {
+		return src, nil
+	}
+
+	// Append to the last parenthesized import block, or start one
+	for i := len(f.Decls) - 1; i >= 0; i-- {
+		gen, ok := f.Decls[i].(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT || !gen.Rparen.IsValid() {
+			continue
+		}
+		at := fset.Position(gen.Rparen).Offset
+		insert := "\t" + strings.Join(missing, "\n\t") + "\n"
+		return append(src[:at:at], append([]byte(insert), src[at:]...)...), nil
+	}
+
+	at := fset.Position(f.Name.End()).Offset
+	insert := "\n\nimport (\n\t" + strings.Join(missing, "\n\t") + "\n)"
+	return append(src[:at:at], append([]byte(insert), src[at:]...)...), nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/adapters"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/ports"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/tasks"
+)
+
+// breakingChange is a change of behavior in a release that apps may have
+// to adapt their config to. Applies reports whether the app's config is
+// affected, nil means every app is. Add one with each release that
+// changes a default or a setting.
+type breakingChange struct {
+	Since   string // First release with the change
+	Setting string // config.yml key it concerns
+	Applies func(config ports.ConfigData) bool
+	Advice  string
+}
+
+var breakingChanges = []breakingChange{
+	{
+		Since:   "v0.2.0",
+		Setting: "database.fail_fast",
+		Applies: func(c ports.ConfigData) bool { return c.Database.FailFast == nil },
+		Advice:  "production apps exit when the database can't be reached at boot, set database.fail_fast: false to keep serving without it",
+	},
+	{
+		Since:   "v0.2.0",
+		Setting: "renderer.mode",
+		Applies: func(c ports.ConfigData) bool { return c.Renderer.Mode == "" },
+		Advice:  "production apps parse every view at boot and log those differing from views/manifest.json, run rebolo build to write it, or set renderer.mode: on_demand",
+	},
+	{
+		Since:   "v0.2.0",
+		Setting: "session.secure",
+		Applies: func(c ports.ConfigData) bool { return c.Session.Secure == nil },
+		Advice:  "session cookies are Secure in production and only sent over HTTPS, set session.secure: false if the app is served over plain HTTP",
+	},
+}
+
+// runUpgrade installs the latest release of the CLI, unless check is set,
+// and reports the breaking changes concerning the app in the working
+// directory
+func runUpgrade(check bool) error {
+	current := frameworkVersion()
+	fmt.Println("🔎 Looking for the latest release...")
+	latest, err := latestVersion()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case current == latest:
+		fmt.Printf("✅ rebolo %s is the latest release\n", current)
+	case check:
+		fmt.Printf("⬆️  rebolo %s is available (installed: %s)\n", latest, versionOrDevel(current))
+		fmt.Println("   Run rebolo upgrade to install it")
+	default:
+		fmt.Printf("⬆️  Installing rebolo %s (installed: %s)...\n", latest, versionOrDevel(current))
+		if err := runBuildCommand("go", "install", tasks.Module+"/cmd/rebolo@"+latest); err != nil {
+			return fmt.Errorf("go install failed: %w", err)
+		}
+		fmt.Printf("✅ Installed rebolo %s\n", latest)
+
+		// The new release knows the changes this one doesn't, let it report
+		if self, err := os.Executable(); err == nil {
+			report := exec.Command(self, "upgrade", "--report")
+			report.Stdout, report.Stderr = os.Stdout, os.Stderr
+			if report.Run() == nil {
+				return nil
+			}
+		}
+	}
+	return reportBreakingChanges()
+}
+
+// reportBreakingChanges lists the breaking changes released since the
+// version in the app's go.mod that concern its config
+func reportBreakingChanges() error {
+	appVersion := appFrameworkVersion("go.mod")
+	if appVersion == "" {
+		return nil
+	}
+	config, err := adapters.NewYAMLConfig().Load()
+	if err != nil {
+		return fmt.Errorf("failed to read config.yml: %w", err)
+	}
+
+	var found []breakingChange
+	for _, change := range breakingChanges {
+		if compareVersions(change.Since, appVersion) <= 0 {
+			continue
+		}
+		if change.Applies == nil || change.Applies(config) {
+			found = append(found, change)
+		}
+	}
+
+	if len(found) == 0 {
+		fmt.Printf("✅ No breaking changes concern this app since %s\n", appVersion)
+	} else {
+		fmt.Printf("\n⚠️  Breaking changes since %s concerning this app's config:\n", appVersion)
+		for _, change := range found {
+			fmt.Printf("   • %s (%s): %s\n", change.Setting, change.Since, change.Advice)
+		}
+	}
+	fmt.Printf("\nUpdate the app with: go get %s@latest && rebolo upgrade-app\n", tasks.Module)
+	return nil
+}
+
+// latestVersion asks the Go module proxy for the latest release, through
+// the go command so GOPROXY and GOPRIVATE apply
+func latestVersion() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "go", "list", "-m", "-json", tasks.Module+"@latest")
+	cmd.Dir = os.TempDir() // Outside the app, whose go.mod would pin it
+	cmd.Env = append(os.Environ(), "GO111MODULE=on", "GOFLAGS=-mod=mod")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the latest release: %w\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var module struct{ Version string }
+	if err := json.Unmarshal(out, &module); err != nil || module.Version == "" {
+		return "", fmt.Errorf("failed to find the latest release in %q", out)
+	}
+	return module.Version, nil
+}
+
+var requireLine = regexp.MustCompile(`(?m)^\s*(?:require\s+)?` + regexp.QuoteMeta(tasks.Module) + `\s+(v\S+)`)
+
+// appFrameworkVersion returns the framework version required by the
+// go.mod at path, "" outside an app
+func appFrameworkVersion(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	m := requireLine.FindSubmatch(data)
+	if m == nil {
+		return ""
+	}
+	return string(m[1])
+}
+
+func versionOrDevel(version string) string {
+	if version == "" {
+		return "development build"
+	}
+	return version
+}
+
+// compareVersions compares two semantic versions like v1.2.3, returning
+// -1, 0 or 1, ignoring build metadata. Pre-releases, pseudo-versions
+// among them, come before their release and compare as strings.
+func compareVersions(a, b string) int {
+	a, _, _ = strings.Cut(a, "+")
+	b, _, _ = strings.Cut(b, "+")
+	coreA, preA, _ := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	coreB, preB, _ := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+	partsA, partsB := strings.Split(coreA, "."), strings.Split(coreB, ".")
+	for i := 0; i < 3; i++ {
+		var x, y int
+		if i < len(partsA) {
+			x, _ = strconv.Atoi(partsA[i])
+		}
+		if i < len(partsB) {
+			y, _ = strconv.Atoi(partsB[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	return strings.Compare(preA, preB)
+}
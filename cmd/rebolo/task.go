@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/tasks"
+)
+
+// runTask runs a task and returns the exit status. The CLI runs the tasks
+// it knows that don't need the app, the app runs the others.
+func runTask(args []string) int {
+	tasks.BuildAssets = buildProductionAssets
+	tasks.DefaultTasks()
+
+	if inAppDir() && runsInApp(args) {
+		return runTaskInApp(args)
+	}
+
+	if err := tasks.RunFromArgs(args); err != nil {
+		fmt.Printf("❌ Task failed: %v\n", err)
+		return tasks.ExitCode(err)
+	}
+	return 0
+}
+
+// runsInApp reports whether the app runs the task: its own tasks, those
+// needing it, and the list of tasks, which includes the app's
+func runsInApp(args []string) bool {
+	if len(args) == 0 {
+		return true
+	}
+	task, err := tasks.Get(args[0])
+	if err != nil {
+		return true
+	}
+	return task.NeedsApp
+}
+
+// inAppDir reports whether the working directory holds an app's main
+// package
+func inAppDir() bool {
+	for _, file := range []string{"go.mod", "main.go"} {
+		if _, err := os.Stat(file); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// runTaskInApp builds the app and runs it as app task <args>, its Start
+// runs the task instead of serving. go run would turn the task's exit
+// status into 1.
+func runTaskInApp(args []string) int {
+	dir, err := os.MkdirTemp("", "rebolo-task")
+	if err != nil {
+		fmt.Printf("❌ Failed to build the app: %v\n", err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+
+	binary := filepath.Join(dir, "app")
+	if err := runBuildCommand("go", "build", "-o", binary, "."); err != nil {
+		fmt.Printf("❌ Failed to build the app: %v\n", err)
+		return 1
+	}
+
+	cmd := exec.Command(binary, append([]string{"task"}, args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			return exit.ExitCode()
+		}
+		fmt.Printf("❌ Failed to run the app: %v\n", err)
+		return 1
+	}
+	return 0
+}
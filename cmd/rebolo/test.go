@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/adapters"
+	"github.com/fsnotify/fsnotify"
+)
+
+// runTests prepares the test database and runs go test with args,
+// re-running on every change when watch is set
+func runTests(args []string, watch bool) error {
+	// Everything below, including the go test process, sees the test environment
+	os.Setenv("REBOLO_ENV", "test")
+
+	if err := prepareTestDatabase(); err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		args = []string{"./..."}
+	}
+
+	if !watch {
+		return goTest(args)
+	}
+	return watchTests(args)
+}
+
+// prepareTestDatabase creates and migrates the database from config.test.yml
+func prepareTestDatabase() error {
+	config, err := adapters.NewYAMLConfig().Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if config.Database.URL == "" {
+		fmt.Println("ℹ️  No test database configured, skipping migrations")
+		return nil
+	}
+
+	if err := ensureDatabase(config); err != nil {
+		return err
+	}
+
+	fmt.Println("🗄️  Migrating test database...")
+	return runMigrations()
+}
+
+// goTest runs go test with the given arguments
+func goTest(args []string) error {
+	cmd := exec.Command("go", append([]string{"test"}, args...)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	return cmd.Run()
+}
+
+// watchTests re-runs the tests when Go files change and re-migrates
+// when a migration is added
+func watchTests(args []string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		cancel()
+	}()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info == nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != "." && (strings.HasPrefix(info.Name(), ".") || info.Name() == "vendor" || info.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return watcher.Add(path)
+		}
+		return nil
+	})
+
+	run := func() {
+		if err := goTest(args); err != nil {
+			fmt.Println("❌ Tests failed")
+		} else {
+			fmt.Println("✅ Tests passed")
+		}
+		fmt.Println("👀 Watching for changes...")
+	}
+	run()
+
+	migrate := false
+	debounce := time.NewTimer(300 * time.Millisecond)
+	debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					watcher.Add(event.Name)
+				}
+			}
+			switch filepath.Ext(event.Name) {
+			case ".go":
+				debounce.Reset(300 * time.Millisecond)
+			case ".sql":
+				migrate = true
+				debounce.Reset(300 * time.Millisecond)
+			case ".yml", ".yaml", ".html":
+				// Fixtures, config and views affect test results too
+				debounce.Reset(300 * time.Millisecond)
+			}
+		case <-debounce.C:
+			if migrate {
+				migrate = false
+				if err := prepareTestDatabase(); err != nil {
+					log.Printf("❌ Migration failed: %v", err)
+					continue
+				}
+			}
+			run()
+		case err := <-watcher.Errors:
+			log.Printf("❌ Watcher error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// upgradeRule describes a deprecated API. Rules with a Replace value are
+// rewritten automatically; the others are only reported with their advice.
+type upgradeRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	Replace string
+	Advice  string
+}
+
+var upgradeRules = []upgradeRule{
+	{
+		Name:    "NewBunDatabase",
+		Pattern: regexp.MustCompile(`\bNewBunDatabase\(`),
+		Replace: "NewPostgresDatabase(",
+		Advice:  "adapters.NewBunDatabase() is deprecated, use adapters.NewPostgresDatabase()",
+	},
+	{
+		Name:    "BunDatabase",
+		Pattern: regexp.MustCompile(`\badapters\.BunDatabase\b`),
+		Replace: "adapters.PostgresDatabase",
+		Advice:  "adapters.BunDatabase is deprecated, use adapters.PostgresDatabase",
+	},
+	{
+		Name:    "RecoveryMiddleware",
+		Pattern: regexp.MustCompile(`\.Use\(rebolo\.RecoveryMiddleware\)`),
+		Advice:  "panic recovery is built into rebolo.New(), remove this middleware to get the custom 500 page and OnError hooks",
+	},
+	{
+		Name:    "GO_ENV",
+		Pattern: regexp.MustCompile(`os\.Getenv\("GO_ENV"\)`),
+		Advice:  "the framework reads REBOLO_ENV, use it instead of GO_ENV so config and code agree",
+	},
+}
+
+// upgradeFinding is a single match of a rule
+type upgradeFinding struct {
+	File string
+	Line int
+	Rule upgradeRule
+}
+
+// runUpgradeApp scans the application in dir, rewrites what it safely can
+// (unless dryRun is set) and prints a migration report
+func runUpgradeApp(dir string, dryRun bool) error {
+	var findings []upgradeFinding
+	changed := 0
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			switch info.Name() {
+			case "vendor", "node_modules", ".git", "public":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		lines := strings.Split(string(src), "\n")
+		modified := false
+		for i, line := range lines {
+			for _, rule := range upgradeRules {
+				if !rule.Pattern.MatchString(line) {
+					continue
+				}
+				finding := upgradeFinding{File: path, Line: i + 1, Rule: rule}
+				if rule.Replace != "" {
+					line = rule.Pattern.ReplaceAllLiteralString(line, rule.Replace)
+					modified = true
+				}
+				findings = append(findings, finding)
+			}
+			lines[i] = line
+		}
+
+		if modified && !dryRun {
+			if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), info.Mode()); err != nil {
+				return err
+			}
+			changed++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	printUpgradeReport(findings, dryRun)
+	if !dryRun && changed > 0 {
+		fmt.Printf("✅ Rewrote %d file(s). Review the changes and run go build ./...\n", changed)
+	}
+	return nil
+}
+
+// printUpgradeReport groups findings by rule
+func printUpgradeReport(findings []upgradeFinding, dryRun bool) {
+	if len(findings) == 0 {
+		fmt.Println("✅ No deprecated APIs found")
+		return
+	}
+
+	byRule := make(map[string][]upgradeFinding)
+	var names []string
+	for _, f := range findings {
+		if _, ok := byRule[f.Rule.Name]; !ok {
+			names = append(names, f.Rule.Name)
+		}
+		byRule[f.Rule.Name] = append(byRule[f.Rule.Name], f)
+	}
+	sort.Strings(names)
+
+	manual := 0
+	fmt.Println("\n📋 Migration report")
+	for _, name := range names {
+		group := byRule[name]
+		rule := group[0].Rule
+
+		status := "manual"
+		if rule.Replace != "" {
+			status = "rewritten"
+			if dryRun {
+				status = "would rewrite"
+			}
+		} else {
+			manual += len(group)
+		}
+
+		fmt.Printf("\n%s (%d, %s)\n", name, len(group), status)
+		fmt.Printf("   %s\n", rule.Advice)
+		for _, f := range group {
+			fmt.Printf("   - %s:%d\n", f.File, f.Line)
+		}
+	}
+
+	if manual > 0 {
+		fmt.Printf("\n⚠️  %d occurrence(s) need manual changes\n", manual)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/adapters"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/watcher"
+	"github.com/fsnotify/fsnotify"
+)
+
+// treeWatcher watches a directory tree for changes to files with the given
+// extensions. Directories created after startup are watched too, and the
+// changed paths are collected until flush so a burst of events (an editor
+// saving, a git checkout) is handled once.
+type treeWatcher struct {
+	*fsnotify.Watcher
+	extensions []string
+	skipDirs   []string
+	ignore     *watcher.Ignore // .rebolignore and watch.ignore in config.yml
+	dirs       map[string]bool // watched directories
+	pending    map[string]bool // changed paths since the last flush
+}
+
+// newTreeWatcher watches root and its subdirectories, except hidden ones,
+// those named in skipDirs and ignored ones
+func newTreeWatcher(root string, extensions, skipDirs []string) (*treeWatcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &treeWatcher{
+		Watcher:    fsw,
+		extensions: extensions,
+		skipDirs:   skipDirs,
+		ignore:     loadIgnore(),
+		dirs:       make(map[string]bool),
+		pending:    make(map[string]bool),
+	}
+	if err := w.addTree(root); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// loadIgnore reads the patterns of files whose changes are ignored, from
+// .rebolignore and watch.ignore in config.yml
+func loadIgnore() *watcher.Ignore {
+	var patterns []string
+	if config, err := adapters.NewYAMLConfig().Load(); err == nil {
+		patterns = config.Watch.Ignore
+	}
+	ignore, err := watcher.LoadIgnore(".", patterns...)
+	if err != nil {
+		fmt.Printf("⚠️  Failed to read %s: %v\n", watcher.IgnoreFile, err)
+	}
+	return ignore
+}
+
+// addTree watches root and its subdirectories
+func (w *treeWatcher) addTree(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info == nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != root && (w.skip(info.Name()) || w.ignore.Match(path, true)) {
+			return filepath.SkipDir
+		}
+		if err := w.Add(path); err != nil {
+			return err
+		}
+		w.dirs[filepath.Clean(path)] = true
+		return nil
+	})
+}
+
+func (w *treeWatcher) skip(dir string) bool {
+	if strings.HasPrefix(dir, ".") {
+		return true
+	}
+	for _, name := range w.skipDirs {
+		if dir == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *treeWatcher) matches(path string) bool {
+	ext := filepath.Ext(path)
+	for _, e := range w.extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// handle records event and reports whether it changed a watched file:
+// writes, creations, renames and removals, but not permission changes.
+// New directories are added to the watcher.
+func (w *treeWatcher) handle(event fsnotify.Event) bool {
+	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) && !event.Has(fsnotify.Remove) {
+		return false
+	}
+	path := filepath.Clean(event.Name)
+
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			if w.skip(info.Name()) || w.ignore.Match(path, true) {
+				return false
+			}
+			w.addTree(path)
+			// A directory moved in may bring files along
+			changed := false
+			filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() && w.matches(p) && !w.ignore.Match(p, false) {
+					changed = true
+				}
+				return nil
+			})
+			if changed {
+				w.pending[path] = true
+			}
+			return changed
+		}
+	}
+
+	// A removed or renamed directory may have had watched files in it,
+	// fsnotify already dropped its watch
+	if (event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename)) && w.dirs[path] {
+		for dir := range w.dirs {
+			if dir == path || strings.HasPrefix(dir, path+string(filepath.Separator)) {
+				delete(w.dirs, dir)
+			}
+		}
+		w.pending[path] = true
+		return true
+	}
+
+	if !w.matches(path) || w.ignore.Match(path, false) {
+		return false
+	}
+	w.pending[path] = true
+	return true
+}
+
+// flush returns the base names of the paths changed since the last flush
+func (w *treeWatcher) flush() []string {
+	var names []string
+	for path := range w.pending {
+		names = append(names, filepath.Base(path))
+	}
+	sort.Strings(names)
+	w.pending = make(map[string]bool)
+	return names
+}
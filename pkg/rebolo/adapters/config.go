@@ -2,6 +2,7 @@ package adapters
 
 import (
 	"os"
+	"strings"
 	"gopkg.in/yaml.v3"
 	"github.com/Palaciodiego008/rebololang/pkg/rebolo/ports"
 )
@@ -21,11 +22,38 @@ func (c *YAMLConfig) Load() (ports.ConfigData, error) {
 	config.Server.Host = c.GetEnv("HOST", "localhost")
 	config.App.Env = c.GetEnv("REBOLO_ENV", "development")
 	config.Assets.HotReload = config.App.Env == "development"
+	config.Errors.Sentry.DSN = c.GetEnv("SENTRY_DSN", "")
+	config.Database.SQLite.WAL = true
+	config.Database.SQLite.BusyTimeout = 5000
+	config.Database.SQLite.ForeignKeys = true
 	
 	// Try to load config.yml
 	if data, err := os.ReadFile("config.yml"); err == nil {
 		yaml.Unmarshal(data, &config)
 	}
+
+	// REBOLO_ENV wins over app.env so `REBOLO_ENV=test` always selects the test settings
+	if env := os.Getenv("REBOLO_ENV"); env != "" {
+		config.App.Env = env
+	}
+
+	// Environment overrides, e.g. config.test.yml or config.production.yml
+	env := config.App.Env
+	if data, err := os.ReadFile("config." + env + ".yml"); err == nil {
+		yaml.Unmarshal(data, &config)
+		config.App.Env = env
+	}
+
+	// Platform provided settings (Heroku, Fly, systemd EnvironmentFile) win over files
+	if port := os.Getenv("PORT"); port != "" {
+		config.Server.Port = port
+	}
+	if url := os.Getenv("DATABASE_URL"); url != "" {
+		config.Database.URL = url
+	}
+	if urls := os.Getenv("DATABASE_REPLICA_URLS"); urls != "" {
+		config.Database.Replicas = strings.Split(urls, ",")
+	}
 	
 	return config, nil
 }
@@ -35,8 +35,12 @@ func (f *DatabaseFactory) CreateDatabase(driver string) (DatabaseAdapter, error)
 		return NewSQLiteDatabase(), nil
 	case "mysql":
 		return NewMySQLDatabase(), nil
+	case "libsql", "turso":
+		return NewLibSQLDatabase(), nil
+	case "neon":
+		return NewNeonDatabase(), nil
 	default:
-		return nil, fmt.Errorf("unsupported database driver: %s (supported: postgres, sqlite, mysql)", driver)
+		return nil, fmt.Errorf("unsupported database driver: %s (supported: postgres, sqlite, mysql, libsql, neon)", driver)
 	}
 }
 
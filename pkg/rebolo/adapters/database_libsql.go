@@ -0,0 +1,128 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// LibSQLDatabase implements Database interface for libSQL and Turso.
+// Remote databases (libsql://, https://) are reached over HTTP with the
+// rebolo-libsql driver, file: URLs open a local SQLite file.
+type LibSQLDatabase struct {
+	db     *sql.DB
+	debug  bool
+	remote bool
+
+	stmtCacheSize int
+	stmts         *StmtCache
+	retry         RetryPolicy
+}
+
+// NewLibSQLDatabase creates a new libSQL database adapter
+func NewLibSQLDatabase() *LibSQLDatabase {
+	return &LibSQLDatabase{retry: ServerlessRetry}
+}
+
+// Connect connects to libSQL database
+func (d *LibSQLDatabase) Connect(ctx context.Context) error {
+	return nil // Will be implemented when DSN is provided
+}
+
+// ConnectWithDSN connects to libSQL with DSN
+// DSN format: libsql://db-org.turso.io?authToken=... or file:local.db
+func (d *LibSQLDatabase) ConnectWithDSN(dsn string, debug bool) error {
+	driverName := "sqlite3"
+	if d.remote = IsRemoteLibSQL(dsn); d.remote {
+		driverName = LibSQLDriverName
+	}
+
+	db, err := openDB(driverName, dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open libsql database: %w", err)
+	}
+
+	d.db = db
+	d.debug = debug
+
+	// Turso databases scale to zero, give them time to wake up
+	if err := pingWithBackoff(d.db, d.retry); err != nil {
+		return fmt.Errorf("failed to ping libsql database: %w", err)
+	}
+
+	if debug {
+		log.Printf("✅ libSQL database connected (debug mode enabled, remote=%v)", d.remote)
+	}
+
+	if d.stmtCacheSize > 0 {
+		d.stmts = NewStmtCache(d.db, d.stmtCacheSize)
+	}
+
+	return nil
+}
+
+// IsRemoteLibSQL reports whether a libSQL DSN points at a server rather than a file
+func IsRemoteLibSQL(dsn string) bool {
+	for _, scheme := range []string{"libsql://", "https://", "http://", "wss://", "ws://"} {
+		if strings.HasPrefix(dsn, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// Close closes the database connection
+func (d *LibSQLDatabase) Close() error {
+	if d.stmts != nil {
+		d.stmts.Close()
+	}
+	if d.db != nil {
+		return d.db.Close()
+	}
+	return nil
+}
+
+// Migrate runs database migrations
+func (d *LibSQLDatabase) Migrate(ctx context.Context) error {
+	// TODO: Implement migration logic
+	return nil
+}
+
+// Health checks database connection health
+func (d *LibSQLDatabase) Health() error {
+	if d.db != nil {
+		return d.db.Ping()
+	}
+	return fmt.Errorf("database not connected")
+}
+
+// DB returns the underlying database/sql instance
+func (d *LibSQLDatabase) DB() interface{} {
+	return d.db
+}
+
+// EnableStatementCache keeps up to size prepared statements for hot
+// queries, must be called before ConnectWithDSN
+func (d *LibSQLDatabase) EnableStatementCache(size int) {
+	d.stmtCacheSize = size
+}
+
+// Statements returns the prepared statement cache, nil unless enabled
+func (d *LibSQLDatabase) Statements() *StmtCache {
+	return d.stmts
+}
+
+// ConnectRetry returns how the first ping is retried
+func (d *LibSQLDatabase) ConnectRetry() RetryPolicy {
+	return d.retry
+}
+
+// SetConnectRetry sets how the first ping is retried, must be called
+// before ConnectWithDSN
+func (d *LibSQLDatabase) SetConnectRetry(policy RetryPolicy) {
+	d.retry = policy
+}
@@ -13,11 +13,15 @@ import (
 type MySQLDatabase struct {
 	db    *sql.DB
 	debug bool
+
+	stmtCacheSize int
+	stmts         *StmtCache
+	retry         RetryPolicy
 }
 
 // NewMySQLDatabase creates a new MySQL database adapter
 func NewMySQLDatabase() *MySQLDatabase {
-	return &MySQLDatabase{}
+	return &MySQLDatabase{retry: DefaultRetry}
 }
 
 // Connect connects to MySQL database
@@ -29,7 +33,7 @@ func (d *MySQLDatabase) Connect(ctx context.Context) error {
 // DSN format: user:password@tcp(host:port)/dbname?parseTime=true
 func (d *MySQLDatabase) ConnectWithDSN(dsn string, debug bool) error {
 	// Open MySQL database
-	db, err := sql.Open("mysql", dsn)
+	db, err := openDB("mysql", dsn)
 	if err != nil {
 		return fmt.Errorf("failed to open mysql database: %w", err)
 	}
@@ -38,19 +42,26 @@ func (d *MySQLDatabase) ConnectWithDSN(dsn string, debug bool) error {
 	d.debug = debug
 	
 	// Test connection
-	if err := d.db.Ping(); err != nil {
+	if err := pingWithBackoff(d.db, d.retry); err != nil {
 		return fmt.Errorf("failed to ping mysql database: %w", err)
 	}
 	
 	if debug {
 		log.Println("✅ MySQL database connected (debug mode enabled)")
 	}
+
+	if d.stmtCacheSize > 0 {
+		d.stmts = NewStmtCache(d.db, d.stmtCacheSize)
+	}
 	
 	return nil
 }
 
 // Close closes the database connection
 func (d *MySQLDatabase) Close() error {
+	if d.stmts != nil {
+		d.stmts.Close()
+	}
 	if d.db != nil {
 		return d.db.Close()
 	}
@@ -75,3 +86,25 @@ func (d *MySQLDatabase) Health() error {
 func (d *MySQLDatabase) DB() interface{} {
 	return d.db
 }
+
+// EnableStatementCache keeps up to size prepared statements for hot
+// queries, must be called before ConnectWithDSN
+func (d *MySQLDatabase) EnableStatementCache(size int) {
+	d.stmtCacheSize = size
+}
+
+// Statements returns the prepared statement cache, nil unless enabled
+func (d *MySQLDatabase) Statements() *StmtCache {
+	return d.stmts
+}
+
+// ConnectRetry returns how the first ping is retried
+func (d *MySQLDatabase) ConnectRetry() RetryPolicy {
+	return d.retry
+}
+
+// SetConnectRetry sets how the first ping is retried, must be called
+// before ConnectWithDSN
+func (d *MySQLDatabase) SetConnectRetry(policy RetryPolicy) {
+	d.retry = policy
+}
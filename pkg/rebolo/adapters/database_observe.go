@@ -0,0 +1,216 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync/atomic"
+	"time"
+)
+
+// QueryObserver is told about each query run by the adapters' connections,
+// with the context it ran with, e.g. the request's
+type QueryObserver func(ctx context.Context, query string, args []interface{}, took time.Duration, err error)
+
+var queryObserver atomic.Pointer[QueryObserver]
+
+// ObserveQueries reports the queries of databases connected afterwards to
+// fn, nil stops reporting them for databases connected afterwards. It
+// wraps every connection, so it's meant for development tools.
+func ObserveQueries(fn QueryObserver) {
+	if fn == nil {
+		queryObserver.Store(nil)
+		return
+	}
+	queryObserver.Store(&fn)
+}
+
+// openDB opens a database like sql.Open, with its queries observed when
+// ObserveQueries was called
+func openDB(driverName, dsn string) (*sql.DB, error) {
+	observe := queryObserver.Load()
+	if observe == nil {
+		return sql.Open(driverName, dsn)
+	}
+
+	// sql.Open is the only way to get at a registered driver
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	drv := db.Driver()
+	db.Close()
+
+	var connector driver.Connector = dsnConnector{dsn: dsn, driver: drv}
+	if dc, ok := drv.(driver.DriverContext); ok {
+		if connector, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+	return sql.OpenDB(&observedConnector{Connector: connector, observe: *observe}), nil
+}
+
+// dsnConnector connects with drivers that have no connectors
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.driver }
+
+type observedConnector struct {
+	driver.Connector
+	observe QueryObserver
+}
+
+func (c *observedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &observedConn{Conn: conn, observe: c.observe}, nil
+}
+
+// observedConn reports the queries run on conn. It implements every
+// optional interface and falls back to what database/sql does when conn
+// doesn't.
+type observedConn struct {
+	driver.Conn
+	observe QueryObserver
+}
+
+func (c *observedConn) report(ctx context.Context, query string, args []driver.NamedValue, start time.Time, err error) {
+	if err == driver.ErrSkip {
+		return
+	}
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	c.observe(ctx, query, values, time.Since(start), err)
+}
+
+func (c *observedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *observedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &observedStmt{Stmt: stmt, conn: c, query: query}, nil
+}
+
+func (c *observedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *observedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	res, err := e.ExecContext(ctx, query, args)
+	c.report(ctx, query, args, start, err)
+	return res, err
+}
+
+func (c *observedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	c.report(ctx, query, args, start, err)
+	return rows, err
+}
+
+func (c *observedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *observedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *observedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *observedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// observedStmt reports the executions of a prepared statement
+type observedStmt struct {
+	driver.Stmt
+	conn  *observedConn
+	query string
+}
+
+func (s *observedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var res driver.Result
+	var err error
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err = e.ExecContext(ctx, args)
+	} else {
+		res, err = s.Stmt.Exec(driverValues(args))
+	}
+	s.conn.report(ctx, s.query, args, start, err)
+	return res, err
+}
+
+func (s *observedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(driverValues(args))
+	}
+	s.conn.report(ctx, s.query, args, start, err)
+	return rows, err
+}
+
+func (s *observedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return s.conn.CheckNamedValue(nv)
+}
+
+// driverValues drops the names, for drivers predating them
+func driverValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
@@ -0,0 +1,301 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultReplicaCheckInterval is how often replicas are pinged when
+// replica_check_interval is not set
+const DefaultReplicaCheckInterval = 5 * time.Second
+
+type primaryKey struct{}
+
+// WithPrimary makes queries run with ctx go to the primary. Use it to read
+// what the request just wrote, replicas may lag behind:
+//
+//	ctx = adapters.WithPrimary(r.Context())
+//	row := app.Replicas().QueryRowContext(ctx, "SELECT ... WHERE id = ?", id)
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// UsesPrimary reports whether ctx was marked with WithPrimary
+func UsesPrimary(ctx context.Context) bool {
+	forced, _ := ctx.Value(primaryKey{}).(bool)
+	return forced
+}
+
+// replica is a read-only copy of the primary and whether its last health
+// check passed
+type replica struct {
+	dsn     string
+	adapter DatabaseAdapter
+	healthy atomic.Bool
+}
+
+// ReplicatedDatabase is a DatabaseAdapter sending writes to a primary and
+// SELECTs to replicas, round robin over the ones passing health checks.
+// When none does, reads fall back to the primary. DB returns the primary,
+// so migrations and code written for a single database keep working.
+type ReplicatedDatabase struct {
+	primary  DatabaseAdapter
+	replicas []*replica
+	next     atomic.Uint32
+
+	// CheckInterval is how often replicas are pinged, set it before ConnectWithDSN
+	CheckInterval time.Duration
+
+	stopChecks context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// NewReplicatedDatabase wraps primary with one adapter per replica DSN,
+// made by newAdapter. Replicas connect when the primary does.
+func NewReplicatedDatabase(primary DatabaseAdapter, replicaDSNs []string, newAdapter func() DatabaseAdapter) *ReplicatedDatabase {
+	d := &ReplicatedDatabase{primary: primary, CheckInterval: DefaultReplicaCheckInterval}
+	for _, dsn := range replicaDSNs {
+		d.replicas = append(d.replicas, &replica{dsn: dsn, adapter: newAdapter()})
+	}
+	return d
+}
+
+// Primary returns the adapter writes go to
+func (d *ReplicatedDatabase) Primary() DatabaseAdapter {
+	return d.primary
+}
+
+// Connect connects the primary
+func (d *ReplicatedDatabase) Connect(ctx context.Context) error {
+	return d.primary.Connect(ctx)
+}
+
+// ConnectWithDSN connects the primary with dsn, then the replicas. A
+// replica that can't connect is left out of reads until a health check
+// passes, only a primary failure is returned.
+func (d *ReplicatedDatabase) ConnectWithDSN(dsn string, debug bool) error {
+	if err := d.primary.ConnectWithDSN(dsn, debug); err != nil {
+		return err
+	}
+
+	for i, r := range d.replicas {
+		if err := r.adapter.ConnectWithDSN(r.dsn, debug); err != nil {
+			log.Printf("⚠️  Replica %d unavailable: %v", i+1, err)
+			continue
+		}
+		r.healthy.Store(true)
+	}
+
+	if debug {
+		log.Printf("✅ %d of %d replica(s) connected", d.Healthy(), len(d.replicas))
+	}
+
+	d.startHealthChecks()
+	return nil
+}
+
+// Close stops the health checks and closes every connection
+func (d *ReplicatedDatabase) Close() error {
+	if d.stopChecks != nil {
+		d.stopChecks()
+		d.wg.Wait()
+	}
+	for _, r := range d.replicas {
+		r.adapter.Close()
+	}
+	return d.primary.Close()
+}
+
+// Migrate runs migrations on the primary
+func (d *ReplicatedDatabase) Migrate(ctx context.Context) error {
+	return d.primary.Migrate(ctx)
+}
+
+// Health checks the primary. Unhealthy replicas only degrade reads.
+func (d *ReplicatedDatabase) Health() error {
+	return d.primary.Health()
+}
+
+// DB returns the primary's database/sql instance
+func (d *ReplicatedDatabase) DB() interface{} {
+	return d.primary.DB()
+}
+
+// Healthy returns how many replicas passed their last health check
+func (d *ReplicatedDatabase) Healthy() int {
+	n := 0
+	for _, r := range d.replicas {
+		if r.healthy.Load() {
+			n++
+		}
+	}
+	return n
+}
+
+// Writer returns the primary's *sql.DB
+func (d *ReplicatedDatabase) Writer() *sql.DB {
+	db, _ := d.primary.DB().(*sql.DB)
+	return db
+}
+
+// Reader returns the *sql.DB for a read: the next healthy replica, or the
+// primary when ctx was marked with WithPrimary or no replica is healthy
+func (d *ReplicatedDatabase) Reader(ctx context.Context) *sql.DB {
+	if UsesPrimary(ctx) || len(d.replicas) == 0 {
+		return d.Writer()
+	}
+
+	start := d.next.Add(1)
+	for i := range d.replicas {
+		r := d.replicas[(int(start)+i)%len(d.replicas)]
+		if !r.healthy.Load() {
+			continue
+		}
+		if db, ok := r.adapter.DB().(*sql.DB); ok && db != nil {
+			return db
+		}
+	}
+	return d.Writer()
+}
+
+// QueryContext runs SELECTs on a replica and any other statement on the primary
+func (d *ReplicatedDatabase) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return d.route(ctx, query).QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext runs SELECTs on a replica and any other statement on the primary
+func (d *ReplicatedDatabase) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return d.route(ctx, query).QueryRowContext(ctx, query, args...)
+}
+
+// ExecContext runs a statement on the primary
+func (d *ReplicatedDatabase) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return d.Writer().ExecContext(ctx, query, args...)
+}
+
+// BeginTx starts a transaction on the primary, reads in it see its writes
+func (d *ReplicatedDatabase) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return d.Writer().BeginTx(ctx, opts)
+}
+
+func (d *ReplicatedDatabase) route(ctx context.Context, query string) *sql.DB {
+	if isRead(query) {
+		return d.Reader(ctx)
+	}
+	return d.Writer()
+}
+
+// isRead reports whether a statement is a plain SELECT. Locking reads
+// (FOR UPDATE, FOR SHARE) must see the primary's rows.
+func isRead(query string) bool {
+	q := strings.ToLower(strings.TrimLeft(query, " \t\r\n("))
+	if !strings.HasPrefix(q, "select") {
+		return false
+	}
+	return !strings.Contains(q, " for update") && !strings.Contains(q, " for share") && !strings.Contains(q, " lock in share mode")
+}
+
+// startHealthChecks pings the replicas every CheckInterval, taking failing
+// ones out of reads and putting them back once they answer again
+func (d *ReplicatedDatabase) startHealthChecks() {
+	if len(d.replicas) == 0 || d.CheckInterval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.stopChecks = cancel
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(d.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.checkReplicas()
+			}
+		}
+	}()
+}
+
+func (d *ReplicatedDatabase) checkReplicas() {
+	for i, r := range d.replicas {
+		err := r.adapter.Health()
+		if db, _ := r.adapter.DB().(*sql.DB); err != nil && db == nil {
+			// It couldn't be opened at boot, try again
+			err = r.adapter.ConnectWithDSN(r.dsn, false)
+		}
+
+		healthy := err == nil
+		if r.healthy.Swap(healthy) != healthy {
+			if healthy {
+				log.Printf("✅ Replica %d is back, reads resume on it", i+1)
+			} else {
+				log.Printf("⚠️  Replica %d failed its health check, reading from the others: %v", i+1, err)
+			}
+		}
+	}
+}
+
+// EnableStatementCache enables the cache of the primary and the replicas
+func (d *ReplicatedDatabase) EnableStatementCache(size int) {
+	for _, adapter := range d.adapters() {
+		if c, ok := adapter.(StatementCacher); ok {
+			c.EnableStatementCache(size)
+		}
+	}
+}
+
+// EnableSpatial enables the spatial extension of the primary, and loads
+// SpatiaLite on SQLite replicas. PostgreSQL replicas get PostGIS from the
+// primary, they can't create it.
+func (d *ReplicatedDatabase) EnableSpatial() {
+	if s, ok := d.primary.(SpatialDatabase); ok {
+		s.EnableSpatial()
+	}
+	for _, r := range d.replicas {
+		if s, ok := r.adapter.(*SQLiteDatabase); ok {
+			s.EnableSpatial()
+		}
+	}
+}
+
+// Statements returns the primary's statement cache
+func (d *ReplicatedDatabase) Statements() *StmtCache {
+	if c, ok := d.primary.(StatementCacher); ok {
+		return c.Statements()
+	}
+	return nil
+}
+
+func (d *ReplicatedDatabase) adapters() []DatabaseAdapter {
+	adapters := []DatabaseAdapter{d.primary}
+	for _, r := range d.replicas {
+		adapters = append(adapters, r.adapter)
+	}
+	return adapters
+}
+
+// ConnectRetry returns the primary's retries
+func (d *ReplicatedDatabase) ConnectRetry() RetryPolicy {
+	if r, ok := d.primary.(ConnectRetrier); ok {
+		return r.ConnectRetry()
+	}
+	return RetryPolicy{}
+}
+
+// SetConnectRetry sets the primary's retries. Replicas are tried once,
+// the health checks pick them up when they come up later.
+func (d *ReplicatedDatabase) SetConnectRetry(policy RetryPolicy) {
+	if r, ok := d.primary.(ConnectRetrier); ok {
+		r.SetConnectRetry(policy)
+	}
+}
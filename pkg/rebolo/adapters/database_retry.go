@@ -0,0 +1,91 @@
+package adapters
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/ports"
+)
+
+// RetryPolicy is how an adapter retries its first ping, e.g. while the
+// database container of a docker compose setup is still starting
+type RetryPolicy struct {
+	Attempts   int           // Tries before giving up, 0 or 1 disables retries
+	Backoff    time.Duration // Wait after the first failure, doubled after each one
+	MaxBackoff time.Duration // Longest wait between tries
+}
+
+var (
+	// DefaultRetry is used by the PostgreSQL and MySQL adapters
+	DefaultRetry = RetryPolicy{Attempts: 5, Backoff: 500 * time.Millisecond, MaxBackoff: 5 * time.Second}
+
+	// ServerlessRetry is used for databases that scale to zero (Neon,
+	// Turso) and take a few seconds to wake up
+	ServerlessRetry = RetryPolicy{Attempts: 6, Backoff: 500 * time.Millisecond, MaxBackoff: 8 * time.Second}
+)
+
+// ConnectRetrier is implemented by adapters whose connection retries can
+// be configured
+type ConnectRetrier interface {
+	ConnectRetry() RetryPolicy
+	// SetConnectRetry replaces the adapter's policy, must be called before ConnectWithDSN
+	SetConnectRetry(policy RetryPolicy)
+}
+
+// ConfigureRetry applies database.retry to an adapter, settings left
+// empty keep the adapter's defaults
+func ConfigureRetry(database DatabaseAdapter, config ports.RetryConfig) error {
+	r, ok := database.(ConnectRetrier)
+	if !ok {
+		return nil
+	}
+	policy, err := RetryPolicyFromConfig(config, r.ConnectRetry())
+	if err != nil {
+		return err
+	}
+	r.SetConnectRetry(policy)
+	return nil
+}
+
+// RetryPolicyFromConfig reads database.retry, settings left empty keep
+// the values of base
+func RetryPolicyFromConfig(config ports.RetryConfig, base RetryPolicy) (RetryPolicy, error) {
+	policy := base
+	if config.Attempts > 0 {
+		policy.Attempts = config.Attempts
+	}
+	for _, d := range []struct {
+		value string
+		into  *time.Duration
+		key   string
+	}{{config.Backoff, &policy.Backoff, "backoff"}, {config.MaxBackoff, &policy.MaxBackoff, "max_backoff"}} {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil || parsed < 0 {
+			return base, fmt.Errorf("invalid database retry %s %q", d.key, d.value)
+		}
+		*d.into = parsed
+	}
+	return policy, nil
+}
+
+// pingWithBackoff pings db following policy and returns the last error
+func pingWithBackoff(db *sql.DB, policy RetryPolicy) error {
+	backoff := policy.Backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = db.Ping(); err == nil || attempt >= policy.Attempts {
+			return err
+		}
+		log.Printf("⏳ Database not ready (attempt %d/%d), retrying in %v: %v", attempt, policy.Attempts, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
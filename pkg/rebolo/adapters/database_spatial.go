@@ -0,0 +1,58 @@
+package adapters
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// SpatialDatabase is implemented by adapters that can load a spatial
+// extension for the geometry columns of pkg/rebolo/geo: PostGIS for
+// PostgreSQL, SpatiaLite for SQLite
+type SpatialDatabase interface {
+	// EnableSpatial loads the extension when connecting, must be called before ConnectWithDSN
+	EnableSpatial()
+}
+
+// SpatiaLiteExtension is the library SQLite loads for SpatiaLite, looked
+// up in the library path. Set a full path when it is installed elsewhere.
+var SpatiaLiteExtension = "mod_spatialite"
+
+var registerSpatiaLite sync.Once
+
+// spatiaLiteDriver registers, once, the sqlite3 driver loading SpatiaLite
+// on every connection and returns its name
+func spatiaLiteDriver() string {
+	registerSpatiaLite.Do(func() {
+		sql.Register("sqlite3_spatialite", &sqlite3.SQLiteDriver{Extensions: []string{SpatiaLiteExtension}})
+	})
+	return "sqlite3_spatialite"
+}
+
+// initSpatiaLite creates the metadata tables of SpatiaLite, with the
+// spatial reference systems, in databases that don't have them yet
+func initSpatiaLite(db *sql.DB) error {
+	var found int
+	if err := db.QueryRow("SELECT CheckSpatialMetaData()").Scan(&found); err != nil {
+		return fmt.Errorf("SpatiaLite unavailable: %w", err)
+	}
+	if found > 0 {
+		return nil
+	}
+	if _, err := db.Exec("SELECT InitSpatialMetaData(1)"); err != nil {
+		return fmt.Errorf("failed to initialize SpatiaLite: %w", err)
+	}
+	return nil
+}
+
+// createPostGIS creates the PostGIS extension unless the database has it.
+// Creating it takes a superuser or the database's owner, the check alone
+// doesn't.
+func createPostGIS(db *sql.DB) error {
+	if _, err := db.Exec("CREATE EXTENSION IF NOT EXISTS postgis"); err != nil {
+		return fmt.Errorf("PostGIS unavailable: %w", err)
+	}
+	return nil
+}
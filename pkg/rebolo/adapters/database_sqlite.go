@@ -5,19 +5,66 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/ports"
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// CheckpointResult holds the outcome of a WAL checkpoint
+type CheckpointResult struct {
+	Mode         string        // PASSIVE, FULL, RESTART or TRUNCATE
+	Busy         bool          // True if the checkpoint could not complete
+	LogFrames    int           // Frames in the WAL file
+	Checkpointed int           // Frames written back to the database
+	Duration     time.Duration // Time taken by the checkpoint
+}
+
+// CheckpointHook runs before a WAL checkpoint; returning an error skips it
+type CheckpointHook func(ctx context.Context) error
+
+// CheckpointCallback runs after a WAL checkpoint
+type CheckpointCallback func(ctx context.Context, result CheckpointResult, err error)
+
 // SQLiteDatabase implements Database interface for SQLite
 type SQLiteDatabase struct {
-	db    *sql.DB
-	debug bool
+	db      *sql.DB
+	debug   bool
+	options ports.SQLiteConfig
+	spatial bool
+
+	stmtCacheSize int
+	stmts         *StmtCache
+	retry         RetryPolicy
+
+	hooksMu          sync.RWMutex
+	beforeCheckpoint []CheckpointHook
+	afterCheckpoint  []CheckpointCallback
+	stopCheckpoints  context.CancelFunc
 }
 
 // NewSQLiteDatabase creates a new SQLite database adapter
 func NewSQLiteDatabase() *SQLiteDatabase {
-	return &SQLiteDatabase{}
+	return &SQLiteDatabase{
+		options: ports.SQLiteConfig{
+			WAL:         true,
+			BusyTimeout: 5000,
+			ForeignKeys: true,
+		},
+	}
+}
+
+// SetOptions configures connection settings, must be called before ConnectWithDSN
+func (d *SQLiteDatabase) SetOptions(options ports.SQLiteConfig) {
+	d.options = options
+}
+
+// EnableSpatial loads SpatiaLite on every connection, must be called
+// before ConnectWithDSN
+func (d *SQLiteDatabase) EnableSpatial() {
+	d.spatial = true
 }
 
 // Connect connects to SQLite database
@@ -27,29 +74,251 @@ func (d *SQLiteDatabase) Connect(ctx context.Context) error {
 
 // ConnectWithDSN connects to SQLite with DSN (file path)
 func (d *SQLiteDatabase) ConnectWithDSN(dsn string, debug bool) error {
+	// Settings are passed as DSN parameters so that every pooled
+	// connection gets them, not only the one that runs a PRAGMA
+	journalMode := d.journalMode()
+	configured := d.applyOptions(dsn, journalMode)
+
 	// Open SQLite database
-	db, err := sql.Open("sqlite3", dsn)
+	driverName := "sqlite3"
+	if d.spatial {
+		driverName = spatiaLiteDriver()
+	}
+	db, err := openDB(driverName, configured)
 	if err != nil {
 		return fmt.Errorf("failed to open sqlite database: %w", err)
 	}
-	
+
 	d.db = db
 	d.debug = debug
-	
+
 	// Test connection
-	if err := d.db.Ping(); err != nil {
+	if err := pingWithBackoff(d.db, d.retry); err != nil {
 		return fmt.Errorf("failed to ping sqlite database: %w", err)
 	}
-	
+
+	if d.spatial {
+		if err := initSpatiaLite(d.db); err != nil {
+			return err
+		}
+	}
+
+	// In-memory databases and some network filesystems can't use WAL and
+	// silently keep another journal mode
+	var mode string
+	if err := d.db.QueryRow("PRAGMA journal_mode").Scan(&mode); err == nil {
+		if journalMode != "" && !strings.EqualFold(mode, journalMode) && !hasDSNParam(dsn, "_journal_mode", "_journal") {
+			log.Printf("⚠️  SQLite journal_mode is %s, %s is not available for this database", strings.ToUpper(mode), journalMode)
+		}
+	}
+
 	if debug {
-		log.Println("✅ SQLite database connected (debug mode enabled)")
+		log.Printf("✅ SQLite database connected (debug mode enabled, journal_mode=%s, busy_timeout=%dms, foreign_keys=%v)",
+			strings.ToUpper(mode), d.options.BusyTimeout, d.options.ForeignKeys)
+	}
+
+	if d.stmtCacheSize > 0 {
+		d.stmts = NewStmtCache(d.db, d.stmtCacheSize)
 	}
-	
+
+	d.startCheckpointLoop()
+
 	return nil
 }
 
+// dsnParam is a go-sqlite3 DSN parameter with its accepted aliases
+type dsnParam struct {
+	keys  []string
+	value string
+}
+
+// sqliteChoices are the accepted values of the string settings
+var sqliteChoices = map[string][]string{
+	"journal_mode": {"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"},
+	"synchronous":  {"OFF", "NORMAL", "FULL", "EXTRA"},
+	"txlock":       {"DEFERRED", "IMMEDIATE", "EXCLUSIVE"},
+}
+
+// sqliteChoice returns value in upper case when it is one of the setting's
+// choices, logging and ignoring it otherwise
+func sqliteChoice(setting, value string) string {
+	value = strings.ToUpper(value)
+	for _, choice := range sqliteChoices[setting] {
+		if value == choice {
+			return value
+		}
+	}
+	log.Printf("⚠️  Invalid sqlite %s %q (valid: %s), ignoring it", setting, value, strings.Join(sqliteChoices[setting], ", "))
+	return ""
+}
+
+// journalMode returns the journal mode to use, empty to keep SQLite's
+func (d *SQLiteDatabase) journalMode() string {
+	if d.options.JournalMode != "" {
+		return sqliteChoice("journal_mode", d.options.JournalMode)
+	}
+	if d.options.WAL {
+		return "WAL"
+	}
+	return ""
+}
+
+// applyOptions adds the configured settings as DSN parameters,
+// leaving any parameter already present in the DSN untouched
+func (d *SQLiteDatabase) applyOptions(dsn, journalMode string) string {
+	var params []dsnParam
+
+	if journalMode != "" {
+		params = append(params, dsnParam{[]string{"_journal_mode", "_journal"}, journalMode})
+	}
+	if d.options.BusyTimeout > 0 {
+		params = append(params, dsnParam{[]string{"_busy_timeout", "_timeout"}, fmt.Sprintf("%d", d.options.BusyTimeout)})
+	}
+	if d.options.ForeignKeys {
+		params = append(params, dsnParam{[]string{"_foreign_keys", "_fk"}, "1"})
+	}
+	if d.options.Synchronous != "" {
+		if value := sqliteChoice("synchronous", d.options.Synchronous); value != "" {
+			params = append(params, dsnParam{[]string{"_synchronous", "_sync"}, value})
+		}
+	}
+	if d.options.TxLock != "" {
+		if value := sqliteChoice("txlock", d.options.TxLock); value != "" {
+			params = append(params, dsnParam{[]string{"_txlock"}, strings.ToLower(value)})
+		}
+	}
+	if d.options.CacheSize != 0 {
+		params = append(params, dsnParam{[]string{"_cache_size"}, fmt.Sprintf("%d", d.options.CacheSize)})
+	}
+
+	for _, p := range params {
+		if hasDSNParam(dsn, p.keys...) {
+			continue
+		}
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		dsn += sep + p.keys[0] + "=" + p.value
+	}
+
+	return dsn
+}
+
+// hasDSNParam reports whether any of the keys is already set in the DSN
+func hasDSNParam(dsn string, keys ...string) bool {
+	idx := strings.Index(dsn, "?")
+	if idx == -1 {
+		return false
+	}
+	for _, pair := range strings.Split(dsn[idx+1:], "&") {
+		name := strings.SplitN(pair, "=", 2)[0]
+		for _, key := range keys {
+			if name == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// OnBeforeCheckpoint registers a hook that runs before every WAL checkpoint.
+// Backup tools such as Litestream can use it to sync the WAL before it is truncated.
+func (d *SQLiteDatabase) OnBeforeCheckpoint(hook CheckpointHook) {
+	d.hooksMu.Lock()
+	defer d.hooksMu.Unlock()
+	d.beforeCheckpoint = append(d.beforeCheckpoint, hook)
+}
+
+// OnAfterCheckpoint registers a callback that runs after every WAL checkpoint
+func (d *SQLiteDatabase) OnAfterCheckpoint(callback CheckpointCallback) {
+	d.hooksMu.Lock()
+	defer d.hooksMu.Unlock()
+	d.afterCheckpoint = append(d.afterCheckpoint, callback)
+}
+
+// Checkpoint runs a WAL checkpoint with the given mode (PASSIVE, FULL, RESTART, TRUNCATE)
+func (d *SQLiteDatabase) Checkpoint(ctx context.Context, mode string) (CheckpointResult, error) {
+	mode = strings.ToUpper(mode)
+	if mode == "" {
+		mode = "PASSIVE"
+	}
+	result := CheckpointResult{Mode: mode}
+
+	switch mode {
+	case "PASSIVE", "FULL", "RESTART", "TRUNCATE":
+	default:
+		return result, fmt.Errorf("invalid checkpoint mode: %s", mode)
+	}
+
+	if d.db == nil {
+		return result, fmt.Errorf("database not connected")
+	}
+
+	d.hooksMu.RLock()
+	before := d.beforeCheckpoint
+	after := d.afterCheckpoint
+	d.hooksMu.RUnlock()
+
+	for _, hook := range before {
+		if err := hook(ctx); err != nil {
+			return result, fmt.Errorf("checkpoint skipped by hook: %w", err)
+		}
+	}
+
+	start := time.Now()
+	var busy int
+	err := d.db.QueryRowContext(ctx, "PRAGMA wal_checkpoint("+mode+")").
+		Scan(&busy, &result.LogFrames, &result.Checkpointed)
+	result.Busy = busy != 0
+	result.Duration = time.Since(start)
+
+	for _, callback := range after {
+		callback(ctx, result, err)
+	}
+
+	return result, err
+}
+
+// startCheckpointLoop runs periodic checkpoints when checkpoint_interval is set
+func (d *SQLiteDatabase) startCheckpointLoop() {
+	if d.options.CheckpointInterval == "" || !d.options.WAL {
+		return
+	}
+
+	interval, err := time.ParseDuration(d.options.CheckpointInterval)
+	if err != nil || interval <= 0 {
+		log.Printf("⚠️  Invalid sqlite checkpoint_interval %q: %v", d.options.CheckpointInterval, err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.stopCheckpoints = cancel
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := d.Checkpoint(ctx, "PASSIVE"); err != nil {
+					log.Printf("⚠️  SQLite checkpoint failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
 // Close closes the database connection
 func (d *SQLiteDatabase) Close() error {
+	if d.stopCheckpoints != nil {
+		d.stopCheckpoints()
+	}
+	if d.stmts != nil {
+		d.stmts.Close()
+	}
 	if d.db != nil {
 		return d.db.Close()
 	}
@@ -74,3 +343,25 @@ func (d *SQLiteDatabase) Health() error {
 func (d *SQLiteDatabase) DB() interface{} {
 	return d.db
 }
+
+// EnableStatementCache keeps up to size prepared statements for hot
+// queries, must be called before ConnectWithDSN
+func (d *SQLiteDatabase) EnableStatementCache(size int) {
+	d.stmtCacheSize = size
+}
+
+// Statements returns the prepared statement cache, nil unless enabled
+func (d *SQLiteDatabase) Statements() *StmtCache {
+	return d.stmts
+}
+
+// ConnectRetry returns how the first ping is retried
+func (d *SQLiteDatabase) ConnectRetry() RetryPolicy {
+	return d.retry
+}
+
+// SetConnectRetry sets how the first ping is retried, must be called
+// before ConnectWithDSN
+func (d *SQLiteDatabase) SetConnectRetry(policy RetryPolicy) {
+	d.retry = policy
+}
@@ -0,0 +1,178 @@
+package adapters
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// StatementCacher is implemented by adapters that can keep prepared
+// statements for hot queries
+type StatementCacher interface {
+	// EnableStatementCache keeps up to size statements, must be called before ConnectWithDSN
+	EnableStatementCache(size int)
+	// Statements returns the cache, nil unless enabled
+	Statements() *StmtCache
+}
+
+// StmtCacheStats are the counters of a statement cache
+type StmtCacheStats struct {
+	Hits      uint64 // Queries that reused a prepared statement
+	Misses    uint64 // Queries that had to prepare one
+	Evictions uint64 // Statements closed to make room
+	Size      int    // Statements currently prepared
+	Capacity  int    // Statements kept at most
+}
+
+// HitRate returns the share of queries that reused a statement, from 0 to 1
+func (s StmtCacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// cachedStmt is a prepared statement and the queries using it. An evicted
+// statement is closed when the last of them returns.
+type cachedStmt struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+// StmtCache prepares each query once and reuses the statement, saving a
+// round trip per query on PostgreSQL and MySQL. It keeps the most recently
+// used statements, up to its capacity.
+type StmtCache struct {
+	db       *sql.DB
+	capacity int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used first
+	stats   StmtCacheStats
+}
+
+// NewStmtCache creates a cache of up to capacity statements prepared on db
+func NewStmtCache(db *sql.DB, capacity int) *StmtCache {
+	return &StmtCache{
+		db:       db,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// QueryContext runs query with a prepared statement
+func (c *StmtCache) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	entry, err := c.acquire(ctx, query)
+	if err != nil {
+		return c.db.QueryContext(ctx, query, args...)
+	}
+	// The rows keep the statement alive after it is released
+	defer c.release(entry)
+	return entry.stmt.QueryContext(ctx, args...)
+}
+
+// QueryRowContext runs query with a prepared statement
+func (c *StmtCache) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	entry, err := c.acquire(ctx, query)
+	if err != nil {
+		return c.db.QueryRowContext(ctx, query, args...)
+	}
+	defer c.release(entry)
+	return entry.stmt.QueryRowContext(ctx, args...)
+}
+
+// ExecContext runs query with a prepared statement
+func (c *StmtCache) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	entry, err := c.acquire(ctx, query)
+	if err != nil {
+		return c.db.ExecContext(ctx, query, args...)
+	}
+	defer c.release(entry)
+	return entry.stmt.ExecContext(ctx, args...)
+}
+
+// Stats returns the cache's counters
+func (c *StmtCache) Stats() StmtCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Size = c.order.Len()
+	stats.Capacity = c.capacity
+	return stats
+}
+
+// Close closes every statement, queries still running finish first
+func (c *StmtCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.order.Len() > 0 {
+		c.evict(c.order.Back())
+	}
+	return nil
+}
+
+// acquire returns the statement for query, preparing it on a miss. A
+// query that can't be prepared returns the error and isn't cached.
+func (c *StmtCache) acquire(ctx context.Context, query string) (*cachedStmt, error) {
+	c.mu.Lock()
+	if el, ok := c.entries[query]; ok {
+		c.order.MoveToFront(el)
+		entry := el.Value.(*cachedStmt)
+		entry.refs++
+		c.stats.Hits++
+		c.mu.Unlock()
+		return entry, nil
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	// Prepare without the lock, a slow prepare shouldn't hold up hits
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[query]; ok {
+		// Another query prepared it meanwhile
+		stmt.Close()
+		entry := el.Value.(*cachedStmt)
+		entry.refs++
+		return entry, nil
+	}
+
+	entry := &cachedStmt{query: query, stmt: stmt, refs: 1}
+	c.entries[query] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		c.evict(c.order.Back())
+		c.stats.Evictions++
+	}
+	return entry, nil
+}
+
+func (c *StmtCache) release(entry *cachedStmt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+// evict removes a statement, closing it unless a query is using it.
+// Callers hold c.mu.
+func (c *StmtCache) evict(el *list.Element) {
+	entry := c.order.Remove(el).(*cachedStmt)
+	delete(c.entries, entry.query)
+	entry.evicted = true
+	if entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
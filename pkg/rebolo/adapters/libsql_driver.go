@@ -0,0 +1,501 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LibSQLDriverName is the database/sql driver for remote libSQL servers
+// (Turso, sqld). It speaks the Hrana protocol over HTTP, so it needs no
+// cgo and no open socket between queries, which suits scale-to-zero
+// databases.
+const LibSQLDriverName = "rebolo-libsql"
+
+func init() {
+	sql.Register(LibSQLDriverName, libsqlDriver{})
+}
+
+// libsqlTimeFormats are the layouts SQLite stores times in, the first is
+// the one times are written with, as go-sqlite3 does
+var libsqlTimeFormats = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+type libsqlDriver struct{}
+
+// Open connects to a libSQL URL: libsql://db-org.turso.io?authToken=...
+// libsql:// uses HTTPS unless tls=0 is set, http:// and https:// URLs are
+// used as they are. Without authToken, TURSO_AUTH_TOKEN or
+// LIBSQL_AUTH_TOKEN from the environment is sent.
+func (libsqlDriver) Open(dsn string) (driver.Conn, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid libsql url: %w", err)
+	}
+
+	query := u.Query()
+	token := query.Get("authToken")
+	if token == "" {
+		token = os.Getenv("TURSO_AUTH_TOKEN")
+	}
+	if token == "" {
+		token = os.Getenv("LIBSQL_AUTH_TOKEN")
+	}
+
+	switch u.Scheme {
+	case "libsql", "wss", "ws":
+		plain := query.Get("tls") == "0" || u.Scheme == "ws"
+		u.Scheme = "https"
+		if plain {
+			u.Scheme = "http"
+		}
+	case "http", "https":
+	default:
+		return nil, fmt.Errorf("unsupported libsql url scheme: %s", u.Scheme)
+	}
+	u.RawQuery = ""
+
+	return &libsqlConn{
+		url:    strings.TrimSuffix(u.String(), "/") + "/v2/pipeline",
+		token:  token,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// libsqlConn is a connection to a libSQL server. Outside transactions
+// each request runs on a stream of its own; a transaction keeps its
+// stream open with the baton the server hands back.
+type libsqlConn struct {
+	url    string
+	token  string
+	client *http.Client
+
+	baton string
+	inTx  bool
+	bad   bool
+}
+
+// Hrana request and response bodies, see
+// https://github.com/tursodatabase/libsql/blob/main/docs/HRANA_3_SPEC.md
+type (
+	hranaPipeline struct {
+		Baton    *string        `json:"baton"`
+		Requests []hranaRequest `json:"requests"`
+	}
+	hranaRequest struct {
+		Type string     `json:"type"`
+		Stmt *hranaStmt `json:"stmt,omitempty"`
+		SQL  string     `json:"sql,omitempty"`
+	}
+	hranaStmt struct {
+		SQL       string          `json:"sql"`
+		Args      []hranaValue    `json:"args,omitempty"`
+		NamedArgs []hranaNamedArg `json:"named_args,omitempty"`
+		WantRows  bool            `json:"want_rows"`
+	}
+	hranaNamedArg struct {
+		Name  string     `json:"name"`
+		Value hranaValue `json:"value"`
+	}
+	hranaValue struct {
+		Type   string          `json:"type"`
+		Value  json.RawMessage `json:"value,omitempty"`
+		Base64 string          `json:"base64,omitempty"`
+	}
+	hranaPipelineResponse struct {
+		Baton   *string `json:"baton"`
+		BaseURL *string `json:"base_url"`
+		Results []struct {
+			Type     string `json:"type"`
+			Response *struct {
+				Type   string       `json:"type"`
+				Result *hranaResult `json:"result"`
+			} `json:"response"`
+			Error *hranaError `json:"error"`
+		} `json:"results"`
+	}
+	hranaResult struct {
+		Cols []struct {
+			Name     string  `json:"name"`
+			Decltype *string `json:"decltype"`
+		} `json:"cols"`
+		Rows             [][]hranaValue `json:"rows"`
+		AffectedRowCount int64          `json:"affected_row_count"`
+		LastInsertRowid  *string        `json:"last_insert_rowid"`
+	}
+	hranaError struct {
+		Message string `json:"message"`
+		Code    string `json:"code"`
+	}
+)
+
+func (e *hranaError) Error() string {
+	if e.Code != "" {
+		return "libsql: " + e.Message + " (" + e.Code + ")"
+	}
+	return "libsql: " + e.Message
+}
+
+// pipeline sends requests on the connection's stream, closing the stream
+// afterwards unless a transaction is open
+func (c *libsqlConn) pipeline(ctx context.Context, requests ...hranaRequest) (*hranaPipelineResponse, error) {
+	body := hranaPipeline{Requests: requests}
+	if c.baton != "" {
+		body.Baton = &c.baton
+	}
+	if !c.inTx {
+		body.Requests = append(body.Requests, hranaRequest{Type: "close"})
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		c.bad = c.inTx // the transaction's stream is lost
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		c.bad = c.inTx
+		return nil, fmt.Errorf("libsql: %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	var result hranaPipelineResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("libsql: invalid response: %w", err)
+	}
+
+	c.baton = ""
+	if c.inTx && result.Baton != nil {
+		c.baton = *result.Baton
+		if result.BaseURL != nil && *result.BaseURL != "" {
+			c.url = strings.TrimSuffix(*result.BaseURL, "/") + "/v2/pipeline"
+		}
+	}
+
+	for _, r := range result.Results {
+		if r.Type == "error" && r.Error != nil {
+			return nil, r.Error
+		}
+	}
+	return &result, nil
+}
+
+// execute runs one statement and returns its result
+func (c *libsqlConn) execute(ctx context.Context, query string, args []driver.NamedValue, wantRows bool) (*hranaResult, error) {
+	stmt := &hranaStmt{SQL: query, WantRows: wantRows}
+	for _, arg := range args {
+		value, err := encodeHranaValue(arg.Value)
+		if err != nil {
+			return nil, err
+		}
+		if arg.Name == "" {
+			stmt.Args = append(stmt.Args, value)
+			continue
+		}
+		name := arg.Name
+		if !strings.ContainsAny(name[:1], ":@$") {
+			name = ":" + name
+		}
+		stmt.NamedArgs = append(stmt.NamedArgs, hranaNamedArg{Name: name, Value: value})
+	}
+
+	resp, err := c.pipeline(ctx, hranaRequest{Type: "execute", Stmt: stmt})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Results) == 0 || resp.Results[0].Response == nil || resp.Results[0].Response.Result == nil {
+		return nil, errors.New("libsql: empty response")
+	}
+	return resp.Results[0].Response.Result, nil
+}
+
+// ExecContext runs a statement. Scripts without arguments, like
+// migrations, run as a sequence of statements.
+func (c *libsqlConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if len(args) == 0 && multipleStatements(query) {
+		if _, err := c.pipeline(ctx, hranaRequest{Type: "sequence", SQL: query}); err != nil {
+			return nil, err
+		}
+		return driver.RowsAffected(0), nil
+	}
+
+	result, err := c.execute(ctx, query, args, false)
+	if err != nil {
+		return nil, err
+	}
+	r := libsqlResult{affected: result.AffectedRowCount}
+	if result.LastInsertRowid != nil {
+		r.lastID, _ = strconv.ParseInt(*result.LastInsertRowid, 10, 64)
+	}
+	return r, nil
+}
+
+// QueryContext runs a statement and reads all its rows
+func (c *libsqlConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result, err := c.execute(ctx, query, args, true)
+	if err != nil {
+		return nil, err
+	}
+	return &libsqlRows{result: result}, nil
+}
+
+// Ping runs SELECT 1
+func (c *libsqlConn) Ping(ctx context.Context) error {
+	_, err := c.execute(ctx, "SELECT 1", nil, true)
+	return err
+}
+
+func (c *libsqlConn) Prepare(query string) (driver.Stmt, error) {
+	return &libsqlStmt{conn: c, query: query}, nil
+}
+
+func (c *libsqlConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx opens a stream for the transaction and runs BEGIN on it
+func (c *libsqlConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if c.inTx {
+		return nil, errors.New("libsql: transaction already open")
+	}
+	c.inTx = true
+	if _, err := c.execute(ctx, "BEGIN", nil, false); err != nil {
+		c.inTx, c.baton = false, ""
+		return nil, err
+	}
+	return &libsqlTx{conn: c}, nil
+}
+
+// IsValid reports whether the connection can go back to the pool
+func (c *libsqlConn) IsValid() bool {
+	return !c.bad
+}
+
+// Close closes the transaction stream, if one is left open
+func (c *libsqlConn) Close() error {
+	if c.baton != "" {
+		c.inTx = false
+		c.pipeline(context.Background())
+	}
+	return nil
+}
+
+type libsqlTx struct {
+	conn *libsqlConn
+}
+
+func (tx *libsqlTx) Commit() error   { return tx.end("COMMIT") }
+func (tx *libsqlTx) Rollback() error { return tx.end("ROLLBACK") }
+
+// end runs COMMIT or ROLLBACK and closes the transaction's stream
+func (tx *libsqlTx) end(statement string) error {
+	c := tx.conn
+	c.inTx = false
+	_, err := c.pipeline(context.Background(), hranaRequest{Type: "execute", Stmt: &hranaStmt{SQL: statement}})
+	c.baton = ""
+	return err
+}
+
+// libsqlStmt is a statement prepared on the client, the server parses it
+// with each execution
+type libsqlStmt struct {
+	conn  *libsqlConn
+	query string
+}
+
+func (s *libsqlStmt) Close() error  { return nil }
+func (s *libsqlStmt) NumInput() int { return -1 }
+
+func (s *libsqlStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *libsqlStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *libsqlStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *libsqlStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+type libsqlResult struct {
+	lastID   int64
+	affected int64
+}
+
+func (r libsqlResult) LastInsertId() (int64, error) { return r.lastID, nil }
+func (r libsqlResult) RowsAffected() (int64, error) { return r.affected, nil }
+
+// libsqlRows iterates over the rows of a result, times come back as
+// time.Time for DATE, DATETIME and TIMESTAMP columns as with go-sqlite3
+type libsqlRows struct {
+	result *hranaResult
+	next   int
+}
+
+func (r *libsqlRows) Columns() []string {
+	names := make([]string, len(r.result.Cols))
+	for i, col := range r.result.Cols {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// ColumnTypeDatabaseTypeName returns the declared type of a column
+func (r *libsqlRows) ColumnTypeDatabaseTypeName(i int) string {
+	if decltype := r.result.Cols[i].Decltype; decltype != nil {
+		return strings.ToUpper(*decltype)
+	}
+	return ""
+}
+
+func (r *libsqlRows) Close() error { return nil }
+
+func (r *libsqlRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.Rows) {
+		return io.EOF
+	}
+	row := r.result.Rows[r.next]
+	r.next++
+
+	for i := range dest {
+		value, err := decodeHranaValue(row[i])
+		if err != nil {
+			return err
+		}
+		if text, ok := value.(string); ok {
+			switch r.ColumnTypeDatabaseTypeName(i) {
+			case "DATE", "DATETIME", "TIMESTAMP":
+				for _, layout := range libsqlTimeFormats {
+					if t, err := time.Parse(layout, text); err == nil {
+						value = t
+						break
+					}
+				}
+			}
+		}
+		dest[i] = value
+	}
+	return nil
+}
+
+func encodeHranaValue(v driver.Value) (hranaValue, error) {
+	switch v := v.(type) {
+	case nil:
+		return hranaValue{Type: "null"}, nil
+	case int64:
+		return hranaValue{Type: "integer", Value: json.RawMessage(strconv.Quote(strconv.FormatInt(v, 10)))}, nil
+	case bool:
+		n := "0"
+		if v {
+			n = "1"
+		}
+		return hranaValue{Type: "integer", Value: json.RawMessage(strconv.Quote(n))}, nil
+	case float64:
+		raw, err := json.Marshal(v)
+		return hranaValue{Type: "float", Value: raw}, err
+	case string:
+		raw, err := json.Marshal(v)
+		return hranaValue{Type: "text", Value: raw}, err
+	case []byte:
+		return hranaValue{Type: "blob", Base64: base64.StdEncoding.EncodeToString(v)}, nil
+	case time.Time:
+		raw, err := json.Marshal(v.Format(libsqlTimeFormats[0]))
+		return hranaValue{Type: "text", Value: raw}, err
+	}
+	return hranaValue{}, fmt.Errorf("libsql: unsupported argument type %T", v)
+}
+
+func decodeHranaValue(v hranaValue) (driver.Value, error) {
+	switch v.Type {
+	case "null":
+		return nil, nil
+	case "integer":
+		var s string
+		if err := json.Unmarshal(v.Value, &s); err != nil {
+			return nil, err
+		}
+		return strconv.ParseInt(s, 10, 64)
+	case "float":
+		var f float64
+		err := json.Unmarshal(v.Value, &f)
+		return f, err
+	case "text":
+		var s string
+		err := json.Unmarshal(v.Value, &s)
+		return s, err
+	case "blob":
+		return base64.StdEncoding.DecodeString(v.Base64)
+	}
+	return nil, fmt.Errorf("libsql: unknown value type %q", v.Type)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+// multipleStatements reports whether a script has more than one
+// statement, skipping semicolons in quotes and comments
+func multipleStatements(script string) bool {
+	script = strings.TrimRight(script, " \t\r\n;")
+	var quote byte
+	for i := 0; i < len(script); i++ {
+		ch := script[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+		case ch == '-' && i+1 < len(script) && script[i+1] == '-':
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+		case ch == ';':
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package adapters
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// minCompressSize is the smallest file worth compressing, smaller ones
+// gain less than the headers cost
+const minCompressSize = 1024
+
+// compressibleExts are the text formats compression shrinks, images,
+// fonts like woff2 and archives already are compressed
+var compressibleExts = map[string]bool{
+	".html": true, ".htm": true, ".css": true, ".js": true, ".mjs": true,
+	".json": true, ".map": true, ".svg": true, ".txt": true, ".xml": true,
+	".wasm": true, ".ico": true, ".ttf": true, ".otf": true, ".webmanifest": true,
+}
+
+// Compressible reports whether a file is worth compressing, by extension
+func Compressible(name string) bool {
+	return compressibleExts[strings.ToLower(path.Ext(name))]
+}
+
+// Precompress writes a .gz next to each compressible file under dir, and a
+// .br when the brotli command is installed, for ServeStatic to send to the
+// clients accepting them. Files with up to date variants are skipped. It
+// returns how many files it compressed.
+func Precompress(dir string) (int, error) {
+	brotli, _ := exec.LookPath("brotli")
+	count := 0
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && file != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !Compressible(file) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() < minCompressSize {
+			return nil
+		}
+
+		compressed := false
+		if stale(file+".gz", info) {
+			if err := gzipFile(file); err != nil {
+				return err
+			}
+			compressed = true
+		}
+		if brotli != "" && stale(file+".br", info) {
+			if out, err := exec.Command(brotli, "--best", "--force", "--keep", "--output="+file+".br", file).CombinedOutput(); err != nil {
+				return fmt.Errorf("brotli %s: %v: %s", file, err, strings.TrimSpace(string(out)))
+			}
+			compressed = true
+		}
+		if compressed {
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// stale reports whether the variant at name is missing or older than the
+// file it was made from
+func stale(name string, original fs.FileInfo) bool {
+	info, err := os.Stat(name)
+	return err != nil || info.ModTime().Before(original.ModTime())
+}
+
+func gzipFile(file string) error {
+	src, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// Written aside and renamed, so a server never sends half a file
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".precompress-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	gz, _ := gzip.NewWriterLevel(tmp, gzip.BestCompression)
+	if _, err := io.Copy(gz, src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file+".gz")
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header allows coding,
+// directly or through *, and not with q=0
+func acceptsEncoding(header, coding string) bool {
+	accepted := false
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.TrimSpace(name)
+		if !strings.EqualFold(name, coding) && name != "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if strings.EqualFold(name, coding) {
+			return q > 0
+		}
+		accepted = q > 0
+	}
+	return accepted
+}
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// gzipResponseWriter compresses a successful response on the fly, others
+// like 304 or 404 go out as they are
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz    *gzip.Writer
+	wrote bool
+}
+
+func (g *gzipResponseWriter) WriteHeader(code int) {
+	if code == http.StatusOK {
+		g.Header().Del("Content-Length")
+		g.Header().Set("Content-Encoding", "gzip")
+		g.gz = gzipWriters.Get().(*gzip.Writer)
+		g.gz.Reset(g.ResponseWriter)
+	}
+	g.ResponseWriter.WriteHeader(code)
+}
+
+func (g *gzipResponseWriter) Write(p []byte) (int, error) {
+	if g.gz == nil {
+		return g.ResponseWriter.Write(p)
+	}
+	g.wrote = true
+	return g.gz.Write(p)
+}
+
+// close writes the end of the compressed body, HEAD requests have none
+func (g *gzipResponseWriter) close() {
+	if g.gz == nil {
+		return
+	}
+	if g.wrote {
+		g.gz.Close()
+	}
+	gzipWriters.Put(g.gz)
+	g.gz = nil
+}
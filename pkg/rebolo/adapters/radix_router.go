@@ -0,0 +1,238 @@
+package adapters
+
+import (
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/core"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/routing"
+	"github.com/gorilla/mux"
+)
+
+// RadixRouter implements Router interface with a tree of path segments:
+// a request walks one node per segment instead of trying every route's
+// regexp in turn, which keeps lookups fast for apps with many routes.
+//
+// It accepts the same patterns as MuxRouter ("/posts/{id}",
+// "/posts/{id:[0-9]+}") and sets the variables with mux.SetURLVars, so
+// mux.Vars and Context.Param keep working. Routes are also registered,
+// unused for dispatch, on a mux.Router to support names and URLFor.
+type RadixRouter struct {
+	root             *radixNode
+	middlewares      []core.Middleware
+	names            *mux.Router
+	notFound         http.Handler
+	methodNotAllowed http.Handler
+}
+
+type radixNode struct {
+	static   map[string]*radixNode
+	params   []*radixNode // in registration order
+	name     string       // variable name of a param node
+	expr     string       // its regexp, if any
+	pattern  *regexp.Regexp
+	handlers map[string]http.Handler // by method
+	prefix   http.Handler            // serves everything below this node
+}
+
+func NewRadixRouter() *RadixRouter {
+	return &RadixRouter{
+		root:             &radixNode{},
+		names:            mux.NewRouter(),
+		notFound:         http.NotFoundHandler(),
+		methodNotAllowed: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusMethodNotAllowed) }),
+	}
+}
+
+func (r *RadixRouter) GET(path string, handler http.HandlerFunc) core.NamedRoute {
+	return r.Route(path, handler, http.MethodGet)
+}
+
+func (r *RadixRouter) POST(path string, handler http.HandlerFunc) core.NamedRoute {
+	return r.Route(path, handler, http.MethodPost)
+}
+
+func (r *RadixRouter) PUT(path string, handler http.HandlerFunc) core.NamedRoute {
+	return r.Route(path, handler, http.MethodPut)
+}
+
+func (r *RadixRouter) DELETE(path string, handler http.HandlerFunc) core.NamedRoute {
+	return r.Route(path, handler, http.MethodDelete)
+}
+
+// Route registers handler for path and the given methods
+func (r *RadixRouter) Route(path string, handler http.HandlerFunc, methods ...string) core.NamedRoute {
+	node := r.root.insert(segments(path))
+	if node.handlers == nil {
+		node.handlers = make(map[string]http.Handler)
+	}
+	for _, method := range methods {
+		node.handlers[method] = handler
+	}
+	return &routing.NamedRoute{Route: r.names.NewRoute().Path(path).Methods(methods...)}
+}
+
+// Static serves every path under prefix with handler, for any method
+func (r *RadixRouter) Static(prefix string, handler http.Handler) {
+	// "/assets/" and "/assets" both cover "/assets/app.css"
+	node := r.root
+	if p := strings.Trim(prefix, "/"); p != "" {
+		node = node.insert(strings.Split(p, "/"))
+	}
+	node.prefix = handler
+}
+
+// NamedRoutes returns the router holding the route names, for URLFor
+func (r *RadixRouter) NamedRoutes() *mux.Router {
+	return r.names
+}
+
+// SetErrorHandlers sets the handlers for unknown paths and methods
+func (r *RadixRouter) SetErrorHandlers(notFound, methodNotAllowed http.Handler) {
+	r.notFound = notFound
+	r.methodNotAllowed = methodNotAllowed
+}
+
+func (r *RadixRouter) Resource(path string, controller core.Controller) {
+	base := path
+	r.GET(base, controller.Index)
+	r.GET(base+"/new", controller.New)
+	r.POST(base, controller.Create)
+	r.GET(base+"/{id}", controller.Show)
+	r.GET(base+"/{id}/edit", controller.Edit)
+	r.Route(base+"/{id}", controller.Update, http.MethodPut, http.MethodPatch)
+	r.DELETE(base+"/{id}", controller.Delete)
+}
+
+// Use adds middleware run for matched routes, like mux.Router.Use
+func (r *RadixRouter) Use(middleware core.Middleware) {
+	r.middlewares = append(r.middlewares, middleware)
+}
+
+func (r *RadixRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Redirect to the canonical path, as gorilla/mux does
+	if p := cleanPath(req.URL.Path); p != req.URL.Path {
+		url := *req.URL
+		url.Path = p
+		w.Header().Set("Location", url.String())
+		w.WriteHeader(http.StatusMovedPermanently)
+		return
+	}
+
+	segs := segments(req.URL.Path)
+	vars := make(map[string]string)
+	node := r.root.match(segs, req.Method, vars)
+	if node == nil {
+		// A route for another method makes it a 405, as in gorilla/mux
+		if r.root.match(segs, "", make(map[string]string)) != nil {
+			r.methodNotAllowed.ServeHTTP(w, req)
+		} else {
+			r.notFound.ServeHTTP(w, req)
+		}
+		return
+	}
+
+	handler := node.handlers[req.Method]
+	if handler == nil {
+		handler = node.prefix
+	}
+
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		handler = r.middlewares[i](handler)
+	}
+	if len(vars) > 0 {
+		req = mux.SetURLVars(req, vars)
+	}
+	handler.ServeHTTP(w, req)
+}
+
+// insert returns the node for the pattern segments, creating it if needed
+func (n *radixNode) insert(segs []string) *radixNode {
+	for _, seg := range segs {
+		n = n.child(seg)
+	}
+	return n
+}
+
+func (n *radixNode) child(seg string) *radixNode {
+	if !strings.HasPrefix(seg, "{") || !strings.HasSuffix(seg, "}") {
+		if n.static == nil {
+			n.static = make(map[string]*radixNode)
+		}
+		child, ok := n.static[seg]
+		if !ok {
+			child = &radixNode{}
+			n.static[seg] = child
+		}
+		return child
+	}
+
+	name, expr, _ := strings.Cut(seg[1:len(seg)-1], ":")
+	for _, child := range n.params {
+		if child.name == name && child.expr == expr {
+			return child
+		}
+	}
+	child := &radixNode{name: name, expr: expr}
+	if expr != "" {
+		child.pattern = regexp.MustCompile("^(?:" + expr + ")$")
+	}
+	n.params = append(n.params, child)
+	return child
+}
+
+// match finds the node serving method on segs, static segments taking
+// precedence over variables, and fills vars. Without an exact match the
+// deepest prefix handler on the way wins. An empty method matches a route
+// for any method.
+func (n *radixNode) match(segs []string, method string, vars map[string]string) *radixNode {
+	if len(segs) == 0 {
+		if method == "" && len(n.handlers) > 0 || n.handlers[method] != nil || n.prefix != nil {
+			return n
+		}
+		return nil
+	}
+
+	seg := segs[0]
+	if child, ok := n.static[seg]; ok {
+		if found := child.match(segs[1:], method, vars); found != nil {
+			return found
+		}
+	}
+	for _, child := range n.params {
+		if seg == "" || child.pattern != nil && !child.pattern.MatchString(seg) {
+			continue
+		}
+		if found := child.match(segs[1:], method, vars); found != nil {
+			vars[child.name] = seg
+			return found
+		}
+	}
+
+	if n.prefix != nil {
+		return n
+	}
+	return nil
+}
+
+// segments splits a path, "/posts/" is ["posts", ""] and "/" is [""]
+func segments(p string) []string {
+	return strings.Split(strings.TrimPrefix(p, "/"), "/")
+}
+
+// cleanPath is path.Clean keeping the trailing slash
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+	np := path.Clean(p)
+	if p[len(p)-1] == '/' && np != "/" {
+		np += "/"
+	}
+	return np
+}
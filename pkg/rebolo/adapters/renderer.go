@@ -2,6 +2,9 @@ package adapters
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"html/template"
@@ -9,102 +12,319 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/assets"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/barcode"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/codec"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/form"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/timefmt"
+)
+
+// templateFuncs are the helpers available in every view
+func templateFuncs() template.FuncMap {
+	funcs := timefmt.FuncMap()
+	for name, fn := range form.FuncMap() {
+		funcs[name] = fn
+	}
+	for name, fn := range assets.FuncMap() {
+		funcs[name] = fn
+	}
+	for name, fn := range barcode.FuncMap() {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// Renderer modes, set with renderer.mode in config.yml
+const (
+	RenderOnDemand   = "on_demand"  // parse templates on the first render
+	RenderPrecompile = "precompile" // parse templates once at boot
 )
 
-// HTMLRenderer implements Renderer interface
+// DefaultTemplateManifest is where rebolo build writes template checksums
+const DefaultTemplateManifest = "views/manifest.json"
+
+// HTMLRenderer implements Renderer interface. Templates are parsed from
+// the views directory the first time one is rendered, apps without views
+// (API-only) never touch the filesystem.
 type HTMLRenderer struct {
-	templates *template.Template
+	dir       string
+	once      sync.Once
+	templates *template.Template // nil when there is no views directory
+	err       error
 }
 
 func NewHTMLRenderer() *HTMLRenderer {
-	tmpl := template.New("root")
+	return &HTMLRenderer{dir: "views"}
+}
 
-	// Walk through views and parse each template with its relative path as name
-	err := filepath.Walk("views", func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() && filepath.Ext(path) == ".html" {
-			// Read the template file
-			content, err := os.ReadFile(path)
-			if err != nil {
-				return err
-			}
+// load parses the templates once and caches the set
+func (r *HTMLRenderer) load() (*template.Template, error) {
+	r.once.Do(func() {
+		r.templates, r.err = loadTemplates(r.dir)
+	})
+	return r.templates, r.err
+}
 
-			// Register template with path relative to "views/" directory
-			// e.g., "views/home/index.html" -> "home/index.html"
-			relativePath := path[len("views/"):]
+// loadTemplates parses every .html file under dir, named by its path
+// relative to dir
+func loadTemplates(dir string) (*template.Template, error) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, nil
+	}
 
-			// Create named template
-			t := tmpl.New(relativePath)
-			_, err = t.Parse(string(content))
-			if err != nil {
-				log.Printf("⚠️ Failed to parse %s: %v", path, err)
-				return err
-			}
+	tmpl := template.New("root").Funcs(templateFuncs())
 
-			log.Printf("   ✓ Loaded: %s (name: %s)", path, relativePath)
+	err := walkTemplates(dir, func(name, path string, content []byte) error {
+		// Create named template
+		if _, err := tmpl.New(name).Parse(string(content)); err != nil {
+			log.Printf("⚠️ Failed to parse %s: %v", path, err)
+			return err
 		}
+
+		log.Printf("   ✓ Loaded: %s (name: %s)", path, name)
 		return nil
 	})
 
 	if err != nil {
 		log.Printf("❌ Error loading templates: %v", err)
-		tmpl = template.New("empty")
+		return nil, err
 	}
 
 	log.Printf("📝 Total templates loaded: %d", len(tmpl.Templates())-1) // -1 for root
 
-	return &HTMLRenderer{templates: tmpl}
+	return tmpl, nil
 }
 
-func (r *HTMLRenderer) RenderHTML(w http.ResponseWriter, templateName string, data interface{}) error {
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+// walkTemplates calls fn with every .html file under dir, named by its
+// path relative to dir, e.g. "views/home/index.html" -> "home/index.html"
+func walkTemplates(dir string, fn func(name, path string, content []byte) error) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".html" {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(name), path, content)
+	})
+}
+
+// Precompile parses the templates now instead of on the first render
+func (r *HTMLRenderer) Precompile() error {
+	_, err := r.load()
+	return err
+}
 
-	// Try multiple template name formats
-	names := []string{
-		templateName,                // home/index.html
-		"views/" + templateName,     // views/home/index.html
-		filepath.Base(templateName), // index.html
-		filepath.Base(filepath.Dir(templateName)) + "/" + filepath.Base(templateName), // home/index.html
+// TemplateManifest maps template names to the SHA-256 checksum of their source
+type TemplateManifest map[string]string
+
+// BuildTemplateManifest parses every template under dir, failing on the
+// first syntax error, and returns their checksums
+func BuildTemplateManifest(dir string) (TemplateManifest, error) {
+	manifest := TemplateManifest{}
+	tmpl := template.New("root").Funcs(templateFuncs())
+
+	err := walkTemplates(dir, func(name, path string, content []byte) error {
+		if _, err := tmpl.New(name).Parse(string(content)); err != nil {
+			return err
+		}
+		sum := sha256.Sum256(content)
+		manifest[name] = hex.EncodeToString(sum[:])
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return manifest, nil
+}
 
-	var err error
-	var renderedName string
+// WriteTemplateManifest saves manifest as JSON to path
+func WriteTemplateManifest(path string, manifest TemplateManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
 
-	// Capture output to a buffer first (for hot reload injection)
-	var buf bytes.Buffer
-
-	for _, name := range names {
-		buf.Reset()
-		err = r.templates.ExecuteTemplate(&buf, name, data)
-		if err == nil {
-			renderedName = name
-			break
+// VerifyManifest compares the templates on disk with the manifest at path,
+// written at build time, and reports those that were added, changed or
+// removed since. A missing manifest is not an error.
+func (r *HTMLRenderer) VerifyManifest(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var built TemplateManifest
+	if err := json.Unmarshal(data, &built); err != nil {
+		return fmt.Errorf("invalid template manifest %s: %w", path, err)
+	}
+
+	current := TemplateManifest{}
+	if _, err := os.Stat(r.dir); err == nil {
+		current, err = BuildTemplateManifest(r.dir)
+		if err != nil {
+			return err
 		}
 	}
 
+	var stale []string
+	for name, sum := range current {
+		if built[name] != sum {
+			stale = append(stale, name)
+		}
+	}
+	for name := range built {
+		if _, ok := current[name]; !ok {
+			stale = append(stale, name)
+		}
+	}
+	if len(stale) > 0 {
+		sort.Strings(stale)
+		return fmt.Errorf("templates changed since build: %s", strings.Join(stale, ", "))
+	}
+	return nil
+}
+
+func (r *HTMLRenderer) RenderHTML(w http.ResponseWriter, templateName string, data interface{}) error {
+	return r.RenderHTMLContext(context.Background(), w, templateName, data)
+}
+
+// RenderHTMLContext renders a template, giving up with ctx's error once
+// ctx is done, so a slow render stops when its request is cancelled
+func (r *HTMLRenderer) RenderHTMLContext(ctx context.Context, w http.ResponseWriter, templateName string, data interface{}) error {
+	buf, err := r.execute(ctx, templateName, data)
 	if err != nil {
-		log.Printf("❌ Failed to render template: %s (tried: %v)", templateName, names)
 		return err
 	}
+	defer buf.release()
 
-	log.Printf("✅ Rendered template: %s (requested: %s)", renderedName, templateName)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
 	// Write to actual response
 	_, err = w.Write(buf.Bytes())
 	return err
 }
 
+// RenderHTMLWithStatus renders a template with the given HTTP status code.
+// Nothing is written if the template fails, so callers can fall back.
+func (r *HTMLRenderer) RenderHTMLWithStatus(w http.ResponseWriter, status int, templateName string, data interface{}) error {
+	buf, err := r.execute(context.Background(), templateName, data)
+	if err != nil {
+		return err
+	}
+	defer buf.release()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// maxPooledBuffer is the largest render buffer kept for reuse, a buffer
+// grown by an unusually big page is left to the GC instead
+const maxPooledBuffer = 256 << 10
+
+// renderBuffers are reused between renders, saving the allocation and
+// growth of a buffer per page
+var renderBuffers = sync.Pool{New: func() interface{} { return new(renderBuffer) }}
+
+// renderBuffer holds a rendered page. Its writes fail once its context is
+// done, which stops a template at the next piece of output.
+type renderBuffer struct {
+	bytes.Buffer
+	ctx context.Context
+}
+
+func (b *renderBuffer) Write(p []byte) (int, error) {
+	if err := b.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return b.Buffer.Write(p)
+}
+
+// release returns the buffer to the pool, its bytes must not be used after
+func (b *renderBuffer) release() {
+	b.ctx = nil
+	if b.Cap() > maxPooledBuffer {
+		return
+	}
+	b.Reset()
+	renderBuffers.Put(b)
+}
+
+// execute renders a template into a pooled buffer, which the caller releases
+func (r *HTMLRenderer) execute(ctx context.Context, templateName string, data interface{}) (*renderBuffer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	templates, err := r.load()
+	if err != nil {
+		return nil, fmt.Errorf("loading templates: %w", err)
+	}
+	if templates == nil {
+		return nil, fmt.Errorf("no %s directory to render %s from", r.dir, templateName)
+	}
+
+	// Other name formats are only worked out when the name isn't found
+	name := templateName
+	if templates.Lookup(name) == nil {
+		for _, alt := range []string{
+			"views/" + templateName,     // views/home/index.html
+			filepath.Base(templateName), // index.html
+			filepath.Base(filepath.Dir(templateName)) + "/" + filepath.Base(templateName), // home/index.html
+		} {
+			if templates.Lookup(alt) != nil {
+				name = alt
+				break
+			}
+		}
+	}
+
+	// Capture output to a buffer first (for hot reload injection)
+	buf := renderBuffers.Get().(*renderBuffer)
+	buf.ctx = ctx
+	if err := templates.ExecuteTemplate(buf, name, data); err != nil {
+		buf.release()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			log.Printf("⚠️  Stopped rendering %s: %v", templateName, ctxErr)
+			return nil, ctxErr
+		}
+		log.Printf("❌ Failed to render template %s: %v", templateName, err)
+		return nil, err
+	}
+
+	log.Printf("✅ Rendered template: %s (requested: %s)", name, templateName)
+	return buf, nil
+}
+
 func (r *HTMLRenderer) RenderJSON(w http.ResponseWriter, data interface{}) error {
 	w.Header().Set("Content-Type", "application/json")
-	return json.NewEncoder(w).Encode(data)
+	return codec.JSON(w, data)
 }
 
 func (r *HTMLRenderer) RenderError(w http.ResponseWriter, message string, status int) error {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	return json.NewEncoder(w).Encode(map[string]string{
+	return codec.Current().Encode(w, map[string]string{
 		"error":  message,
 		"status": fmt.Sprintf("%d", status),
 	})
@@ -0,0 +1,130 @@
+package adapters
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/middleware"
+)
+
+// renderBudgets are the most allocations rendering a page may take. Lower
+// them when a change saves allocations, so they can't creep back.
+var renderBudgets = []struct {
+	name      string
+	path      string
+	hotReload bool
+	allocs    float64
+}{
+	{"json", "/posts/42.json", false, 3},
+	{"template", "/posts/42", false, 44},
+	{"template+hotreload", "/posts/42", true, 47},
+	{"large+hotreload", "/posts", true, 3205},
+}
+
+func BenchmarkRenderJSON(b *testing.B) {
+	handler, req := renderRequest(b, "/posts/42.json", false)
+	benchmarkRequest(b, handler, req)
+}
+
+func BenchmarkRenderTemplate(b *testing.B) {
+	handler, req := renderRequest(b, "/posts/42", false)
+	benchmarkRequest(b, handler, req)
+}
+
+func BenchmarkRenderTemplateHotReload(b *testing.B) {
+	handler, req := renderRequest(b, "/posts/42", true)
+	benchmarkRequest(b, handler, req)
+}
+
+func BenchmarkRenderLargeHotReload(b *testing.B) {
+	handler, req := renderRequest(b, "/posts", true)
+	benchmarkRequest(b, handler, req)
+}
+
+func TestRenderAllocs(t *testing.T) {
+	for _, bm := range renderBudgets {
+		if allocs := allocsPerRequest(renderRequest(t, bm.path, bm.hotReload)); allocs > bm.allocs {
+			t.Errorf("%s: %.0f allocations per request, over its budget of %.0f", bm.name, allocs, bm.allocs)
+		}
+	}
+}
+
+type benchPost struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var post = benchPost{
+	ID:        42,
+	Title:     "Benchmarks",
+	Body:      "Measuring the hot path of every request.",
+	Tags:      []string{"go", "performance"},
+	CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+}
+
+// posts is the page of a long list, 100 rows or about 20KB
+var posts = func() []benchPost {
+	list := make([]benchPost, 100)
+	for i := range list {
+		list[i] = post
+		list[i].ID = i + 1
+	}
+	return list
+}()
+
+const postsTemplate = `<!DOCTYPE html>
+<html><head><title>Posts</title></head>
+<body>
+<table>
+{{range .Posts}}<tr><td>{{.ID}}</td><td>{{.Title}}</td><td>{{.Body}}</td><td>{{range .Tags}}{{.}} {{end}}</td></tr>
+{{end}}</table>
+</body></html>
+`
+
+const postTemplate = `<!DOCTYPE html>
+<html><head><title>{{.Post.Title}}</title></head>
+<body>
+<h1>{{.Post.Title}}</h1>
+<p>{{.Post.Body}}</p>
+<ul>{{range .Post.Tags}}<li>{{.}}</li>{{end}}</ul>
+</body></html>
+`
+
+// renderRequest renders a post as JSON, a post's page or a long list
+// page, with the hot reload script injected or not. Logs are discarded
+// while tb runs.
+func renderRequest(tb testing.TB, path string, hotReload bool) (http.Handler, *http.Request) {
+	dir := tb.TempDir()
+	os.MkdirAll(filepath.Join(dir, "posts"), 0755)
+	os.WriteFile(filepath.Join(dir, "posts", "show.html"), []byte(postTemplate), 0644)
+	os.WriteFile(filepath.Join(dir, "posts", "index.html"), []byte(postsTemplate), 0644)
+	output := log.Writer()
+	log.SetOutput(io.Discard)
+	tb.Cleanup(func() { log.SetOutput(output) })
+
+	renderer := &HTMLRenderer{dir: dir}
+	routes := http.NewServeMux()
+	routes.HandleFunc("GET /posts/42.json", func(w http.ResponseWriter, r *http.Request) {
+		renderer.RenderJSON(w, post)
+	})
+	routes.HandleFunc("GET /posts/42", func(w http.ResponseWriter, r *http.Request) {
+		renderer.RenderHTMLContext(r.Context(), w, "posts/show.html", map[string]interface{}{"Post": post})
+	})
+	routes.HandleFunc("GET /posts", func(w http.ResponseWriter, r *http.Request) {
+		renderer.RenderHTMLContext(r.Context(), w, "posts/index.html", map[string]interface{}{"Posts": posts})
+	})
+	var handler http.Handler = routes
+	if hotReload {
+		handler = middleware.HotReloadMiddleware(true, "/__rebolo__/changes", "/__rebolo__/ws")(handler)
+	}
+	return handler, httptest.NewRequest("GET", path, nil)
+}
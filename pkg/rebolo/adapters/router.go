@@ -8,6 +8,25 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// AppRouter is a core.Router that can also serve a path prefix and build
+// URLs for named routes, what rebolo.Application needs from its router
+type AppRouter interface {
+	core.Router
+	Route(path string, handler http.HandlerFunc, methods ...string) core.NamedRoute
+	Static(prefix string, handler http.Handler)
+	NamedRoutes() *mux.Router
+	SetErrorHandlers(notFound, methodNotAllowed http.Handler)
+}
+
+// NewRouter creates the router selected by server.router in config.yml:
+// "radix" for RadixRouter, gorilla/mux otherwise
+func NewRouter(kind string) AppRouter {
+	if kind == "radix" {
+		return NewRadixRouter()
+	}
+	return NewMuxRouter()
+}
+
 // MuxRouter implements Router interface
 type MuxRouter struct {
 	*mux.Router
@@ -35,6 +54,27 @@ func (r *MuxRouter) DELETE(path string, handler http.HandlerFunc) core.NamedRout
 	return &routing.NamedRoute{Route: r.HandleFunc(path, handler).Methods("DELETE")}
 }
 
+// Route registers handler for path and the given methods
+func (r *MuxRouter) Route(path string, handler http.HandlerFunc, methods ...string) core.NamedRoute {
+	return &routing.NamedRoute{Route: r.HandleFunc(path, handler).Methods(methods...)}
+}
+
+// Static serves every path under prefix with handler
+func (r *MuxRouter) Static(prefix string, handler http.Handler) {
+	r.PathPrefix(prefix).Handler(handler)
+}
+
+// NamedRoutes returns the router holding the route names, for URLFor
+func (r *MuxRouter) NamedRoutes() *mux.Router {
+	return r.Router
+}
+
+// SetErrorHandlers sets the handlers for unknown paths and methods
+func (r *MuxRouter) SetErrorHandlers(notFound, methodNotAllowed http.Handler) {
+	r.Router.NotFoundHandler = notFound
+	r.Router.MethodNotAllowedHandler = methodNotAllowed
+}
+
 func (r *MuxRouter) Resource(path string, controller core.Controller) {
 	base := path
 	r.GET(base, controller.Index)
@@ -0,0 +1,80 @@
+package adapters
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// routerBudgets are the most allocations routing a request may take, by
+// router. Lower them when a change saves allocations, so they can't creep
+// back.
+var routerBudgets = map[string]float64{
+	"mux":   9,
+	"radix": 6,
+}
+
+func BenchmarkRouterMux(b *testing.B) {
+	handler, req := routerRequest("mux")
+	benchmarkRequest(b, handler, req)
+}
+
+func BenchmarkRouterRadix(b *testing.B) {
+	handler, req := routerRequest("radix")
+	benchmarkRequest(b, handler, req)
+}
+
+func TestRouterAllocs(t *testing.T) {
+	for kind, budget := range routerBudgets {
+		if allocs := allocsPerRequest(routerRequest(kind)); allocs > budget {
+			t.Errorf("%s router: %.0f allocations per request, over its budget of %.0f", kind, allocs, budget)
+		}
+	}
+}
+
+// routerRequest routes a nested resource among the routes of a typical app
+func routerRequest(kind string) (http.Handler, *http.Request) {
+	router := NewRouter(kind)
+	ok := func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) }
+	for _, resource := range []string{"users", "posts", "comments", "tags", "categories", "orders", "products", "invoices"} {
+		base := "/" + resource
+		router.GET(base, ok)
+		router.GET(base+"/new", ok)
+		router.POST(base, ok)
+		router.GET(base+"/{id}", ok)
+		router.GET(base+"/{id}/edit", ok)
+		router.Route(base+"/{id}", ok, http.MethodPut, http.MethodPatch)
+		router.DELETE(base+"/{id}", ok)
+	}
+	router.GET("/posts/{post_id}/comments/{id}", ok)
+	return router, httptest.NewRequest("GET", "/posts/42/comments/7", nil)
+}
+
+// benchmarkRequest serves req over and over
+func benchmarkRequest(b *testing.B, handler http.Handler, req *http.Request) {
+	w := discard{header: make(http.Header)}
+	b.ReportAllocs()
+	for b.Loop() {
+		clear(w.header)
+		handler.ServeHTTP(w, req)
+	}
+}
+
+// allocsPerRequest returns the allocations serving req takes
+func allocsPerRequest(handler http.Handler, req *http.Request) float64 {
+	w := discard{header: make(http.Header)}
+	return testing.AllocsPerRun(100, func() {
+		clear(w.header)
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// discard is a ResponseWriter that keeps nothing, so only the framework's
+// allocations are counted
+type discard struct {
+	header http.Header
+}
+
+func (d discard) Header() http.Header         { return d.header }
+func (d discard) Write(p []byte) (int, error) { return len(p), nil }
+func (d discard) WriteHeader(int)             {}
@@ -0,0 +1,221 @@
+package adapters
+
+import (
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StaticOptions configures how ServeStatic serves a directory
+type StaticOptions struct {
+	// MaxAge is how long browsers may cache files that aren't fingerprinted.
+	// Zero makes them revalidate on every use.
+	MaxAge time.Duration
+	// Fingerprinted reports whether a file name carries a content hash, such
+	// files are cached for a year as immutable. Defaults to IsFingerprinted.
+	Fingerprinted func(name string) bool
+	// Index is the file served for a directory, "index.html" by default
+	Index string
+	// Browse lists directories that have no index file
+	Browse bool
+	// SPA serves the root index file for paths without an extension that
+	// match no file, so client-side routes survive a reload
+	SPA bool
+	// NotFound handles missing files, http.NotFound by default
+	NotFound http.Handler
+}
+
+// fingerprint matches names like app.3f9a1c2b.css or index-BXk2a9_Q.js
+var fingerprint = regexp.MustCompile(`[.-]([0-9A-Za-z_-]{8,})\.[0-9A-Za-z]+$`)
+
+// IsFingerprinted reports whether name carries a content hash, as written
+// by bundlers: a segment of 8 or more characters before the extension
+// with a digit, or with upper and lower case letters and no dash. Names
+// like app.settings.js or logo-dark-mode.png don't count.
+func IsFingerprinted(name string) bool {
+	m := fingerprint.FindStringSubmatch(path.Base(name))
+	if m == nil {
+		return false
+	}
+	hash := m[1]
+	if strings.ContainsAny(hash, "0123456789") {
+		return true
+	}
+	return !strings.Contains(hash, "-") && strings.ToLower(hash) != hash && strings.ToUpper(hash) != hash
+}
+
+// StaticHandler serves files from a file system under a URL prefix, with
+// cache headers, index files and an optional SPA fallback. Dot files are
+// never served.
+type StaticHandler struct {
+	prefix string
+	root   http.FileSystem
+	opts   StaticOptions
+}
+
+// NewStaticHandler serves root under prefix
+func NewStaticHandler(prefix string, root http.FileSystem, opts StaticOptions) *StaticHandler {
+	if opts.Fingerprinted == nil {
+		opts.Fingerprinted = IsFingerprinted
+	}
+	if opts.Index == "" {
+		opts.Index = "index.html"
+	}
+	if opts.NotFound == nil {
+		opts.NotFound = http.HandlerFunc(http.NotFound)
+	}
+	return &StaticHandler{prefix: prefix, root: root, opts: opts}
+}
+
+func (h *StaticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	rel := strings.TrimPrefix(r.URL.Path, strings.TrimSuffix(h.prefix, "/"))
+	name := path.Clean("/" + rel)
+	if hidden(name) {
+		h.opts.NotFound.ServeHTTP(w, r)
+		return
+	}
+
+	f, info, err := h.open(name)
+	if err != nil {
+		if h.opts.SPA && path.Ext(name) == "" {
+			h.serveFallback(w, r)
+			return
+		}
+		h.opts.NotFound.ServeHTTP(w, r)
+		return
+	}
+	defer f.Close()
+
+	if info.IsDir() {
+		// Relative links in the index need the trailing slash
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			target := path.Base(r.URL.Path) + "/"
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+			return
+		}
+
+		index, indexInfo, err := h.open(path.Join(name, h.opts.Index))
+		if err == nil && !indexInfo.IsDir() {
+			defer index.Close()
+			h.serveFile(w, r, path.Join(name, h.opts.Index), index, indexInfo)
+			return
+		}
+		if h.opts.Browse {
+			w.Header().Set("Cache-Control", "no-cache")
+			http.StripPrefix(strings.TrimSuffix(h.prefix, "/"), http.FileServer(h.root)).ServeHTTP(w, r)
+			return
+		}
+		if h.opts.SPA {
+			h.serveFallback(w, r)
+			return
+		}
+		h.opts.NotFound.ServeHTTP(w, r)
+		return
+	}
+
+	h.serveFile(w, r, name, f, info)
+}
+
+// serveFallback serves the root index file for a client-side route
+func (h *StaticHandler) serveFallback(w http.ResponseWriter, r *http.Request) {
+	f, info, err := h.open("/" + h.opts.Index)
+	if err != nil || info.IsDir() {
+		h.opts.NotFound.ServeHTTP(w, r)
+		return
+	}
+	defer f.Close()
+	h.serveFile(w, r, "/"+h.opts.Index, f, info)
+}
+
+// serveFile writes a file with its cache headers. http.ServeContent
+// handles ranges and conditional requests. Compressible files are sent
+// as their precompressed .br or .gz variant when the client accepts it,
+// or gzipped on the fly.
+func (h *StaticHandler) serveFile(w http.ResponseWriter, r *http.Request, name string, f http.File, info fs.FileInfo) {
+	switch {
+	case h.opts.Fingerprinted(info.Name()):
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	case h.opts.MaxAge > 0:
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(h.opts.MaxAge.Seconds())))
+	default:
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+
+	if !Compressible(name) || info.Size() < minCompressSize {
+		http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+		return
+	}
+	w.Header().Add("Vary", "Accept-Encoding")
+	accept := r.Header.Get("Accept-Encoding")
+	for _, coding := range [...]struct{ name, ext string }{{"br", ".br"}, {"gzip", ".gz"}} {
+		if !acceptsEncoding(accept, coding.name) {
+			continue
+		}
+		variant, variantInfo, err := h.open(name + coding.ext)
+		if err != nil {
+			continue
+		}
+		defer variant.Close()
+		if variantInfo.IsDir() || variantInfo.ModTime().Before(info.ModTime()) {
+			continue
+		}
+		// The type comes from the name, the variant's bytes can't be sniffed
+		if w.Header().Get("Content-Type") == "" {
+			ctype := mime.TypeByExtension(path.Ext(name))
+			if ctype == "" {
+				ctype = "application/octet-stream"
+			}
+			w.Header().Set("Content-Type", ctype)
+		}
+		w.Header().Set("Content-Encoding", coding.name)
+		http.ServeContent(w, r, info.Name(), info.ModTime(), variant)
+		return
+	}
+
+	// Ranges are of the file as stored, so those aren't compressed
+	if r.Header.Get("Range") != "" || !acceptsEncoding(accept, "gzip") {
+		http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+		return
+	}
+	gw := &gzipResponseWriter{ResponseWriter: w}
+	defer gw.close()
+	http.ServeContent(gw, r, info.Name(), info.ModTime(), f)
+}
+
+func (h *StaticHandler) open(name string) (http.File, fs.FileInfo, error) {
+	f, err := h.root.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, info, nil
+}
+
+// hidden reports whether a path goes through a dot file or directory,
+// like .env or .git
+func hidden(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,526 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/inflect"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/middleware"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/migrate"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/query"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/schema"
+	"github.com/gorilla/mux"
+)
+
+// Options configures the admin panel
+type Options struct {
+	Title     string                     // shown in the header, "Admin" by default
+	Tables    []string                   // tables to manage, all but schema_migrations by default
+	PerPage   int                        // rows per list page, 25 by default
+	Authorize func(r *http.Request) bool // who may use the admin, see Mount
+}
+
+// Admin serves list, search, create, edit and delete screens for the
+// tables of the application's database. Tables are inspected on each
+// request, so columns added by migrations show up without a restart.
+type Admin struct {
+	app    *rebolo.Application
+	prefix string
+	opts   Options
+}
+
+// Mount serves the admin panel under prefix:
+//
+//	admin.Mount(app, "/admin", admin.Options{
+//		Authorize: func(r *http.Request) bool { return currentUser(r).IsAdmin },
+//	})
+//
+// Without Authorize, the panel asks for HTTP basic auth against the
+// ADMIN_USER and ADMIN_PASSWORD environment variables and answers 403
+// when they aren't set, so it is never left open by accident.
+func Mount(app *rebolo.Application, prefix string, opts ...Options) *Admin {
+	a := &Admin{app: app, prefix: strings.TrimRight(prefix, "/")}
+	if len(opts) > 0 {
+		a.opts = opts[0]
+	}
+	if a.opts.Title == "" {
+		a.opts.Title = "Admin"
+	}
+	if a.opts.PerPage <= 0 {
+		a.opts.PerPage = 25
+	}
+
+	app.GET(a.prefix, a.protect(a.dashboard))
+	app.GET(a.prefix+"/{table}", a.protect(a.list))
+	app.GET(a.prefix+"/{table}/new", a.protect(a.newRow))
+	app.POST(a.prefix+"/{table}", a.protect(a.create))
+	app.GET(a.prefix+"/{table}/{id}/edit", a.protect(a.edit))
+	app.PUT(a.prefix+"/{table}/{id}", a.protect(a.update))
+	app.DELETE(a.prefix+"/{table}/{id}", a.protect(a.destroy))
+
+	log.Printf("🛠️  Admin panel at %s", a.prefix)
+	return a
+}
+
+// protect runs Authorize, or checks basic auth without it
+func (a *Admin) protect(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.opts.Authorize != nil {
+			if !a.opts.Authorize(r) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next(w, r)
+			return
+		}
+
+		user, password := os.Getenv("ADMIN_USER"), os.Getenv("ADMIN_PASSWORD")
+		if user == "" || password == "" {
+			http.Error(w, "Admin disabled: set ADMIN_USER and ADMIN_PASSWORD or Options.Authorize", http.StatusForbidden)
+			return
+		}
+		u, p, ok := r.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(u), []byte(user)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(p), []byte(password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="`+a.opts.Title+`"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// store returns the SQL access of the application's database
+func (a *Admin) store() (*store, error) {
+	inspector, err := schema.NewInspector(a.app.DB(), a.app.DatabaseDriver())
+	if err != nil {
+		return nil, err
+	}
+	return &store{db: a.app.DB(), inspector: inspector}, nil
+}
+
+// tables returns the tables the admin manages
+func (a *Admin) tables(r *http.Request, s *store) ([]string, error) {
+	if len(a.opts.Tables) > 0 {
+		return a.opts.Tables, nil
+	}
+	all, err := s.inspector.Tables(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]string, 0, len(all))
+	for _, t := range all {
+		if t != migrate.Table {
+			tables = append(tables, t)
+		}
+	}
+	return tables, nil
+}
+
+// table inspects the table of the request, 404 when it isn't managed
+func (a *Admin) table(w http.ResponseWriter, r *http.Request) (*store, *schema.Table, bool) {
+	s, err := a.store()
+	if err != nil {
+		a.fail(w, err)
+		return nil, nil, false
+	}
+	tables, err := a.tables(r, s)
+	if err != nil {
+		a.fail(w, err)
+		return nil, nil, false
+	}
+
+	name := mux.Vars(r)["table"]
+	for _, t := range tables {
+		if t == name {
+			table, err := s.inspector.Table(r.Context(), name)
+			if err != nil {
+				a.fail(w, err)
+				return nil, nil, false
+			}
+			return s, table, true
+		}
+	}
+	http.NotFound(w, r)
+	return nil, nil, false
+}
+
+type tableSummary struct {
+	Name  string
+	Label string
+	Count int
+}
+
+func (a *Admin) dashboard(w http.ResponseWriter, r *http.Request) {
+	s, err := a.store()
+	if err != nil {
+		a.fail(w, err)
+		return
+	}
+	tables, err := a.tables(r, s)
+	if err != nil {
+		a.fail(w, err)
+		return
+	}
+
+	summaries := make([]tableSummary, 0, len(tables))
+	for _, t := range tables {
+		count, err := s.count(r.Context(), t, "", nil)
+		if err != nil {
+			a.fail(w, err)
+			return
+		}
+		summaries = append(summaries, tableSummary{Name: t, Label: inflect.Humanize(t), Count: count})
+	}
+	a.render(w, r, "dashboard", map[string]interface{}{"Tables": summaries})
+}
+
+func (a *Admin) list(w http.ResponseWriter, r *http.Request) {
+	s, table, ok := a.table(w, r)
+	if !ok {
+		return
+	}
+
+	columns := make([]string, len(table.Columns))
+	for i, c := range table.Columns {
+		columns[i] = c.Name
+	}
+	q, err := query.Parse(r.URL.Query(), query.Options{Sortable: columns, DefaultSort: defaultSort(table)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	search := strings.TrimSpace(r.URL.Query().Get("q"))
+	where, args := s.search(table, search)
+	total, err := s.count(r.Context(), table.Name, where, args)
+	if err != nil {
+		a.fail(w, err)
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pages := (total + a.opts.PerPage - 1) / a.opts.PerPage
+	if page < 1 {
+		page = 1
+	}
+	rows, err := s.rows(r.Context(), table, where, args, q.Sort, a.opts.PerPage, (page-1)*a.opts.PerPage)
+	if err != nil {
+		a.fail(w, err)
+		return
+	}
+
+	params := url.Values{}
+	if search != "" {
+		params.Set("q", search)
+	}
+	if sort := r.URL.Query().Get("sort"); sort != "" {
+		params.Set("sort", sort)
+	}
+	pageURL := func(p int) string {
+		values := url.Values{}
+		for k, v := range params {
+			values[k] = v
+		}
+		values.Set("page", strconv.Itoa(p))
+		return "?" + values.Encode()
+	}
+
+	data := map[string]interface{}{
+		"Table":      table,
+		"Label":      inflect.Humanize(table.Name),
+		"PrimaryKey": primaryKey(table),
+		"Rows":       rows,
+		"Search":     search,
+		"Sort":       r.URL.Query().Get("sort"),
+		"Total":      total,
+		"Page":       page,
+		"Pages":      pages,
+	}
+	if page > 1 {
+		data["PrevURL"] = pageURL(page - 1)
+	}
+	if page < pages {
+		data["NextURL"] = pageURL(page + 1)
+	}
+	a.render(w, r, "list", data)
+}
+
+func (a *Admin) newRow(w http.ResponseWriter, r *http.Request) {
+	_, table, ok := a.table(w, r)
+	if !ok {
+		return
+	}
+	a.renderForm(w, r, table, "", map[string]string{}, "")
+}
+
+func (a *Admin) create(w http.ResponseWriter, r *http.Request) {
+	s, table, ok := a.table(w, r)
+	if !ok {
+		return
+	}
+
+	values := formValues(r, table, "")
+	if err := s.insert(r.Context(), table, values); err != nil {
+		a.renderForm(w, r, table, "", stringValues(values), err.Error())
+		return
+	}
+	http.Redirect(w, r, a.prefix+"/"+table.Name, http.StatusSeeOther)
+}
+
+func (a *Admin) edit(w http.ResponseWriter, r *http.Request) {
+	s, table, ok := a.table(w, r)
+	if !ok {
+		return
+	}
+	pk := primaryKey(table)
+	if pk == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	row, err := s.find(r.Context(), table, pk, id)
+	if err == sql.ErrNoRows {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		a.fail(w, err)
+		return
+	}
+	a.renderForm(w, r, table, id, row, "")
+}
+
+func (a *Admin) update(w http.ResponseWriter, r *http.Request) {
+	s, table, ok := a.table(w, r)
+	if !ok {
+		return
+	}
+	pk := primaryKey(table)
+	if pk == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	values := formValues(r, table, pk)
+	if err := s.update(r.Context(), table, pk, id, values); err != nil {
+		a.renderForm(w, r, table, id, stringValues(values), err.Error())
+		return
+	}
+	http.Redirect(w, r, a.prefix+"/"+table.Name, http.StatusSeeOther)
+}
+
+func (a *Admin) destroy(w http.ResponseWriter, r *http.Request) {
+	s, table, ok := a.table(w, r)
+	if !ok {
+		return
+	}
+	pk := primaryKey(table)
+	if pk == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := s.delete(r.Context(), table, pk, mux.Vars(r)["id"]); err != nil {
+		a.fail(w, err)
+		return
+	}
+	http.Redirect(w, r, a.prefix+"/"+table.Name, http.StatusSeeOther)
+}
+
+type field struct {
+	Column   schema.Column
+	Label    string
+	Input    string // text, number, checkbox, date, datetime-local or textarea
+	Value    string
+	ReadOnly bool
+}
+
+func (a *Admin) renderForm(w http.ResponseWriter, r *http.Request, table *schema.Table, id string, values map[string]string, errMsg string) {
+	pk := primaryKey(table)
+	fields := make([]field, 0, len(table.Columns))
+	for _, c := range table.Columns {
+		if automatic(c) {
+			continue
+		}
+		fields = append(fields, field{
+			Column:   c,
+			Label:    inflect.Humanize(c.Name),
+			Input:    inputType(c),
+			Value:    values[c.Name],
+			ReadOnly: id != "" && c.Name == pk,
+		})
+	}
+
+	action := a.prefix + "/" + table.Name
+	if id != "" {
+		action += "/" + url.PathEscape(id)
+	}
+	if errMsg != "" {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	a.render(w, r, "form", map[string]interface{}{
+		"Table":  table,
+		"Label":  inflect.Humanize(table.Name),
+		"ID":     id,
+		"Fields": fields,
+		"Action": action,
+		"Error":  errMsg,
+	})
+}
+
+func (a *Admin) render(w http.ResponseWriter, r *http.Request, name string, data map[string]interface{}) {
+	data["Title"] = a.opts.Title
+	data["Prefix"] = a.prefix
+	data["CSRF"] = middleware.CSRFToken(r)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pages.ExecuteTemplate(w, name, data); err != nil {
+		log.Printf("❌ Admin template %s: %v", name, err)
+	}
+}
+
+func (a *Admin) fail(w http.ResponseWriter, err error) {
+	log.Printf("❌ Admin: %v", err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// primaryKey returns the single primary key column of a table, empty when
+// it has none or a composite one, in which case rows are read-only
+func primaryKey(table *schema.Table) string {
+	pk := ""
+	for _, c := range table.Columns {
+		if c.PrimaryKey {
+			if pk != "" {
+				return ""
+			}
+			pk = c.Name
+		}
+	}
+	return pk
+}
+
+// defaultSort lists the newest rows first
+func defaultSort(table *schema.Table) string {
+	if table.Column("created_at") != nil {
+		return "-created_at"
+	}
+	if pk := primaryKey(table); pk != "" {
+		return "-" + pk
+	}
+	return ""
+}
+
+// automatic reports whether the database or the admin fills a column:
+// serial primary keys and the timestamps
+func automatic(c schema.Column) bool {
+	if c.Name == "created_at" || c.Name == "updated_at" {
+		return true
+	}
+	if !c.PrimaryKey {
+		return false
+	}
+	t := strings.ToLower(c.Type)
+	return strings.Contains(t, "serial") || strings.Contains(t, "int") ||
+		strings.Contains(c.Default.String, "nextval")
+}
+
+// textual reports whether a column holds text, the columns ?q= searches
+func textual(c schema.Column) bool {
+	t := strings.ToLower(c.Type)
+	return strings.Contains(t, "char") || strings.Contains(t, "text") || strings.Contains(t, "clob")
+}
+
+func boolean(c schema.Column) bool {
+	return strings.Contains(strings.ToLower(c.Type), "bool")
+}
+
+func inputType(c schema.Column) string {
+	t := strings.ToLower(c.Type)
+	switch {
+	case boolean(c):
+		return "checkbox"
+	case strings.Contains(t, "timestamp") || strings.Contains(t, "datetime"):
+		return "datetime-local"
+	case strings.Contains(t, "date"):
+		return "date"
+	case strings.Contains(t, "int") || strings.Contains(t, "real") || strings.Contains(t, "numeric") ||
+		strings.Contains(t, "decimal") || strings.Contains(t, "float") || strings.Contains(t, "double"):
+		return "number"
+	case strings.Contains(t, "text") || strings.Contains(t, "clob") || strings.Contains(t, "json"):
+		return "textarea"
+	}
+	return "text"
+}
+
+// formValues reads the editable columns from a form post. Empty values of
+// nullable columns are stored as NULL, checkboxes as booleans.
+func formValues(r *http.Request, table *schema.Table, pk string) map[string]interface{} {
+	r.ParseForm()
+	values := make(map[string]interface{})
+	for _, c := range table.Columns {
+		if automatic(c) || c.Name == pk {
+			continue
+		}
+		if boolean(c) {
+			values[c.Name] = r.PostForm.Get(c.Name) != ""
+			continue
+		}
+		if _, sent := r.PostForm[c.Name]; !sent {
+			continue
+		}
+		value := r.PostForm.Get(c.Name)
+		if value == "" && c.Nullable {
+			values[c.Name] = nil
+			continue
+		}
+		values[c.Name] = value
+	}
+	return values
+}
+
+// stringValues turns posted values back into form values after an error
+func stringValues(values map[string]interface{}) map[string]string {
+	strs := make(map[string]string, len(values))
+	for k, v := range values {
+		strs[k] = display(v)
+	}
+	return strs
+}
+
+var pages = template.Must(template.New("admin").Funcs(template.FuncMap{
+	"humanize": inflect.Humanize,
+	"checked":  func(value string) bool { return value == "true" || value == "1" },
+	"display":  display,
+	"pathEscape": func(v interface{}) string {
+		return url.PathEscape(display(v))
+	},
+	"sortURL": func(column, current, search string) string {
+		sort := column
+		if current == column {
+			sort = "-" + column
+		}
+		values := url.Values{"sort": {sort}}
+		if search != "" {
+			values.Set("q", search)
+		}
+		return "?" + values.Encode()
+	},
+	"truncate": func(s string) string {
+		if len([]rune(s)) > 60 {
+			return string([]rune(s)[:60]) + "…"
+		}
+		return s
+	},
+	"isNull": func(v interface{}) bool { return v == nil },
+}).Parse(pageTemplates))
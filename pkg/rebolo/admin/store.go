@@ -0,0 +1,246 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/query"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/schema"
+)
+
+// store runs the admin's SQL. Table and column names always come from
+// the inspector, values are passed as arguments.
+type store struct {
+	db        *sql.DB
+	inspector *schema.Inspector
+}
+
+// row is a table row as listed, its values in column order
+type row struct {
+	ID     string
+	Values []interface{}
+}
+
+func (s *store) count(ctx context.Context, table, where string, args []interface{}) (int, error) {
+	stmt := "SELECT COUNT(*) FROM " + schema.QuoteIdent(s.inspector.Driver(), table)
+	if where != "" {
+		stmt += " " + where
+	}
+	var n int
+	err := s.db.QueryRowContext(ctx, schema.Rebind(s.inspector.Driver(), stmt), args...).Scan(&n)
+	return n, err
+}
+
+func (s *store) rows(ctx context.Context, table *schema.Table, where string, args []interface{}, order []query.Order, limit, offset int) ([]row, error) {
+	stmt := "SELECT " + s.columns(table) + " FROM " + schema.QuoteIdent(s.inspector.Driver(), table.Name)
+	if where != "" {
+		stmt += " " + where
+	}
+	if len(order) > 0 {
+		columns := make([]string, len(order))
+		for i, o := range order {
+			columns[i] = schema.QuoteIdent(s.inspector.Driver(), o.Column)
+			if o.Desc {
+				columns[i] += " DESC"
+			}
+		}
+		stmt += " ORDER BY " + strings.Join(columns, ", ")
+	}
+	stmt += " LIMIT " + strconv.Itoa(limit) + " OFFSET " + strconv.Itoa(offset)
+
+	rs, err := s.db.QueryContext(ctx, schema.Rebind(s.inspector.Driver(), stmt), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	pk := primaryKey(table)
+	var result []row
+	for rs.Next() {
+		values, err := scan(rs, len(table.Columns))
+		if err != nil {
+			return nil, err
+		}
+		r := row{Values: values}
+		for i, c := range table.Columns {
+			if c.Name == pk {
+				r.ID = display(values[i])
+			}
+		}
+		result = append(result, r)
+	}
+	return result, rs.Err()
+}
+
+// find reads a row as form values
+func (s *store) find(ctx context.Context, table *schema.Table, pk, id string) (map[string]string, error) {
+	stmt := "SELECT " + s.columns(table) + " FROM " + schema.QuoteIdent(s.inspector.Driver(), table.Name) + " WHERE " + schema.QuoteIdent(s.inspector.Driver(), pk) + " = ?"
+	rs, err := s.db.QueryContext(ctx, schema.Rebind(s.inspector.Driver(), stmt), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	if !rs.Next() {
+		if err := rs.Err(); err != nil {
+			return nil, err
+		}
+		return nil, sql.ErrNoRows
+	}
+	values, err := scan(rs, len(table.Columns))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(values))
+	for i, c := range table.Columns {
+		result[c.Name] = inputValue(values[i], inputType(c))
+	}
+	return result, nil
+}
+
+func (s *store) insert(ctx context.Context, table *schema.Table, values map[string]interface{}) error {
+	now := time.Now()
+	for _, column := range []string{"created_at", "updated_at"} {
+		if table.Column(column) != nil {
+			values[column] = now
+		}
+	}
+
+	names := sortedKeys(values)
+	if len(names) == 0 {
+		_, err := s.db.ExecContext(ctx, "INSERT INTO "+schema.QuoteIdent(s.inspector.Driver(), table.Name)+" DEFAULT VALUES")
+		return err
+	}
+	quoted := make([]string, len(names))
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		quoted[i] = schema.QuoteIdent(s.inspector.Driver(), name)
+		args[i] = values[name]
+	}
+	stmt := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", schema.QuoteIdent(s.inspector.Driver(), table.Name), strings.Join(quoted, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", "))
+	_, err := s.db.ExecContext(ctx, schema.Rebind(s.inspector.Driver(), stmt), args...)
+	return err
+}
+
+func (s *store) update(ctx context.Context, table *schema.Table, pk, id string, values map[string]interface{}) error {
+	if table.Column("updated_at") != nil {
+		values["updated_at"] = time.Now()
+	}
+
+	names := sortedKeys(values)
+	if len(names) == 0 {
+		return nil
+	}
+	sets := make([]string, len(names))
+	args := make([]interface{}, 0, len(names)+1)
+	for i, name := range names {
+		sets[i] = schema.QuoteIdent(s.inspector.Driver(), name) + " = ?"
+		args = append(args, values[name])
+	}
+	args = append(args, id)
+	stmt := fmt.Sprintf("UPDATE %s SET %s WHERE %s = ?", schema.QuoteIdent(s.inspector.Driver(), table.Name), strings.Join(sets, ", "), schema.QuoteIdent(s.inspector.Driver(), pk))
+	_, err := s.db.ExecContext(ctx, schema.Rebind(s.inspector.Driver(), stmt), args...)
+	return err
+}
+
+func (s *store) delete(ctx context.Context, table *schema.Table, pk, id string) error {
+	stmt := "DELETE FROM " + schema.QuoteIdent(s.inspector.Driver(), table.Name) + " WHERE " + schema.QuoteIdent(s.inspector.Driver(), pk) + " = ?"
+	_, err := s.db.ExecContext(ctx, schema.Rebind(s.inspector.Driver(), stmt), id)
+	return err
+}
+
+// search matches q against the text columns of a table
+func (s *store) search(table *schema.Table, q string) (string, []interface{}) {
+	if q == "" {
+		return "", nil
+	}
+	var conditions []string
+	var args []interface{}
+	for _, c := range table.Columns {
+		if textual(c) {
+			conditions = append(conditions, "LOWER("+schema.QuoteIdent(s.inspector.Driver(), c.Name)+") LIKE ?")
+			args = append(args, "%"+strings.ToLower(q)+"%")
+		}
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conditions, " OR "), args
+}
+
+func (s *store) columns(table *schema.Table) string {
+	quoted := make([]string, len(table.Columns))
+	for i, c := range table.Columns {
+		quoted[i] = schema.QuoteIdent(s.inspector.Driver(), c.Name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func scan(rs *sql.Rows, n int) ([]interface{}, error) {
+	values := make([]interface{}, n)
+	pointers := make([]interface{}, n)
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if err := rs.Scan(pointers...); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// display formats a column value for the list
+func display(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	}
+	return fmt.Sprint(v)
+}
+
+// inputValue formats a column value for its form input
+func inputValue(v interface{}, input string) string {
+	if input != "date" && input != "datetime-local" {
+		return display(v)
+	}
+
+	t, ok := v.(time.Time)
+	if !ok {
+		// SQLite and MySQL may hand timestamps back as text
+		str := display(v)
+		for _, layout := range timeLayouts {
+			if parsed, err := time.Parse(layout, str); err == nil {
+				t, ok = parsed, true
+				break
+			}
+		}
+		if !ok {
+			return str
+		}
+	}
+	if input == "date" {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02T15:04")
+}
+
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02"}
@@ -0,0 +1,128 @@
+package admin
+
+// pageTemplates are the admin's pages. They are self-contained, so the
+// admin looks the same whatever the app's layout and assets.
+const pageTemplates = `
+{{define "header"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Title}}</title>
+    <style>
+        body { margin: 0; font-family: system-ui, sans-serif; color: #1f2937; background: #f3f4f6; }
+        header { background: #111827; color: #fff; padding: 0.75rem 1.5rem; }
+        header a { color: #fff; text-decoration: none; font-weight: 600; }
+        main { max-width: 1100px; margin: 1.5rem auto; padding: 0 1.5rem; }
+        h1 { font-size: 1.4rem; }
+        a { color: #2563eb; }
+        table { width: 100%; border-collapse: collapse; background: #fff; }
+        th, td { padding: 0.5rem 0.75rem; border-bottom: 1px solid #e5e7eb; text-align: left; font-size: 0.9rem; }
+        th a { color: inherit; }
+        .null { color: #9ca3af; font-style: italic; }
+        .toolbar { display: flex; gap: 0.75rem; align-items: center; margin-bottom: 1rem; }
+        .toolbar form { flex: 1; }
+        .toolbar input[type=search] { width: 100%; max-width: 320px; padding: 0.4rem; }
+        .button { display: inline-block; padding: 0.4rem 0.9rem; background: #2563eb; color: #fff; border: 0; border-radius: 4px; text-decoration: none; cursor: pointer; font-size: 0.9rem; }
+        .danger { background: #dc2626; }
+        .actions { white-space: nowrap; }
+        .actions form { display: inline; }
+        .field { margin-bottom: 1rem; }
+        .field label { display: block; font-weight: 600; margin-bottom: 0.25rem; }
+        .field input:not([type=checkbox]), .field textarea { width: 100%; max-width: 480px; padding: 0.4rem; }
+        .field small { color: #6b7280; }
+        .error { background: #fee2e2; color: #991b1b; padding: 0.75rem; border-radius: 4px; }
+        .pagination { margin-top: 1rem; display: flex; gap: 1rem; align-items: center; }
+    </style>
+</head>
+<body>
+<header><a href="{{.Prefix}}">{{.Title}}</a></header>
+<main>
+{{end}}
+
+{{define "footer"}}</main>
+</body>
+</html>
+{{end}}
+
+{{define "csrf"}}{{if .CSRF}}<input type="hidden" name="_csrf" value="{{.CSRF}}">{{end}}{{end}}
+
+{{define "dashboard"}}{{template "header" .}}
+<h1>Tables</h1>
+<table>
+    <tr><th>Table</th><th>Rows</th></tr>
+    {{range .Tables}}
+    <tr><td><a href="{{$.Prefix}}/{{.Name}}">{{.Label}}</a></td><td>{{.Count}}</td></tr>
+    {{else}}
+    <tr><td colspan="2">No tables yet, run your migrations.</td></tr>
+    {{end}}
+</table>
+{{template "footer" .}}{{end}}
+
+{{define "list"}}{{template "header" .}}
+<h1>{{.Label}} <small>({{.Total}})</small></h1>
+<div class="toolbar">
+    <form method="GET">
+        <input type="search" name="q" value="{{.Search}}" placeholder="Search...">
+        {{if .Sort}}<input type="hidden" name="sort" value="{{.Sort}}">{{end}}
+    </form>
+    {{if .PrimaryKey}}<a class="button" href="{{.Prefix}}/{{.Table.Name}}/new">New</a>{{end}}
+</div>
+<table>
+    <tr>
+        {{range .Table.Columns}}<th><a href="{{sortURL .Name $.Sort $.Search}}">{{humanize .Name}}</a></th>{{end}}
+        {{if .PrimaryKey}}<th></th>{{end}}
+    </tr>
+    {{range .Rows}}
+    <tr>
+        {{range .Values}}<td>{{if isNull .}}<span class="null">NULL</span>{{else}}{{truncate (display .)}}{{end}}</td>{{end}}
+        {{if $.PrimaryKey}}
+        <td class="actions">{{if .ID}}
+            <a href="{{$.Prefix}}/{{$.Table.Name}}/{{pathEscape .ID}}/edit">Edit</a>
+            <form method="POST" action="{{$.Prefix}}/{{$.Table.Name}}/{{pathEscape .ID}}" onsubmit="return confirm('Delete this row?')">
+                <input type="hidden" name="_method" value="DELETE">
+                {{template "csrf" $}}
+                <button type="submit" class="button danger">Delete</button>
+            </form>
+        {{end}}</td>
+        {{end}}
+    </tr>
+    {{else}}
+    <tr><td colspan="{{len .Table.Columns}}">{{if .Search}}Nothing matches "{{.Search}}".{{else}}No rows yet.{{end}}</td></tr>
+    {{end}}
+</table>
+{{if gt .Pages 1}}
+<div class="pagination">
+    {{with .PrevURL}}<a href="{{.}}">&larr; Previous</a>{{end}}
+    <span>Page {{.Page}} of {{.Pages}}</span>
+    {{with .NextURL}}<a href="{{.}}">Next &rarr;</a>{{end}}
+</div>
+{{end}}
+{{template "footer" .}}{{end}}
+
+{{define "form"}}{{template "header" .}}
+<h1>{{if .ID}}Edit {{.Label}} #{{.ID}}{{else}}New {{.Label}}{{end}}</h1>
+{{with .Error}}<p class="error">{{.}}</p>{{end}}
+<form method="POST" action="{{.Action}}">
+    {{if .ID}}<input type="hidden" name="_method" value="PUT">{{end}}
+    {{template "csrf" .}}
+    {{range .Fields}}
+    <div class="field">
+        <label for="{{.Column.Name}}">{{.Label}}</label>
+        {{if .ReadOnly}}
+        <input type="text" id="{{.Column.Name}}" value="{{.Value}}" disabled>
+        {{else if eq .Input "checkbox"}}
+        <input type="checkbox" id="{{.Column.Name}}" name="{{.Column.Name}}" value="true"{{if checked .Value}} checked{{end}}>
+        {{else if eq .Input "textarea"}}
+        <textarea id="{{.Column.Name}}" name="{{.Column.Name}}" rows="5"{{if and (not .Column.Nullable) (not .Column.Default.Valid)}} required{{end}}>{{.Value}}</textarea>
+        {{else}}
+        <input type="{{.Input}}" id="{{.Column.Name}}" name="{{.Column.Name}}" value="{{.Value}}"{{if eq .Input "number"}} step="any"{{end}}{{if and (not .Column.Nullable) (not .Column.Default.Valid)}} required{{end}}>
+        {{end}}
+        <small>{{.Column.Type}}{{if .Column.Nullable}}, optional{{end}}</small>
+    </div>
+    {{end}}
+    <button type="submit" class="button">Save</button>
+    <a href="{{.Prefix}}/{{.Table.Name}}">Cancel</a>
+</form>
+{{template "footer" .}}{{end}}
+`
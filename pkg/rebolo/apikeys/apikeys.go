@@ -0,0 +1,328 @@
+// Package apikeys issues, lists and revokes API keys, and authenticates
+// requests sending one as "Authorization: Bearer <key>".
+//
+//	keys := apikeys.Enable(app)
+//	app.Use(keys.Middleware)
+//	api := app.Group(keys.Require) // for routes only callable with a key
+//
+//	// in a settings page
+//	plain, key, err := keys.Issue(ctx.Context(), accountID, "CI deploys")
+//	// show plain once, only a hash of it is stored
+//
+//	// in a handler behind the middleware
+//	accountID := apikeys.AccountID(ctx.Request)
+//
+// A key looks like rk_<id>_<secret>. The id finds the key's row, the
+// secret is kept as a SHA-256 hash, so a leaked table can't be used to
+// call the API.
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/schema"
+)
+
+// Request values set by the middleware
+const (
+	KeyValue       = "api_key"    // the *Key the request was made with
+	AccountIDValue = "account_id" // the key's AccountID
+	AccountValue   = "account"    // what Options.LoadAccount returned
+)
+
+// DefaultTable is where keys are kept unless Options.Table says otherwise
+const DefaultTable = "api_keys"
+
+// ErrNotFound is returned by Revoke for keys that don't exist or belong
+// to another account
+var ErrNotFound = errors.New("API key not found")
+
+// errInvalidKey is returned for keys that are malformed, unknown, revoked
+// or don't match
+var errInvalidKey = errors.New("invalid API key")
+
+// Options configures API keys
+type Options struct {
+	Table  string // where keys are kept, "api_keys" by default
+	Prefix string // put before every key to tell them apart in logs and secret scanners, "rk_" by default
+	// LoadAccount, when set, loads the account owning the key and the
+	// middleware stores it as the "account" value. An error rejects the
+	// request with a 401.
+	LoadAccount func(ctx context.Context, accountID string) (interface{}, error)
+}
+
+// Key is an issued API key, without its secret
+type Key struct {
+	ID         string
+	AccountID  string
+	Name       string
+	CreatedAt  time.Time
+	LastUsedAt time.Time // zero until the key is first used
+}
+
+// Keys issues API keys and authenticates requests made with them
+type Keys struct {
+	app  *rebolo.Application
+	opts Options
+}
+
+// Enable creates the key table if needed. It doesn't add a middleware,
+// use Middleware or Require on the routes taking API keys.
+func Enable(app *rebolo.Application, opts ...Options) *Keys {
+	k := &Keys{app: app}
+	if len(opts) > 0 {
+		k.opts = opts[0]
+	}
+	if k.opts.Table == "" {
+		k.opts.Table = DefaultTable
+	}
+	if k.opts.Prefix == "" {
+		k.opts.Prefix = "rk_"
+	}
+
+	if err := k.createTable(context.Background()); err != nil {
+		log.Printf("❌ API keys unavailable: %v", err)
+	}
+	return k
+}
+
+// CreateTableSQL returns the statement creating the key table, for a
+// migration instead of the one Enable runs
+func CreateTableSQL(table string) string {
+	return "CREATE TABLE IF NOT EXISTS " + table + ` (
+	id VARCHAR(32) PRIMARY KEY,
+	account_id VARCHAR(255) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	secret_hash VARCHAR(64) NOT NULL,
+	created_at BIGINT NOT NULL,
+	last_used_at BIGINT
+)`
+}
+
+// Issue creates a key for accountID and returns it. The plain key is only
+// known now, show it to the user once.
+func (k *Keys) Issue(ctx context.Context, accountID, name string) (string, *Key, error) {
+	id, err := randomBytes(8, hex.EncodeToString)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := randomBytes(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", nil, err
+	}
+
+	key := &Key{ID: id, AccountID: accountID, Name: name, CreatedAt: time.Now()}
+	_, err = k.app.DB().ExecContext(ctx, schema.Rebind(k.app.DatabaseDriver(),
+		"INSERT INTO "+k.opts.Table+" (id, account_id, name, secret_hash, created_at) VALUES (?, ?, ?, ?, ?)"),
+		id, accountID, name, hash(secret), key.CreatedAt.Unix())
+	if err != nil {
+		return "", nil, err
+	}
+	return k.opts.Prefix + id + "_" + secret, key, nil
+}
+
+// List returns the keys of accountID, newest first
+func (k *Keys) List(ctx context.Context, accountID string) ([]Key, error) {
+	rows, err := k.app.DB().QueryContext(ctx, schema.Rebind(k.app.DatabaseDriver(),
+		"SELECT id, account_id, name, created_at, last_used_at FROM "+k.opts.Table+" WHERE account_id = ? ORDER BY created_at DESC"),
+		accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []Key
+	for rows.Next() {
+		var key Key
+		var createdAt int64
+		var lastUsedAt sql.NullInt64
+		if err := rows.Scan(&key.ID, &key.AccountID, &key.Name, &createdAt, &lastUsedAt); err != nil {
+			return nil, err
+		}
+		key.CreatedAt = time.Unix(createdAt, 0)
+		if lastUsedAt.Valid {
+			key.LastUsedAt = time.Unix(lastUsedAt.Int64, 0)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Revoke deletes the key id of accountID, requests made with it fail from
+// then on
+func (k *Keys) Revoke(ctx context.Context, accountID, id string) error {
+	result, err := k.app.DB().ExecContext(ctx, schema.Rebind(k.app.DatabaseDriver(),
+		"DELETE FROM "+k.opts.Table+" WHERE id = ? AND account_id = ?"), id, accountID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RevokeAll deletes every key of accountID, when the account is closed
+func (k *Keys) RevokeAll(ctx context.Context, accountID string) error {
+	_, err := k.app.DB().ExecContext(ctx, schema.Rebind(k.app.DatabaseDriver(),
+		"DELETE FROM "+k.opts.Table+" WHERE account_id = ?"), accountID)
+	return err
+}
+
+// Middleware authenticates requests sending a key and sets the
+// "api_key", "account_id" and "account" values. Requests without a key,
+// or with a bearer token that isn't one (no prefix), go through as they
+// are; those with a wrong or revoked key get a 401.
+func (k *Keys) Middleware(next http.Handler) http.Handler {
+	return k.authenticate(next, false)
+}
+
+// Require is Middleware that also rejects requests without a key
+func (k *Keys) Require(next http.Handler) http.Handler {
+	return k.authenticate(next, true)
+}
+
+func (k *Keys) authenticate(next http.Handler, required bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Already checked by Middleware in the global stack
+		if Current(r) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		plain := bearer(r)
+		if !strings.HasPrefix(plain, k.opts.Prefix) {
+			if required {
+				unauthorized(w)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, err := k.verify(r.Context(), plain)
+		if err != nil {
+			if !errors.Is(err, errInvalidKey) {
+				log.Printf("⚠️  Failed to check API key: %v", err)
+			}
+			unauthorized(w)
+			return
+		}
+
+		r = rebolo.WithValue(r, KeyValue, key)
+		r = rebolo.WithValue(r, AccountIDValue, key.AccountID)
+		if k.opts.LoadAccount != nil {
+			account, err := k.opts.LoadAccount(r.Context(), key.AccountID)
+			if err != nil {
+				log.Printf("⚠️  Failed to load account %s of API key %s: %v", key.AccountID, key.ID, err)
+				unauthorized(w)
+				return
+			}
+			r = rebolo.WithValue(r, AccountValue, account)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Current returns the key the request was made with, nil when the
+// middleware didn't authenticate one
+func Current(r *http.Request) *Key {
+	key, _ := rebolo.Value(r, KeyValue).(*Key)
+	return key
+}
+
+// AccountID returns the account owning the request's key, empty when the
+// middleware didn't authenticate one
+func AccountID(r *http.Request) string {
+	id, _ := rebolo.Value(r, AccountIDValue).(string)
+	return id
+}
+
+// verify returns the key of plain
+func (k *Keys) verify(ctx context.Context, plain string) (*Key, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(plain, k.opts.Prefix), "_")
+	if !ok || id == "" || secret == "" {
+		return nil, errInvalidKey
+	}
+
+	key := &Key{ID: id}
+	var secretHash string
+	var createdAt int64
+	var lastUsedAt sql.NullInt64
+	err := k.app.DB().QueryRowContext(ctx, schema.Rebind(k.app.DatabaseDriver(),
+		"SELECT account_id, name, secret_hash, created_at, last_used_at FROM "+k.opts.Table+" WHERE id = ?"), id).
+		Scan(&key.AccountID, &key.Name, &secretHash, &createdAt, &lastUsedAt)
+	if err == sql.ErrNoRows {
+		return nil, errInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hash(secret)), []byte(secretHash)) != 1 {
+		return nil, errInvalidKey
+	}
+	key.CreatedAt = time.Unix(createdAt, 0)
+	if lastUsedAt.Valid {
+		key.LastUsedAt = time.Unix(lastUsedAt.Int64, 0)
+	}
+
+	// Record the use at most once a minute, not a write per request
+	now := time.Now()
+	if now.Sub(key.LastUsedAt) > time.Minute {
+		if _, err := k.app.DB().ExecContext(ctx, schema.Rebind(k.app.DatabaseDriver(),
+			"UPDATE "+k.opts.Table+" SET last_used_at = ? WHERE id = ?"), now.Unix(), id); err != nil {
+			log.Printf("⚠️  Failed to record API key use: %v", err)
+		}
+		key.LastUsedAt = now
+	}
+	return key, nil
+}
+
+func (k *Keys) createTable(ctx context.Context) error {
+	db := k.app.DB()
+	if db == nil {
+		return errors.New("no database connection")
+	}
+	_, err := db.ExecContext(ctx, CreateTableSQL(k.opts.Table))
+	return err
+}
+
+// bearer returns the key sent as "Authorization: Bearer <key>" or in
+// X-API-Key
+func bearer(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomBytes returns n random bytes, encoded
+func randomBytes(n int, encode func([]byte) string) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encode(b), nil
+}
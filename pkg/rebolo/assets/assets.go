@@ -0,0 +1,283 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for assets.manifest and assets.url in config.yml
+const (
+	DefaultManifest = "public/manifest.json"
+	DefaultURL      = "/public/"
+)
+
+// Entry is what the build wrote for an entry point, as URLs
+type Entry struct {
+	JS  string
+	CSS []string
+}
+
+// Manifest maps entry points, named by their file name without extension
+// ("src/index.js" is "index"), to the fingerprinted files the build wrote.
+// It reads Bun's and esbuild's --metafile output and Vite's manifest.json.
+// Entries missing from it, or all of them when there is no manifest, map
+// to unfingerprinted names: "index" is /public/index.js and /public/index.css.
+type Manifest struct {
+	file  string
+	dir   string // the build's output directory, served at url
+	url   string
+	watch bool
+
+	mu      sync.RWMutex
+	entries map[string]Entry
+	modTime time.Time
+}
+
+// NewManifest reads the manifest at file, for outputs served under url.
+// With watch set it's read again whenever the file changes, for
+// development, where the build runs next to the server.
+func NewManifest(file, url string, watch bool) (*Manifest, error) {
+	if file == "" {
+		file = DefaultManifest
+	}
+	if url == "" {
+		url = DefaultURL
+	}
+	// Vite keeps its manifest in .vite under the output directory
+	dir := filepath.Dir(file)
+	if filepath.Base(dir) == ".vite" {
+		dir = filepath.Dir(dir)
+	}
+	m := &Manifest{
+		file:    file,
+		dir:     dir,
+		url:     strings.TrimSuffix(url, "/") + "/",
+		watch:   watch,
+		entries: map[string]Entry{},
+	}
+	return m, m.reload()
+}
+
+// JS returns the URL of the JavaScript bundle of entry
+func (m *Manifest) JS(entry string) string {
+	if e, ok := m.lookup(entry); ok && e.JS != "" {
+		return e.JS
+	}
+	return m.url + entry + ".js"
+}
+
+// CSS returns the URLs of the stylesheets of entry
+func (m *Manifest) CSS(entry string) []string {
+	if e, ok := m.lookup(entry); ok && len(e.CSS) > 0 {
+		return e.CSS
+	}
+	return []string{m.url + entry + ".css"}
+}
+
+// Entries returns the entry points of the manifest
+func (m *Manifest) Entries() map[string]Entry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	entries := make(map[string]Entry, len(m.entries))
+	for name, e := range m.entries {
+		entries[name] = e
+	}
+	return entries
+}
+
+func (m *Manifest) lookup(entry string) (Entry, bool) {
+	if m.watch {
+		m.reload()
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.entries[entry]
+	return e, ok
+}
+
+// reload reads the manifest when it changed since the last read. A
+// missing manifest is not an error, names are used as is.
+func (m *Manifest) reload() error {
+	info, err := os.Stat(m.file)
+	if err != nil {
+		m.mu.Lock()
+		m.entries, m.modTime = map[string]Entry{}, time.Time{}
+		m.mu.Unlock()
+		return nil
+	}
+
+	m.mu.RLock()
+	fresh := info.ModTime().Equal(m.modTime)
+	m.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	data, err := os.ReadFile(m.file)
+	if err != nil {
+		return err
+	}
+	entries, err := m.parse(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", m.file, err)
+	}
+
+	m.mu.Lock()
+	m.entries, m.modTime = entries, info.ModTime()
+	m.mu.Unlock()
+	return nil
+}
+
+// parse reads a Bun/esbuild metafile, which lists "outputs", or a Vite
+// manifest, keyed by source file
+func (m *Manifest) parse(data []byte) (map[string]Entry, error) {
+	var meta struct {
+		Outputs map[string]struct {
+			EntryPoint string `json:"entryPoint"`
+			CSSBundle  string `json:"cssBundle"`
+		} `json:"outputs"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+
+	entries := map[string]Entry{}
+	if meta.Outputs != nil {
+		// Output paths are relative to where the build ran, the app root
+		for output, o := range meta.Outputs {
+			if o.EntryPoint == "" {
+				continue
+			}
+			e := entries[entryName(o.EntryPoint)]
+			switch filepath.Ext(output) {
+			case ".css":
+				e.CSS = appendNew(e.CSS, m.urlOf(output))
+			default:
+				e.JS = m.urlOf(output)
+				if o.CSSBundle != "" {
+					e.CSS = appendNew(e.CSS, m.urlOf(o.CSSBundle))
+				}
+			}
+			entries[entryName(o.EntryPoint)] = e
+		}
+		return entries, nil
+	}
+
+	var vite map[string]struct {
+		File    string   `json:"file"`
+		IsEntry bool     `json:"isEntry"`
+		CSS     []string `json:"css"`
+	}
+	if err := json.Unmarshal(data, &vite); err != nil {
+		return nil, err
+	}
+	// Vite writes paths relative to its outDir
+	for src, chunk := range vite {
+		if !chunk.IsEntry {
+			continue
+		}
+		e := Entry{JS: m.urlOf(filepath.Join(m.dir, chunk.File))}
+		if filepath.Ext(chunk.File) == ".css" {
+			e = Entry{CSS: []string{e.JS}}
+		}
+		for _, css := range chunk.CSS {
+			e.CSS = append(e.CSS, m.urlOf(filepath.Join(m.dir, css)))
+		}
+		entries[entryName(src)] = e
+	}
+	return entries, nil
+}
+
+// urlOf turns a path under the output directory into its URL
+func (m *Manifest) urlOf(file string) string {
+	rel, err := filepath.Rel(m.dir, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(file)
+	}
+	return m.url + filepath.ToSlash(rel)
+}
+
+// appendNew appends url unless list has it, a stylesheet can be listed
+// as an output and as the cssBundle of the script
+func appendNew(list []string, url string) []string {
+	for _, u := range list {
+		if u == url {
+			return list
+		}
+	}
+	return append(list, url)
+}
+
+// entryName names an entry point by its file name without extension
+func entryName(src string) string {
+	base := path.Base(filepath.ToSlash(src))
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+var (
+	mu              sync.RWMutex
+	defaultManifest = &Manifest{dir: "public", file: DefaultManifest, url: DefaultURL, entries: map[string]Entry{}}
+)
+
+// SetDefault sets the manifest the template helpers use, the app does it
+// at boot from config.yml
+func SetDefault(m *Manifest) {
+	mu.Lock()
+	defer mu.Unlock()
+	defaultManifest = m
+}
+
+// Default returns the manifest the template helpers use
+func Default() *Manifest {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultManifest
+}
+
+// FuncMap returns the template helpers, registered by the HTML renderer:
+//
+//	{{cssBundle "index"}}  {{jsBundle "index"}}  {{assetPath "index.js"}}
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"jsBundle":  jsBundle,
+		"cssBundle": cssBundle,
+		"assetPath": assetPath,
+	}
+}
+
+// jsBundle returns the script tag of an entry point. Bun and Vite write
+// ES modules, which are deferred.
+func jsBundle(entry string) template.HTML {
+	return template.HTML(fmt.Sprintf(`<script type="module" src="%s"></script>`,
+		template.HTMLEscapeString(Default().JS(entry))))
+}
+
+// cssBundle returns the stylesheet links of an entry point
+func cssBundle(entry string) template.HTML {
+	var b strings.Builder
+	for _, href := range Default().CSS(entry) {
+		fmt.Fprintf(&b, `<link rel="stylesheet" href="%s">`, template.HTMLEscapeString(href))
+	}
+	return template.HTML(b.String())
+}
+
+// assetPath returns the URL of a file the build wrote: "index.js" is the
+// JavaScript bundle of the index entry point, other names are served as is
+func assetPath(name string) string {
+	m := Default()
+	entry := strings.TrimSuffix(name, path.Ext(name))
+	switch path.Ext(name) {
+	case ".js":
+		return m.JS(entry)
+	case ".css":
+		return m.CSS(entry)[0]
+	}
+	return m.url + strings.TrimPrefix(name, "/")
+}
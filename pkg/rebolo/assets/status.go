@@ -0,0 +1,53 @@
+package assets
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StatusFile is where rebolo dev records the outcome of the last asset
+// build, for the app to show failures on the hot reload overlay. Dot
+// files aren't served by ServeStatic.
+const StatusFile = "public/.rebolo-build.json"
+
+// Status is the outcome of the last asset build
+type Status struct {
+	Error   string    `json:"error,omitempty"` // the build's output when it failed
+	BuiltAt time.Time `json:"built_at"`
+}
+
+// OK reports whether the last build succeeded
+func (s Status) OK() bool {
+	return s.Error == ""
+}
+
+// WriteStatus records a build, failed when output isn't empty
+func WriteStatus(output string) error {
+	data, err := json.Marshal(Status{Error: output, BuiltAt: time.Now()})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(StatusFile), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(StatusFile, data, 0644)
+}
+
+// ReadStatus returns the last build recorded, a successful one when
+// nothing was recorded
+func ReadStatus() Status {
+	var status Status
+	data, err := os.ReadFile(StatusFile)
+	if err != nil {
+		return status
+	}
+	json.Unmarshal(data, &status)
+	return status
+}
+
+// BuildError returns the output of the last build if it failed
+func BuildError() string {
+	return ReadStatus().Error
+}
@@ -0,0 +1,151 @@
+// Package barcode draws QR codes and Code 128 barcodes as PNG or SVG, for
+// tickets, two-factor setup pages and labels:
+//
+//	code, err := barcode.QR("otpauth://totp/Rebolo:ada?secret=JBSWY3DPEHPK3PXP", barcode.M)
+//	err = code.PNG(w, 256)
+//
+// Handlers send them with ctx.QRCode, ctx.QRCodeSVG and ctx.Barcode, and
+// views inline them with {{qrcode .URL 200}}.
+package barcode
+
+import (
+	"bufio"
+	"html/template"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Code is a QR code or a barcode, as modules: the squares of a QR code or
+// the narrowest bars of a barcode
+type Code struct {
+	modules [][]bool // dark modules, one row for barcodes
+	quiet   int      // light modules needed around the code
+}
+
+// Width returns how many modules wide the code is, its quiet zone included
+func (c *Code) Width() int {
+	return len(c.modules[0]) + 2*c.quiet
+}
+
+// linear reports whether c is a barcode, its single row of bars drawn as
+// tall as a quarter of its width
+func (c *Code) linear() bool {
+	return len(c.modules) == 1
+}
+
+// height returns how many modules high the code is
+func (c *Code) height() int {
+	if c.linear() {
+		return max(c.Width()/4, 1)
+	}
+	return len(c.modules) + 2*c.quiet
+}
+
+// dark reports whether the module at x, y, quiet zone included, is dark
+func (c *Code) dark(x, y int) bool {
+	if c.linear() {
+		y = c.quiet
+	}
+	x, y = x-c.quiet, y-c.quiet
+	return y >= 0 && y < len(c.modules) && x >= 0 && x < len(c.modules[y]) && c.modules[y][x]
+}
+
+// scale returns the pixels per module for an image at most size pixels
+// wide, at least 1 so small sizes still scan
+func (c *Code) scale(size int) int {
+	return max(size/c.Width(), 1)
+}
+
+// Image returns the code as a black on white image at most size pixels
+// wide. Modules are whole pixels, so the image can be narrower than size.
+func (c *Code) Image(size int) image.Image {
+	scale := c.scale(size)
+	img := image.NewPaletted(image.Rect(0, 0, c.Width()*scale, c.height()*scale), color.Palette{color.White, color.Black})
+	for y := 0; y < c.height(); y++ {
+		for x := 0; x < c.Width(); x++ {
+			if !c.dark(x, y) {
+				continue
+			}
+			for py := y * scale; py < (y+1)*scale; py++ {
+				for px := x * scale; px < (x+1)*scale; px++ {
+					img.SetColorIndex(px, py, 1)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// PNG writes the code as a PNG at most size pixels wide
+func (c *Code) PNG(w io.Writer, size int) error {
+	return png.Encode(w, c.Image(size))
+}
+
+// SVG writes the code as an SVG size pixels wide
+func (c *Code) SVG(w io.Writer, size int) error {
+	b := bufio.NewWriter(w)
+	b.WriteString(c.svg(size))
+	return b.Flush()
+}
+
+// HTML returns the code as an inline SVG size pixels wide
+func (c *Code) HTML(size int) template.HTML {
+	return template.HTML(c.svg(size))
+}
+
+func (c *Code) svg(size int) string {
+	width, height := c.Width(), c.height()
+	var path strings.Builder
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; {
+			if !c.dark(x, y) {
+				x++
+				continue
+			}
+			run := 1
+			for x+run < width && c.dark(x+run, y) {
+				run++
+			}
+			rows := 1
+			if c.linear() {
+				rows = height // one rect per bar
+			}
+			path.WriteString("M" + strconv.Itoa(x) + "," + strconv.Itoa(y) + "h" + strconv.Itoa(run) + "v" + strconv.Itoa(rows) + "h-" + strconv.Itoa(run) + "z")
+			x += run
+		}
+		if c.linear() {
+			break
+		}
+	}
+
+	px := strconv.Itoa(size)
+	pxHeight := strconv.Itoa(size * height / width)
+	return `<svg xmlns="http://www.w3.org/2000/svg" width="` + px + `" height="` + pxHeight + `" viewBox="0 0 ` + strconv.Itoa(width) + ` ` + strconv.Itoa(height) +
+		`" shape-rendering="crispEdges"><rect width="100%" height="100%" fill="#fff"/><path fill="#000" d="` + path.String() + `"/></svg>`
+}
+
+// FuncMap returns the template helpers, registered by the HTML renderer:
+//
+//	{{qrcode .OTPURL 200}}  {{barcode .Ticket.Number 300}}
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"qrcode": func(data string, size int) (template.HTML, error) {
+			code, err := QR(data, M)
+			if err != nil {
+				return "", err
+			}
+			return code.HTML(size), nil
+		},
+		"barcode": func(data string, size int) (template.HTML, error) {
+			code, err := Code128(data)
+			if err != nil {
+				return "", err
+			}
+			return code.HTML(size), nil
+		},
+	}
+}
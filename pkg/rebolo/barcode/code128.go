@@ -0,0 +1,100 @@
+package barcode
+
+import "fmt"
+
+// Code 128 symbols
+const (
+	code128CodeC  = 99
+	code128CodeB  = 100
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// code128Patterns are the bar and space widths of each symbol, the stop
+// symbol having a final bar
+var code128Patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+// Code128 encodes data, printable ASCII, as a Code 128 barcode, the one
+// of shipping labels and tickets. Runs of digits are packed two per
+// symbol.
+func Code128(data string) (*Code, error) {
+	if data == "" {
+		return nil, fmt.Errorf("barcode: nothing to encode")
+	}
+	for i := 0; i < len(data); i++ {
+		if data[i] < ' ' || data[i] > '~' {
+			return nil, fmt.Errorf("barcode: Code 128 only encodes printable ASCII, not %q", data[i])
+		}
+	}
+
+	var symbols []int
+	set := 0 // code set in use, none yet
+	for i := 0; i < len(data); {
+		run := 0
+		for i+run < len(data) && '0' <= data[i+run] && data[i+run] <= '9' {
+			run++
+		}
+		// Switching to C pays off for 4 digits at either end, 6 between
+		if run >= 6 || run >= 4 && (i == 0 || i+run == len(data)) {
+			if run%2 == 1 {
+				symbols, set = code128B(symbols, set, data[i])
+				i++
+				run--
+			}
+			switch set {
+			case 0:
+				symbols = append(symbols, code128StartC)
+			case code128CodeB:
+				symbols = append(symbols, code128CodeC)
+			}
+			set = code128CodeC
+			for ; run > 0; run -= 2 {
+				symbols = append(symbols, int(data[i]-'0')*10+int(data[i+1]-'0'))
+				i += 2
+			}
+			continue
+		}
+		symbols, set = code128B(symbols, set, data[i])
+		i++
+	}
+
+	checksum := symbols[0]
+	for i, s := range symbols[1:] {
+		checksum += (i + 1) * s
+	}
+	symbols = append(symbols, checksum%103, code128Stop)
+
+	var bars []bool
+	for _, s := range symbols {
+		for i, w := range code128Patterns[s] {
+			for n := 0; n < int(w-'0'); n++ {
+				bars = append(bars, i%2 == 0)
+			}
+		}
+	}
+	return &Code{modules: [][]bool{bars}, quiet: 10}, nil
+}
+
+// code128B appends c in code set B, switching to it first if needed
+func code128B(symbols []int, set int, c byte) ([]int, int) {
+	switch set {
+	case 0:
+		symbols = append(symbols, code128StartB)
+	case code128CodeC:
+		symbols = append(symbols, code128CodeB)
+	}
+	return append(symbols, int(c-' ')), code128CodeB
+}
@@ -0,0 +1,539 @@
+package barcode
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrTooLong is returned by QR for data that doesn't fit in the largest
+// QR code of the level, about 2.9 KB at L
+var ErrTooLong = errors.New("barcode: data too long for a QR code")
+
+// Level is how much of a QR code can be damaged and still be read. Higher
+// levels make larger codes for the same data.
+type Level int
+
+// Error correction levels
+const (
+	L Level = iota // 7% of the code can be restored
+	M              // 15%, what QRCode uses
+	Q              // 25%
+	H              // 30%, for codes printed with a logo over them
+)
+
+// formatBits returns the level as written in the format information
+func (l Level) formatBits() int {
+	return [...]int{1, 0, 3, 2}[l]
+}
+
+// mode is how a QR code's data is encoded, with the bits of its character
+// count for versions 1-9, 10-26 and 27-40
+type mode struct {
+	indicator int
+	countBits [3]int
+}
+
+var (
+	numericMode      = mode{1, [3]int{10, 12, 14}}
+	alphanumericMode = mode{2, [3]int{9, 11, 13}}
+	byteMode         = mode{4, [3]int{8, 16, 16}}
+)
+
+const alphanumericChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// QR encodes data as the smallest QR code holding it at level. Digits
+// only and upper case text use the denser numeric and alphanumeric modes,
+// anything else is stored as its UTF-8 bytes.
+func QR(data string, level Level) (*Code, error) {
+	if level < L || level > H {
+		level = M
+	}
+	m := byteMode
+	switch {
+	case data != "" && strings.Trim(data, "0123456789") == "":
+		m = numericMode
+	case data != "" && strings.Trim(data, alphanumericChars) == "":
+		m = alphanumericMode
+	}
+
+	for version := 1; version <= 40; version++ {
+		bits := dataBits(m, data, version)
+		if bits != nil && len(*bits) <= numDataCodewords(version, level)*8 {
+			q := newQR(version, level)
+			q.drawCodewords(q.addECC(bits.codewords(numDataCodewords(version, level))))
+			q.applyBestMask()
+			return &Code{modules: q.modules, quiet: 4}, nil
+		}
+	}
+	return nil, ErrTooLong
+}
+
+// bitBuffer is a sequence of bits
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, value>>uint(i)&1 == 1)
+	}
+}
+
+// codewords pads the bits to n codewords
+func (b bitBuffer) codewords(n int) []byte {
+	capacity := n * 8
+	for i := 0; i < 4 && len(b) < capacity; i++ {
+		b = append(b, false) // terminator
+	}
+	for len(b)%8 != 0 {
+		b = append(b, false)
+	}
+	for pad := 0xEC; len(b) < capacity; pad ^= 0xEC ^ 0x11 {
+		b.append(pad, 8)
+	}
+	words := make([]byte, n)
+	for i, bit := range b {
+		if bit {
+			words[i/8] |= 1 << uint(7-i%8)
+		}
+	}
+	return words
+}
+
+// dataBits returns the mode, character count and data of a segment, nil
+// when the count doesn't fit in version's count bits
+func dataBits(m mode, data string, version int) *bitBuffer {
+	countBits := m.countBits[0]
+	if version >= 27 {
+		countBits = m.countBits[2]
+	} else if version >= 10 {
+		countBits = m.countBits[1]
+	}
+	if len(data) >= 1<<uint(countBits) {
+		return nil
+	}
+
+	b := &bitBuffer{}
+	b.append(m.indicator, 4)
+	b.append(len(data), countBits)
+	switch m {
+	case numericMode:
+		for i := 0; i < len(data); i += 3 {
+			group := data[i:min(i+3, len(data))]
+			n := 0
+			for _, c := range group {
+				n = n*10 + int(c-'0')
+			}
+			b.append(n, len(group)*3+1)
+		}
+	case alphanumericMode:
+		for i := 0; i < len(data); i += 2 {
+			if i+1 < len(data) {
+				b.append(strings.IndexByte(alphanumericChars, data[i])*45+strings.IndexByte(alphanumericChars, data[i+1]), 11)
+			} else {
+				b.append(strings.IndexByte(alphanumericChars, data[i]), 6)
+			}
+		}
+	default:
+		for i := 0; i < len(data); i++ {
+			b.append(int(data[i]), 8)
+		}
+	}
+	return b
+}
+
+// Error correction codewords per block and number of blocks, by level
+// then version
+var (
+	eccCodewordsPerBlock = [4][41]int{
+		{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+		{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	}
+	numErrorCorrectionBlocks = [4][41]int{
+		{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+		{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+		{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+		{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+	}
+)
+
+// numRawDataModules returns the modules of a version left for data and
+// error correction once the function patterns are drawn
+func numRawDataModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		n -= (25*align-10)*align - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+func numDataCodewords(version int, level Level) int {
+	return numRawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*numErrorCorrectionBlocks[level][version]
+}
+
+// alignmentPositions returns the centers of the alignment patterns on
+// each axis
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	align := version/7 + 2
+	step := (version*8 + align*3 + 5) / (align*4 - 4) * 2
+	positions := make([]int, align)
+	positions[0] = 6
+	for i, pos := align-1, version*4+17-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+// qr is a QR code being drawn
+type qr struct {
+	version    int
+	level      Level
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+func newQR(version int, level Level) *qr {
+	size := version*4 + 17
+	q := &qr{version: version, level: level, size: size}
+	q.modules = make([][]bool, size)
+	q.isFunction = make([][]bool, size)
+	for y := range q.modules {
+		q.modules[y] = make([]bool, size)
+		q.isFunction[y] = make([]bool, size)
+	}
+	q.drawFunctionPatterns()
+	return q
+}
+
+func (q *qr) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.isFunction[y][x] = true
+}
+
+// drawFunctionPatterns draws the timing, finder and alignment patterns,
+// the version, and reserves the format information
+func (q *qr) drawFunctionPatterns() {
+	for i := 0; i < q.size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+
+	q.drawFinder(3, 3)
+	q.drawFinder(q.size-4, 3)
+	q.drawFinder(3, q.size-4)
+
+	positions := alignmentPositions(q.version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// Not over the finder patterns
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	q.drawFormatBits(0)
+	q.drawVersion()
+}
+
+// drawFinder draws a finder pattern and its separator around center
+func (q *qr) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || x >= q.size || y < 0 || y >= q.size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			q.set(x, y, d != 2 && d != 4)
+		}
+	}
+}
+
+// drawFormatBits draws the level and mask, twice, with their BCH code
+func (q *qr) drawFormatBits(mask int) {
+	data := q.level.formatBits()<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>uint(i)&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.set(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.size-15+i, bit(i))
+	}
+	q.set(8, q.size-8, true) // the dark module
+}
+
+// drawVersion draws the version, twice, with its BCH code, from version 7
+func (q *qr) drawVersion() {
+	if q.version < 7 {
+		return
+	}
+	rem := q.version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	bits := q.version<<12 | rem
+	for i := 0; i < 18; i++ {
+		dark := bits>>uint(i)&1 == 1
+		a, b := q.size-11+i%3, i/3
+		q.set(a, b, dark)
+		q.set(b, a, dark)
+	}
+}
+
+// addECC splits the data in blocks, adds their error correction
+// codewords, and interleaves them
+func (q *qr) addECC(data []byte) []byte {
+	numBlocks := numErrorCorrectionBlocks[q.level][q.version]
+	eccLen := eccCodewordsPerBlock[q.level][q.version]
+	raw := numRawDataModules(q.version) / 8
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+
+	generator := rsGenerator(eccLen)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		block := append([]byte(nil), data[k:k+n]...)
+		k += n
+		ecc := rsRemainder(block, generator)
+		if i < numShort {
+			block = append(block, 0) // short blocks skip this position
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	result := make([]byte, 0, raw)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// drawCodewords fills the data area in the zigzag order of the standard,
+// two columns at a time from the bottom right
+func (q *qr) drawCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.size - 1 - vert // upwards
+				}
+				if !q.isFunction[y][x] && i < len(data)*8 {
+					q.modules[y][x] = data[i>>3]>>uint(7-i&7)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules selected by mask, twice undoing it
+func (q *qr) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !q.isFunction[y][x] {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// applyBestMask applies the mask with the lowest penalty, the one making
+// the code easiest to scan
+func (q *qr) applyBestMask() {
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(mask)
+		if penalty := q.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		q.applyMask(mask)
+	}
+	q.applyMask(best)
+	q.drawFormatBits(best)
+}
+
+// penalty scores the patterns that confuse scanners: long runs of one
+// color, 2x2 blocks, finder-like patterns and unbalanced dark modules
+func (q *qr) penalty() int {
+	penalty, dark := 0, 0
+	at := func(x, y int, transposed bool) bool {
+		if transposed {
+			return q.modules[x][y]
+		}
+		return q.modules[y][x]
+	}
+	finderLike := []bool{true, false, true, true, true, false, true}
+
+	for _, transposed := range []bool{false, true} {
+		for y := 0; y < q.size; y++ {
+			run := 1
+			for x := 1; x <= q.size; x++ {
+				if x < q.size && at(x, y, transposed) == at(x-1, y, transposed) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += run - 2
+				}
+				run = 1
+			}
+			// 1:1:3:1:1 with 4 light modules on either side, the
+			// outside of the code being light
+			for x := 0; x+7 <= q.size; x++ {
+				match := true
+				for k, want := range finderLike {
+					if at(x+k, y, transposed) != want {
+						match = false
+						break
+					}
+				}
+				if match && (q.light(x-4, x, y, transposed) || q.light(x+7, x+11, y, transposed)) {
+					penalty += 40
+				}
+			}
+		}
+	}
+
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			c := q.modules[y][x]
+			if c {
+				dark++
+			}
+			if x+1 < q.size && y+1 < q.size && c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+				penalty += 3
+			}
+		}
+	}
+	total := q.size * q.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return penalty + k*10
+}
+
+// light reports whether the modules from..to of row y, or of column y
+// when transposed, are all light
+func (q *qr) light(from, to, y int, transposed bool) bool {
+	for x := from; x < to; x++ {
+		if x < 0 || x >= q.size {
+			continue
+		}
+		if transposed && q.modules[x][y] || !transposed && q.modules[y][x] {
+			return false
+		}
+	}
+	return true
+}
+
+// rsGenerator returns the Reed-Solomon generator polynomial of degree n,
+// its leading 1 left out
+func rsGenerator(n int) []byte {
+	g := make([]byte, n)
+	g[n-1] = 1
+	root := byte(1)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			g[j] = gfMultiply(g[j], root)
+			if j+1 < n {
+				g[j] ^= g[j+1]
+			}
+		}
+		root = gfMultiply(root, 2)
+	}
+	return g
+}
+
+// rsRemainder returns the error correction codewords of data
+func rsRemainder(data, generator []byte) []byte {
+	rem := make([]byte, len(generator))
+	for _, b := range data {
+		factor := b ^ rem[0]
+		copy(rem, rem[1:])
+		rem[len(rem)-1] = 0
+		for i, g := range generator {
+			rem[i] ^= gfMultiply(g, factor)
+		}
+	}
+	return rem
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>uint(i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,125 @@
+// Package broker carries events and jobs between processes through a
+// message broker, so several services, or several instances of one, share
+// them. The app picks the broker in config.yml:
+//
+//	broker:
+//	  driver: nats
+//	  url: nats://localhost:4222
+//	  stream: REBOLO   # JetStream, messages survive restarts
+//
+// NATS, with or without JetStream, and an in-process memory broker are
+// built in. The Kafka and RabbitMQ drivers, in the kafka and rabbitmq
+// packages, register themselves when imported:
+//
+//	import _ "github.com/Palaciodiego008/rebololang/pkg/rebolo/broker/kafka"
+//
+// Other brokers plug in with Register from the app:
+//
+//	broker.Register("sqs", func(url string, opts broker.Options) (broker.Broker, error) {
+//		return newSQSBroker(url, opts) // implements broker.Broker
+//	})
+package broker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Message is what a subscriber gets
+type Message struct {
+	Subject string
+	Data    []byte
+}
+
+// Handler handles a message. An error asks the broker to deliver it again,
+// when it can.
+type Handler func(ctx context.Context, msg Message) error
+
+// Broker publishes messages on subjects, dot separated names like
+// "events.todo.created", and delivers them to subscribers
+type Broker interface {
+	// Publish sends data on subject
+	Publish(ctx context.Context, subject string, data []byte) error
+	// Subscribe calls fn with the messages of subject, a name or a prefix
+	// ending in ".>" like "events.>". Each message goes to one of the
+	// subscribers sharing group, every group gets it.
+	Subscribe(subject, group string, fn Handler) error
+	// Close stops the subscriptions and disconnects
+	Close() error
+}
+
+// Options configure a broker
+type Options struct {
+	// Name identifies the app to the broker, in its logs and monitoring
+	Name string
+	// Stream is the NATS JetStream stream keeping the messages. Core NATS,
+	// which drops messages nobody is subscribed to, is used when empty.
+	// For Kafka it's the topic, and for RabbitMQ the exchange, "rebolo"
+	// when empty.
+	Stream string
+	// Subjects are those the stream is created with when it doesn't exist
+	Subjects []string
+}
+
+// Factory opens a broker at url
+type Factory func(url string, opts Options) (Broker, error)
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]Factory{
+		"memory": func(string, Options) (Broker, error) { return NewMemory(), nil },
+		"nats":   func(url string, opts Options) (Broker, error) { return NewNATS(url, opts) },
+	}
+)
+
+// Register makes a broker driver available to Open and config.yml
+func Register(driver string, factory Factory) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	drivers[driver] = factory
+}
+
+// Open opens a broker with the driver registered as driver
+func Open(driver, url string, opts Options) (Broker, error) {
+	driversMu.RLock()
+	factory, ok := drivers[driver]
+	driversMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown broker driver %q (available: %s)", driver, strings.Join(Drivers(), ", "))
+	}
+	return factory(url, opts)
+}
+
+// Drivers returns the registered driver names, sorted
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Deliver runs fn on msg, turning a panic into an error, for drivers
+func Deliver(fn Handler, msg Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return fn(context.Background(), msg)
+}
+
+// Match reports whether subject matches a subscription's subject, a name
+// or a prefix ending in ".>"
+func Match(pattern, subject string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, ">"); ok && strings.HasSuffix(prefix, ".") {
+		return strings.HasPrefix(subject, prefix) && len(subject) > len(prefix)
+	}
+	return pattern == subject
+}
@@ -0,0 +1,236 @@
+// Package kafka is the Kafka driver of the broker package, on the franz-go
+// client. Importing it registers the "kafka" driver for config.yml:
+//
+//	import _ "github.com/Palaciodiego008/rebololang/pkg/rebolo/broker/kafka"
+//
+//	broker:
+//	  driver: kafka
+//	  url: kafka://host1:9092,host2:9092
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/broker"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+)
+
+func init() {
+	broker.Register("kafka", func(url string, opts broker.Options) (broker.Broker, error) {
+		return New(url, opts)
+	})
+}
+
+var _ broker.Broker = &Broker{}
+
+// Partitions is how many partitions the topic is created with, as many
+// subscribers of a group can share it
+const Partitions = 6
+
+// Broker is a broker on a Kafka cluster.
+//
+// Messages go to one topic, Options.Stream or "rebolo", created with
+// Partitions partitions when missing, keyed by subject so those of a
+// subject stay in order. Each group reads the topic as a consumer group
+// per subject it subscribes to, its subscribers sharing the partitions.
+// A new group starts from the newest message, like subscribers without a
+// group, rather than replaying what the topic retains. Offsets are
+// committed once messages are handled. Kafka can't deliver a single
+// message again, so a failing handler is retried Retries times before the
+// message is skipped.
+type Broker struct {
+	client *kgo.Client
+	topic  string
+	opts   []kgo.Opt // connecting to the cluster, for the subscribers
+	// Retries is how many times a failed message is handled again before
+	// it's skipped, 3 by default
+	Retries int
+
+	mu     sync.Mutex
+	subs   []*kgo.Client
+	closed bool
+	done   chan struct{}
+}
+
+// New connects to the Kafka cluster of rawURL:
+// kafka://[user:password@]host[:port][,host[:port]...], kafka+tls:// for
+// TLS. Credentials sign in with SASL PLAIN.
+func New(rawURL string, opts broker.Options) (*Broker, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Kafka URL: %w", err)
+	}
+	if u.Scheme != "kafka" && u.Scheme != "kafka+tls" {
+		return nil, fmt.Errorf("invalid Kafka URL %q: the scheme must be kafka or kafka+tls", rawURL)
+	}
+
+	var seeds []string
+	for _, host := range strings.Split(u.Host, ",") {
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, "9092")
+		}
+		seeds = append(seeds, host)
+	}
+	b := &Broker{
+		topic:   opts.Stream,
+		opts:    []kgo.Opt{kgo.SeedBrokers(seeds...)},
+		Retries: 3,
+		done:    make(chan struct{}),
+	}
+	if b.topic == "" {
+		b.topic = "rebolo"
+	}
+	if opts.Name != "" {
+		b.opts = append(b.opts, kgo.ClientID(opts.Name))
+	}
+	if u.Scheme == "kafka+tls" {
+		b.opts = append(b.opts, kgo.DialTLSConfig(&tls.Config{}))
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		b.opts = append(b.opts, kgo.SASL(plain.Auth{User: u.User.Username(), Pass: password}.AsMechanism()))
+	}
+
+	b.client, err = kgo.NewClient(append(slices.Clone(b.opts), kgo.DefaultProduceTopic(b.topic))...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Kafka URL %q: %w", rawURL, err)
+	}
+	if err := b.ensureTopic(); err != nil {
+		b.client.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// Publish sends data on subject and returns once the partition's
+// replicas stored it
+func (b *Broker) Publish(ctx context.Context, subject string, data []byte) error {
+	return b.client.ProduceSync(ctx, &kgo.Record{Key: []byte(subject), Value: data}).FirstErr()
+}
+
+// Subscribe calls fn with the messages of subject, one at a time, in the
+// background. Subscriptions of a group join its consumer group for the
+// subject.
+func (b *Broker) Subscribe(subject, group string, fn broker.Handler) error {
+	opts := append(slices.Clone(b.opts),
+		kgo.ConsumeTopics(b.topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtEnd()),
+	)
+	if group != "" {
+		opts = append(opts,
+			kgo.ConsumerGroup(b.topic+"."+group+"."+subject),
+			kgo.DisableAutoCommit(),
+			kgo.BlockRebalanceOnPoll(), // until the polled messages are committed
+		)
+	}
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		client.Close()
+		return kgo.ErrClientClosed
+	}
+	b.subs = append(b.subs, client)
+	go b.consume(client, subject, group != "", fn)
+	return nil
+}
+
+// Close stops the subscriptions, leaving their groups, and disconnects
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	close(b.done)
+	for _, client := range b.subs {
+		client.Close()
+	}
+	b.client.Close()
+	return nil
+}
+
+// consume handles the messages client polls until it's closed, committing
+// them when it's in a group
+func (b *Broker) consume(client *kgo.Client, subject string, grouped bool, fn broker.Handler) {
+	ctx := context.Background()
+	for {
+		fetches := client.PollFetches(ctx)
+		if fetches.IsClientClosed() {
+			return
+		}
+		fetches.EachError(func(topic string, partition int32, err error) {
+			log.Printf("⚠️  Kafka subscription to %s: %v", subject, err)
+		})
+		for records := fetches.RecordIter(); !records.Done(); {
+			rec := records.Next()
+			if !broker.Match(subject, string(rec.Key)) {
+				continue
+			}
+			if !b.handle(fn, broker.Message{Subject: string(rec.Key), Data: rec.Value}) {
+				return // closed, the group's next member gets the rest
+			}
+		}
+		if grouped {
+			if err := client.CommitUncommittedOffsets(ctx); err != nil && !errors.Is(err, kgo.ErrClientClosed) {
+				log.Printf("⚠️  Kafka subscription to %s: committing offsets: %v", subject, err)
+			}
+			client.AllowRebalance()
+		}
+	}
+}
+
+// handle runs the handler on a message, retrying it when it fails. It
+// reports false when the broker was closed before the message was settled.
+func (b *Broker) handle(fn broker.Handler, msg broker.Message) bool {
+	select {
+	case <-b.done:
+		return false
+	default:
+	}
+	for attempt := 0; ; attempt++ {
+		err := broker.Deliver(fn, msg)
+		if err == nil {
+			return true
+		}
+		if attempt >= b.Retries {
+			log.Printf("⚠️  Message on %s failed, skipped after %d attempts: %v", msg.Subject, attempt+1, err)
+			return true
+		}
+		log.Printf("⚠️  Message on %s failed: %v", msg.Subject, err)
+		select {
+		case <-b.done:
+			return false
+		case <-time.After(time.Duration(attempt+1) * time.Second):
+		}
+	}
+}
+
+// ensureTopic creates the topic when it doesn't exist
+func (b *Broker) ensureTopic() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := kadm.NewClient(b.client).CreateTopic(ctx, Partitions, -1, nil, b.topic)
+	if err != nil && !errors.Is(err, kerr.TopicAlreadyExists) {
+		return fmt.Errorf("creating Kafka topic %s: %w", b.topic, err)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/broker"
+	"github.com/twmb/franz-go/pkg/kfake"
+)
+
+// clusterURL is KAFKA_URL, to test against a real cluster, or the URL of
+// an in-process fake one
+func clusterURL(t *testing.T) string {
+	if url := os.Getenv("KAFKA_URL"); url != "" {
+		return url
+	}
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cluster.Close)
+	return "kafka://" + strings.Join(cluster.ListenAddrs(), ",")
+}
+
+func open(t *testing.T, url, topic string) *Broker {
+	b, err := New(url, broker.Options{Name: "test", Stream: topic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+// received keeps what handlers got, by subscriber
+type received struct {
+	mu   sync.Mutex
+	msgs map[string][]string
+}
+
+func (rc *received) handler(name string) broker.Handler {
+	return func(_ context.Context, msg broker.Message) error {
+		rc.mu.Lock()
+		defer rc.mu.Unlock()
+		rc.msgs[name] = append(rc.msgs[name], msg.Subject+" "+string(msg.Data))
+		return nil
+	}
+}
+
+func (rc *received) get(name string) []string {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]string(nil), rc.msgs[name]...)
+}
+
+// publishUntil publishes on subject until done reports the subscribers
+// got what they wait for, as they join in the background
+func publishUntil(t *testing.T, b *Broker, subject string, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for i := 0; !done(); i++ {
+		if time.Now().After(deadline) {
+			t.Fatal("subscribers didn't get the messages in time")
+		}
+		if err := b.Publish(context.Background(), subject, []byte(fmt.Sprint("ping ", i))); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	url := clusterURL(t)
+	topic := fmt.Sprintf("rebolo-test-%d", time.Now().UnixNano())
+	b := open(t, url, topic)
+
+	// Published before anyone subscribed, new groups don't replay it
+	if err := b.Publish(context.Background(), "orders.created", []byte("early")); err != nil {
+		t.Fatal(err)
+	}
+
+	rc := &received{msgs: make(map[string][]string)}
+	if err := b.Subscribe("orders.>", "", rc.handler("all")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Subscribe("orders.created", "mailer", rc.handler("mailer")); err != nil {
+		t.Fatal(err)
+	}
+	publishUntil(t, b, "orders.created", func() bool {
+		return len(rc.get("all")) > 0 && len(rc.get("mailer")) > 0
+	})
+
+	if err := b.Publish(context.Background(), "orders.paid", []byte("42")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Publish(context.Background(), "users.created", []byte("7")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return contains(rc.get("all"), "orders.paid 42") })
+
+	for _, name := range []string{"all", "mailer"} {
+		if contains(rc.get(name), "orders.created early") {
+			t.Errorf("%s got a message published before it subscribed", name)
+		}
+		if contains(rc.get(name), "users.created 7") {
+			t.Errorf("%s got a message of another subject", name)
+		}
+	}
+	if contains(rc.get("mailer"), "orders.paid 42") {
+		t.Error("the mailer group got a message of another subject")
+	}
+}
+
+func TestGroupSharesMessages(t *testing.T) {
+	url := clusterURL(t)
+	topic := fmt.Sprintf("rebolo-test-%d", time.Now().UnixNano())
+	first, second := open(t, url, topic), open(t, url, topic)
+
+	rc := &received{msgs: make(map[string][]string)}
+	if err := first.Subscribe("jobs.>", "workers", rc.handler("first")); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Subscribe("jobs.>", "workers", rc.handler("second")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Subjects spread over the partitions, shared between both members
+	seen := func() map[string]int {
+		counts := make(map[string]int)
+		for _, name := range []string{"first", "second"} {
+			for _, msg := range rc.get(name) {
+				counts[msg]++
+			}
+		}
+		return counts
+	}
+	deadline := time.Now().Add(30 * time.Second)
+	for i := 0; len(rc.get("first")) == 0 || len(rc.get("second")) == 0; i++ {
+		if time.Now().After(deadline) {
+			t.Fatal("the group's members didn't share the messages")
+		}
+		subject := fmt.Sprintf("jobs.job%d", i%20)
+		if err := first.Publish(context.Background(), subject, []byte(fmt.Sprint(i))); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	for msg, n := range seen() {
+		if n > 1 {
+			t.Errorf("%q was handled %d times by the group", msg, n)
+		}
+	}
+}
+
+func TestRetries(t *testing.T) {
+	b := open(t, clusterURL(t), fmt.Sprintf("rebolo-test-%d", time.Now().UnixNano()))
+	b.Retries = 1
+
+	var mu sync.Mutex
+	attempts := 0
+	err := b.Subscribe("orders.paid", "billing", func(context.Context, broker.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		return errors.New("payment gateway down")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	publishUntil(t, b, "orders.paid", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return attempts > 0
+	})
+	// The first message is tried again once, then skipped
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return attempts >= 2
+	})
+}
+
+func TestNewInvalidURL(t *testing.T) {
+	if _, err := New("nats://localhost:4222", broker.Options{}); err == nil {
+		t.Error("a nats:// URL was accepted")
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	if !contains(broker.Drivers(), "kafka") {
+		t.Errorf("kafka isn't registered: %v", broker.Drivers())
+	}
+}
+
+func waitFor(t *testing.T, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package broker
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+var _ Broker = &Memory{}
+
+// Memory delivers messages within the process, for development and tests
+// of code that runs on a broker in production. Messages nobody is
+// subscribed to are dropped, and failed ones aren't delivered again.
+type Memory struct {
+	mu     sync.RWMutex
+	groups []*memoryGroup
+	closed bool
+}
+
+// memoryGroup is a group's subscribers of a subject, which take turns
+type memoryGroup struct {
+	subject string
+	group   string
+	fns     []Handler
+	next    int
+}
+
+// NewMemory creates an in-process broker
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Publish delivers data to a subscriber of each group, in the background
+func (m *Memory) Publish(ctx context.Context, subject string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return errClosed
+	}
+	for _, g := range m.groups {
+		if !Match(g.subject, subject) {
+			continue
+		}
+		fn := g.fns[g.next%len(g.fns)]
+		g.next++
+		msg := Message{Subject: subject, Data: append([]byte(nil), data...)}
+		go func() {
+			if err := fn(context.Background(), msg); err != nil {
+				log.Printf("⚠️  Message on %s failed: %v", subject, err)
+			}
+		}()
+	}
+	return nil
+}
+
+// Subscribe adds fn to the subscribers of subject in group
+func (m *Memory) Subscribe(subject, group string, fn Handler) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return errClosed
+	}
+	for _, g := range m.groups {
+		if g.subject == subject && g.group == group && group != "" {
+			g.fns = append(g.fns, fn)
+			return nil
+		}
+	}
+	m.groups = append(m.groups, &memoryGroup{subject: subject, group: group, fns: []Handler{fn}})
+	return nil
+}
+
+// Close drops the subscriptions
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.groups = nil
+	m.closed = true
+	return nil
+}
@@ -0,0 +1,515 @@
+package broker
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ Broker = &NATS{}
+
+var errClosed = errors.New("broker closed")
+
+// NATS is a broker on a NATS server. It speaks the NATS protocol itself,
+// without a client library, and reconnects when the connection drops.
+//
+// With Options.Stream set, messages go through JetStream: they are kept in
+// the stream until each group acknowledges them, which subscribers do when
+// their handler returns nil, and are delivered again otherwise.
+type NATS struct {
+	addr     string
+	user     string
+	password string
+	token    string
+	tls      bool
+	opts     Options
+	// Timeout bounds requests to the server, like JetStream acks, 5
+	// seconds by default
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	conn    net.Conn
+	reader  *bufio.Reader
+	w       *bufio.Writer
+	subs    map[int]*natsSub
+	nextSID int
+	inbox   string
+	nextReq int
+	pending map[string]chan natsMsg
+	closed  bool
+	done    chan struct{}
+}
+
+// natsSub is a subscription, wire is the subject it's made on: the one
+// given to Subscribe, or the consumer's deliver subject with JetStream
+type natsSub struct {
+	wire  string
+	group string
+	fn    Handler
+	msgs  chan natsMsg
+}
+
+type natsMsg struct {
+	subject string
+	reply   string
+	data    []byte
+}
+
+// NewNATS connects to the NATS server at rawURL:
+// nats://[user:password@|token@]host[:port], tls:// for TLS
+func NewNATS(rawURL string, opts Options) (*NATS, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NATS URL: %w", err)
+	}
+	if u.Scheme != "nats" && u.Scheme != "tls" {
+		return nil, fmt.Errorf("invalid NATS URL %q: the scheme must be nats or tls", rawURL)
+	}
+
+	n := &NATS{
+		addr:    u.Host,
+		tls:     u.Scheme == "tls",
+		opts:    opts,
+		Timeout: 5 * time.Second,
+		subs:    make(map[int]*natsSub),
+		inbox:   "_INBOX." + randomToken(),
+		pending: make(map[string]chan natsMsg),
+		done:    make(chan struct{}),
+	}
+	if u.Port() == "" {
+		n.addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			n.user, n.password = u.User.Username(), password
+		} else {
+			n.token = u.User.Username()
+		}
+	}
+
+	if err := n.connect(); err != nil {
+		return nil, err
+	}
+	go n.read()
+
+	if opts.Stream != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), n.Timeout)
+		defer cancel()
+		if err := n.ensureStream(ctx); err != nil {
+			n.Close()
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// Publish sends data on subject. With JetStream it returns once the
+// stream stored the message.
+func (n *NATS) Publish(ctx context.Context, subject string, data []byte) error {
+	if n.opts.Stream == "" {
+		return n.pub(subject, "", data)
+	}
+
+	reply, err := n.request(ctx, subject, data)
+	if err != nil {
+		return err
+	}
+	return apiError(reply)
+}
+
+// Subscribe calls fn with the messages of subject, one at a time. With
+// JetStream, a durable consumer named after group and subject is created
+// on the stream, so messages published while no subscriber runs are
+// delivered once one starts.
+func (n *NATS) Subscribe(subject, group string, fn Handler) error {
+	wire := subject
+	if n.opts.Stream != "" {
+		var err error
+		if wire, err = n.ensureConsumer(subject, group); err != nil {
+			return err
+		}
+	}
+
+	sub := &natsSub{wire: wire, group: group, fn: fn, msgs: make(chan natsMsg, 256)}
+	n.mu.Lock()
+	n.nextSID++
+	sid := n.nextSID
+	n.subs[sid] = sub
+	n.mu.Unlock()
+
+	go n.handle(sub)
+	return n.write(subCommand(sid, sub))
+}
+
+// Close stops the subscriptions and disconnects
+func (n *NATS) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return nil
+	}
+	n.closed = true
+	close(n.done)
+	if n.conn != nil {
+		n.w.Flush()
+		return n.conn.Close()
+	}
+	return nil
+}
+
+// handle runs a subscription's handler on its messages, acknowledging
+// those coming from JetStream
+func (n *NATS) handle(sub *natsSub) {
+	for {
+		select {
+		case <-n.done:
+			return
+		case msg := <-sub.msgs:
+			err := Deliver(sub.fn, Message{Subject: msg.subject, Data: msg.data})
+			if err != nil {
+				log.Printf("⚠️  Message on %s failed: %v", msg.subject, err)
+			}
+			if n.opts.Stream == "" || msg.reply == "" {
+				continue
+			}
+			ack := "+ACK"
+			if err != nil {
+				ack = "-NAK"
+			}
+			if err := n.pub(msg.reply, "", []byte(ack)); err != nil {
+				log.Printf("⚠️  Failed to acknowledge message on %s: %v", msg.subject, err)
+			}
+		}
+	}
+}
+
+// request publishes data with a reply subject and waits for the reply
+func (n *NATS) request(ctx context.Context, subject string, data []byte) ([]byte, error) {
+	ch := make(chan natsMsg, 1)
+	n.mu.Lock()
+	n.nextReq++
+	reply := n.inbox + "." + strconv.Itoa(n.nextReq)
+	n.pending[reply] = ch
+	n.mu.Unlock()
+	defer func() {
+		n.mu.Lock()
+		delete(n.pending, reply)
+		n.mu.Unlock()
+	}()
+
+	if err := n.pub(subject, reply, data); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(n.Timeout)
+	defer timer.Stop()
+	select {
+	case msg := <-ch:
+		return msg.data, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("no reply from NATS to %s in %s", subject, n.Timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ensureStream creates the JetStream stream unless it exists
+func (n *NATS) ensureStream(ctx context.Context) error {
+	reply, err := n.request(ctx, "$JS.API.STREAM.INFO."+n.opts.Stream, nil)
+	if err != nil {
+		return fmt.Errorf("JetStream unavailable: %w", err)
+	}
+	if apiError(reply) == nil {
+		return nil
+	}
+	if len(n.opts.Subjects) == 0 {
+		return fmt.Errorf("JetStream stream %s doesn't exist and no subjects were given to create it", n.opts.Stream)
+	}
+
+	config, _ := json.Marshal(map[string]interface{}{"name": n.opts.Stream, "subjects": n.opts.Subjects})
+	if reply, err = n.request(ctx, "$JS.API.STREAM.CREATE."+n.opts.Stream, config); err != nil {
+		return err
+	}
+	if err := apiError(reply); err != nil {
+		return fmt.Errorf("creating JetStream stream %s: %w", n.opts.Stream, err)
+	}
+	log.Printf("✅ JetStream stream %s created", n.opts.Stream)
+	return nil
+}
+
+// ensureConsumer creates the durable consumer of group for subject and
+// returns the subject it delivers on
+func (n *NATS) ensureConsumer(subject, group string) (string, error) {
+	durable := strings.NewReplacer(".", "_", ">", "all", "*", "any").Replace(strings.Trim(group+"_"+subject, "_"))
+	deliver := "_REBOLO.deliver." + n.opts.Stream + "." + durable
+	config := map[string]interface{}{
+		"durable_name":    durable,
+		"deliver_subject": deliver,
+		"filter_subject":  subject,
+		"ack_policy":      "explicit",
+		"ack_wait":        int64(30 * time.Second),
+	}
+	if group != "" {
+		config["deliver_group"] = group
+	}
+	body, _ := json.Marshal(map[string]interface{}{"stream_name": n.opts.Stream, "config": config})
+
+	ctx, cancel := context.WithTimeout(context.Background(), n.Timeout)
+	defer cancel()
+	reply, err := n.request(ctx, "$JS.API.CONSUMER.DURABLE.CREATE."+n.opts.Stream+"."+durable, body)
+	if err != nil {
+		return "", err
+	}
+	if err := apiError(reply); err != nil {
+		return "", fmt.Errorf("creating JetStream consumer %s: %w", durable, err)
+	}
+	return deliver, nil
+}
+
+// apiError returns the error of a JetStream API reply, if any
+func apiError(reply []byte) error {
+	var r struct {
+		Error *struct {
+			Code        int    `json:"code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(reply, &r); err != nil {
+		return fmt.Errorf("unexpected JetStream reply %q", reply)
+	}
+	if r.Error != nil {
+		return fmt.Errorf("jetstream: %s (%d)", r.Error.Description, r.Error.Code)
+	}
+	return nil
+}
+
+// pub sends a message, with a reply subject when not empty
+func (n *NATS) pub(subject, reply string, data []byte) error {
+	cmd := "PUB " + subject + " "
+	if reply != "" {
+		cmd += reply + " "
+	}
+	return n.write(cmd+strconv.Itoa(len(data))+"\r\n", data, []byte("\r\n"))
+}
+
+// write sends a command, and the payload following it
+func (n *NATS) write(cmd string, payload ...[]byte) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return errClosed
+	}
+	if n.conn == nil {
+		return errors.New("not connected to NATS")
+	}
+	n.w.WriteString(cmd)
+	for _, p := range payload {
+		n.w.Write(p)
+	}
+	return n.w.Flush()
+}
+
+func subCommand(sid int, sub *natsSub) string {
+	if sub.group != "" {
+		return "SUB " + sub.wire + " " + sub.group + " " + strconv.Itoa(sid) + "\r\n"
+	}
+	return "SUB " + sub.wire + " " + strconv.Itoa(sid) + "\r\n"
+}
+
+// connect dials the server, authenticates and subscribes again to every
+// subject, after a reconnection
+func (n *NATS) connect() error {
+	conn, err := net.DialTimeout("tcp", n.addr, n.Timeout)
+	if err != nil {
+		return fmt.Errorf("connecting to NATS: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(n.Timeout))
+	r := bufio.NewReader(conn)
+
+	line, err := r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("connecting to NATS: unexpected greeting %q: %v", line, err)
+	}
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	json.Unmarshal([]byte(line[5:]), &info)
+	if n.tls || info.TLSRequired {
+		host, _, _ := net.SplitHostPort(n.addr)
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host})
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return fmt.Errorf("connecting to NATS: %w", err)
+		}
+		conn = tlsConn
+		r = bufio.NewReader(conn)
+	}
+
+	connect, _ := json.Marshal(map[string]interface{}{
+		"verbose":    false,
+		"pedantic":   false,
+		"lang":       "go",
+		"version":    "rebolo",
+		"protocol":   1,
+		"name":       n.opts.Name,
+		"user":       n.user,
+		"pass":       n.password,
+		"auth_token": n.token,
+	})
+	w := bufio.NewWriter(conn)
+	w.WriteString("CONNECT " + string(connect) + "\r\nPING\r\n")
+	if err := w.Flush(); err != nil {
+		conn.Close()
+		return fmt.Errorf("connecting to NATS: %w", err)
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("connecting to NATS: %w", err)
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			conn.Close()
+			return fmt.Errorf("connecting to NATS: %s", strings.TrimSpace(line[4:]))
+		}
+		if strings.HasPrefix(line, "PONG") {
+			break
+		}
+	}
+	conn.SetDeadline(time.Time{})
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	w.WriteString("SUB " + n.inbox + ".* 0\r\n")
+	for sid, sub := range n.subs {
+		w.WriteString(subCommand(sid, sub))
+	}
+	if err := w.Flush(); err != nil {
+		conn.Close()
+		return fmt.Errorf("connecting to NATS: %w", err)
+	}
+	n.conn, n.w = conn, w
+	n.reader = r
+	return nil
+}
+
+// read dispatches what the server sends until Close, reconnecting when
+// the connection drops
+func (n *NATS) read() {
+	for {
+		n.mu.Lock()
+		r := n.reader
+		n.mu.Unlock()
+
+		err := n.readLoop(r)
+
+		n.mu.Lock()
+		closed := n.closed
+		if n.conn != nil {
+			n.conn.Close()
+			n.conn = nil
+		}
+		n.mu.Unlock()
+		if closed {
+			return
+		}
+
+		log.Printf("⚠️  Lost connection to NATS, reconnecting: %v", err)
+		for wait := 500 * time.Millisecond; ; wait = min(wait*2, 10*time.Second) {
+			select {
+			case <-n.done:
+				return
+			case <-time.After(wait):
+			}
+			if err := n.connect(); err != nil {
+				log.Printf("⚠️  %v", err)
+				continue
+			}
+			log.Printf("✅ Reconnected to NATS")
+			break
+		}
+	}
+}
+
+func (n *NATS) readLoop(r *bufio.Reader) error {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case strings.HasPrefix(line, "MSG "):
+			// MSG <subject> <sid> [reply] <size>
+			fields := strings.Fields(line[4:])
+			if len(fields) < 3 {
+				return fmt.Errorf("malformed message %q", line)
+			}
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil {
+				return fmt.Errorf("malformed message %q", line)
+			}
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return err
+			}
+			msg := natsMsg{subject: fields[0], data: data[:size]}
+			if len(fields) == 4 {
+				msg.reply = fields[2]
+			}
+			n.dispatch(fields[1], msg)
+		case line == "PING":
+			n.write("PONG\r\n")
+		case strings.HasPrefix(line, "-ERR"):
+			log.Printf("⚠️  NATS: %s", strings.TrimSpace(line[4:]))
+		}
+	}
+}
+
+// dispatch hands a message to its subscription, or a reply to the request
+// waiting for it
+func (n *NATS) dispatch(sid string, msg natsMsg) {
+	n.mu.Lock()
+	if sid == "0" {
+		ch := n.pending[msg.subject]
+		n.mu.Unlock()
+		if ch != nil {
+			select {
+			case ch <- msg:
+			default:
+			}
+		}
+		return
+	}
+	id, _ := strconv.Atoi(sid)
+	sub := n.subs[id]
+	n.mu.Unlock()
+	if sub == nil {
+		return
+	}
+	select {
+	case sub.msgs <- msg:
+	case <-n.done:
+	}
+}
+
+func randomToken() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,184 @@
+// Package codec encodes the JSON responses of RenderJSON and ctx.JSON. The
+// standard library's encoder is built in, faster ones like jsoniter or
+// sonic plug in with Register from the app, using their library:
+//
+//	codec.Register("sonic", codec.EncoderFunc(func(w io.Writer, v interface{}) error {
+//		return sonic.ConfigStd.NewEncoder(w).Encode(v)
+//	}))
+//
+// and are picked in config.yml, which can also stream long slices:
+//
+//	json:
+//	  encoder: sonic
+//	  stream_over: 1000   # items
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Encoder writes v to w as JSON, followed by a newline
+type Encoder interface {
+	Encode(w io.Writer, v interface{}) error
+}
+
+// EncoderFunc is a function used as an Encoder
+type EncoderFunc func(w io.Writer, v interface{}) error
+
+func (f EncoderFunc) Encode(w io.Writer, v interface{}) error { return f(w, v) }
+
+// Std is encoding/json's encoder
+var Std Encoder = EncoderFunc(func(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+})
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[string]Encoder{"std": Std}
+
+	current    atomic.Pointer[Encoder]
+	streamOver atomic.Int64
+)
+
+// Register makes an encoder available to Use and config.yml
+func Register(name string, enc Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[name] = enc
+}
+
+// Use makes the encoder registered as name the one JSON uses, "" is std
+func Use(name string) error {
+	if name == "" {
+		name = "std"
+	}
+	encodersMu.RLock()
+	enc, ok := encoders[name]
+	encodersMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown JSON encoder %q (available: %s)", name, strings.Join(Encoders(), ", "))
+	}
+	current.Store(&enc)
+	return nil
+}
+
+// Encoders returns the registered encoder names, sorted
+func Encoders() []string {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	names := make([]string, 0, len(encoders))
+	for name := range encoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StreamOver makes JSON stream slices with more than n items, 0 never does
+func StreamOver(n int) {
+	streamOver.Store(int64(n))
+}
+
+// Current returns the encoder in use
+func Current() Encoder {
+	if enc := current.Load(); enc != nil {
+		return *enc
+	}
+	return Std
+}
+
+// JSON writes v to w with the encoder in use, streaming it when it's a
+// slice longer than StreamOver
+func JSON(w io.Writer, v interface{}) error {
+	if n := streamOver.Load(); n > 0 {
+		if rv := reflect.ValueOf(v); streamable(v, rv) && int64(rv.Len()) > n {
+			return Stream(w, v)
+		}
+	}
+	return Current().Encode(w, v)
+}
+
+// Stream writes v to w as a JSON array one item at a time, so the whole
+// payload is never in memory. v is a slice, an array, a channel read until
+// it's closed, or an iter.Seq[any]; anything else is encoded whole. An
+// error after the first item leaves the array unterminated.
+func Stream(w io.Writer, v interface{}) error {
+	if seq, ok := v.(iter.Seq[any]); ok {
+		return streamSeq(w, seq)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch {
+	case streamable(v, rv):
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return Current().Encode(w, v)
+		}
+		return streamSeq(w, func(yield func(any) bool) {
+			for i := 0; i < rv.Len(); i++ {
+				if !yield(rv.Index(i).Interface()) {
+					return
+				}
+			}
+		})
+	case rv.Kind() == reflect.Chan && rv.Type().ChanDir()&reflect.RecvDir != 0:
+		return streamSeq(w, func(yield func(any) bool) {
+			for {
+				item, ok := rv.Recv()
+				if !ok || !yield(item.Interface()) {
+					return
+				}
+			}
+		})
+	}
+	return Current().Encode(w, v)
+}
+
+// streamable reports whether v is a slice or array encoding.json would
+// write as an array, not []byte or a type with its own MarshalJSON
+func streamable(v interface{}, rv reflect.Value) bool {
+	if kind := rv.Kind(); kind != reflect.Slice && kind != reflect.Array {
+		return false
+	}
+	if rv.Type().Elem().Kind() == reflect.Uint8 {
+		return false
+	}
+	_, marshaler := v.(json.Marshaler)
+	return !marshaler
+}
+
+// streamSeq writes the items of seq as an array. Each item is encoded
+// before it's written, so a failing one doesn't leave half a value.
+func streamSeq(w io.Writer, seq iter.Seq[any]) error {
+	enc := Current()
+	var buf bytes.Buffer
+	var err error
+
+	if _, err = io.WriteString(w, "["); err != nil {
+		return err
+	}
+	first := true
+	for item := range seq {
+		buf.Reset()
+		if !first {
+			buf.WriteByte(',')
+		}
+		if err = enc.Encode(&buf, item); err != nil {
+			return err
+		}
+		if _, err = w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))); err != nil {
+			return err
+		}
+		first = false
+	}
+	_, err = io.WriteString(w, "]\n")
+	return err
+}
@@ -1,10 +1,22 @@
 package context
 
 import (
-	"encoding/json"
+	"bytes"
+	stdcontext "context"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/barcode"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/codec"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/errors"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/export"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/middleware"
 	"github.com/Palaciodiego008/rebololang/pkg/rebolo/session"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/timefmt"
 	"github.com/Palaciodiego008/rebololang/pkg/rebolo/validation"
 	"github.com/gorilla/mux"
 )
@@ -16,6 +28,18 @@ type AppContext interface {
 	RenderHTML(w http.ResponseWriter, template string, data interface{}) error
 }
 
+// ContextRenderer is implemented by apps whose renders stop when the
+// request's context is done
+type ContextRenderer interface {
+	RenderHTMLContext(ctx stdcontext.Context, w http.ResponseWriter, template string, data interface{}) error
+}
+
+// PDFConfigurer is implemented by apps with PDF settings, which c.PDF
+// prints with
+type PDFConfigurer interface {
+	PDFOptions() export.PDFOptions
+}
+
 // Context wraps http.Request and http.ResponseWriter with convenient helpers
 type Context struct {
 	Request  *http.Request
@@ -34,6 +58,120 @@ func NewContext(w http.ResponseWriter, r *http.Request, app AppContext) *Context
 	}
 }
 
+// valuesKey is the request context key of the request's values
+type valuesKey struct{}
+
+// values holds what middleware and handlers pass along a request. It is
+// shared by every copy of the request, so a value set downstream is seen
+// by the middleware that wrapped it too.
+type values struct {
+	mu sync.RWMutex
+	m  map[string]interface{}
+}
+
+// WithValue stores a value for the rest of the request, for middleware
+// written as plain http.Handlers:
+//
+//	next.ServeHTTP(w, context.WithValue(r, "user", user))
+func WithValue(r *http.Request, key string, value interface{}) *http.Request {
+	store, ok := r.Context().Value(valuesKey{}).(*values)
+	if !ok {
+		store = &values{m: make(map[string]interface{})}
+		r = r.WithContext(stdcontext.WithValue(r.Context(), valuesKey{}, store))
+	}
+	store.mu.Lock()
+	store.m[key] = value
+	store.mu.Unlock()
+	return r
+}
+
+// Value returns a value stored with WithValue or Context.SetValue, nil if
+// there is none
+func Value(r *http.Request, key string) interface{} {
+	store, ok := r.Context().Value(valuesKey{}).(*values)
+	if !ok {
+		return nil
+	}
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	return store.m[key]
+}
+
+// IsHTMX reports whether r was sent by htmx, which sets the HX-Request
+// header, for handlers answering with a fragment instead of a page
+func IsHTMX(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true"
+}
+
+// SetValue stores a value (current user, tenant, locale) for the rest of
+// the request
+func (c *Context) SetValue(key string, value interface{}) {
+	c.Request = WithValue(c.Request, key, value)
+}
+
+// Value returns a value set by SetValue or by middleware, nil if there is none
+func (c *Context) Value(key string) interface{} {
+	return Value(c.Request, key)
+}
+
+// TimeFormatter formats times for the user making the request. The
+// timezone comes from the "timezone" value (see SetValue) or cookie, the
+// locale from the "locale" value or Accept-Language, falling back to
+// app.timezone and app.locale.
+func (c *Context) TimeFormatter() timefmt.Formatter {
+	timezone, _ := c.Value("timezone").(string)
+	if timezone == "" {
+		if cookie, err := c.Request.Cookie("timezone"); err == nil {
+			timezone = cookie.Value
+		}
+	}
+
+	locale, _ := c.Value("locale").(string)
+	if locale == "" {
+		// First language of "es-CO,es;q=0.9,en;q=0.8"
+		lang, _, _ := strings.Cut(c.Get("Accept-Language"), ",")
+		lang, _, _ = strings.Cut(lang, ";")
+		locale = strings.TrimSpace(lang)
+	}
+	return timefmt.New(timezone, locale)
+}
+
+// FormatTime formats the date and time of t for the user, see TimeFormatter
+func (c *Context) FormatTime(t time.Time) string {
+	return c.TimeFormatter().DateTime(t)
+}
+
+// FormatDate formats the day of t for the user, see TimeFormatter
+func (c *Context) FormatDate(t time.Time) string {
+	return c.TimeFormatter().Date(t)
+}
+
+// Context returns the request's context, done when the client goes away or
+// a Timeout passes. Pass it to queries so they stop with the request:
+//
+//	rows, err := db.QueryContext(c.Context(), "SELECT ...")
+func (c *Context) Context() stdcontext.Context {
+	return c.Request.Context()
+}
+
+// Deadline returns when the request's context times out, ok is false
+// when it has no deadline
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	return c.Request.Context().Deadline()
+}
+
+// Timeout bounds the rest of the handler to d: queries and renders using
+// Context() fail with context.DeadlineExceeded once it passes, and the
+// error becomes a 503. An earlier deadline is kept. Call the returned
+// function when done:
+//
+//	defer c.Timeout(2 * time.Second)()
+func (c *Context) Timeout(d time.Duration) stdcontext.CancelFunc {
+	ctx, cancel := stdcontext.WithTimeout(c.Request.Context(), d)
+	c.Request = c.Request.WithContext(ctx)
+	return cancel
+}
+
 // Session retrieves the session for the current request
 func (c *Context) Session() (*session.Session, error) {
 	return c.App.GetSession(c.Request, c.Response)
@@ -48,6 +186,29 @@ func (c *Context) Flash() (*session.Flash, error) {
 	return session.NewFlash(sess), nil
 }
 
+// AddFlash adds a flash message for the next page the user sees, level
+// being session.LevelSuccess, LevelError, LevelWarning or LevelInfo:
+//
+//	c.AddFlash(session.LevelSuccess, "Post created")
+//	c.Redirect("/posts", http.StatusSeeOther)
+func (c *Context) AddFlash(level, message string) error {
+	flash, err := c.Flash()
+	if err != nil {
+		return err
+	}
+	flash.Add(level, message)
+	return nil
+}
+
+// Flashes returns the flash messages and clears them
+func (c *Context) Flashes() []session.FlashMessage {
+	flash, err := c.Flash()
+	if err != nil {
+		return nil
+	}
+	return flash.Get()
+}
+
 // Param retrieves a URL parameter by name (from gorilla/mux)
 func (c *Context) Param(key string) string {
 	return c.params[key]
@@ -68,16 +229,54 @@ func (c *Context) Bind(v interface{}) error {
 	return c.App.Bind(c.Request, v)
 }
 
-// Render renders an HTML template with data
+// Render renders an HTML template with data. The render is abandoned if
+// the request is cancelled or times out (see Timeout). Map data gets the
+// flash messages as "Flash", read with {{.Flash.HTML}} or
+// {{range .Flash.Get}}, and cleared only when the template reads them.
 func (c *Context) Render(template string, data interface{}) error {
+	data = c.withFlash(data)
+	if renderer, ok := c.App.(ContextRenderer); ok {
+		return renderer.RenderHTMLContext(c.Context(), c.Response, template, data)
+	}
 	return c.App.RenderHTML(c.Response, template, data)
 }
 
+// withFlash returns a copy of map data with the request's Flash added,
+// other data is returned as is
+func (c *Context) withFlash(data interface{}) interface{} {
+	m, ok := data.(map[string]interface{})
+	if data != nil && !ok {
+		return data
+	}
+	if _, set := m["Flash"]; set {
+		return data
+	}
+	flash, err := c.Flash()
+	if err != nil {
+		return data
+	}
+
+	withFlash := make(map[string]interface{}, len(m)+1)
+	for k, v := range m {
+		withFlash[k] = v
+	}
+	withFlash["Flash"] = flash
+	return withFlash
+}
+
 // JSON sends a JSON response
 func (c *Context) JSON(status int, data interface{}) error {
 	c.Response.Header().Set("Content-Type", "application/json")
 	c.Response.WriteHeader(status)
-	return json.NewEncoder(c.Response).Encode(data)
+	return codec.JSON(c.Response, data)
+}
+
+// JSONStream sends items, a slice, a channel or an iter.Seq[any], as a
+// JSON array one item at a time, without building it in memory
+func (c *Context) JSONStream(status int, items interface{}) error {
+	c.Response.Header().Set("Content-Type", "application/json")
+	c.Response.WriteHeader(status)
+	return codec.Stream(c.Response, items)
 }
 
 // String sends a plain text response
@@ -88,11 +287,131 @@ func (c *Context) String(status int, text string) error {
 	return err
 }
 
+// PDF renders a template and sends the page printed to a PDF by headless
+// Chrome, see export.PDF. It prints with the app's PDF settings unless
+// opts are given, so relative links in the page resolve against the
+// application's URL and its stylesheets and images load.
+func (c *Context) PDF(template string, data interface{}, opts ...export.PDFOptions) error {
+	page := &pageWriter{header: http.Header{}}
+	var err error
+	if renderer, ok := c.App.(ContextRenderer); ok {
+		err = renderer.RenderHTMLContext(c.Context(), page, template, data)
+	} else {
+		err = c.App.RenderHTML(page, template, data)
+	}
+	if err != nil {
+		return err
+	}
+
+	var o export.PDFOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	} else if app, ok := c.App.(PDFConfigurer); ok {
+		o = app.PDFOptions()
+	}
+	pdf, err := export.PDF(c.Context(), page.Bytes(), o)
+	if err != nil {
+		return err
+	}
+	c.Response.Header().Set("Content-Type", "application/pdf")
+	c.Response.Header().Set("Content-Length", strconv.Itoa(len(pdf)))
+	_, err = c.Response.Write(pdf)
+	return err
+}
+
+// XLSX sends rows as an Excel spreadsheet, see export.XLSX for the rows
+// it takes. Name the download with Attachment:
+//
+//	return c.Attachment("orders.xlsx").XLSX(rows)
+func (c *Context) XLSX(rows interface{}) error {
+	var buf bytes.Buffer
+	if err := export.XLSX(&buf, rows); err != nil {
+		return err
+	}
+	c.Response.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Response.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	_, err := c.Response.Write(buf.Bytes())
+	return err
+}
+
+// QRCode sends a PNG of a QR code of data, at most size pixels wide, see
+// barcode.QR
+func (c *Context) QRCode(data string, size int) error {
+	code, err := barcode.QR(data, barcode.M)
+	if err != nil {
+		return err
+	}
+	return c.image(code, size)
+}
+
+// QRCodeSVG sends a QR code of data as an SVG size pixels wide, which
+// stays sharp when printed
+func (c *Context) QRCodeSVG(data string, size int) error {
+	code, err := barcode.QR(data, barcode.M)
+	if err != nil {
+		return err
+	}
+	c.Response.Header().Set("Content-Type", "image/svg+xml")
+	return code.SVG(c.Response, size)
+}
+
+// Barcode sends a PNG of a Code 128 barcode of data, at most size pixels
+// wide, see barcode.Code128
+func (c *Context) Barcode(data string, size int) error {
+	code, err := barcode.Code128(data)
+	if err != nil {
+		return err
+	}
+	return c.image(code, size)
+}
+
+// image sends code as a PNG
+func (c *Context) image(code *barcode.Code, size int) error {
+	var buf bytes.Buffer
+	if err := code.PNG(&buf, size); err != nil {
+		return err
+	}
+	c.Response.Header().Set("Content-Type", "image/png")
+	c.Response.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	_, err := c.Response.Write(buf.Bytes())
+	return err
+}
+
+// Attachment makes browsers download the response as filename instead of
+// showing it
+func (c *Context) Attachment(filename string) *Context {
+	c.Response.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	return c
+}
+
+// pageWriter keeps a rendered page, for PDF to print it
+type pageWriter struct {
+	bytes.Buffer
+	header http.Header
+}
+
+func (p *pageWriter) Header() http.Header { return p.header }
+func (p *pageWriter) WriteHeader(int)     {}
+
 // Redirect redirects to a URL
 func (c *Context) Redirect(url string, code int) {
 	http.Redirect(c.Response, c.Request, url, code)
 }
 
+// Push starts HTTP/2 server pushes of assets the page needs, falling back
+// to preload Link headers where push isn't available. Call it before
+// rendering.
+func (c *Context) Push(assets ...string) error {
+	return middleware.Push(c.Response, c.Request, assets...)
+}
+
+// EarlyHints sends a 103 Early Hints response preloading assets, so the
+// browser fetches them while the page is rendered. Call it before any slow
+// work of the handler.
+func (c *Context) EarlyHints(assets ...string) {
+	middleware.SendEarlyHints(c.Response, c.Request, assets...)
+}
+
 // Status sets the HTTP status code
 func (c *Context) Status(code int) *Context {
 	c.Response.WriteHeader(code)
@@ -125,15 +444,45 @@ func (c *Context) IsAjax() bool {
 	return c.Get("X-Requested-With") == "XMLHttpRequest"
 }
 
+// IsHTMX returns true if the request was sent by htmx
+func (c *Context) IsHTMX() bool {
+	return IsHTMX(c.Request)
+}
+
+// HXTarget returns the id of the element htmx swaps the response into
+func (c *Context) HXTarget() string {
+	return c.Get("HX-Target")
+}
+
+// HXTrigger makes htmx fire event on the page once the response is swapped
+func (c *Context) HXTrigger(event string) *Context {
+	return c.Set("HX-Trigger", event)
+}
+
 // IsJSON returns true if the request content type is JSON
 func (c *Context) IsJSON() bool {
-	return c.Get("Content-Type") == "application/json"
+	return strings.HasPrefix(c.Get("Content-Type"), "application/json")
 }
 
-// Error sends an error response
+// WantsJSON returns true if the client expects a JSON response
+func (c *Context) WantsJSON() bool {
+	accept := c.Get("Accept")
+	if strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/html") {
+		return true
+	}
+	return c.IsJSON() || c.IsAjax()
+}
+
+// Error returns an HTTPError with the given status code.
+// Return it from a ContextHandler and the framework renders the response:
+//
+//	return ctx.Error(err, http.StatusNotFound)
 func (c *Context) Error(err error, code int) error {
-	http.Error(c.Response, err.Error(), code)
-	return err
+	message := http.StatusText(code)
+	if code < 500 && err != nil {
+		message = err.Error()
+	}
+	return errors.NewError(code, message).Wrap(err)
 }
 
 // SaveSession is a helper to save the session
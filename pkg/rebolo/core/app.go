@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"net/http"
+	"time"
 )
 
 // App represents the core application
@@ -80,8 +81,20 @@ func NewApp(config Config, router Router, database Database, renderer Renderer)
 	}
 }
 
+// ShutdownTimeout is how long Serve waits for in-flight requests to finish
+// once its context is done
+const ShutdownTimeout = 10 * time.Second
+
 // Start starts the application server
 func (a *App) Start() error {
+	return a.Serve(context.Background(), nil)
+}
+
+// Serve connects the database and serves HTTP on the configured port until
+// ctx is done, then stops accepting connections and waits up to
+// ShutdownTimeout for in-flight requests. configure, when not nil, can
+// adjust the server before it starts listening.
+func (a *App) Serve(ctx context.Context, configure func(*http.Server)) error {
 	// Connect to database if configured
 	if a.config.GetDatabaseURL() != "" {
 		if err := a.database.Connect(context.Background()); err != nil {
@@ -89,19 +102,39 @@ func (a *App) Start() error {
 		}
 	}
 
+	port := a.config.GetPort()
+	if port == "" {
+		port = "3000"
+	}
+
+	server := &http.Server{Addr: ":" + port, Handler: a.Handler()}
+	if configure != nil {
+		configure(server)
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- server.ListenAndServe() }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// Handler returns the router wrapped with the application middleware
+func (a *App) Handler() http.Handler {
 	// Apply middleware - wrap the router with middleware in reverse order
 	// (first middleware becomes outermost, last becomes innermost)
 	var handler http.Handler = a.router
 	for i := len(a.middleware) - 1; i >= 0; i-- {
 		handler = a.middleware[i](handler)
 	}
-
-	port := a.config.GetPort()
-	if port == "" {
-		port = "3000"
-	}
-
-	return http.ListenAndServe(":"+port, handler)
+	return handler
 }
 
 // AddMiddleware adds middleware to the application
@@ -109,6 +142,11 @@ func (a *App) AddMiddleware(middleware Middleware) {
 	a.middleware = append(a.middleware, middleware)
 }
 
+// Middleware returns the application middleware, outermost first
+func (a *App) Middleware() []Middleware {
+	return a.middleware
+}
+
 // Router returns the router instance
 func (a *App) Router() Router {
 	return a.router
@@ -123,3 +161,8 @@ func (a *App) Database() Database {
 func (a *App) Renderer() Renderer {
 	return a.renderer
 }
+
+// SetRenderer replaces the renderer, e.g. after templates were reloaded
+func (a *App) SetRenderer(renderer Renderer) {
+	a.renderer = renderer
+}
@@ -2,6 +2,7 @@ package errors
 
 import (
 	"fmt"
+	"html"
 	"net/http"
 )
 
@@ -19,7 +20,7 @@ func NewErrorHandlers() ErrorHandlers {
 	handlers[404] = func(w http.ResponseWriter, r *http.Request, err error, code int) {
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		w.WriteHeader(404)
-		html := fmt.Sprintf(`
+		page := fmt.Sprintf(`
 <!DOCTYPE html>
 <html lang="es">
 <head>
@@ -72,8 +73,8 @@ func NewErrorHandlers() ErrorHandlers {
     </div>
 </body>
 </html>
-`, r.URL.Path)
-		w.Write([]byte(html))
+`, html.EscapeString(r.URL.Path))
+		w.Write([]byte(page))
 	}
 
 	// Default 500 handler
@@ -81,12 +82,11 @@ func NewErrorHandlers() ErrorHandlers {
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		w.WriteHeader(500)
 
-		errorMsg := ""
-		if err != nil {
-			errorMsg = err.Error()
-		}
+		// Only the public message, escaped: internal errors can hold SQL,
+		// paths or user input
+		errorMsg := HTMLMessage(err, code, false)
 
-		html := fmt.Sprintf(`
+		page := fmt.Sprintf(`
 <!DOCTYPE html>
 <html lang="es">
 <head>
@@ -154,7 +154,7 @@ func NewErrorHandlers() ErrorHandlers {
 			}
 			return ""
 		}())
-		w.Write([]byte(html))
+		w.Write([]byte(page))
 	}
 
 	return handlers
@@ -0,0 +1,99 @@
+package errors
+
+import (
+	stderrors "errors"
+	"net/http"
+)
+
+// HTTPError is an error that maps to an HTTP status code.
+// Return it from a ContextHandler and the framework renders the matching response.
+type HTTPError struct {
+	Code    int    // HTTP status code
+	Message string // Message safe to show to the client
+	Err     error  // Underlying cause, never shown to the client
+}
+
+// NewError creates an HTTPError with a status code and a client-facing message
+func NewError(code int, message string) *HTTPError {
+	if message == "" {
+		message = http.StatusText(code)
+	}
+	return &HTTPError{Code: code, Message: message}
+}
+
+// Common HTTP errors
+var (
+	ErrBadRequest         = NewError(http.StatusBadRequest, "")
+	ErrUnauthorized       = NewError(http.StatusUnauthorized, "")
+	ErrForbidden          = NewError(http.StatusForbidden, "")
+	ErrNotFound           = NewError(http.StatusNotFound, "")
+	ErrMethodNotAllowed   = NewError(http.StatusMethodNotAllowed, "")
+	ErrConflict           = NewError(http.StatusConflict, "")
+	ErrUnprocessable      = NewError(http.StatusUnprocessableEntity, "")
+	ErrTooManyRequests    = NewError(http.StatusTooManyRequests, "")
+	ErrInternal           = NewError(http.StatusInternalServerError, "")
+	ErrNotImplemented     = NewError(http.StatusNotImplemented, "")
+	ErrServiceUnavailable = NewError(http.StatusServiceUnavailable, "")
+	ErrGatewayTimeout     = NewError(http.StatusGatewayTimeout, "")
+)
+
+// Error implements the error interface
+func (e *HTTPError) Error() string {
+	if e.Err != nil && e.Err.Error() != e.Message {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying cause
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an HTTPError with the same status code,
+// so errors.Is(err, ErrNotFound) works for wrapped errors too
+func (e *HTTPError) Is(target error) bool {
+	t, ok := target.(*HTTPError)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of the error with the given cause attached
+func (e *HTTPError) Wrap(err error) *HTTPError {
+	return &HTTPError{Code: e.Code, Message: e.Message, Err: err}
+}
+
+// WithMessage returns a copy of the error with a different client-facing message
+func (e *HTTPError) WithMessage(message string) *HTTPError {
+	return &HTTPError{Code: e.Code, Message: message, Err: e.Err}
+}
+
+// StatusCode returns the HTTP status code for an error, 500 if it carries none
+func StatusCode(err error) int {
+	var httpErr *HTTPError
+	if stderrors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	var safeErr *SafeError
+	if stderrors.As(err, &safeErr) {
+		return safeErr.Code
+	}
+	return http.StatusInternalServerError
+}
+
+// PublicMessage returns the message that is safe to show to clients
+// answered with code. Errors without an HTTP status only expose the
+// status text of code.
+func PublicMessage(err error, code int) string {
+	var httpErr *HTTPError
+	if stderrors.As(err, &httpErr) {
+		return httpErr.Message
+	}
+	var safeErr *SafeError
+	if stderrors.As(err, &safeErr) {
+		return string(safeErr.Message)
+	}
+	if text := http.StatusText(code); text != "" {
+		return text
+	}
+	return http.StatusText(http.StatusInternalServerError)
+}
@@ -0,0 +1,35 @@
+package errors
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Reporter sends an error to an external service (Sentry, logs, etc.)
+type Reporter interface {
+	Report(r *http.Request, err error, code int) error
+}
+
+// PanicError wraps a value recovered from a panic together with its stack trace
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// NewPanicError creates a PanicError from a recovered value and stack
+func NewPanicError(value interface{}, stack []byte) *PanicError {
+	return &PanicError{Value: value, Stack: stack}
+}
+
+// Error implements the error interface
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// Unwrap returns the recovered value when it is an error
+func (p *PanicError) Unwrap() error {
+	if err, ok := p.Value.(error); ok {
+		return err
+	}
+	return nil
+}
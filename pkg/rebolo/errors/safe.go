@@ -0,0 +1,58 @@
+package errors
+
+import (
+	stderrors "errors"
+	"html/template"
+)
+
+// SafeError is an error whose message is HTML meant for the user, like a
+// link to sign in again. Error pages render its message as is, while any
+// other error message is escaped, so only build it from markup you wrote:
+//
+//	return errors.NewSafeError(http.StatusUnauthorized, `Your session expired, <a href="/login">sign in</a>`)
+type SafeError struct {
+	Code    int           // HTTP status code
+	Message template.HTML // Rendered unescaped by error pages
+	Err     error         // Underlying cause, never shown to the client
+}
+
+// NewSafeError creates a SafeError with a status code and an HTML message
+func NewSafeError(code int, message template.HTML) *SafeError {
+	return &SafeError{Code: code, Message: message}
+}
+
+// Error implements the error interface
+func (e *SafeError) Error() string {
+	if e.Err != nil {
+		return string(e.Message) + ": " + e.Err.Error()
+	}
+	return string(e.Message)
+}
+
+// Unwrap returns the underlying cause
+func (e *SafeError) Unwrap() error {
+	return e.Err
+}
+
+// Wrap returns a copy of the error with the given cause attached
+func (e *SafeError) Wrap(err error) *SafeError {
+	return &SafeError{Code: e.Code, Message: e.Message, Err: err}
+}
+
+// HTMLMessage returns the message an error page answering with code shows
+// for err, escaped unless err is a SafeError. Without detailed only the
+// public message is shown (see PublicMessage), detailed shows err.Error()
+// for development.
+func HTMLMessage(err error, code int, detailed bool) template.HTML {
+	if err == nil {
+		return ""
+	}
+	var safeErr *SafeError
+	if stderrors.As(err, &safeErr) {
+		return safeErr.Message
+	}
+	if detailed {
+		return template.HTML(template.HTMLEscapeString(err.Error()))
+	}
+	return template.HTML(template.HTMLEscapeString(PublicMessage(err, code)))
+}
@@ -0,0 +1,165 @@
+package errors
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// SentryReporter reports errors to Sentry using its HTTP store API
+type SentryReporter struct {
+	endpoint    string
+	publicKey   string
+	environment string
+	release     string
+	serverName  string
+	client      *http.Client
+}
+
+// NewSentryReporter creates a reporter from a Sentry DSN
+// DSN format: https://<public_key>@<host>/<project_id>
+func NewSentryReporter(dsn, environment, release string) (*SentryReporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sentry dsn: %w", err)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("invalid sentry dsn: missing public key")
+	}
+
+	path := strings.Trim(u.Path, "/")
+	idx := strings.LastIndex(path, "/")
+	projectID := path[idx+1:]
+	prefix := ""
+	if idx > 0 {
+		prefix = "/" + path[:idx]
+	}
+	if projectID == "" {
+		return nil, fmt.Errorf("invalid sentry dsn: missing project id")
+	}
+
+	hostname, _ := os.Hostname()
+
+	return &SentryReporter{
+		endpoint:    fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, projectID),
+		publicKey:   u.User.Username(),
+		environment: environment,
+		release:     release,
+		serverName:  hostname,
+		client:      &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+// sentryEvent is the subset of the Sentry event payload we send
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Platform    string                 `json:"platform"`
+	Logger      string                 `json:"logger"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Message     string                 `json:"message"`
+	Exception   map[string]interface{} `json:"exception"`
+	Request     map[string]interface{} `json:"request,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+}
+
+// Report sends the error to Sentry
+func (s *SentryReporter) Report(r *http.Request, err error, code int) error {
+	if err == nil {
+		return nil
+	}
+
+	event := sentryEvent{
+		EventID:     newEventID(),
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Level:       "error",
+		Platform:    "go",
+		Logger:      "rebolo",
+		ServerName:  s.serverName,
+		Environment: s.environment,
+		Release:     s.release,
+		Message:     err.Error(),
+		Exception: map[string]interface{}{
+			"values": []map[string]interface{}{
+				{"type": fmt.Sprintf("%T", err), "value": err.Error()},
+			},
+		},
+		Tags: map[string]string{
+			"status_code": fmt.Sprintf("%d", code),
+		},
+	}
+
+	if panicErr, ok := err.(*PanicError); ok {
+		event.Level = "fatal"
+		event.Extra = map[string]interface{}{"stack": string(panicErr.Stack)}
+	}
+
+	if r != nil {
+		headers := make(map[string]string)
+		for key := range r.Header {
+			// Never forward credentials to a third party
+			if key == "Cookie" || key == "Authorization" {
+				continue
+			}
+			headers[key] = r.Header.Get(key)
+		}
+		event.Request = map[string]interface{}{
+			"url":          requestURL(r),
+			"method":       r.Method,
+			"query_string": r.URL.RawQuery,
+			"headers":      headers,
+		}
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf(
+		"Sentry sentry_version=7, sentry_client=rebolo/1.0, sentry_timestamp=%d, sentry_key=%s",
+		time.Now().Unix(), s.publicKey))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// requestURL rebuilds the absolute URL of a request
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, r.Host, r.URL.Path)
+}
+
+// newEventID generates a random 32 character hex event ID
+func newEventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
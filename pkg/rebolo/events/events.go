@@ -0,0 +1,271 @@
+// Package events is a bus for domain events: app code publishes what
+// happened, and in-process handlers, background jobs and HTTP webhooks
+// subscribe to it.
+//
+//	events.Subscribe("todo.*", func(ctx context.Context, e events.Event) error {
+//		return search.Index(ctx, e)
+//	})
+//	events.SubscribeJob("todo.created", worker.Job{Handler: "notify_watchers"})
+//
+//	events.Publish("todo.created", todo)
+//
+// Handlers run before Publish returns, jobs are performed on the app's
+// worker and webhooks are delivered in the background, signed and retried,
+// see Webhook.
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/broker"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/worker"
+)
+
+// Event is something that happened, published under a name like
+// "todo.created"
+type Event struct {
+	ID   string      `json:"id"`
+	Name string      `json:"name"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// Decode stores the event's data in v. Handlers get the value that was
+// published, jobs get it decoded from JSON, Decode works for both.
+func (e Event) Decode(v interface{}) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Handler handles events in the process
+type Handler func(ctx context.Context, e Event) error
+
+// subscription is a handler, or a job, for the events matching pattern
+type subscription struct {
+	pattern string
+	handler Handler
+	job     *worker.Job
+}
+
+// Bus delivers published events to their subscribers
+type Bus struct {
+	mu       sync.RWMutex
+	subs     []subscription
+	webhooks []*Webhook
+	jobs     worker.Performer
+	broker   broker.Broker
+
+	// Client sends webhooks, with a 10 second timeout by default
+	Client *http.Client
+	// Backoff is the wait before a webhook's first retry, 10 seconds by
+	// default, tripled before each of the next
+	Backoff time.Duration
+}
+
+// New creates a bus without subscribers
+func New() *Bus {
+	return &Bus{
+		Client:  &http.Client{Timeout: 10 * time.Second},
+		Backoff: 10 * time.Second,
+	}
+}
+
+// Default is the bus of the package functions, the app performs its jobs
+// and sends the webhooks of config.yml
+var Default = New()
+
+// Subscribe calls fn with the events matching pattern: a name, a prefix
+// like "todo.*", or "*" for every event
+func (b *Bus) Subscribe(pattern string, fn Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, subscription{pattern: pattern, handler: fn})
+}
+
+// SubscribeJob performs job for the events matching pattern. Its Args are
+// the event's id, name, time and data, so a worker.Define job can take an
+// Event.
+func (b *Bus) SubscribeJob(pattern string, job worker.Job) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, subscription{pattern: pattern, job: &job})
+}
+
+// SetPerformer sets what performs the jobs of SubscribeJob, the app does
+// it for Default
+func (b *Bus) SetPerformer(p worker.Performer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.jobs = p
+}
+
+// SetBroker publishes events through a message broker, on "events.<name>"
+// subjects, for the instances listening to it, see Listen. Without a
+// broker, events stay in the process.
+func (b *Bus) SetBroker(br broker.Broker) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.broker = br
+}
+
+// Listen runs the handlers and jobs subscribed to the events that come
+// from the broker, published by every service on it. Each event goes to
+// one instance of each group, the app listens as its name when it starts.
+func (b *Bus) Listen(group string) error {
+	b.mu.RLock()
+	br := b.broker
+	b.mu.RUnlock()
+	if br == nil {
+		return errors.New("no broker to listen to")
+	}
+	return br.Subscribe("events.>", group, func(ctx context.Context, msg broker.Message) error {
+		var e Event
+		if err := json.Unmarshal(msg.Data, &e); err != nil {
+			return fmt.Errorf("decoding event on %s: %w", msg.Subject, err)
+		}
+		return b.dispatch(ctx, e, msg.Data)
+	})
+}
+
+// Publish publishes an event, see PublishContext
+func (b *Bus) Publish(name string, data interface{}) error {
+	return b.PublishContext(context.Background(), name, data)
+}
+
+// PublishContext runs the handlers subscribed to the event with ctx,
+// performs its jobs and queues its webhooks. The errors of handlers and
+// jobs are returned together, after every subscriber got the event.
+//
+// With a broker, the event is sent to it and handlers and jobs run when it
+// comes back, on the listening instances it's delivered to; the error is
+// the broker's. Webhooks are still sent by the publishing instance.
+func (b *Bus) PublishContext(ctx context.Context, name string, data interface{}) error {
+	e := Event{ID: newID(), Name: name, Time: time.Now().UTC(), Data: data}
+
+	b.mu.RLock()
+	webhooks := b.webhooks
+	br := b.broker
+	b.mu.RUnlock()
+
+	// Brokers, jobs and webhooks get the event as JSON, encoded once
+	var body []byte
+	if br != nil || len(webhooks) > 0 {
+		var err error
+		if body, err = json.Marshal(e); err != nil {
+			return fmt.Errorf("encoding event %s: %w", name, err)
+		}
+	}
+
+	var err error
+	if br != nil {
+		err = br.Publish(ctx, "events."+name, body)
+	} else {
+		err = b.dispatch(ctx, e, body)
+	}
+
+	for _, w := range webhooks {
+		if w.wants(name) {
+			go b.deliver(w, e, body, 1)
+		}
+	}
+	return err
+}
+
+// dispatch runs the handlers and performs the jobs subscribed to e, body
+// is e as JSON if it was encoded already
+func (b *Bus) dispatch(ctx context.Context, e Event, body []byte) error {
+	b.mu.RLock()
+	subs := b.subs
+	performer := b.jobs
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, sub := range subs {
+		if !Match(sub.pattern, e.Name) {
+			continue
+		}
+		if sub.handler != nil {
+			errs = append(errs, run(ctx, sub.handler, e))
+			continue
+		}
+
+		if performer == nil {
+			errs = append(errs, fmt.Errorf("no worker performs job %s for event %s", sub.job.Handler, e.Name))
+			continue
+		}
+		if body == nil {
+			var err error
+			if body, err = json.Marshal(e); err != nil {
+				return fmt.Errorf("encoding event %s: %w", e.Name, err)
+			}
+		}
+		job := *sub.job
+		job.Args = nil
+		if err := json.Unmarshal(body, &job.Args); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		errs = append(errs, performer.Perform(job))
+	}
+	return errors.Join(errs...)
+}
+
+// run calls a handler, turning a panic into an error
+func run(ctx context.Context, fn Handler, e Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("event %s handler panicked: %v", e.Name, r)
+		}
+	}()
+	return fn(ctx, e)
+}
+
+// Match reports whether an event name matches a subscription pattern
+func Match(pattern, name string) bool {
+	switch {
+	case pattern == "*":
+		return true
+	case strings.HasSuffix(pattern, ".*"):
+		return strings.HasPrefix(name, pattern[:len(pattern)-1])
+	}
+	return pattern == name
+}
+
+// Publish publishes an event on the Default bus
+func Publish(name string, data interface{}) error {
+	return Default.Publish(name, data)
+}
+
+// PublishContext publishes an event on the Default bus
+func PublishContext(ctx context.Context, name string, data interface{}) error {
+	return Default.PublishContext(ctx, name, data)
+}
+
+// Subscribe subscribes fn to events of the Default bus
+func Subscribe(pattern string, fn Handler) {
+	Default.Subscribe(pattern, fn)
+}
+
+// SubscribeJob subscribes job to events of the Default bus
+func SubscribeJob(pattern string, job worker.Job) {
+	Default.SubscribeJob(pattern, job)
+}
+
+// newID returns a random event ID, also sent as X-Rebolo-Delivery
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
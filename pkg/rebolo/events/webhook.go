@@ -0,0 +1,152 @@
+package events
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Webhook is an HTTP endpoint events are POSTed to as JSON, with the
+// headers:
+//
+//	X-Rebolo-Event: todo.created
+//	X-Rebolo-Delivery: <event id, the same on retries>
+//	X-Rebolo-Signature: t=<unix time>,v1=<hex HMAC-SHA256 of "<t>.<body>">
+//
+// Deliveries that fail or don't get a 2xx are retried.
+type Webhook struct {
+	URL    string
+	Secret string   // signs deliveries, see VerifySignature
+	Events []string // patterns of the events sent, every event when empty
+	// Attempts is how many times a delivery is tried, 5 by default
+	Attempts int
+}
+
+// AddWebhook sends the events w subscribes to
+func (b *Bus) AddWebhook(w Webhook) {
+	if w.Attempts <= 0 {
+		w.Attempts = 5
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.webhooks = append(b.webhooks, &w)
+}
+
+// AddWebhook sends events of the Default bus to w
+func AddWebhook(w Webhook) {
+	Default.AddWebhook(w)
+}
+
+func (w *Webhook) wants(name string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, pattern := range w.Events {
+		if Match(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// deliver sends the event and schedules a retry when it fails
+func (b *Bus) deliver(w *Webhook, e Event, body []byte, attempt int) {
+	err := b.send(w, e, body)
+	if err == nil {
+		return
+	}
+	if attempt >= w.Attempts {
+		log.Printf("❌ Webhook %s of event %s failed after %d attempts: %v", w.URL, e.Name, attempt, err)
+		return
+	}
+
+	wait := b.Backoff
+	for i := 1; i < attempt; i++ {
+		wait *= 3
+	}
+	log.Printf("⚠️  Webhook %s of event %s failed, retrying in %s: %v", w.URL, e.Name, wait, err)
+	time.AfterFunc(wait, func() { b.deliver(w, e, body, attempt+1) })
+}
+
+func (b *Bus) send(w *Webhook, e Event, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Rebolo-Webhooks")
+	req.Header.Set("X-Rebolo-Event", e.Name)
+	req.Header.Set("X-Rebolo-Delivery", e.ID)
+	if w.Secret != "" {
+		req.Header.Set("X-Rebolo-Signature", Sign(w.Secret, time.Now(), body))
+	}
+
+	resp, err := b.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("got %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the X-Rebolo-Signature of body sent at t
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + signature(secret, ts, body)
+}
+
+func signature(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ErrInvalidSignature is returned by VerifySignature for deliveries that
+// weren't signed with the secret, or too long ago
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// VerifySignature checks the X-Rebolo-Signature header of a delivery, for
+// apps receiving webhooks. Deliveries signed more than tolerance ago are
+// refused, so a captured one can't be replayed; 0 skips the check.
+//
+//	body, _ := io.ReadAll(r.Body)
+//	err := events.VerifySignature(secret, r.Header.Get("X-Rebolo-Signature"), body, 5*time.Minute)
+func VerifySignature(secret, header string, body []byte, tolerance time.Duration) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			sig = value
+		}
+	}
+	sent, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(sig), []byte(signature(secret, ts, body))) {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 {
+		if age := time.Since(time.Unix(sent, 0)); age > tolerance || age < -tolerance {
+			return ErrInvalidSignature
+		}
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+// Package experiment runs A/B tests without an external service. Each
+// user, or anonymous session, always gets the same variant of an
+// experiment, and exposures and conversions are published on the event
+// bus for handlers or webhooks to record:
+//
+//	var checkoutButton = experiment.New("checkout_button", "control", "green")
+//
+//	func (pc *ProductController) Show(c *rebolo.Context) error {
+//		return c.Render("products/show.html", map[string]interface{}{
+//			"Button": checkoutButton.Assign(c),
+//		})
+//	}
+//
+//	// Once the order is placed
+//	checkoutButton.Convert(c, "purchase")
+package experiment
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/events"
+)
+
+// Events published on events.Default
+const (
+	ExposureEvent   = "experiment.exposure"   // A subject saw a variant for the first time, data is an Exposure
+	ConversionEvent = "experiment.conversion" // A subject reached a goal, data is a Conversion
+)
+
+// subjectKey is the session value identifying anonymous subjects
+const subjectKey = "experiment_subject"
+
+// Variant is a version of the experiment
+type Variant struct {
+	Name   string
+	Weight int // Share of the subjects relative to the other variants, 1 when 0
+}
+
+// Experiment splits subjects between its variants, the first being the
+// control, which subjects get when their variant can't be stored
+type Experiment struct {
+	Name     string
+	Variants []Variant
+	// UserKey is the session value holding the signed in user's ID,
+	// "user_id" when empty. Users keep their variant across sessions and
+	// devices, anonymous subjects keep it for the session.
+	UserKey string
+}
+
+// Exposure is the data of an ExposureEvent
+type Exposure struct {
+	Experiment string `json:"experiment"`
+	Variant    string `json:"variant"`
+	Subject    string `json:"subject"`
+}
+
+// Conversion is the data of a ConversionEvent
+type Conversion struct {
+	Experiment string `json:"experiment"`
+	Variant    string `json:"variant"`
+	Subject    string `json:"subject"`
+	Goal       string `json:"goal"`
+}
+
+// New creates an experiment splitting subjects evenly between variants,
+// "control" and "treatment" when none are given
+func New(name string, variants ...string) *Experiment {
+	if len(variants) == 0 {
+		variants = []string{"control", "treatment"}
+	}
+	e := &Experiment{Name: name}
+	for _, v := range variants {
+		e.Variants = append(e.Variants, Variant{Name: v, Weight: 1})
+	}
+	return e
+}
+
+// Weighted creates an experiment giving each variant its weight's share
+// of the subjects, e.g. a 90/10 split:
+//
+//	experiment.Weighted("new_search", experiment.Variant{Name: "control", Weight: 9}, experiment.Variant{Name: "new", Weight: 1})
+func Weighted(name string, variants ...Variant) *Experiment {
+	return &Experiment{Name: name, Variants: variants}
+}
+
+// Variant returns the variant of subject, the same every time for the
+// same experiment and subject
+func (e *Experiment) Variant(subject string) string {
+	if len(e.Variants) == 0 {
+		return ""
+	}
+	total := 0
+	for _, v := range e.Variants {
+		total += weight(v)
+	}
+	sum := sha256.Sum256([]byte(e.Name + ":" + subject))
+	n := int(binary.BigEndian.Uint64(sum[:8]) % uint64(total))
+	for _, v := range e.Variants {
+		if n < weight(v) {
+			return v.Name
+		}
+		n -= weight(v)
+	}
+	return e.Variants[0].Name
+}
+
+// Assign returns the variant of the request's subject: its signed in
+// user, or its session. The variant is kept in the session, so it doesn't
+// change when an anonymous visitor signs in, and the first assignment
+// publishes an ExposureEvent. Call it before writing the response, the
+// session is saved.
+func (e *Experiment) Assign(c *rebolo.Context) string {
+	if len(e.Variants) == 0 {
+		return ""
+	}
+	sess, err := c.Session()
+	if err != nil {
+		log.Printf("⚠️  Experiment %s: %v, showing %s", e.Name, err, e.Variants[0].Name)
+		return e.Variants[0].Name
+	}
+	if variant := sess.GetString(e.sessionKey()); e.has(variant) {
+		return variant
+	}
+
+	subject := e.subject(sess.Get(e.userKey()))
+	if subject == "" {
+		if subject = sess.GetString(subjectKey); subject == "" {
+			subject = "session:" + randomID()
+			sess.Set(subjectKey, subject)
+		}
+	}
+	variant := e.Variant(subject)
+	sess.Set(e.sessionKey(), variant)
+	sess.Set(e.sessionKey()+".subject", subject)
+	if err := sess.Save(); err != nil {
+		log.Printf("⚠️  Experiment %s: %v, showing %s", e.Name, err, e.Variants[0].Name)
+		return e.Variants[0].Name
+	}
+
+	exposure := Exposure{Experiment: e.Name, Variant: variant, Subject: subject}
+	if err := events.PublishContext(c.Context(), ExposureEvent, exposure); err != nil {
+		log.Printf("⚠️  Experiment %s: publishing exposure: %v", e.Name, err)
+	}
+	return variant
+}
+
+// Convert records that the request's subject reached goal, publishing a
+// ConversionEvent. Subjects that were never assigned a variant aren't in
+// the experiment, nothing is recorded for them.
+func (e *Experiment) Convert(c *rebolo.Context, goal string) error {
+	sess, err := c.Session()
+	if err != nil {
+		return err
+	}
+	variant := sess.GetString(e.sessionKey())
+	if !e.has(variant) {
+		return nil
+	}
+	// The subject of the exposure, even if the visitor signed in since
+	return e.publish(c.Context(), sess.GetString(e.sessionKey()+".subject"), variant, goal)
+}
+
+// ConvertUser records that a user reached goal outside a request, like in
+// a job once a payment clears. The variant is the user's bucket, which
+// is the one they saw unless they were assigned one before signing in:
+// those conversions are only recorded by Convert.
+func (e *Experiment) ConvertUser(ctx context.Context, userID interface{}, goal string) error {
+	subject := e.subject(userID)
+	return e.publish(ctx, subject, e.Variant(subject), goal)
+}
+
+func (e *Experiment) publish(ctx context.Context, subject, variant, goal string) error {
+	return events.PublishContext(ctx, ConversionEvent, Conversion{
+		Experiment: e.Name,
+		Variant:    variant,
+		Subject:    subject,
+		Goal:       goal,
+	})
+}
+
+// subject is the subject of a user ID, empty when there is none
+func (e *Experiment) subject(userID interface{}) string {
+	if userID == nil || userID == "" {
+		return ""
+	}
+	return fmt.Sprintf("user:%v", userID)
+}
+
+func (e *Experiment) has(variant string) bool {
+	for _, v := range e.Variants {
+		if v.Name == variant {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Experiment) sessionKey() string {
+	return "experiment." + e.Name
+}
+
+func (e *Experiment) userKey() string {
+	if e.UserKey == "" {
+		return "user_id"
+	}
+	return e.UserKey
+}
+
+func weight(v Variant) int {
+	if v.Weight <= 0 {
+		return 1
+	}
+	return v.Weight
+}
+
+func randomID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
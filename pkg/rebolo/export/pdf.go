@@ -0,0 +1,131 @@
+// Package export turns pages into PDF documents and rows into Excel
+// spreadsheets, for the download buttons of CRUD apps. Handlers use them
+// through ctx.PDF and ctx.XLSX.
+//
+// PDFs are printed by headless Chrome or Chromium, which renders the page
+// like the browser does, CSS included. Spreadsheets are written by the
+// package itself.
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ChromeEnv is the environment variable naming the Chrome binary, when it
+// isn't one of ChromeBinaries on the PATH
+const ChromeEnv = "CHROME_PATH"
+
+// ChromeBinaries are the names Chrome is looked for under on the PATH
+var ChromeBinaries = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "chrome"}
+
+// PDFTimeout is how long printing a PDF may take when ctx has no deadline
+var PDFTimeout = 30 * time.Second
+
+// ErrNoChrome is returned by PDF when Chrome can't be found
+var ErrNoChrome = errors.New("export: PDFs need Chrome or Chromium, install it or set " + ChromeEnv)
+
+// PDFOptions configures PDF
+type PDFOptions struct {
+	// BaseURL resolves the page's relative links, like its stylesheets
+	// and images, usually the application's URL. The page is printed from
+	// a file, so without it they don't load. Chrome fetches what the page
+	// links to, never take it from the request.
+	BaseURL string
+	// NoSandbox runs Chrome without its sandbox, which it needs to start
+	// as root, as in most containers. Only print pages you trust with it.
+	NoSandbox bool
+}
+
+var headTag = regexp.MustCompile(`(?i)<head(\s[^>]*)?>`)
+
+// PDF prints an HTML page to a PDF. Its size and margins are set with
+// CSS, like @page { size: A4; margin: 2cm }.
+func PDF(ctx context.Context, page []byte, opts ...PDFOptions) ([]byte, error) {
+	chrome, err := findChrome()
+	if err != nil {
+		return nil, err
+	}
+	var o PDFOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.BaseURL != "" && !bytes.Contains(bytes.ToLower(page), []byte("<base ")) {
+		page = withBase(page, o.BaseURL)
+	}
+
+	dir, err := os.MkdirTemp("", "rebolo-pdf-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	file, out := filepath.Join(dir, "page.html"), filepath.Join(dir, "page.pdf")
+	if err := os.WriteFile(file, page, 0o600); err != nil {
+		return nil, err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, PDFTimeout)
+		defer cancel()
+	}
+	args := []string{
+		"--headless",
+		"--disable-gpu",
+		"--no-first-run",
+		"--user-data-dir=" + filepath.Join(dir, "profile"),
+		"--no-pdf-header-footer",
+		"--print-to-pdf-no-header", // Chrome before 111
+		"--print-to-pdf=" + out,
+	}
+	if o.NoSandbox {
+		args = append(args, "--no-sandbox")
+	}
+	args = append(args, "file://"+filepath.ToSlash(file))
+
+	cmd := exec.CommandContext(ctx, chrome, args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("export: printing PDF: %w", ctx.Err())
+		}
+		if os.Geteuid() == 0 && !o.NoSandbox {
+			return nil, fmt.Errorf("export: printing PDF: %v: Chrome doesn't run as root with its sandbox, see PDFOptions.NoSandbox: %s", err, strings.TrimSpace(string(output)))
+		}
+		return nil, fmt.Errorf("export: printing PDF: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	pdf, err := os.ReadFile(out)
+	if err != nil {
+		return nil, fmt.Errorf("export: Chrome didn't print the PDF: %w", err)
+	}
+	return pdf, nil
+}
+
+func findChrome() (string, error) {
+	if path := os.Getenv(ChromeEnv); path != "" {
+		return path, nil
+	}
+	for _, name := range ChromeBinaries {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", ErrNoChrome
+}
+
+// withBase adds a <base> tag for baseURL at the start of the page's head
+func withBase(page []byte, baseURL string) []byte {
+	base := []byte(`<base href="` + html.EscapeString(strings.TrimRight(baseURL, "/")) + `/">`)
+	if loc := headTag.FindIndex(page); loc != nil {
+		return append(append(append([]byte{}, page[:loc[1]]...), base...), page[loc[1]:]...)
+	}
+	return append(base, page...)
+}
@@ -0,0 +1,293 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"database/sql/driver"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Styles of styles.xml, by index in cellXfs
+const (
+	styleNone     = 0
+	styleDateTime = 1
+	styleDate     = 2
+	styleHeader   = 3
+)
+
+// excelEpoch is day 0 of Excel's dates, before its 1900 leap year bug
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// XLSX writes rows as an Excel spreadsheet of one sheet. Rows are a slice
+// of slices, one cell per item, or a slice of structs, whose exported
+// fields become columns under a bold header row. The header is the field
+// name, or its xlsx tag; xlsx:"-" leaves the field out:
+//
+//	type orderRow struct {
+//		ID       int64     `xlsx:"Order"`
+//		Customer string
+//		Total    float64
+//		PlacedAt time.Time `xlsx:"Placed"`
+//		Token    string    `xlsx:"-"`
+//	}
+//
+// Numbers, booleans and times keep their type, so they sort and sum in
+// Excel. Other values are written as text.
+func XLSX(w io.Writer, rows interface{}) error {
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return fmt.Errorf("export: XLSX needs a slice of rows, got %T", rows)
+	}
+
+	z := zip.NewWriter(w)
+	for _, part := range [][2]string{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", relsXML},
+		{"xl/workbook.xml", workbookXML},
+		{"xl/_rels/workbook.xml.rels", workbookRelsXML},
+		{"xl/styles.xml", stylesXML},
+	} {
+		f, err := z.Create(part[0])
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, xml.Header+part[1]); err != nil {
+			return err
+		}
+	}
+	f, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	if err := writeSheet(f, v); err != nil {
+		return err
+	}
+	return z.Close()
+}
+
+func writeSheet(w io.Writer, rows reflect.Value) error {
+	b := bufio.NewWriter(w)
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+
+	var fields []reflect.StructField
+	if typ := elemType(rows.Type().Elem()); typ.Kind() == reflect.Struct {
+		fields = columns(typ)
+		// Keep the header in view when scrolling
+		b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	}
+	b.WriteString(`<sheetData>`)
+
+	row := 0
+	if fields != nil {
+		row++
+		b.WriteString(`<row r="1">`)
+		for i, field := range fields {
+			name := field.Name
+			if tag := field.Tag.Get("xlsx"); tag != "" {
+				name = tag
+			}
+			writeCell(b, cellRef(i, row), name, styleHeader)
+		}
+		b.WriteString(`</row>`)
+	}
+
+	for i := 0; i < rows.Len(); i++ {
+		item := rows.Index(i)
+		for item.Kind() == reflect.Interface || item.Kind() == reflect.Ptr {
+			if item.IsNil() {
+				break
+			}
+			item = item.Elem()
+		}
+		row++
+		b.WriteString(`<row r="` + strconv.Itoa(row) + `">`)
+		switch {
+		case fields != nil && item.Kind() == reflect.Struct:
+			for col, field := range fields {
+				// Fails for fields of nil embedded pointers, left empty
+				if value, err := item.FieldByIndexErr(field.Index); err == nil {
+					writeCell(b, cellRef(col, row), value.Interface(), styleNone)
+				}
+			}
+		case item.Kind() == reflect.Slice || item.Kind() == reflect.Array:
+			if item.Type().Elem().Kind() == reflect.Uint8 {
+				writeCell(b, cellRef(0, row), item.Interface(), styleNone) // []byte is text
+				break
+			}
+			for col := 0; col < item.Len(); col++ {
+				writeCell(b, cellRef(col, row), item.Index(col).Interface(), styleNone)
+			}
+		case item.IsValid() && !(item.Kind() == reflect.Ptr || item.Kind() == reflect.Interface):
+			writeCell(b, cellRef(0, row), item.Interface(), styleNone)
+		}
+		b.WriteString(`</row>`)
+	}
+
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.Flush()
+}
+
+func elemType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// columns returns the exported fields of t written as columns
+func columns(t reflect.Type) []reflect.StructField {
+	fields := []reflect.StructField{}
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous || field.Tag.Get("xlsx") == "-" {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// cellRef returns the A1 reference of a zero based column and a row
+func cellRef(col, row int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name + strconv.Itoa(row)
+}
+
+// writeCell writes v as a cell of the type Excel has for it, nothing for
+// nil, empty strings and zero times
+func writeCell(b *bufio.Writer, ref string, v interface{}, style int) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return
+	}
+	switch value := v.(type) {
+	case nil:
+		return
+	case time.Time:
+		if value.IsZero() {
+			return
+		}
+		// Excel has no timezones, the time is shown as the clock read it
+		wall := time.Date(value.Year(), value.Month(), value.Day(), value.Hour(), value.Minute(), value.Second(), value.Nanosecond(), time.UTC)
+		style := styleDateTime
+		if wall.Equal(wall.Truncate(24 * time.Hour)) {
+			style = styleDate
+		}
+		days := wall.Sub(excelEpoch).Hours() / 24
+		b.WriteString(`<c r="` + ref + `" s="` + strconv.Itoa(style) + `"><v>` + strconv.FormatFloat(days, 'f', -1, 64) + `</v></c>`)
+		return
+	case fmt.Stringer:
+		writeText(b, ref, value.String(), style)
+		return
+	case driver.Valuer:
+		// sql.NullString, sql.NullTime and the like
+		dv, err := value.Value()
+		if err != nil {
+			writeText(b, ref, err.Error(), style)
+			return
+		}
+		writeCell(b, ref, dv, style)
+		return
+	case []byte:
+		writeText(b, ref, string(value), style)
+		return
+	case error:
+		writeText(b, ref, value.Error(), style)
+		return
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		writeCell(b, ref, rv.Elem().Interface(), style)
+	case reflect.Bool:
+		value := "0"
+		if rv.Bool() {
+			value = "1"
+		}
+		b.WriteString(`<c r="` + ref + `" t="b"><v>` + value + `</v></c>`)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.WriteString(`<c r="` + ref + `"><v>` + strconv.FormatInt(rv.Int(), 10) + `</v></c>`)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		b.WriteString(`<c r="` + ref + `"><v>` + strconv.FormatUint(rv.Uint(), 10) + `</v></c>`)
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			writeText(b, ref, strconv.FormatFloat(f, 'g', -1, 64), style)
+			return
+		}
+		b.WriteString(`<c r="` + ref + `"><v>` + strconv.FormatFloat(f, 'g', -1, 64) + `</v></c>`)
+	case reflect.String:
+		writeText(b, ref, rv.String(), style)
+	default:
+		writeText(b, ref, fmt.Sprint(v), style)
+	}
+}
+
+// writeText writes an inline string cell. Excel never reads those as
+// formulas, so values like "=HYPERLINK(...)" stay text.
+func writeText(b *bufio.Writer, ref, text string, style int) {
+	if text == "" {
+		return
+	}
+	b.WriteString(`<c r="` + ref + `" t="inlineStr"`)
+	if style != styleNone {
+		b.WriteString(` s="` + strconv.Itoa(style) + `"`)
+	}
+	b.WriteString(`><is><t xml:space="preserve">`)
+	xml.EscapeText(b, []byte(strings.Map(xmlChar, text)))
+	b.WriteString(`</t></is></c>`)
+}
+
+// xmlChar drops the characters XML 1.0 can't hold
+func xmlChar(r rune) rune {
+	if r == '\t' || r == '\n' || r == '\r' || r >= 0x20 && r != 0xFFFE && r != 0xFFFF {
+		return r
+	}
+	return -1
+}
+
+const contentTypesXML = `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const relsXML = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const workbookXML = `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>` +
+	`</workbook>`
+
+const workbookRelsXML = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// stylesXML has the cellXfs of the style constants: none, date and time
+// (format 22), date (format 14) and bold
+const stylesXML = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="22" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="14" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`</cellXfs>` +
+	`</styleSheet>`
@@ -0,0 +1,298 @@
+package form
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/inflect"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/middleware"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/validation"
+)
+
+// Form binds a struct to the form helpers of templates: inputs are filled
+// with its field values, validation errors are shown next to them and the
+// CSRF token is added to the form.
+//
+//	c.App.RenderHTML(w, "posts/edit.html", map[string]interface{}{
+//		"Post": post,
+//		"Form": form.New(r, post).WithErrors(err),
+//	})
+type Form struct {
+	Model     interface{}
+	Errors    map[string][]string // messages by struct field name
+	CSRFToken string
+}
+
+// Option is a choice of selectField
+type Option struct {
+	Value string
+	Label string
+}
+
+// New creates a form for model, a struct or a pointer to one (nil for an
+// empty form). The CSRF token comes from middleware.CSRFMiddleware.
+func New(r *http.Request, model interface{}) *Form {
+	f := &Form{Model: model, Errors: make(map[string][]string)}
+	if r != nil {
+		f.CSRFToken = middleware.CSRFToken(r)
+	}
+	return f
+}
+
+// WithErrors shows the validation errors returned by BindAndValidate or
+// ValidateStruct next to their fields. Other errors are ignored.
+func (f *Form) WithErrors(err error) *Form {
+	if errs, ok := err.(validation.ValidationErrors); ok {
+		for _, e := range errs {
+			f.Errors[e.Field] = append(f.Errors[e.Field], e.Message)
+		}
+	}
+	return f
+}
+
+// Value returns the value of a struct field, nil if there is none
+func (f *Form) Value(field string) interface{} {
+	v := reflect.ValueOf(f.Model)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if fv := v.FieldByName(field); fv.IsValid() && fv.CanInterface() {
+			return fv.Interface()
+		}
+	case reflect.Map:
+		if fv := v.MapIndex(reflect.ValueOf(field)); fv.IsValid() {
+			return fv.Interface()
+		}
+	}
+	return nil
+}
+
+// Name returns the input name of a struct field: its form tag, its json
+// tag or the field name in snake_case, as the binder expects
+func (f *Form) Name(field string) string {
+	t := reflect.TypeOf(f.Model)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t != nil && t.Kind() == reflect.Struct {
+		if sf, ok := t.FieldByName(field); ok {
+			for _, key := range []string{"form", "json"} {
+				if name, _, _ := strings.Cut(sf.Tag.Get(key), ","); name != "" && name != "-" {
+					return name
+				}
+			}
+		}
+	}
+	return inflect.Underscore(field)
+}
+
+// FuncMap returns the template helpers, registered by the HTML renderer:
+//
+//	{{formFor .Form "/posts"}} ... </form>
+//	{{formFor .Form (print "/posts/" .Post.ID) "PUT"}}
+//	{{textField .Form "Title"}}  {{textField .Form "Email" "email"}}
+//	{{textArea .Form "Body"}}  {{checkBox .Form "Published"}}
+//	{{selectField .Form "Status" .Statuses}}  {{errorsFor .Form "Title"}}
+//	{{csrfField .Form}}
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"formFor":     formFor,
+		"textField":   textField,
+		"textArea":    textArea,
+		"checkBox":    checkBox,
+		"selectField": selectField,
+		"errorsFor":   errorsFor,
+		"csrfField":   csrfField,
+	}
+}
+
+// formFor opens a form posting to action. Methods other than GET and POST
+// are sent in the _method field, for the MethodOverride middleware.
+func formFor(f *Form, action string, method ...string) template.HTML {
+	f = orEmpty(f)
+	m := http.MethodPost
+	if len(method) > 0 && method[0] != "" {
+		m = strings.ToUpper(method[0])
+	}
+
+	var b strings.Builder
+	formMethod := "POST"
+	if m == http.MethodGet {
+		formMethod = "GET"
+	}
+	fmt.Fprintf(&b, `<form method="%s" action="%s">`, formMethod, html.EscapeString(action))
+	if m != http.MethodGet && m != http.MethodPost {
+		fmt.Fprintf(&b, `<input type="hidden" name="_method" value="%s">`, html.EscapeString(m))
+	}
+	b.WriteString(string(csrfField(f)))
+	return template.HTML(b.String())
+}
+
+// csrfField renders the hidden CSRF token input, from a *Form or a token
+func csrfField(source interface{}) template.HTML {
+	token := ""
+	switch s := source.(type) {
+	case *Form:
+		if s != nil {
+			token = s.CSRFToken
+		}
+	case string:
+		token = s
+	}
+	if token == "" {
+		return ""
+	}
+	return template.HTML(fmt.Sprintf(`<input type="hidden" name="%s" value="%s">`, middleware.CSRFFieldName, html.EscapeString(token)))
+}
+
+// textField renders a labelled input, of type text unless given
+func textField(f *Form, field string, inputType ...string) template.HTML {
+	f = orEmpty(f)
+	t := "text"
+	if len(inputType) > 0 && inputType[0] != "" {
+		t = inputType[0]
+	}
+	value := formatValue(f.Value(field), t)
+	name := html.EscapeString(f.Name(field))
+	input := fmt.Sprintf(`<input type="%s" id="%s" name="%s" value="%s">`,
+		html.EscapeString(t), name, name, html.EscapeString(value))
+	return f.group(field, label(f, field)+input)
+}
+
+// textArea renders a labelled textarea
+func textArea(f *Form, field string) template.HTML {
+	f = orEmpty(f)
+	value := ""
+	if v := f.Value(field); v != nil {
+		value = fmt.Sprint(v)
+	}
+	name := html.EscapeString(f.Name(field))
+	input := fmt.Sprintf(`<textarea id="%s" name="%s" rows="4">%s</textarea>`,
+		name, name, html.EscapeString(value))
+	return f.group(field, label(f, field)+input)
+}
+
+// checkBox renders a checkbox sending "true", checked when the field is true
+func checkBox(f *Form, field string) template.HTML {
+	f = orEmpty(f)
+	checked := ""
+	if v, ok := f.Value(field).(bool); ok && v {
+		checked = " checked"
+	}
+	name := html.EscapeString(f.Name(field))
+	input := fmt.Sprintf(`<label><input type="checkbox" id="%s" name="%s" value="true"%s> %s</label>`,
+		name, name, checked, html.EscapeString(inflect.Humanize(field)))
+	return f.group(field, input)
+}
+
+// selectField renders a labelled select. options is a []string, a
+// []Option or a map of values to labels.
+func selectField(f *Form, field string, options interface{}) template.HTML {
+	f = orEmpty(f)
+	current := ""
+	if v := f.Value(field); v != nil {
+		current = fmt.Sprint(v)
+	}
+
+	var b strings.Builder
+	name := html.EscapeString(f.Name(field))
+	fmt.Fprintf(&b, `<select id="%s" name="%s">`, name, name)
+	for _, o := range toOptions(options) {
+		selected := ""
+		if o.Value == current {
+			selected = " selected"
+		}
+		fmt.Fprintf(&b, `<option value="%s"%s>%s</option>`, html.EscapeString(o.Value), selected, html.EscapeString(o.Label))
+	}
+	b.WriteString(`</select>`)
+	return f.group(field, label(f, field)+b.String())
+}
+
+// errorsFor renders the validation errors of a field, nothing if it has none
+func errorsFor(f *Form, field string) template.HTML {
+	f = orEmpty(f)
+	var b strings.Builder
+	for _, message := range f.Errors[field] {
+		fmt.Fprintf(&b, `<div class="field-error">%s</div>`, html.EscapeString(message))
+	}
+	return template.HTML(b.String())
+}
+
+// formatValue formats a field value for an input of type inputType,
+// times in the format date and time inputs expect
+func formatValue(v interface{}, inputType string) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		switch inputType {
+		case "date":
+			return v.Format("2006-01-02")
+		case "time":
+			return v.Format("15:04")
+		case "datetime-local":
+			return v.Format("2006-01-02T15:04")
+		}
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
+
+// orEmpty lets the helpers render empty inputs when a template gets no form
+func orEmpty(f *Form) *Form {
+	if f == nil {
+		return &Form{}
+	}
+	return f
+}
+
+func (f *Form) group(field, content string) template.HTML {
+	class := "form-group"
+	if len(f.Errors[field]) > 0 {
+		class += " has-error"
+	}
+	return template.HTML(fmt.Sprintf(`<div class="%s">%s%s</div>`, class, content, errorsFor(f, field)))
+}
+
+func label(f *Form, field string) string {
+	return fmt.Sprintf(`<label for="%s">%s:</label>`, html.EscapeString(f.Name(field)), html.EscapeString(inflect.Humanize(field)))
+}
+
+func toOptions(options interface{}) []Option {
+	switch o := options.(type) {
+	case []Option:
+		return o
+	case []string:
+		result := make([]Option, len(o))
+		for i, value := range o {
+			result[i] = Option{Value: value, Label: value}
+		}
+		return result
+	case map[string]string:
+		values := make([]string, 0, len(o))
+		for value := range o {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+		result := make([]Option, len(values))
+		for i, value := range values {
+			result[i] = Option{Value: value, Label: o[value]}
+		}
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+// Package geo holds points and polygons kept in PostGIS or SpatiaLite
+// geometry columns, and builds the SQL finding rows near a point or in a
+// box:
+//
+//	type Place struct {
+//		ID       int64
+//		Name     string
+//		Location geo.Point
+//	}
+//
+//	near, args := geo.Within("location", geo.Point{Lat: 4.65, Lng: -74.06}, 2000)
+//	rows, err := db.QueryContext(ctx, "SELECT id, name, location FROM places WHERE "+near, args...)
+//
+// Coordinates are WGS 84 latitudes and longitudes (SRID 4326), distances
+// are in meters. Set database.spatial in config.yml to load the extension.
+package geo
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/schema"
+)
+
+// SRID is the spatial reference system of the coordinates, WGS 84 as used
+// by GPS and web maps
+const SRID = 4326
+
+// earthRadius is the mean radius of the Earth in meters
+const earthRadius = 6371008.8
+
+// Point is a location. The zero Point, (0, 0) being the usual sign of a
+// missing location, is stored as NULL.
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Polygon is an area: its outer ring first, then the rings of its holes.
+// Rings are closed when stored, their last point being their first.
+type Polygon [][]Point
+
+// Box is the area between two corners, like the bounds of a web map
+type Box struct {
+	SouthWest Point
+	NorthEast Point
+}
+
+var driverName atomic.Value
+
+// SetDriver sets the database the values and SQL are for, the application
+// sets it from database.driver. Values are PostGIS ones unless it is
+// sqlite, for SpatiaLite.
+func SetDriver(name string) {
+	driverName.Store(schema.NormalizeDriver(name))
+}
+
+func spatiaLite() bool {
+	name, _ := driverName.Load().(string)
+	return name == "sqlite"
+}
+
+// ParsePoint reads a point as "lat,lng", the way maps show them, or as
+// WKT like "POINT(-74.06 4.65)". An empty string is the zero Point.
+func ParsePoint(s string) (Point, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Point{}, nil
+	}
+	if isWKT(s) {
+		g, err := parseWKT(s)
+		if err != nil {
+			return Point{}, err
+		}
+		if g.kind != wkbPoint {
+			return Point{}, fmt.Errorf("geo: %q is not a point", s)
+		}
+		p := g.rings[0][0]
+		return p, p.check()
+	}
+
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return Point{}, fmt.Errorf("geo: invalid point %q, want \"lat,lng\"", s)
+	}
+	lat, err1 := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	lng, err2 := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err1 != nil || err2 != nil {
+		return Point{}, fmt.Errorf("geo: invalid point %q, want \"lat,lng\"", s)
+	}
+	p := Point{Lat: lat, Lng: lng}
+	return p, p.check()
+}
+
+// IsZero reports whether p is the zero Point, stored as NULL
+func (p Point) IsZero() bool {
+	return p == Point{}
+}
+
+// String returns p as "lat, lng", what ParsePoint reads, and the zero
+// Point as ""
+func (p Point) String() string {
+	if p.IsZero() {
+		return ""
+	}
+	return formatFloat(p.Lat) + ", " + formatFloat(p.Lng)
+}
+
+// DistanceTo returns the great-circle distance to q in meters
+func (p Point) DistanceTo(q Point) float64 {
+	lat1, lat2 := radians(p.Lat), radians(q.Lat)
+	dLat, dLng := lat2-lat1, radians(q.Lng-p.Lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func (p Point) check() error {
+	if math.Abs(p.Lat) > 90 || math.Abs(p.Lng) > 180 || math.IsNaN(p.Lat) || math.IsNaN(p.Lng) {
+		return fmt.Errorf("geo: %s is not a valid latitude and longitude", p)
+	}
+	return nil
+}
+
+// Scan reads a geometry column holding a point: hex or binary (E)WKB
+// from PostGIS, a SpatiaLite blob, or WKT
+func (p *Point) Scan(src interface{}) error {
+	if src == nil {
+		*p = Point{}
+		return nil
+	}
+	g, err := decode(src)
+	if err != nil {
+		return err
+	}
+	*p, err = g.point()
+	return err
+}
+
+// Value stores p in a geometry column, NULL for the zero Point
+func (p Point) Value() (driver.Value, error) {
+	if p.IsZero() {
+		return nil, nil
+	}
+	return encode(geometry{kind: wkbPoint, rings: [][]Point{{p}}}), nil
+}
+
+// ParsePolygon reads a polygon as WKT, like
+// "POLYGON((-74.1 4.6, -74 4.6, -74 4.7, -74.1 4.6))". An empty string is
+// a nil Polygon.
+func ParsePolygon(s string) (Polygon, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	g, err := parseWKT(s)
+	if err != nil {
+		return nil, err
+	}
+	if g.kind != wkbPolygon {
+		return nil, fmt.Errorf("geo: %q is not a polygon", s)
+	}
+	for _, ring := range g.rings {
+		for _, p := range ring {
+			if err := p.check(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return Polygon(g.rings).closed(), nil
+}
+
+// String returns the polygon as WKT, what ParsePolygon reads, and a nil
+// Polygon as ""
+func (pg Polygon) String() string {
+	if len(pg) == 0 {
+		return ""
+	}
+	return wkt(geometry{kind: wkbPolygon, rings: pg.closed()})
+}
+
+// Contains reports whether p is inside the outer ring and outside the holes
+func (pg Polygon) Contains(p Point) bool {
+	if len(pg) == 0 || !inRing(pg[0], p) {
+		return false
+	}
+	for _, hole := range pg[1:] {
+		if inRing(hole, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// Scan reads a geometry column holding a polygon, see Point.Scan
+func (pg *Polygon) Scan(src interface{}) error {
+	if src == nil {
+		*pg = nil
+		return nil
+	}
+	g, err := decode(src)
+	if err != nil {
+		return err
+	}
+	if g.kind != wkbPolygon {
+		return fmt.Errorf("geo: cannot scan a %s into a Polygon", kindName(g.kind))
+	}
+	*pg = g.rings
+	return nil
+}
+
+// Value stores the polygon in a geometry column, NULL when nil
+func (pg Polygon) Value() (driver.Value, error) {
+	if len(pg) == 0 {
+		return nil, nil
+	}
+	return encode(geometry{kind: wkbPolygon, rings: pg.closed()}), nil
+}
+
+// closed returns the polygon with the last point of each ring back on its
+// first
+func (pg Polygon) closed() Polygon {
+	rings := make(Polygon, len(pg))
+	for i, ring := range pg {
+		if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+			ring = append(append([]Point(nil), ring...), ring[0])
+		}
+		rings[i] = ring
+	}
+	return rings
+}
+
+// ParseBox reads a box as "west,south,east,north", the bbox of OGC
+// services and Leaflet's LatLngBounds.toBBoxString()
+func ParseBox(s string) (Box, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return Box{}, fmt.Errorf("geo: invalid box %q, want \"west,south,east,north\"", s)
+	}
+	var v [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return Box{}, fmt.Errorf("geo: invalid box %q, want \"west,south,east,north\"", s)
+		}
+		v[i] = f
+	}
+	b := Box{SouthWest: Point{Lat: v[1], Lng: v[0]}, NorthEast: Point{Lat: v[3], Lng: v[2]}}
+	if err := b.SouthWest.check(); err != nil {
+		return Box{}, err
+	}
+	if err := b.NorthEast.check(); err != nil {
+		return Box{}, err
+	}
+	return b, nil
+}
+
+// Contains reports whether p is inside the box
+func (b Box) Contains(p Point) bool {
+	return p.Lat >= b.SouthWest.Lat && p.Lat <= b.NorthEast.Lat &&
+		p.Lng >= b.SouthWest.Lng && p.Lng <= b.NorthEast.Lng
+}
+
+// inRing reports whether p is inside ring, by counting the edges a ray
+// from p crosses
+func inRing(ring []Point, p Point) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lng < (b.Lng-a.Lng)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
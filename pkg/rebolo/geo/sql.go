@@ -0,0 +1,49 @@
+package geo
+
+import "strconv"
+
+// Within returns the condition matching the rows whose geometry in column
+// is at most meters away from p, with ? placeholders:
+//
+//	near, args := geo.Within("location", here, 5000)
+//	stmt := "SELECT id, name FROM places WHERE " + near
+func Within(column string, p Point, meters float64) (string, []interface{}) {
+	if spatiaLite() {
+		return "PtDistWithin(" + column + ", " + makePoint() + ", ?)", []interface{}{p.Lng, p.Lat, meters}
+	}
+	return "ST_DWithin(" + column + "::geography, " + makePoint() + "::geography, ?)", []interface{}{p.Lng, p.Lat, meters}
+}
+
+// Distance returns the expression of the distance in meters between the
+// geometry in column and p, to select or sort by:
+//
+//	distance, args := geo.Distance("location", here)
+//	stmt := "SELECT id, name, " + distance + " AS meters FROM places ORDER BY meters LIMIT 10"
+func Distance(column string, p Point) (string, []interface{}) {
+	if spatiaLite() {
+		return "ST_Distance(" + column + ", " + makePoint() + ", 1)", []interface{}{p.Lng, p.Lat}
+	}
+	return "ST_Distance(" + column + "::geography, " + makePoint() + "::geography)", []interface{}{p.Lng, p.Lat}
+}
+
+// InBox returns the condition matching the rows whose geometry in column
+// intersects b, the points inside it and the polygons overlapping it, like
+// the places to show on the part of a map in view
+func InBox(column string, b Box) (string, []interface{}) {
+	args := []interface{}{b.SouthWest.Lng, b.SouthWest.Lat, b.NorthEast.Lng, b.NorthEast.Lat}
+	if spatiaLite() {
+		return "ST_Intersects(" + column + ", BuildMbr(?, ?, ?, ?, " + srid + "))", args
+	}
+	return "ST_Intersects(" + column + ", ST_MakeEnvelope(?, ?, ?, ?, " + srid + "))", args
+}
+
+var srid = strconv.Itoa(SRID)
+
+// makePoint returns the SQL making a point of longitude and latitude
+// placeholders
+func makePoint() string {
+	if spatiaLite() {
+		return "MakePoint(?, ?, " + srid + ")"
+	}
+	return "ST_SetSRID(ST_MakePoint(?, ?), " + srid + ")"
+}
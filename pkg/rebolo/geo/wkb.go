@@ -0,0 +1,400 @@
+package geo
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Geometry types of WKB and SpatiaLite blobs
+const (
+	wkbPoint   = 1
+	wkbPolygon = 3
+)
+
+// Flags of the EWKB geometry types written by PostGIS
+const (
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+// Markers of SpatiaLite blobs
+const (
+	spatiaLiteStart  = 0x00
+	spatiaLiteMBREnd = 0x7C
+	spatiaLiteEnd    = 0xFE
+)
+
+// geometry is a decoded point or polygon, a point being one ring of one
+// point
+type geometry struct {
+	kind  uint32
+	rings [][]Point
+}
+
+func (g geometry) point() (Point, error) {
+	if g.kind != wkbPoint {
+		return Point{}, fmt.Errorf("geo: cannot scan a %s into a Point", kindName(g.kind))
+	}
+	return g.rings[0][0], nil
+}
+
+func kindName(kind uint32) string {
+	switch kind {
+	case 1:
+		return "point"
+	case 2:
+		return "linestring"
+	case 3:
+		return "polygon"
+	case 4:
+		return "multipoint"
+	case 5:
+		return "multilinestring"
+	case 6:
+		return "multipolygon"
+	case 7:
+		return "geometry collection"
+	}
+	return "geometry of type " + strconv.Itoa(int(kind))
+}
+
+// encode returns g as EWKT for PostGIS, or as a blob for SpatiaLite
+func encode(g geometry) interface{} {
+	if spatiaLite() {
+		return spatiaLiteBlob(g)
+	}
+	return "SRID=" + srid + ";" + wkt(g)
+}
+
+// decode reads a geometry from what the drivers return for a geometry
+// column: hex EWKB for PostGIS, a blob for SpatiaLite, or WKT from
+// ST_AsText
+func decode(src interface{}) (geometry, error) {
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return geometry{}, fmt.Errorf("geo: cannot scan %T into a geometry", src)
+	}
+
+	if text := strings.TrimSpace(string(data)); isWKT(text) {
+		return parseWKT(text)
+	}
+	if isHex(data) {
+		decoded := make([]byte, hex.DecodedLen(len(data)))
+		if _, err := hex.Decode(decoded, data); err != nil {
+			return geometry{}, fmt.Errorf("geo: invalid hex geometry: %w", err)
+		}
+		data = decoded
+	}
+	if isSpatiaLiteBlob(data) {
+		return readSpatiaLite(data)
+	}
+	return readWKB(data)
+}
+
+func isHex(data []byte) bool {
+	if len(data) == 0 || len(data)%2 != 0 {
+		return false
+	}
+	for _, c := range data {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// readWKB reads a point or polygon as WKB, or PostGIS' EWKB
+func readWKB(data []byte) (geometry, error) {
+	r := &reader{data: data}
+	r.byteOrder()
+	typ := r.uint32()
+	if typ&ewkbSRID != 0 {
+		r.uint32()
+	}
+	dims := 2
+	if typ&ewkbZ != 0 {
+		dims++
+	}
+	if typ&ewkbM != 0 {
+		dims++
+	}
+	typ &^= ewkbZ | ewkbM | ewkbSRID
+	// ISO WKB adds 1000 for Z, 2000 for M and 3000 for both
+	switch typ / 1000 {
+	case 1, 2:
+		dims++
+	case 3:
+		dims += 2
+	}
+	return r.geometry(typ%1000, dims)
+}
+
+func isSpatiaLiteBlob(data []byte) bool {
+	return len(data) > 43 && data[0] == spatiaLiteStart && data[1] <= 1 &&
+		data[38] == spatiaLiteMBREnd && data[len(data)-1] == spatiaLiteEnd
+}
+
+// readSpatiaLite reads a SpatiaLite blob: a header with the byte order,
+// SRID and bounding box, then the geometry's class and coordinates
+func readSpatiaLite(data []byte) (geometry, error) {
+	r := &reader{data: data[1 : len(data)-1]}
+	r.byteOrder()
+	r.skip(4 + 4*8 + 1) // SRID, bounding box and its end marker
+	class := r.uint32()
+	if class >= 1000000 {
+		return geometry{}, fmt.Errorf("geo: compressed SpatiaLite geometries aren't supported")
+	}
+	dims := 2
+	switch class / 1000 {
+	case 1, 2:
+		dims++
+	case 3:
+		dims += 2
+	}
+	return r.geometry(class%1000, dims)
+}
+
+// spatiaLiteBlob returns g as a little endian SpatiaLite blob
+func spatiaLiteBlob(g geometry) []byte {
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, ring := range g.rings {
+		for _, p := range ring {
+			minX, maxX = math.Min(minX, p.Lng), math.Max(maxX, p.Lng)
+			minY, maxY = math.Min(minY, p.Lat), math.Max(maxY, p.Lat)
+		}
+	}
+
+	le := binary.LittleEndian
+	b := []byte{spatiaLiteStart, 1}
+	b = le.AppendUint32(b, SRID)
+	for _, f := range []float64{minX, minY, maxX, maxY} {
+		b = le.AppendUint64(b, math.Float64bits(f))
+	}
+	b = append(b, spatiaLiteMBREnd)
+	b = le.AppendUint32(b, g.kind)
+	if g.kind == wkbPolygon {
+		b = le.AppendUint32(b, uint32(len(g.rings)))
+	}
+	for _, ring := range g.rings {
+		if g.kind == wkbPolygon {
+			b = le.AppendUint32(b, uint32(len(ring)))
+		}
+		for _, p := range ring {
+			b = le.AppendUint64(b, math.Float64bits(p.Lng))
+			b = le.AppendUint64(b, math.Float64bits(p.Lat))
+		}
+	}
+	return append(b, spatiaLiteEnd)
+}
+
+// reader reads the binary geometries, keeping the first error
+type reader struct {
+	data  []byte
+	order binary.ByteOrder
+	err   error
+}
+
+func (r *reader) skip(n int) {
+	if r.err == nil && len(r.data) < n {
+		r.err = fmt.Errorf("geo: geometry is truncated")
+	}
+	if r.err != nil {
+		return
+	}
+	r.data = r.data[n:]
+}
+
+// byteOrder reads the byte telling big (0) from little (1) endian
+func (r *reader) byteOrder() {
+	r.order = binary.LittleEndian
+	if len(r.data) > 0 && r.data[0] == 0 {
+		r.order = binary.BigEndian
+	}
+	r.skip(1)
+}
+
+func (r *reader) uint32() uint32 {
+	b := r.data
+	r.skip(4)
+	if r.err != nil {
+		return 0
+	}
+	return r.order.Uint32(b)
+}
+
+func (r *reader) float64() float64 {
+	b := r.data
+	r.skip(8)
+	if r.err != nil {
+		return 0
+	}
+	return math.Float64frombits(r.order.Uint64(b))
+}
+
+// point reads x and y, skipping the other dims
+func (r *reader) point(dims int) Point {
+	x, y := r.float64(), r.float64()
+	r.skip(8 * (dims - 2))
+	if math.IsNaN(x) && math.IsNaN(y) {
+		return Point{} // POINT EMPTY
+	}
+	return Point{Lat: y, Lng: x}
+}
+
+// count reads a number of items of size bytes, failing when there isn't
+// room for them
+func (r *reader) count(size int) int {
+	n := int(r.uint32())
+	if r.err == nil && n > len(r.data)/size {
+		r.err = fmt.Errorf("geo: geometry is truncated")
+	}
+	if r.err != nil {
+		return 0
+	}
+	return n
+}
+
+func (r *reader) geometry(kind uint32, dims int) (geometry, error) {
+	g := geometry{kind: kind}
+	switch kind {
+	case wkbPoint:
+		g.rings = [][]Point{{r.point(dims)}}
+	case wkbPolygon:
+		rings := r.count(4)
+		for i := 0; i < rings; i++ {
+			ring := make([]Point, r.count(8*dims))
+			for j := range ring {
+				ring[j] = r.point(dims)
+			}
+			g.rings = append(g.rings, ring)
+		}
+	default:
+		if r.err == nil {
+			r.err = fmt.Errorf("geo: unsupported %s, only points and polygons are", kindName(kind))
+		}
+	}
+	return g, r.err
+}
+
+// wkt returns g as WKT, longitude first
+func wkt(g geometry) string {
+	var b strings.Builder
+	if g.kind == wkbPoint {
+		p := g.rings[0][0]
+		b.WriteString("POINT(" + formatFloat(p.Lng) + " " + formatFloat(p.Lat) + ")")
+		return b.String()
+	}
+	b.WriteString("POLYGON(")
+	for i, ring := range g.rings {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("(")
+		for j, p := range ring {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(formatFloat(p.Lng) + " " + formatFloat(p.Lat))
+		}
+		b.WriteString(")")
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+func isWKT(s string) bool {
+	upper := strings.ToUpper(s)
+	return strings.HasPrefix(upper, "SRID=") || strings.HasPrefix(upper, "POINT") || strings.HasPrefix(upper, "POLYGON")
+}
+
+// parseWKT reads a point or polygon as WKT, or EWKT with an SRID
+func parseWKT(s string) (geometry, error) {
+	invalid := fmt.Errorf("geo: invalid WKT %q", s)
+	text := strings.TrimSpace(s)
+	if strings.HasPrefix(strings.ToUpper(text), "SRID=") {
+		_, rest, ok := strings.Cut(text, ";")
+		if !ok {
+			return geometry{}, invalid
+		}
+		text = strings.TrimSpace(rest)
+	}
+
+	var g geometry
+	upper := strings.ToUpper(text)
+	switch {
+	case strings.HasPrefix(upper, "POINT"):
+		g.kind, text = wkbPoint, text[len("POINT"):]
+	case strings.HasPrefix(upper, "POLYGON"):
+		g.kind, text = wkbPolygon, text[len("POLYGON"):]
+	default:
+		return g, fmt.Errorf("geo: unsupported WKT %q, only points and polygons are", s)
+	}
+	text = strings.TrimSpace(text)
+	// Z and M coordinates are read and dropped
+	for _, dims := range []string{"ZM", "Z", "M"} {
+		if strings.HasPrefix(strings.ToUpper(text), dims) {
+			text = strings.TrimSpace(text[len(dims):])
+			break
+		}
+	}
+	if !strings.HasPrefix(text, "(") || !strings.HasSuffix(text, ")") {
+		return g, invalid
+	}
+	body := strings.TrimSpace(text[1 : len(text)-1])
+
+	if g.kind == wkbPoint {
+		points, err := wktPoints(body)
+		if err != nil || len(points) != 1 {
+			return g, invalid
+		}
+		g.rings = [][]Point{points}
+		return g, nil
+	}
+	for body != "" {
+		end := strings.Index(body, ")")
+		if !strings.HasPrefix(body, "(") || end < 0 {
+			return g, invalid
+		}
+		ring, err := wktPoints(body[1:end])
+		if err != nil || len(ring) < 3 {
+			return g, invalid
+		}
+		g.rings = append(g.rings, ring)
+		body = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(body[end+1:]), ","))
+	}
+	if len(g.rings) == 0 {
+		return g, invalid
+	}
+	return g, nil
+}
+
+// wktPoints reads "lng lat, lng lat..."
+func wktPoints(s string) ([]Point, error) {
+	var points []Point
+	for _, pair := range strings.Split(s, ",") {
+		fields := strings.Fields(pair)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("geo: invalid coordinates %q", pair)
+		}
+		lng, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, err
+		}
+		lat, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, Point{Lat: lat, Lng: lng})
+	}
+	return points, nil
+}
@@ -0,0 +1,82 @@
+package graphql
+
+import (
+	stdcontext "context"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/context"
+)
+
+// DefaultPath is where Application.GraphQL mounts the server when
+// graphql.path isn't set in config.yml
+const DefaultPath = "/graphql"
+
+type contextKey struct{}
+
+// Handler serves server, a gqlgen handler.Server, a graphql-go
+// relay.Handler or any other GraphQL http.Handler, passing the rebolo
+// Context of each request on to the resolvers, see FromContext
+func Handler(app context.AppContext, server http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := context.NewContext(w, r, app)
+		server.ServeHTTP(w, r.WithContext(stdcontext.WithValue(r.Context(), contextKey{}, c)))
+	})
+}
+
+// FromContext returns the rebolo Context of the request a resolver runs
+// for, nil outside Handler. It shares the session and the values set by
+// middleware, so auth works as in any other handler. gqlgen rewrites the
+// imports of resolver files to its own graphql package, use the
+// rebolo.GraphQLContext alias there:
+//
+//	func (r *queryResolver) Me(ctx context.Context) (*model.User, error) {
+//		user, _ := rebolo.GraphQLContext(ctx).Value("user").(*model.User)
+//		return user, nil
+//	}
+func FromContext(ctx stdcontext.Context) *context.Context {
+	c, _ := ctx.Value(contextKey{}).(*context.Context)
+	return c
+}
+
+// Playground serves the GraphiQL IDE for the server at endpoint. It is
+// loaded from the unpkg CDN.
+func Playground(title, endpoint string) http.Handler {
+	page := fmt.Sprintf(playgroundPage, html.EscapeString(title), strconv.Quote(endpoint))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	})
+}
+
+// WantsPlayground reports whether r is a browser opening the endpoint,
+// rather than a GET query
+func WantsPlayground(r *http.Request) bool {
+	return r.Method == http.MethodGet &&
+		r.URL.Query().Get("query") == "" &&
+		strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+const playgroundPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <title>%s - GraphiQL</title>
+    <link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css">
+    <style>body { margin: 0; } #graphiql { height: 100vh; }</style>
+</head>
+<body>
+    <div id="graphiql"></div>
+    <script src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+    <script src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+    <script src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+    <script>
+        const fetcher = GraphiQL.createFetcher({ url: %s });
+        ReactDOM.createRoot(document.getElementById("graphiql")).render(React.createElement(GraphiQL, { fetcher }));
+    </script>
+</body>
+</html>
+`
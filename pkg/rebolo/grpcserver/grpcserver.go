@@ -0,0 +1,71 @@
+package grpcserver
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// NewServer creates a gRPC server for Application.GRPC that logs every
+// call like the HTTP request logger and turns panics in handlers into
+// Internal errors. opts are passed on to grpc.NewServer:
+//
+//	srv := grpcserver.NewServer()
+//	pb.RegisterGreeterServer(srv, &greeter{})
+//	app.GRPC(srv)
+func NewServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(UnaryLogger, UnaryRecovery),
+		grpc.ChainStreamInterceptor(StreamLogger, StreamRecovery),
+	}, opts...)
+	return grpc.NewServer(opts...)
+}
+
+// UnaryLogger logs unary calls: method, peer, status code and duration
+func UnaryLogger(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, err, start)
+	return resp, err
+}
+
+// StreamLogger logs streaming calls once the stream ends
+func StreamLogger(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	logCall(ss.Context(), info.FullMethod, err, start)
+	return err
+}
+
+// UnaryRecovery answers Internal instead of crashing when a handler panics
+func UnaryRecovery(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer recoverCall(info.FullMethod, &err)
+	return handler(ctx, req)
+}
+
+// StreamRecovery answers Internal instead of crashing when a handler panics
+func StreamRecovery(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer recoverCall(info.FullMethod, &err)
+	return handler(srv, ss)
+}
+
+func recoverCall(method string, err *error) {
+	if rec := recover(); rec != nil {
+		log.Printf("❌ PANIC in %s: %v\n%s", method, rec, debug.Stack())
+		*err = status.Error(codes.Internal, "internal error")
+	}
+}
+
+func logCall(ctx context.Context, method string, err error, start time.Time) {
+	addr := "-"
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+	}
+	log.Printf("[gRPC] %s %s %s %v", method, addr, status.Code(err), time.Since(start))
+}
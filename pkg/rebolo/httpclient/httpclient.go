@@ -0,0 +1,254 @@
+// Package httpclient calls JSON APIs, Rebolo apps among them. Clients
+// generated by rebolo generate client are built on it:
+//
+//	c := httpclient.New("https://billing.internal", httpclient.WithBearerToken(os.Getenv("BILLING_KEY")))
+//	var invoice Invoice
+//	err := c.Do(ctx, "GET", httpclient.Path("/api/invoices/{id}", id), nil, nil, &invoice)
+//	if httpclient.StatusCode(err) == http.StatusNotFound {
+//		// ...
+//	}
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Client sends JSON requests to the API at BaseURL
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+	Header  http.Header // Sent with every request
+	Retries int         // Retries of idempotent requests failing with a network error or a 502, 503 or 504
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sends the requests with hc, e.g. one with a custom transport
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.HTTP = hc }
+}
+
+// WithHeader sends a header with every request
+func WithHeader(key, value string) Option {
+	return func(c *Client) { c.Header.Set(key, value) }
+}
+
+// WithBearerToken authenticates the requests with a token, like the API
+// keys of the apikeys package
+func WithBearerToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithTimeout bounds each request, 30s by default
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) { c.HTTP.Timeout = d }
+}
+
+// WithRetries retries idempotent requests n times, waiting 200ms then
+// twice as long after each failure
+func WithRetries(n int) Option {
+	return func(c *Client) { c.Retries = n }
+}
+
+// New creates a client of the API at baseURL
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+		Header:  make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Error is a response with an error status. Message and Fields are read
+// from the JSON errors Rebolo apps answer with.
+type Error struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Message    string
+	Fields     []FieldError // Validation errors of a 422
+	Body       []byte
+}
+
+// FieldError is a validation error of a field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	message := e.Message
+	if message == "" {
+		message = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, message)
+}
+
+// StatusCode returns the status of an *Error, 0 for other errors like
+// network ones
+func StatusCode(err error) int {
+	var e *Error
+	if stderrors.As(err, &e) {
+		return e.StatusCode
+	}
+	return 0
+}
+
+// Path fills the {name} parameters of template with params in order,
+// escaped:
+//
+//	httpclient.Path("/api/posts/{id}/comments/{commentID}", 4, "a b") // "/api/posts/4/comments/a%20b"
+func Path(template string, params ...interface{}) string {
+	var b strings.Builder
+	for _, param := range params {
+		start := strings.Index(template, "{")
+		end := strings.Index(template, "}")
+		if start < 0 || end < start {
+			break
+		}
+		b.WriteString(template[:start])
+		b.WriteString(url.PathEscape(fmt.Sprint(param)))
+		template = template[end+1:]
+	}
+	b.WriteString(template)
+	return b.String()
+}
+
+// Query builds query parameters from name and value pairs, leaving zero
+// values out and repeating the parameter for each item of a slice:
+//
+//	httpclient.Query("page", 2, "tag", []string{"go", "web"}, "q", "") // page=2&tag=go&tag=web
+func Query(pairs ...interface{}) url.Values {
+	query := url.Values{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		name := fmt.Sprint(pairs[i])
+		value := reflect.ValueOf(pairs[i+1])
+		if !value.IsValid() || value.IsZero() {
+			continue
+		}
+		if value.Kind() == reflect.Ptr {
+			value = value.Elem()
+		}
+		if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+			for j := 0; j < value.Len(); j++ {
+				query.Add(name, fmt.Sprint(value.Index(j).Interface()))
+			}
+			continue
+		}
+		query.Add(name, fmt.Sprint(value.Interface()))
+	}
+	return query
+}
+
+// Do sends a request with body encoded as JSON, unless nil, and decodes
+// the response into out, unless nil. Responses with an error status
+// return an *Error.
+func (c *Client) Do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	target := c.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode %s %s body: %w", method, path, err)
+		}
+	}
+
+	attempts := 1
+	if idempotent(method) {
+		attempts += c.Retries
+	}
+	backoff := 200 * time.Millisecond
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		retry, err = c.send(ctx, method, target, payload, out)
+		if !retry || attempt >= attempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// send makes one attempt, reporting whether a failure is worth retrying
+func (c *Client) send(ctx context.Context, method, target string, payload []byte, out interface{}) (bool, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return false, err
+	}
+	for key, values := range c.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		apiErr := &Error{Method: method, URL: target, StatusCode: resp.StatusCode, Body: data}
+		var problem struct {
+			Error  string       `json:"error"`
+			Errors []FieldError `json:"errors"`
+		}
+		if json.Unmarshal(data, &problem) == nil {
+			apiErr.Message, apiErr.Fields = problem.Error, problem.Errors
+		}
+		switch resp.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true, apiErr
+		}
+		return false, apiErr
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		io.Copy(io.Discard, resp.Body)
+		return false, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && err != io.EOF {
+		return false, fmt.Errorf("failed to decode %s %s response: %w", method, target, err)
+	}
+	return false, nil
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
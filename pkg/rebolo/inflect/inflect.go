@@ -0,0 +1,270 @@
+package inflect
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+type rule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+var (
+	mu           sync.RWMutex
+	plurals      []rule
+	singulars    []rule
+	irregulars   = make(map[string]string) // singular -> plural
+	singularOf   = make(map[string]string) // plural -> singular
+	uncountables = make(map[string]bool)
+)
+
+func init() {
+	for _, r := range [][2]string{
+		{`$`, "s"},
+		{`s$`, "s"},
+		{`^(ax|test)is$`, "${1}es"},
+		{`(octop|vir)us$`, "${1}i"},
+		{`(octop|vir)i$`, "${1}i"},
+		{`(alias|status|campus)$`, "${1}es"},
+		{`(bu)s$`, "${1}ses"},
+		{`(buffal|tomat|potat|her|ech)o$`, "${1}oes"},
+		{`([ti])um$`, "${1}a"},
+		{`([ti])a$`, "${1}a"},
+		{`sis$`, "ses"},
+		{`(?:([^f])fe|([lr])f)$`, "${1}${2}ves"},
+		{`(hive)$`, "${1}s"},
+		{`([^aeiouy]|qu)y$`, "${1}ies"},
+		{`(x|ch|ss|sh)$`, "${1}es"},
+		{`(matr|vert|ind)(?:ix|ex)$`, "${1}ices"},
+		{`^(m|l)ouse$`, "${1}ice"},
+		{`^(m|l)ice$`, "${1}ice"},
+		{`^(ox)$`, "${1}en"},
+		{`^(oxen)$`, "${1}"},
+		{`(quiz)$`, "${1}zes"},
+	} {
+		AddPlural(r[0], r[1])
+	}
+
+	for _, r := range [][2]string{
+		{`s$`, ""},
+		{`(ss)$`, "${1}"},
+		{`([ti])a$`, "${1}um"},
+		{`(analy|ba|diagno|parenthe|progno|synop|the)(sis|ses)$`, "${1}sis"},
+		{`([^f])ves$`, "${1}fe"},
+		{`(hive)s$`, "${1}"},
+		{`(tive)s$`, "${1}"},
+		{`([lr])ves$`, "${1}f"},
+		{`([^aeiouy]|qu)ies$`, "${1}y"},
+		{`(m)ovies$`, "${1}ovie"},
+		{`(x|ch|ss|sh)es$`, "${1}"},
+		{`^(m|l)ice$`, "${1}ouse"},
+		{`(bus)(es)?$`, "${1}"},
+		{`(o)es$`, "${1}"},
+		{`(shoe)s$`, "${1}"},
+		{`(cris|test)(is|es)$`, "${1}is"},
+		{`^(a)x[ie]s$`, "${1}xis"},
+		{`(octop|vir)(us|i)$`, "${1}us"},
+		{`(alias|status|campus)(es)?$`, "${1}"},
+		{`^(ox)en`, "${1}"},
+		{`(vert|ind)ices$`, "${1}ex"},
+		{`(matr)ices$`, "${1}ix"},
+		{`(quiz)zes$`, "${1}"},
+		{`(database)s$`, "${1}"},
+	} {
+		AddSingular(r[0], r[1])
+	}
+
+	for singular, plural := range map[string]string{
+		"person":    "people",
+		"man":       "men",
+		"woman":     "women",
+		"child":     "children",
+		"sex":       "sexes",
+		"move":      "moves",
+		"zombie":    "zombies",
+		"goose":     "geese",
+		"foot":      "feet",
+		"tooth":     "teeth",
+		"criterion": "criteria",
+	} {
+		AddIrregular(singular, plural)
+	}
+
+	AddUncountable("equipment", "information", "rice", "money", "species", "series",
+		"fish", "sheep", "jeans", "police", "news", "data", "metadata", "feedback")
+}
+
+// AddPlural registers a pluralization rule. pattern is a case-insensitive
+// regexp and replacement may use ${1} style groups. Rules added later take
+// precedence.
+func AddPlural(pattern, replacement string) {
+	mu.Lock()
+	defer mu.Unlock()
+	plurals = append(plurals, rule{regexp.MustCompile("(?i)" + pattern), replacement})
+}
+
+// AddSingular registers a singularization rule, see AddPlural
+func AddSingular(pattern, replacement string) {
+	mu.Lock()
+	defer mu.Unlock()
+	singulars = append(singulars, rule{regexp.MustCompile("(?i)" + pattern), replacement})
+}
+
+// AddIrregular registers a word whose plural doesn't follow the rules,
+// e.g. AddIrregular("person", "people")
+func AddIrregular(singular, plural string) {
+	mu.Lock()
+	defer mu.Unlock()
+	singular, plural = strings.ToLower(singular), strings.ToLower(plural)
+	delete(uncountables, singular)
+	irregulars[singular] = plural
+	singularOf[plural] = singular
+}
+
+// AddUncountable registers words with the same singular and plural form
+func AddUncountable(words ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, word := range words {
+		uncountables[strings.ToLower(word)] = true
+	}
+}
+
+// Pluralize returns the plural of word (person -> people, blog_post -> blog_posts)
+func Pluralize(word string) string {
+	return inflect(word, plurals, irregulars, singularOf)
+}
+
+// Singularize returns the singular of word (people -> person, statuses -> status)
+func Singularize(word string) string {
+	return inflect(word, singulars, singularOf, irregulars)
+}
+
+// inflect applies the irregular words or the rules to the last word of a
+// snake_case compound, so admin_person becomes admin_people. Words already
+// in the target form of an irregular are returned unchanged.
+func inflect(word string, rules []rule, irregular, inflected map[string]string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	prefix, last := "", word
+	if i := strings.LastIndexAny(word, "_- "); i >= 0 {
+		prefix, last = word[:i+1], word[i+1:]
+	}
+	if last == "" {
+		return word
+	}
+
+	lower := strings.ToLower(last)
+	if _, ok := inflected[lower]; ok || uncountables[lower] {
+		return word
+	}
+	if other, ok := irregular[lower]; ok {
+		// Keep the capitalization of the original word
+		if unicode.IsUpper([]rune(last)[0]) {
+			other = strings.ToUpper(other[:1]) + other[1:]
+		}
+		return prefix + other
+	}
+
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].pattern.MatchString(last) {
+			return prefix + rules[i].pattern.ReplaceAllString(last, rules[i].replacement)
+		}
+	}
+	return word
+}
+
+// Underscore converts CamelCase, dashes and spaces to snake_case
+// (BlogPost -> blog_post, HTMLParser -> html_parser)
+func Underscore(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == ' ':
+			b.WriteByte('_')
+		case unicode.IsUpper(r):
+			if i > 0 && runes[i-1] != '_' && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Camelize converts snake_case, dashes and camelCase to CamelCase
+// (send_email -> SendEmail)
+func Camelize(s string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(Underscore(s), "_") {
+		if part == "" {
+			continue
+		}
+		runes := []rune(part)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	return b.String()
+}
+
+// Humanize turns a field or column name into words for labels
+// (PublishedAt, published_at -> Published at)
+func Humanize(s string) string {
+	words := strings.ReplaceAll(Underscore(s), "_", " ")
+	if words == "" {
+		return s
+	}
+	return strings.ToUpper(words[:1]) + words[1:]
+}
+
+// Tableize returns the table name of a model (BlogPost -> blog_posts, Person -> people)
+func Tableize(model string) string {
+	return Pluralize(Underscore(model))
+}
+
+// Classify returns the model name of a table (blog_posts -> BlogPost)
+func Classify(table string) string {
+	return Camelize(Singularize(Underscore(table)))
+}
+
+// Inflections are custom inflections, typically loaded from the app's inflections.yml:
+//
+//	irregular:
+//	  cactus: cacti
+//	uncountable:
+//	  - equipment
+type Inflections struct {
+	Irregular   map[string]string `yaml:"irregular"`
+	Uncountable []string          `yaml:"uncountable"`
+}
+
+// LoadFile registers the inflections of a YAML file
+func LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var inflections Inflections
+	if err := yaml.Unmarshal(data, &inflections); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	for singular, plural := range inflections.Irregular {
+		AddIrregular(singular, plural)
+	}
+	AddUncountable(inflections.Uncountable...)
+	return nil
+}
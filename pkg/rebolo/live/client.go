@@ -0,0 +1,103 @@
+package live
+
+// clientScript connects the page to its component. Elements send events
+// with live-click, live-submit (forms) and live-change (inputs, or forms
+// on any input), adding their live-value-* attributes to the payload.
+// Renders are patched into the page node by node, so focus and what is
+// being typed survive.
+const clientScript = `(function () {
+  var root = document.currentScript.previousElementSibling;
+  var url = (location.protocol === 'https:' ? 'wss://' : 'ws://') + location.host + location.pathname + location.search;
+  var socket;
+
+  function connect() {
+    socket = new WebSocket(url);
+    socket.onmessage = function (e) {
+      var msg = JSON.parse(e.data);
+      if (msg.type === 'render') {
+        var next = document.createElement('div');
+        next.innerHTML = msg.html;
+        patchChildren(root, next);
+      } else if (msg.type === 'redirect') {
+        location.href = msg.url;
+      }
+    };
+    // The server drops the socket when the component fails, a new one
+    // mounts a fresh component
+    socket.onclose = function () { setTimeout(connect, 1000); };
+  }
+
+  function send(event, payload) {
+    if (socket && socket.readyState === WebSocket.OPEN) {
+      socket.send(JSON.stringify({ event: event, payload: payload }));
+    }
+  }
+
+  function values(el) {
+    var payload = {};
+    for (var i = 0; i < el.attributes.length; i++) {
+      var attr = el.attributes[i];
+      if (attr.name.indexOf('live-value-') === 0) payload[attr.name.slice(11)] = attr.value;
+    }
+    if (el.tagName === 'FORM') {
+      new FormData(el).forEach(function (value, name) { payload[name] = String(value); });
+    }
+    return payload;
+  }
+
+  root.addEventListener('click', function (e) {
+    var el = e.target.closest('[live-click]');
+    if (!el || !root.contains(el)) return;
+    e.preventDefault();
+    send(el.getAttribute('live-click'), values(el));
+  });
+  root.addEventListener('submit', function (e) {
+    var form = e.target;
+    if (!form.hasAttribute('live-submit')) return;
+    e.preventDefault();
+    send(form.getAttribute('live-submit'), values(form));
+  });
+  root.addEventListener('input', function (e) {
+    var el = e.target.closest('[live-change]');
+    if (!el || !root.contains(el)) return;
+    var payload = values(el);
+    if (el.tagName !== 'FORM') payload[e.target.name || 'value'] = e.target.value;
+    send(el.getAttribute('live-change'), payload);
+  });
+
+  function patchChildren(from, to) {
+    var old = from.childNodes, next = to.childNodes;
+    for (var i = 0; i < next.length; i++) {
+      if (i < old.length) patch(old[i], next[i]);
+      else from.appendChild(next[i].cloneNode(true));
+    }
+    while (old.length > next.length) from.removeChild(from.lastChild);
+  }
+
+  function patch(node, next) {
+    if (node.nodeType !== next.nodeType || node.nodeName !== next.nodeName) {
+      node.parentNode.replaceChild(next.cloneNode(true), node);
+      return;
+    }
+    if (node.nodeType !== 1) {
+      if (node.nodeValue !== next.nodeValue) node.nodeValue = next.nodeValue;
+      return;
+    }
+    for (var i = node.attributes.length - 1; i >= 0; i--) {
+      if (!next.hasAttribute(node.attributes[i].name)) node.removeAttribute(node.attributes[i].name);
+    }
+    for (i = 0; i < next.attributes.length; i++) {
+      var attr = next.attributes[i];
+      if (node.getAttribute(attr.name) !== attr.value) node.setAttribute(attr.name, attr.value);
+    }
+    patchChildren(node, next);
+    // Attributes only set the default of form fields, the field being
+    // edited keeps what the user typed
+    if (/^(INPUT|TEXTAREA|SELECT)$/.test(node.tagName) && node !== document.activeElement) {
+      if (node.type === 'checkbox' || node.type === 'radio') node.checked = next.checked;
+      else node.value = next.value;
+    }
+  }
+
+  connect();
+})();`
@@ -0,0 +1,265 @@
+// Package live serves stateful components rendered on the server, in the
+// style of Phoenix LiveView. A page is rendered over HTTP first, then the
+// browser opens a WebSocket on the same URL: events (clicks, submissions,
+// input) go to the component, which changes its state, is rendered again
+// with the app's views, and the new HTML is patched into the page.
+//
+//	type Counter struct{ Count int }
+//
+//	func (c *Counter) Template() string             { return "counter/show.html" }
+//	func (c *Counter) Mount(s *live.Socket) error  { return nil }
+//	func (c *Counter) HandleEvent(s *live.Socket, event string, p live.Payload) error {
+//		if event == "inc" {
+//			c.Count++
+//		}
+//		return nil
+//	}
+//
+//	app.Live("/counter", func() live.Component { return &Counter{} })
+//
+// with views/counter/show.html:
+//
+//	<p>{{.Count}}</p> <button live-click="inc">+</button>
+//
+// It's experimental, the API may change.
+package live
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/context"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/errors"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/websocket"
+)
+
+// Component is a component rendered on the server. A new one is created
+// for the page and for its socket, its state lives in its fields.
+type Component interface {
+	// Template names the view rendering the component, which gets the
+	// component as data
+	Template() string
+	// Mount sets up the state, from the params, query or session of the
+	// request. It runs for the page and again once the socket connects.
+	Mount(s *Socket) error
+	// HandleEvent changes the state on an event sent by the page, the
+	// component is rendered again after it
+	HandleEvent(s *Socket, event string, payload Payload) error
+}
+
+// InfoHandler is implemented by components updated from the server, with
+// messages sent by Socket.Send from other goroutines (tickers, pub/sub)
+type InfoHandler interface {
+	HandleInfo(s *Socket, msg interface{}) error
+}
+
+// Payload holds the values of an event: the live-value-* attributes of
+// the element and, for forms, their fields
+type Payload map[string]string
+
+// Int returns a value as an int, 0 when it isn't one
+func (p Payload) Int(key string) int {
+	n, _ := strconv.Atoi(p[key])
+	return n
+}
+
+// Options configure a Handler
+type Options struct {
+	// Layout is a view wrapping the component on the page, which gets
+	// .Title and the component as .Content. Without it a bare page is served.
+	Layout string
+	Title  string
+}
+
+// Handler serves a component: its page, and the socket it connects to
+type Handler struct {
+	app          context.AppContext
+	newComponent func() Component
+	opts         Options
+}
+
+// NewHandler serves the components made by newComponent, rendered with the
+// app's views. Application.Live registers one on a route.
+func NewHandler(app context.AppContext, newComponent func() Component, opts ...Options) *Handler {
+	h := &Handler{app: app, newComponent: newComponent}
+	if len(opts) > 0 {
+		h.opts = opts[0]
+	}
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsUpgrade(r) {
+		h.ServeSocket(w, r)
+		return
+	}
+	if err := h.Page(context.NewContext(w, r, h.app)); err != nil {
+		log.Printf("❌ live %s: %v", r.URL.Path, err)
+		code := errors.StatusCode(err)
+		http.Error(w, errors.PublicMessage(err, code), code)
+	}
+}
+
+// Page renders the component on its page, with the script connecting it
+func (h *Handler) Page(c *context.Context) error {
+	s := newSocket(c, nil)
+	component := h.newComponent()
+	if err := component.Mount(s); err != nil {
+		return err
+	}
+	if s.redirect != "" {
+		http.Redirect(c.Response, c.Request, s.redirect, http.StatusSeeOther)
+		return nil
+	}
+
+	html, err := h.render(component)
+	if err != nil {
+		return err
+	}
+	content := template.HTML(`<div data-live>` + html + `</div><script>` + clientScript + `</script>`)
+
+	if h.opts.Layout != "" {
+		return h.app.RenderHTML(c.Response, h.opts.Layout, map[string]interface{}{
+			"Title":   h.opts.Title,
+			"Content": content,
+		})
+	}
+	c.Response.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err = fmt.Fprintf(c.Response, bareLayout, template.HTMLEscapeString(h.opts.Title), content)
+	return err
+}
+
+const bareLayout = `<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"><title>%s</title></head>
+<body>%s</body>
+</html>`
+
+// ServeSocket runs the component for a connected page until it goes away
+func (h *Handler) ServeSocket(w http.ResponseWriter, r *http.Request) {
+	// Browsers send cookies with cross-site WebSocket requests, only the
+	// app's own pages may connect
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+			http.Error(w, "cross-origin live socket", http.StatusForbidden)
+			return
+		}
+	}
+
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	s := newSocket(context.NewContext(w, r, h.app), conn)
+	defer close(s.done)
+	component := h.newComponent()
+	if err := component.Mount(s); err != nil {
+		log.Printf("❌ live %s: mount: %v", r.URL.Path, err)
+		return
+	}
+
+	events := make(chan event)
+	go readEvents(conn, events, s.done)
+
+	last := ""
+	for {
+		if s.redirect != "" {
+			send(conn, map[string]string{"type": "redirect", "url": s.redirect})
+			return
+		}
+		html, err := h.render(component)
+		if err != nil {
+			log.Printf("❌ live %s: render: %v", r.URL.Path, err)
+			return
+		}
+		if html != last {
+			if err := send(conn, map[string]string{"type": "render", "html": html}); err != nil {
+				return
+			}
+			last = html
+		}
+
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			err = component.HandleEvent(s, e.Event, e.Payload)
+		case msg := <-s.info:
+			if handler, ok := component.(InfoHandler); ok {
+				err = handler.HandleInfo(s, msg)
+			}
+		}
+		// The page reconnects, mounting a fresh component
+		if err != nil {
+			log.Printf("❌ live %s: %v", r.URL.Path, err)
+			return
+		}
+	}
+}
+
+// event is a message from the page
+type event struct {
+	Event   string  `json:"event"`
+	Payload Payload `json:"payload"`
+}
+
+// readEvents reads the page's events until the connection closes
+func readEvents(conn *websocket.Conn, events chan<- event, done <-chan struct{}) {
+	defer close(events)
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var e event
+		if err := json.Unmarshal(data, &e); err != nil || e.Event == "" {
+			continue
+		}
+		select {
+		case events <- e:
+		case <-done:
+			return
+		}
+	}
+}
+
+func send(conn *websocket.Conn, message map[string]string) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// render renders the component with the app's views
+func (h *Handler) render(component Component) (string, error) {
+	var buf buffer
+	if err := h.app.RenderHTML(&buf, component.Template(), component); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// buffer is the http.ResponseWriter components are rendered into
+type buffer struct {
+	bytes.Buffer
+	header http.Header
+}
+
+func (b *buffer) Header() http.Header {
+	if b.header == nil {
+		b.header = http.Header{}
+	}
+	return b.header
+}
+
+func (b *buffer) WriteHeader(int) {}
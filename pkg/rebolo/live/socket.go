@@ -0,0 +1,60 @@
+package live
+
+import (
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/context"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/websocket"
+)
+
+// Socket is a component's link to its page. It embeds the Context of the
+// request the component was mounted for, for its params, query, session
+// and the values set by middleware. Once connected the session can be
+// read but not saved, there is no response left to set its cookie on.
+type Socket struct {
+	*context.Context
+
+	conn     *websocket.Conn // nil while the page is rendered over HTTP
+	info     chan interface{}
+	done     chan struct{}
+	redirect string
+}
+
+func newSocket(c *context.Context, conn *websocket.Conn) *Socket {
+	return &Socket{
+		Context: c,
+		conn:    conn,
+		info:    make(chan interface{}, 16),
+		done:    make(chan struct{}),
+	}
+}
+
+// Connected reports whether the page is connected, Mount runs first for
+// the HTTP render. Start tickers and subscriptions only once connected.
+func (s *Socket) Connected() bool {
+	return s.conn != nil
+}
+
+// Send passes msg to the component's HandleInfo, which runs on the
+// socket's goroutine and renders the component again. It's safe to call
+// from any goroutine and returns false once the page is gone.
+func (s *Socket) Send(msg interface{}) bool {
+	if !s.Connected() {
+		return false
+	}
+	select {
+	case s.info <- msg:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// Done is closed when the page goes away, for goroutines started by the
+// component to stop
+func (s *Socket) Done() <-chan struct{} {
+	return s.done
+}
+
+// Redirect sends the page to url, after the current event
+func (s *Socket) Redirect(url string) {
+	s.redirect = url
+}
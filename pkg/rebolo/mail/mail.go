@@ -134,9 +134,13 @@ type SMTPSender struct {
 	config SMTPConfig
 }
 
-// NewSMTPSender creates a new SMTP sender
+// NewSMTPSender creates a new SMTP sender, authenticating unless username
+// is empty
 func NewSMTPSender(host string, port int, username, password string) *SMTPSender {
-	auth := smtp.PlainAuth("", username, password, host)
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
 	return &SMTPSender{
 		config: SMTPConfig{
 			Host:     host,
@@ -0,0 +1,144 @@
+package mail
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OutboxPath is where applications serve their outbox
+const OutboxPath = "/__rebolo__/mail"
+
+// DefaultOutboxSize is how many messages an Outbox keeps by default
+const DefaultOutboxSize = 100
+
+// Delivery is a message kept by an Outbox
+type Delivery struct {
+	ID      int64
+	Time    time.Time
+	Message *Message
+}
+
+// Outbox is a Sender keeping messages instead of sending them, the last
+// ones listed at OutboxPath. Applications use it in development, and
+// tests read what was sent from it.
+type Outbox struct {
+	mu         sync.RWMutex
+	size       int
+	nextID     int64
+	deliveries []Delivery // Oldest first
+}
+
+// NewOutbox creates an outbox keeping the last size messages
+func NewOutbox(size int) *Outbox {
+	if size <= 0 {
+		size = DefaultOutboxSize
+	}
+	return &Outbox{size: size}
+}
+
+// Send keeps a copy of msg
+func (o *Outbox) Send(msg *Message) error {
+	o.mu.Lock()
+	o.nextID++
+	d := Delivery{ID: o.nextID, Time: time.Now(), Message: msg.clone()}
+	if len(o.deliveries) == o.size {
+		o.deliveries = append(o.deliveries[:0], o.deliveries[1:]...)
+	}
+	o.deliveries = append(o.deliveries, d)
+	o.mu.Unlock()
+
+	log.Printf("📧 Mail to %s: %q kept at %s/%d instead of sent", strings.Join(msg.To, ", "), msg.Subject, OutboxPath, d.ID)
+	return nil
+}
+
+// Deliveries returns the kept messages, newest first
+func (o *Outbox) Deliveries() []Delivery {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	deliveries := make([]Delivery, len(o.deliveries))
+	for i, d := range o.deliveries {
+		deliveries[len(deliveries)-1-i] = d
+	}
+	return deliveries
+}
+
+// Last returns the newest message, or nil when none was sent
+func (o *Outbox) Last() *Message {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if len(o.deliveries) == 0 {
+		return nil
+	}
+	return o.deliveries[len(o.deliveries)-1].Message
+}
+
+// Delivery returns the message with id, or nil when it was dropped
+func (o *Outbox) Delivery(id int64) *Delivery {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	for i := range o.deliveries {
+		if o.deliveries[i].ID == id {
+			d := o.deliveries[i]
+			return &d
+		}
+	}
+	return nil
+}
+
+// Clear drops the kept messages
+func (o *Outbox) Clear() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.deliveries = nil
+}
+
+// ServeHTTP lists the messages at OutboxPath and shows one at
+// OutboxPath/<id>. DELETE clears them.
+func (o *Outbox) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodDelete {
+		o.Clear()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, OutboxPath), "/")
+	if id == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		mailPages.ExecuteTemplate(w, "outbox", o.Deliveries())
+		return
+	}
+
+	n, _ := strconv.ParseInt(id, 10, 64)
+	d := o.Delivery(n)
+	if d == nil {
+		http.Error(w, "message not kept, or dropped since", http.StatusNotFound)
+		return
+	}
+	ServeMessage(w, r, d.Message, d.Message.Subject, OutboxPath)
+}
+
+// clone copies msg, so later changes to it don't show in the outbox
+func (m *Message) clone() *Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := &Message{
+		From:        m.From,
+		To:          append([]string(nil), m.To...),
+		Cc:          append([]string(nil), m.Cc...),
+		Bcc:         append([]string(nil), m.Bcc...),
+		Subject:     m.Subject,
+		Body:        m.Body,
+		HTMLBody:    m.HTMLBody,
+		Headers:     make(map[string]string, len(m.Headers)),
+		Attachments: append([]Attachment(nil), m.Attachments...),
+	}
+	for key, value := range m.Headers {
+		c.Headers[key] = value
+	}
+	return c
+}
@@ -0,0 +1,149 @@
+package mail
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PreviewPath is where applications serve mail previews in development
+const PreviewPath = "/__rebolo__/mailers"
+
+// Previews builds emails with sample data to check them in a browser,
+// as they'd be sent, without sending them
+type Previews struct {
+	mu     sync.RWMutex
+	builds map[string]func() (*Message, error)
+}
+
+// NewPreviews creates an empty set of previews
+func NewPreviews() *Previews {
+	return &Previews{builds: make(map[string]func() (*Message, error))}
+}
+
+// Add adds a preview named <mailer>/<action>, like user_mailer/welcome,
+// building its email with build
+func (p *Previews) Add(name string, build func() (*Message, error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.builds[strings.Trim(name, "/")] = build
+}
+
+// Names returns the names of the previews, sorted
+func (p *Previews) Names() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	names := make([]string, 0, len(p.builds))
+	for name := range p.builds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ServeHTTP lists the previews at PreviewPath and shows one at
+// PreviewPath/<name>: its headers and bodies. ?part=html and ?part=text
+// serve one body alone.
+func (p *Previews) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, PreviewPath), "/")
+	if name == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		mailPages.ExecuteTemplate(w, "previews", p.Names())
+		return
+	}
+
+	p.mu.RLock()
+	build := p.builds[name]
+	p.mu.RUnlock()
+	if build == nil {
+		http.Error(w, "no preview named "+name, http.StatusNotFound)
+		return
+	}
+	msg, err := build()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ServeMessage(w, r, msg, name, PreviewPath)
+}
+
+// ServeMessage shows msg with its headers, its HTML body in a frame and
+// its text body, with a link back to back. ?part=html and ?part=text
+// serve one body alone.
+func ServeMessage(w http.ResponseWriter, r *http.Request, msg *Message, title, back string) {
+	switch r.URL.Query().Get("part") {
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(msg.HTMLBody))
+		return
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(msg.Body))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := mailPages.ExecuteTemplate(w, "message", map[string]interface{}{
+		"Title":   title,
+		"Back":    back,
+		"Message": msg,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+var mailPages = template.Must(template.New("mail").Parse(`
+{{define "style"}}<style>
+body{font:14px/1.4 system-ui,sans-serif;margin:2rem;color:#222}
+table{border-collapse:collapse;width:100%}
+th,td{text-align:left;padding:.3rem .6rem;border-bottom:1px solid #ddd;vertical-align:top}
+th{width:8rem}
+code,pre{font:13px/1.4 ui-monospace,monospace}
+pre{white-space:pre-wrap;margin:0;padding:1rem;background:#f6f6f6}
+iframe{width:100%;height:32rem;border:1px solid #ddd}
+a{color:#06c}
+</style>{{end}}
+
+{{define "previews"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Mail previews</title>{{template "style"}}</head><body>
+<h1>Mail previews</h1>
+{{if .}}<ul>{{range .}}<li><a href="/__rebolo__/mailers/{{.}}">{{.}}</a></li>{{end}}</ul>
+{{else}}<p>No previews yet, add them with app.MailPreview or rebolo generate mailer.</p>{{end}}
+<p><a href="/__rebolo__/mail">Sent mail</a></p>
+</body></html>{{end}}
+
+{{define "outbox"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Mail</title>{{template "style"}}</head><body>
+<h1>Sent mail</h1>
+{{if .}}<table>
+<tr><th>Time</th><th>To</th><th>Subject</th></tr>
+{{range .}}<tr>
+<td>{{.Time.Format "15:04:05"}}</td>
+<td>{{range $i, $to := .Message.To}}{{if $i}}, {{end}}{{$to}}{{end}}</td>
+<td><a href="/__rebolo__/mail/{{.ID}}">{{or .Message.Subject "(no subject)"}}</a></td>
+</tr>{{end}}
+</table>{{else}}<p>No mail sent yet. Mail sent with app.SendMail shows here instead of being delivered.</p>{{end}}
+<p><a href="/__rebolo__/mailers">Mail previews</a></p>
+</body></html>{{end}}
+
+{{define "message"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Title}}</title>{{template "style"}}</head><body>
+<p><a href="{{.Back}}">← Back</a></p>
+<h1>{{.Title}}</h1>
+{{with .Message}}<table>
+<tr><th>From</th><td>{{.From}}</td></tr>
+<tr><th>To</th><td>{{range $i, $to := .To}}{{if $i}}, {{end}}{{$to}}{{end}}</td></tr>
+{{if .Cc}}<tr><th>Cc</th><td>{{range $i, $cc := .Cc}}{{if $i}}, {{end}}{{$cc}}{{end}}</td></tr>{{end}}
+{{if .Bcc}}<tr><th>Bcc</th><td>{{range $i, $bcc := .Bcc}}{{if $i}}, {{end}}{{$bcc}}{{end}}</td></tr>{{end}}
+<tr><th>Subject</th><td>{{.Subject}}</td></tr>
+{{range $k, $v := .Headers}}<tr><th>{{$k}}</th><td>{{$v}}</td></tr>{{end}}
+{{if .Attachments}}<tr><th>Attachments</th><td>{{range .Attachments}}{{.Name}} ({{.ContentType}}) {{end}}</td></tr>{{end}}
+</table>
+{{if .HTMLBody}}<h2>HTML</h2><iframe sandbox src="?part=html"></iframe>{{end}}
+{{if .Body}}<h2>Text</h2><pre>{{.Body}}</pre>{{end}}{{end}}
+</body></html>{{end}}
+`))
@@ -0,0 +1,110 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/timefmt"
+)
+
+// ViewsDir is where Render reads the mailer templates from
+var ViewsDir = filepath.Join("views", "mailers")
+
+// Render sets the bodies of msg from the templates of name under
+// ViewsDir: <name>.html for HTMLBody and <name>.txt for Body, at least one
+// of which must exist. A layout.html or layout.txt in ViewsDir wraps them,
+// putting the email where it calls {{template "content" .}}. Templates are
+// read on every render, so previews show edits without a restart.
+//
+//	msg := mail.NewMessage().AddTo(user.Email).SetSubject("Welcome")
+//	err := mail.Render(msg, "user_mailer/welcome", user)
+func Render(msg *Message, name string, data interface{}) error {
+	html, hasHTML, err := renderHTML(name, data)
+	if err != nil {
+		return err
+	}
+	text, hasText, err := renderText(name, data)
+	if err != nil {
+		return err
+	}
+	if !hasHTML && !hasText {
+		return fmt.Errorf("no %s.html or %s.txt in %s", name, name, ViewsDir)
+	}
+	if hasHTML {
+		msg.SetHTMLBody(html)
+	}
+	if hasText {
+		msg.SetBody(text)
+	}
+	return nil
+}
+
+func renderHTML(name string, data interface{}) (string, bool, error) {
+	content, layout, ok, err := readTemplate(name, ".html")
+	if !ok || err != nil {
+		return "", ok, err
+	}
+	tmpl, err := htmltemplate.New("content").Funcs(timefmt.FuncMap()).Parse(content)
+	if err == nil && layout != "" {
+		tmpl, err = tmpl.New("layout").Parse(layout)
+	}
+	if err != nil {
+		return "", true, fmt.Errorf("parsing %s.html: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", true, fmt.Errorf("rendering %s.html: %w", name, err)
+	}
+	return buf.String(), true, nil
+}
+
+func renderText(name string, data interface{}) (string, bool, error) {
+	content, layout, ok, err := readTemplate(name, ".txt")
+	if !ok || err != nil {
+		return "", ok, err
+	}
+	tmpl, err := texttemplate.New("content").Funcs(texttemplate.FuncMap(timefmt.FuncMap())).Parse(content)
+	if err == nil && layout != "" {
+		tmpl, err = tmpl.New("layout").Parse(layout)
+	}
+	if err != nil {
+		return "", true, fmt.Errorf("parsing %s.txt: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", true, fmt.Errorf("rendering %s.txt: %w", name, err)
+	}
+	return buf.String(), true, nil
+}
+
+// readTemplate reads the template of name with ext and the layout with
+// the same extension, if any. It reports whether the template exists.
+func readTemplate(name, ext string) (content, layout string, ok bool, err error) {
+	data, err := os.ReadFile(filepath.Join(ViewsDir, filepath.FromSlash(name)+ext))
+	if os.IsNotExist(err) {
+		return "", "", false, nil
+	}
+	if err != nil {
+		return "", "", false, err
+	}
+	if l, err := os.ReadFile(filepath.Join(ViewsDir, "layout"+ext)); err == nil {
+		layout = string(l)
+	}
+	return string(data), layout, true, nil
+}
+
+// LogSender logs messages instead of sending them. Applications use it
+// until mail.host is set in config.yml.
+type LogSender struct{}
+
+// Send logs msg's recipients and subject
+func (LogSender) Send(msg *Message) error {
+	log.Printf("📧 Mail to %s: %q not sent, set mail.host in config.yml to send it", strings.Join(msg.To, ", "), msg.Subject)
+	return nil
+}
@@ -0,0 +1,37 @@
+package maintenance
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// TempFilePatterns match the temporary files left behind by crashed or
+// killed processes: uploads net/http spilled to disk, and the binaries of
+// rebolo dev and rebolo task
+var TempFilePatterns = []string{"multipart-*", "rebolo-dev-*", "rebolo-task*"}
+
+// RemoveTempFiles deletes the files and directories in dir matching
+// patterns that weren't modified for maxAge, and returns how many it
+// deleted. Younger ones may still be in use.
+func RemoveTempFiles(dir string, patterns []string, maxAge time.Duration) (int, error) {
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return removed, err
+		}
+		for _, path := range matches {
+			info, err := os.Lstat(path)
+			if err != nil || info.ModTime().After(cutoff) {
+				continue
+			}
+			if err := os.RemoveAll(path); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,204 @@
+package maintenance
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/schema"
+)
+
+// Operation names supported by the runner
+const (
+	Vacuum   = "vacuum"
+	Analyze  = "analyze"
+	Optimize = "optimize"
+)
+
+// Options controls what a maintenance run does
+type Options struct {
+	// Tables to maintain, all tables when empty
+	Tables []string
+	// Operations to run, the driver defaults when empty
+	Operations []string
+	// MaxDuration bounds the whole run; no new operation starts once it is
+	// exceeded, the one running is left to finish
+	MaxDuration time.Duration
+}
+
+// Result describes a single maintenance operation
+type Result struct {
+	Table     string // Empty for database-wide operations
+	Operation string
+	Duration  time.Duration
+	Err       error
+}
+
+// Runner runs maintenance statements for a specific driver
+type Runner struct {
+	db     *sql.DB
+	driver string
+}
+
+// NewRunner creates a maintenance runner for the given driver (postgres, sqlite, mysql)
+func NewRunner(db *sql.DB, driver string) (*Runner, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	driver = schema.NormalizeDriver(driver)
+	switch driver {
+	case "postgres", "sqlite", "mysql":
+	default:
+		return nil, fmt.Errorf("maintenance not supported for driver: %s", driver)
+	}
+
+	return &Runner{db: db, driver: driver}, nil
+}
+
+// DefaultOperations returns the operations run when none are requested
+func (r *Runner) DefaultOperations() []string {
+	switch r.driver {
+	case "mysql":
+		return []string{Optimize, Analyze}
+	default:
+		return []string{Vacuum, Analyze}
+	}
+}
+
+// Run executes the maintenance operations and returns one result per statement
+func (r *Runner) Run(ctx context.Context, opts Options) ([]Result, error) {
+	operations := opts.Operations
+	if len(operations) == 0 {
+		operations = r.DefaultOperations()
+	}
+	for _, op := range operations {
+		if !r.supports(op) {
+			return nil, fmt.Errorf("operation %s not supported by %s", op, r.driver)
+		}
+	}
+
+	// Checked between statements only, a VACUUM cancelled halfway is work lost
+	var deadline time.Time
+	if opts.MaxDuration > 0 {
+		deadline = time.Now().Add(opts.MaxDuration)
+	}
+
+	existing, err := r.Tables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only maintain tables that exist, this also keeps user input out of the SQL
+	tables := existing
+	if len(opts.Tables) > 0 {
+		known := make(map[string]bool, len(existing))
+		for _, t := range existing {
+			known[t] = true
+		}
+		tables = nil
+		for _, t := range opts.Tables {
+			if !known[t] {
+				return nil, fmt.Errorf("table %s does not exist", t)
+			}
+			tables = append(tables, t)
+		}
+	}
+
+	var results []Result
+	for _, op := range operations {
+		for _, stmt := range r.statements(op, tables, len(opts.Tables) > 0) {
+			if err := ctx.Err(); err != nil {
+				return results, err
+			}
+			if !deadline.IsZero() && time.Now().After(deadline) {
+				log.Printf("⏱️  Maintenance stopped: duration limit reached")
+				return results, nil
+			}
+
+			start := time.Now()
+			err := r.exec(ctx, stmt.sql)
+			results = append(results, Result{
+				Table:     stmt.table,
+				Operation: op,
+				Duration:  time.Since(start),
+				Err:       err,
+			})
+		}
+	}
+
+	return results, nil
+}
+
+// Tables lists the user tables of the current database
+func (r *Runner) Tables(ctx context.Context) ([]string, error) {
+	inspector, err := schema.NewInspector(r.db, r.driver)
+	if err != nil {
+		return nil, err
+	}
+	return inspector.Tables(ctx)
+}
+
+func (r *Runner) supports(op string) bool {
+	switch op {
+	case Vacuum:
+		return r.driver != "mysql"
+	case Analyze:
+		return true
+	case Optimize:
+		return r.driver == "mysql"
+	}
+	return false
+}
+
+type statement struct {
+	table string
+	sql   string
+}
+
+// statements builds the SQL for an operation over the given tables
+func (r *Runner) statements(op string, tables []string, targeted bool) []statement {
+	// SQLite can only VACUUM the whole database file
+	if r.driver == "sqlite" && op == Vacuum {
+		if targeted {
+			log.Printf("⚠️  SQLite VACUUM is database-wide, skipping for targeted tables")
+			return nil
+		}
+		return []statement{{sql: "VACUUM"}}
+	}
+
+	stmts := make([]statement, 0, len(tables))
+	for _, t := range tables {
+		var sql string
+		switch {
+		case r.driver == "mysql" && op == Optimize:
+			sql = "OPTIMIZE TABLE " + schema.QuoteIdent(r.driver, t)
+		case r.driver == "mysql" && op == Analyze:
+			sql = "ANALYZE TABLE " + schema.QuoteIdent(r.driver, t)
+		default:
+			sql = strings.ToUpper(op) + " " + schema.QuoteIdent(r.driver, t)
+		}
+		stmts = append(stmts, statement{table: t, sql: sql})
+	}
+	return stmts
+}
+
+// exec runs a statement, draining result sets returned by MySQL admin statements
+func (r *Runner) exec(ctx context.Context, query string) error {
+	if r.driver == "mysql" {
+		rows, err := r.db.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+		}
+		return rows.Err()
+	}
+
+	_, err := r.db.ExecContext(ctx, query)
+	return err
+}
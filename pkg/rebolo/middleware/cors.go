@@ -0,0 +1,240 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions is a cross-origin resource sharing policy
+type CORSOptions struct {
+	// Origins allowed to call the app: "https://app.example.com",
+	// "https://*.example.com" for its subdomains, or "*" for any, which
+	// can't be combined with Credentials
+	Origins []string
+	// Methods allowed, GET, HEAD, POST, PUT, PATCH and DELETE by default
+	Methods []string
+	// Headers allowed in requests, Content-Type, Authorization,
+	// X-Requested-With and X-CSRF-Token by default. "*" allows any.
+	Headers []string
+	// Expose are the response headers scripts can read besides the basic ones
+	Expose []string
+	// Credentials lets requests carry cookies and HTTP auth, from the
+	// Origins listed. The origin is echoed, as browsers refuse "*" with
+	// credentials.
+	Credentials bool
+	// MaxAge is how long browsers cache a preflight, not at all when zero
+	MaxAge time.Duration
+}
+
+// CORSRoute is the policy of the paths matching Path, like "/api/*" or
+// "/webhooks/*/status"
+type CORSRoute struct {
+	Path string
+	CORSOptions
+}
+
+var (
+	defaultCORSMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"}
+	defaultCORSHeaders = []string{"Content-Type", "Authorization", "X-Requested-With", "X-CSRF-Token"}
+)
+
+// CORS answers preflight requests and adds the CORS headers of opts to
+// the responses to allowed origins. Requests to paths matching one of the
+// routes follow the first one's policy instead. It must run before
+// routing, as preflights are OPTIONS requests the routes don't handle.
+// It panics when a policy allows credentials from "*", which would let
+// any site make requests with the user's cookies:
+//
+//	app.AddMiddleware(middleware.CORS(middleware.CORSOptions{
+//		Origins:     []string{"https://app.example.com"},
+//		Credentials: true,
+//	}, middleware.CORSRoute{Path: "/api/public/*", CORSOptions: middleware.CORSOptions{Origins: []string{"*"}}}))
+func CORS(opts CORSOptions, routes ...CORSRoute) MiddlewareFunc {
+	policy := newCORSPolicy(opts)
+	routePolicies := make([]*corsPolicy, len(routes))
+	for i, route := range routes {
+		routePolicies[i] = newCORSPolicy(route.CORSOptions)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := policy
+			for i, route := range routes {
+				if matchPath(r.URL.Path, route.Path) {
+					p = routePolicies[i]
+					break
+				}
+			}
+			if p.serve(w, r) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// CORSMiddleware allows allowOrigin, see CORS for a full policy
+func CORSMiddleware(allowOrigin string) MiddlewareFunc {
+	return CORS(CORSOptions{
+		Origins: []string{allowOrigin},
+		Methods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		Headers: []string{"Content-Type", "Authorization"},
+	})
+}
+
+// corsPolicy is a CORSOptions ready to check requests
+type corsPolicy struct {
+	opts      CORSOptions
+	anyOrigin bool
+	origins   []string
+	patterns  [][2]string // prefix and suffix around a *
+	anyHeader bool
+	headers   map[string]bool
+	methods   string
+	maxAge    string
+}
+
+func newCORSPolicy(opts CORSOptions) *corsPolicy {
+	if len(opts.Methods) == 0 {
+		opts.Methods = defaultCORSMethods
+	}
+	if len(opts.Headers) == 0 {
+		opts.Headers = defaultCORSHeaders
+	}
+
+	p := &corsPolicy{opts: opts, headers: map[string]bool{}, methods: strings.Join(opts.Methods, ", ")}
+	for _, origin := range opts.Origins {
+		switch {
+		case origin == "*":
+			if opts.Credentials {
+				panic(`middleware: CORS credentials need the origins listed, not "*"`)
+			}
+			p.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			prefix, suffix, _ := strings.Cut(strings.ToLower(origin), "*")
+			p.patterns = append(p.patterns, [2]string{prefix, suffix})
+		default:
+			p.origins = append(p.origins, strings.ToLower(strings.TrimSuffix(origin, "/")))
+		}
+	}
+	for _, header := range opts.Headers {
+		if header == "*" {
+			p.anyHeader = true
+		}
+		p.headers[http.CanonicalHeaderKey(header)] = true
+	}
+	if opts.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(opts.MaxAge.Seconds()))
+	}
+	return p
+}
+
+// allows reports whether origin may call the app
+func (p *corsPolicy) allows(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	for _, allowed := range p.origins {
+		if origin == allowed {
+			return true
+		}
+	}
+	for _, pattern := range p.patterns {
+		if len(origin) > len(pattern[0])+len(pattern[1]) && strings.HasPrefix(origin, pattern[0]) && strings.HasSuffix(origin, pattern[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// serve writes the CORS headers of r, and answers it when it's a
+// preflight. It reports whether the request goes on to the app.
+func (p *corsPolicy) serve(w http.ResponseWriter, r *http.Request) bool {
+	h := w.Header()
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+	// Unless every origin gets the same "*", the answer depends on the
+	// Origin, and caches must keep one per origin
+	if !p.anyOrigin {
+		h.Add("Vary", "Origin")
+	}
+	if preflight {
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if !p.allows(origin) {
+		if preflight {
+			// Without the headers the browser blocks the request
+			w.WriteHeader(http.StatusNoContent)
+			return false
+		}
+		return true
+	}
+
+	if preflight {
+		method := r.Header.Get("Access-Control-Request-Method")
+		requested := r.Header.Get("Access-Control-Request-Headers")
+		if !p.allowsMethod(method) || !p.allowsHeaders(requested) {
+			w.WriteHeader(http.StatusNoContent)
+			return false
+		}
+		p.allowOrigin(h, origin)
+		h.Set("Access-Control-Allow-Methods", p.methods)
+		if requested != "" {
+			// Only the requested ones, which are all allowed
+			h.Set("Access-Control-Allow-Headers", requested)
+		}
+		if p.maxAge != "" {
+			h.Set("Access-Control-Max-Age", p.maxAge)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return false
+	}
+
+	p.allowOrigin(h, origin)
+	if len(p.opts.Expose) > 0 {
+		h.Set("Access-Control-Expose-Headers", strings.Join(p.opts.Expose, ", "))
+	}
+	return true
+}
+
+func (p *corsPolicy) allowOrigin(h http.Header, origin string) {
+	if p.anyOrigin {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	if p.opts.Credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (p *corsPolicy) allowsMethod(method string) bool {
+	for _, allowed := range p.opts.Methods {
+		if strings.EqualFold(method, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsHeaders reports whether all the headers of a preflight's
+// Access-Control-Request-Headers are allowed
+func (p *corsPolicy) allowsHeaders(requested string) bool {
+	if p.anyHeader || requested == "" {
+		return true
+	}
+	for _, header := range strings.Split(requested, ",") {
+		if header = strings.TrimSpace(header); header != "" && !p.headers[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+	return true
+}
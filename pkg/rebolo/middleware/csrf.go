@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+)
+
+// CSRF token names: the cookie holding it, the form field and the header
+// requests send it back in
+const (
+	CSRFCookieName = "_csrf"
+	CSRFFieldName  = "_csrf"
+	CSRFHeaderName = "X-CSRF-Token"
+)
+
+type csrfKey struct{}
+
+// CSRFMiddleware protects form posts against cross-site request forgery.
+// Every visitor gets a random token in a cookie; POST, PUT, PATCH and
+// DELETE requests must send the same token in the _csrf form field (see
+// the csrfField and formFor template helpers) or the X-CSRF-Token header.
+func CSRFMiddleware() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := ""
+			if cookie, err := r.Cookie(CSRFCookieName); err == nil && len(cookie.Value) == 43 {
+				token = cookie.Value
+			} else {
+				token = newCSRFToken()
+				http.SetCookie(w, &http.Cookie{
+					Name:     CSRFCookieName,
+					Value:    token,
+					Path:     "/",
+					HttpOnly: true,
+					Secure:   r.TLS != nil,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			default:
+				sent := r.Header.Get(CSRFHeaderName)
+				if sent == "" {
+					sent = r.PostFormValue(CSRFFieldName)
+				}
+				if subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+					http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), csrfKey{}, token)))
+		})
+	}
+}
+
+// CSRFToken returns the token of the request, empty without CSRFMiddleware
+func CSRFToken(r *http.Request) string {
+	token, _ := r.Context().Value(csrfKey{}).(string)
+	return token
+}
+
+func newCSRFToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
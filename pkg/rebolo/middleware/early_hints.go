@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// PreloadLink returns the Link header value preloading asset, with the
+// destination ("as") guessed from its extension
+func PreloadLink(asset string) string {
+	link := "<" + asset + ">; rel=preload"
+	as := preloadAs(asset)
+	if as != "" {
+		link += "; as=" + as
+	}
+	if as == "font" {
+		// Fonts are fetched in CORS mode, the preload has to match
+		link += "; crossorigin"
+	}
+	return link
+}
+
+// preloadAs returns the preload destination of asset by its extension
+func preloadAs(asset string) string {
+	if i := strings.IndexAny(asset, "?#"); i != -1 {
+		asset = asset[:i]
+	}
+	switch strings.ToLower(path.Ext(asset)) {
+	case ".css":
+		return "style"
+	case ".js", ".mjs":
+		return "script"
+	case ".woff", ".woff2", ".ttf", ".otf":
+		return "font"
+	case ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".avif", ".ico":
+		return "image"
+	}
+	return ""
+}
+
+// SendEarlyHints adds preload Link headers for assets to the response and,
+// to HTTP/2 clients, sends them right away as 103 Early Hints, so the
+// browser fetches critical CSS and JS while the page is rendered. Call it
+// before writing the response. HTTP/1.1 clients only get the headers on
+// the final response, most ignore 103 and some older ones choke on it.
+func SendEarlyHints(w http.ResponseWriter, r *http.Request, assets ...string) {
+	if len(assets) == 0 {
+		return
+	}
+	for _, asset := range assets {
+		w.Header().Add("Link", PreloadLink(asset))
+	}
+	if r.ProtoAtLeast(2, 0) {
+		w.WriteHeader(http.StatusEarlyHints)
+	}
+}
+
+// Push starts HTTP/2 server pushes of assets. Where push isn't available,
+// over HTTP/1.1 or when the client disabled it, a preload Link header is
+// added instead, so the asset is still fetched early.
+func Push(w http.ResponseWriter, r *http.Request, assets ...string) error {
+	pusher, _ := w.(http.Pusher)
+	for _, asset := range assets {
+		if pusher != nil {
+			err := pusher.Push(asset, &http.PushOptions{
+				Header: http.Header{"Accept-Encoding": r.Header.Values("Accept-Encoding")},
+			})
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+		}
+		w.Header().Add("Link", PreloadLink(asset))
+	}
+	return nil
+}
+
+// EarlyHintsMiddleware sends early hints for assets on page loads, GET
+// requests accepting HTML. Configure it with assets.preload.
+func EarlyHintsMiddleware(assets ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), "text/html") {
+				SendEarlyHints(w, r, assets...)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
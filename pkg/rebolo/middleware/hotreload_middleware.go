@@ -1,78 +1,258 @@
 package middleware
 
 import (
+	"bufio"
 	"bytes"
 	"io"
+	"net"
 	"net/http"
 	"strings"
+	"sync"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/websocket"
 )
 
-// HotReloadScript is the client-side JavaScript that polls for changes and reloads
+// HotReloadScript is the client-side JavaScript that listens for changes on
+// a WebSocket: stylesheets are swapped in place, anything else reloads the
+// page. While the socket is down it polls instead, which is how rebolo dev
+// reports Go build errors. Asset build errors come over the socket.
 const HotReloadScript = `
 <script>
 (function() {
-	console.log('🔥 Rebolo hot reload enabled (polling mode)');
+	console.log('🔥 Rebolo hot reload enabled');
+	
+	// Set while the page shows a build error, reload once it's fixed
+	let broken = !!window.__reboloBuildError;
+	let opened = false;
+	let disconnected = false;
+	let polling = null;
 	
-	let lastCheck = Date.now();
+	function showBuildError(output) {
+		if (window.__reboloBuildError) {
+			return;
+		}
+		let overlay = document.getElementById('__rebolo_build_error');
+		if (!overlay) {
+			overlay = document.createElement('div');
+			overlay.id = '__rebolo_build_error';
+			overlay.style.cssText = 'position:fixed;inset:0;z-index:2147483647;overflow:auto;padding:2rem;' +
+				'background:rgba(20,20,20,.95);color:#eee;font:14px/1.4 monospace;white-space:pre-wrap';
+			document.body.appendChild(overlay);
+		}
+		overlay.textContent = '❌ Build failed\n\n' + output;
+	}
+	
+	// Swap each same-origin stylesheet for a fresh copy, without a reload
+	function refreshStyles() {
+		document.querySelectorAll('link[rel="stylesheet"]').forEach(function(link) {
+			const url = new URL(link.href);
+			if (url.origin !== location.origin) {
+				return;
+			}
+			url.searchParams.set('__rebolo', Date.now());
+			const fresh = link.cloneNode();
+			fresh.href = url.toString();
+			fresh.onload = function() { link.remove(); };
+			link.after(fresh);
+		});
+	}
 	
 	async function checkForChanges() {
 		try {
 			const response = await fetch('/__rebolo__/changes');
 			const data = await response.json();
 			
-			if (data.changed) {
+			if (data.buildError) {
+				broken = true;
+				showBuildError(data.buildError);
+				return;
+			}
+			
+			if (data.changed || broken) {
 				console.log('🔄 File changed detected!');
 				console.log('⚡ Reloading page...');
 				location.reload();
 			}
 		} catch (err) {
-			console.error('Hot reload check error:', err);
+			// The server is restarting
 		}
 	}
 	
-	// Check for changes every second
-	setInterval(checkForChanges, 1000);
+	function connect() {
+		const protocol = location.protocol === 'https:' ? 'wss://' : 'ws://';
+		const socket = new WebSocket(protocol + location.host + '/__rebolo__/ws');
+		
+		socket.onopen = function() {
+			// The server restarted, or the build was fixed, while away
+			if (disconnected || broken) {
+				location.reload();
+				return;
+			}
+			opened = true;
+			clearInterval(polling);
+			polling = null;
+		};
+		
+		socket.onmessage = function(event) {
+			const data = JSON.parse(event.data);
+			if (data.type === 'error') {
+				broken = true;
+				showBuildError(data.output);
+			} else if (broken) {
+				// The assets build again
+				location.reload();
+			} else if (data.type === 'css') {
+				console.log('🎨 Stylesheets updated');
+				refreshStyles();
+			} else if (data.type === 'reload') {
+				console.log('⚡ Reloading page...');
+				location.reload();
+			}
+		};
+		
+		socket.onclose = function() {
+			disconnected = disconnected || opened;
+			if (!polling) {
+				polling = setInterval(checkForChanges, 1000);
+			}
+			setTimeout(connect, 1000);
+		};
+	}
 	
-	console.log('✅ Hot reload polling started');
+	connect();
 })();
 </script>
 `
 
-// responseWriter wraps http.ResponseWriter to capture and modify the response body
-type responseWriter struct {
-	http.ResponseWriter
-	body       *bytes.Buffer
-	statusCode int
+// maxPooledBuffer is the largest page buffer kept for reuse, a buffer
+// grown by an unusually big page is left to the GC instead
+const maxPooledBuffer = 256 << 10
+
+// pageBuffers are reused between responses, saving the allocation and
+// growth of a buffer per page
+var pageBuffers = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// hotReloadWriter buffers HTML responses so the hot reload script, or the
+// debug toolbar, can be injected. Anything else, and responses that are
+// flushed or hijacked (SSE, WebSockets, downloads), is written straight
+// through the shared ResponseWriter.
+type hotReloadWriter struct {
+	*ResponseWriter
+	snippet     func() string // HTML injected before </body>
+	body        *bytes.Buffer
+	statusCode  int
+	wroteHeader bool
+	passthrough bool
 }
 
-func newResponseWriter(w http.ResponseWriter) *responseWriter {
-	return &responseWriter{
-		ResponseWriter: w,
-		body:           &bytes.Buffer{},
+func newHotReloadWriter(w http.ResponseWriter, snippet func() string) *hotReloadWriter {
+	return &hotReloadWriter{
+		ResponseWriter: NewResponseWriter(w),
+		snippet:        snippet,
+		body:           pageBuffers.Get().(*bytes.Buffer),
 		statusCode:     http.StatusOK,
 	}
 }
 
-func (rw *responseWriter) Write(b []byte) (int, error) {
+func (rw *hotReloadWriter) WriteHeader(statusCode int) {
+	// Early hints go out right away, they aren't the response
+	if statusCode >= 100 && statusCode < 200 && statusCode != http.StatusSwitchingProtocols {
+		rw.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+	rw.statusCode = statusCode
+
+	// Only HTML is buffered, the content type is known up front
+	if !strings.Contains(rw.Header().Get("Content-Type"), "text/html") {
+		rw.passthrough = true
+		rw.ResponseWriter.WriteHeader(statusCode)
+	}
+}
+
+func (rw *hotReloadWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		if rw.Header().Get("Content-Type") == "" {
+			rw.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.passthrough {
+		return rw.ResponseWriter.Write(b)
+	}
 	return rw.body.Write(b)
 }
 
-func (rw *responseWriter) WriteHeader(statusCode int) {
-	rw.statusCode = statusCode
+// ReadFrom buffers HTML like Write and copies anything else straight through
+func (rw *hotReloadWriter) ReadFrom(r io.Reader) (int64, error) {
+	if rw.wroteHeader && rw.passthrough {
+		return rw.ResponseWriter.ReadFrom(r)
+	}
+	return io.Copy(writerOnly{rw}, r)
+}
+
+// Flush streams the response: what was buffered is sent as is and the
+// rest is written through
+func (rw *hotReloadWriter) Flush() {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if !rw.passthrough {
+		rw.passthrough = true
+		rw.ResponseWriter.WriteHeader(rw.statusCode)
+		rw.ResponseWriter.Write(rw.body.Bytes())
+		rw.body.Reset()
+	}
+	rw.ResponseWriter.Flush()
+}
+
+// Hijack hands the connection over, e.g. for a WebSocket
+func (rw *hotReloadWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	rw.wroteHeader = true
+	rw.passthrough = true
+	return rw.ResponseWriter.Hijack()
 }
 
-func (rw *responseWriter) Flush() {
+// Unwrap gives http.ResponseController access to the shared writer
+func (rw *hotReloadWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// finish injects the snippet into a buffered HTML response, writes it,
+// and returns the buffer to the pool
+func (rw *hotReloadWriter) finish() {
+	defer rw.release()
+	if rw.passthrough || !rw.wroteHeader {
+		return
+	}
+
 	// Remove Content-Length as we're modifying the body
 	rw.Header().Del("Content-Length")
+	rw.ResponseWriter.WriteHeader(rw.statusCode)
 
-	// Write actual headers
-	for k, v := range rw.Header() {
-		rw.ResponseWriter.Header()[k] = v
+	// Inject snippet before </body>
+	body := rw.body.Bytes()
+	if idx := bytes.LastIndex(body, []byte("</body>")); idx != -1 {
+		rw.ResponseWriter.Write(body[:idx])
+		io.WriteString(rw.ResponseWriter, rw.snippet())
+		body = body[idx:]
 	}
-	rw.ResponseWriter.WriteHeader(rw.statusCode)
+	rw.ResponseWriter.Write(body)
+}
 
-	// Write body
-	io.Copy(rw.ResponseWriter, rw.body)
+// release returns the buffer to the pool, nothing is buffered after
+func (rw *hotReloadWriter) release() {
+	body := rw.body
+	rw.body = nil
+	rw.passthrough = true
+	if body == nil || body.Cap() > maxPooledBuffer {
+		return
+	}
+	body.Reset()
+	pageBuffers.Put(body)
 }
 
 // HotReloadMiddleware injects hot reload script into HTML responses in development mode
@@ -92,29 +272,16 @@ func HotReloadMiddleware(enabled bool, skipPaths ...string) func(http.Handler) h
 				}
 			}
 
-			// Wrap response writer to capture output
-			rw := newResponseWriter(w)
-
-			// Call next handler
-			next.ServeHTTP(rw, r)
-
-			// Get content type
-			contentType := rw.Header().Get("Content-Type")
-
-			// Only inject script into HTML responses
-			if strings.Contains(contentType, "text/html") {
-				body := rw.body.String()
-
-				// Inject script before </body>
-				if idx := strings.LastIndex(body, "</body>"); idx != -1 {
-					body = body[:idx] + HotReloadScript + body[idx:]
-					rw.body.Reset()
-					rw.body.WriteString(body)
-				}
+			// WebSockets and event streams never get a page to inject into
+			if websocket.IsUpgrade(r) || strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+				next.ServeHTTP(w, r)
+				return
 			}
 
-			// Flush response
-			rw.Flush()
+			// Wrap response writer to capture HTML output
+			rw := newHotReloadWriter(w, func() string { return HotReloadScript })
+			next.ServeHTTP(rw, r)
+			rw.finish()
 		})
 	}
 }
@@ -6,24 +6,6 @@ import (
 
 // Common middleware examples
 
-// CORSMiddleware adds CORS headers
-func CORSMiddleware(allowOrigin string) MiddlewareFunc {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-			
-			if r.Method == "OPTIONS" {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-			
-			next.ServeHTTP(w, r)
-		})
-	}
-}
-
 // AuthMiddleware checks if user is authenticated (example)
 func AuthMiddleware(redirectTo string) MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
@@ -40,7 +22,8 @@ func AuthMiddleware(redirectTo string) MiddlewareFunc {
 	}
 }
 
-// RateLimitMiddleware implements simple rate limiting (placeholder)
+// RateLimitMiddleware implements simple rate limiting (placeholder), the
+// ratelimit package limits requests per IP, API key or user
 func RateLimitMiddleware(requestsPerMinute int) MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -3,6 +3,9 @@ package middleware
 import (
 	"net/http"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
 	"strings"
 )
 
@@ -14,11 +17,22 @@ type MiddlewareConfig struct {
 	handler     MiddlewareFunc
 	skipPaths   []string
 	skipMethods []string
+	skipRoutes  []skipRoute
+	onlyEnvs    []string
+	exceptEnvs  []string
+}
+
+// skipRoute is a route excluded from a middleware: a path pattern limited
+// to some methods, or all of them when empty
+type skipRoute struct {
+	pattern string
+	methods []string
 }
 
 // MiddlewareStack manages a stack of middleware with skip patterns
 type MiddlewareStack struct {
 	middlewares []*MiddlewareConfig
+	env         string
 }
 
 // NewMiddlewareStack creates a new middleware stack
@@ -28,6 +42,11 @@ func NewMiddlewareStack() *MiddlewareStack {
 	}
 }
 
+// SetEnvironment sets the environment OnlyEnv and ExceptEnv are checked against
+func (ms *MiddlewareStack) SetEnvironment(env string) {
+	ms.env = env
+}
+
 // Use adds a middleware to the stack
 func (ms *MiddlewareStack) Use(middleware MiddlewareFunc) *MiddlewareConfig {
 	config := &MiddlewareConfig{
@@ -51,6 +70,116 @@ func (mc *MiddlewareConfig) SkipMethod(methods ...string) *MiddlewareConfig {
 	return mc
 }
 
+// OnlyEnv runs this middleware only in the given environments
+func (mc *MiddlewareConfig) OnlyEnv(envs ...string) *MiddlewareConfig {
+	mc.onlyEnvs = append(mc.onlyEnvs, envs...)
+	return mc
+}
+
+// ExceptEnv leaves this middleware out in the given environments
+func (mc *MiddlewareConfig) ExceptEnv(envs ...string) *MiddlewareConfig {
+	mc.exceptEnvs = append(mc.exceptEnvs, envs...)
+	return mc
+}
+
+// Middlewares returns the middleware in the stack, outermost first
+func (ms *MiddlewareStack) Middlewares() []*MiddlewareConfig {
+	return ms.middlewares
+}
+
+// String names the middleware and where it's skipped, like
+// "middleware.CSRFMiddleware (skip /api/*)"
+func (mc *MiddlewareConfig) String() string {
+	var notes []string
+	paths := mc.skipPaths
+	for _, route := range mc.skipRoutes {
+		if len(route.methods) == 0 {
+			paths = append(paths, route.pattern)
+		} else {
+			paths = append(paths, strings.Join(route.methods, ",")+" "+route.pattern)
+		}
+	}
+	if len(paths) > 0 {
+		notes = append(notes, "skip "+strings.Join(paths, ", "))
+	}
+	if len(mc.skipMethods) > 0 {
+		notes = append(notes, "skip "+strings.Join(mc.skipMethods, ", ")+" requests")
+	}
+	if len(mc.onlyEnvs) > 0 {
+		notes = append(notes, "only in "+strings.Join(mc.onlyEnvs, ", "))
+	}
+	if len(mc.exceptEnvs) > 0 {
+		notes = append(notes, "not in "+strings.Join(mc.exceptEnvs, ", "))
+	}
+
+	name := FuncName(mc.handler)
+	if len(notes) == 0 {
+		return name
+	}
+	return name + " (" + strings.Join(notes, "; ") + ")"
+}
+
+// FuncName returns the name of a middleware function, without its import
+// path or the suffixes of closures: middleware.CSRFMiddleware
+func FuncName(fn interface{}) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return "<nil>"
+	}
+	f := runtime.FuncForPC(v.Pointer())
+	if f == nil {
+		return "<unknown>"
+	}
+	name := f.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSuffix(name, "-fm")
+	// CSRFMiddleware.func1.1 is a closure returned by CSRFMiddleware
+	for {
+		i := strings.LastIndex(name, ".")
+		if i < 0 || !closureSuffix.MatchString(name[i+1:]) {
+			break
+		}
+		name = name[:i]
+	}
+	return name
+}
+
+// closureSuffix matches the parts naming closures: func1, 1, gowrap2
+var closureSuffix = regexp.MustCompile(`^(func|gowrap)?\d+$`)
+
+// enabledIn reports whether the middleware runs in env
+func (mc *MiddlewareConfig) enabledIn(env string) bool {
+	for _, e := range mc.exceptEnvs {
+		if e == env {
+			return false
+		}
+	}
+	if len(mc.onlyEnvs) == 0 {
+		return true
+	}
+	for _, e := range mc.onlyEnvs {
+		if e == env {
+			return true
+		}
+	}
+	return false
+}
+
+// SkipRoute skips this middleware for a route pattern like "/posts/{id}"
+// and the given methods, or all methods when none are given
+func (mc *MiddlewareConfig) SkipRoute(pattern string, methods ...string) *MiddlewareConfig {
+	mc.skipRoutes = append(mc.skipRoutes, skipRoute{
+		pattern: routeVariable.ReplaceAllString(pattern, "*"),
+		methods: methods,
+	})
+	return mc
+}
+
+// routeVariable matches the {name} and {name:regexp} parts of a route
+var routeVariable = regexp.MustCompile(`\{[^/]*?\}`)
+
 // shouldSkip checks if middleware should be skipped for this request
 func (mc *MiddlewareConfig) shouldSkip(r *http.Request) bool {
 	// Check path patterns
@@ -66,6 +195,21 @@ func (mc *MiddlewareConfig) shouldSkip(r *http.Request) bool {
 			return true
 		}
 	}
+
+	// Check routes
+	for _, route := range mc.skipRoutes {
+		if matched, _ := filepath.Match(route.pattern, r.URL.Path); !matched {
+			continue
+		}
+		if len(route.methods) == 0 {
+			return true
+		}
+		for _, method := range route.methods {
+			if strings.EqualFold(r.Method, method) {
+				return true
+			}
+		}
+	}
 	
 	return false
 }
@@ -90,22 +234,27 @@ func matchPath(path, pattern string) bool {
 
 // Apply applies all middleware in the stack to a handler
 func (ms *MiddlewareStack) Apply(handler http.Handler) http.Handler {
-	// Apply middleware in reverse order (last registered = outermost)
+	// Apply middleware in reverse order (first registered = outermost)
 	for i := len(ms.middlewares) - 1; i >= 0; i-- {
 		config := ms.middlewares[i]
+		if !config.enabledIn(ms.env) {
+			continue
+		}
 		handler = ms.wrapWithSkip(config, handler)
 	}
 	return handler
 }
 
-// wrapWithSkip wraps a handler with middleware that can be skipped
+// wrapWithSkip wraps a handler with middleware that can be skipped. The
+// middleware wraps next once, not on every request.
 func (ms *MiddlewareStack) wrapWithSkip(config *MiddlewareConfig, next http.Handler) http.Handler {
+	wrapped := config.handler(next)
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if config.shouldSkip(r) {
 			next.ServeHTTP(w, r)
 			return
 		}
-		config.handler(next).ServeHTTP(w, r)
+		wrapped.ServeHTTP(w, r)
 	})
 }
 
@@ -129,14 +278,12 @@ func (mg *MiddlewareGroup) Use(middleware MiddlewareFunc) *MiddlewareGroup {
 	return mg
 }
 
-// Apply applies group middleware to a handler
+// Apply applies group middleware to a handler. The global stack already
+// wraps the whole router, so it isn't applied again here.
 func (mg *MiddlewareGroup) Apply(handler http.Handler) http.Handler {
-	// Apply group middleware
 	for i := len(mg.middlewares) - 1; i >= 0; i-- {
 		handler = mg.middlewares[i](handler)
 	}
-	
-	// Apply global middleware
-	return mg.stack.Apply(handler)
+	return handler
 }
 
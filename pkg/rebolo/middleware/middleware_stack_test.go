@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// stackBudget is the most allocations a request may take going through
+// the stack. Lower it when a change saves allocations, so they can't
+// creep back.
+const stackBudget = 0
+
+func BenchmarkMiddlewareStack(b *testing.B) {
+	handler, req := stackRequest()
+	w := httptest.NewRecorder()
+	b.ReportAllocs()
+	for b.Loop() {
+		handler.ServeHTTP(w, req)
+	}
+}
+
+func TestMiddlewareStackAllocs(t *testing.T) {
+	handler, req := stackRequest()
+	w := httptest.NewRecorder()
+	if allocs := testing.AllocsPerRun(100, func() { handler.ServeHTTP(w, req) }); allocs > stackBudget {
+		t.Errorf("%.0f allocations per request, over the budget of %d", allocs, stackBudget)
+	}
+}
+
+// stackRequest goes through five middleware skipping other paths
+func stackRequest() (http.Handler, *http.Request) {
+	stack := NewMiddlewareStack()
+	stack.SetEnvironment("production")
+	for i := 0; i < 5; i++ {
+		stack.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r)
+			})
+		}).Skip("/health", "/assets/*")
+	}
+	handler := stack.Apply(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	return handler, httptest.NewRequest("GET", "/posts/42", nil)
+}
@@ -0,0 +1,478 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/websocket"
+	"github.com/gorilla/mux"
+)
+
+// RecorderPath is where EnableHotReload serves the recorded requests
+const RecorderPath = "/__rebolo__/requests"
+
+const (
+	// DefaultRecorderSize is how many requests a Recorder keeps by default
+	DefaultRecorderSize = 50
+	// recordedBodySize is how much of a request body is kept
+	recordedBodySize = 16 << 10
+	// recordedLogLines is how many log lines a record keeps
+	recordedLogLines = 200
+)
+
+// RecordedQuery is a database query run while serving a request
+type RecordedQuery struct {
+	SQL      string        `json:"sql"`
+	Args     []interface{} `json:"args,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Record is a request served while recording. Authorization headers,
+// cookies, and form fields and session values looking like passwords or
+// tokens are filtered.
+type Record struct {
+	ID         int64             `json:"id"`
+	Time       time.Time         `json:"time"`
+	Method     string            `json:"method"`
+	Path       string            `json:"path"`
+	Route      string            `json:"route,omitempty"`   // Name of the route, if named
+	Pattern    string            `json:"pattern,omitempty"` // Path template of the route
+	RawQuery   string            `json:"query,omitempty"`
+	Headers    http.Header       `json:"headers"`
+	Params     map[string]string `json:"params,omitempty"` // Route variables
+	Form       url.Values        `json:"form,omitempty"`   // URL-encoded body
+	Body       string            `json:"body,omitempty"`   // Start of other text bodies
+	Status     int               `json:"status"`
+	Size       int64             `json:"size"`
+	Duration   time.Duration     `json:"duration"`
+	Template   string            `json:"template,omitempty"`
+	RenderTime time.Duration     `json:"render_time,omitempty"`
+	Queries    []RecordedQuery   `json:"queries,omitempty"`
+	Session    map[string]string `json:"session,omitempty"`
+	Logs       []string          `json:"logs,omitempty"` // Lines logged while it was served
+
+	mu sync.Mutex
+}
+
+// AddQuery adds a query run for the request
+func (rec *Record) AddQuery(q RecordedQuery) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.Queries = append(rec.Queries, q)
+}
+
+// Rendered records the template rendered for the request and how long it took
+func (rec *Record) Rendered(template string, took time.Duration) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.Template = template
+	rec.RenderTime += took
+}
+
+// QueryTime returns the time spent in queries
+func (rec *Record) QueryTime() time.Duration {
+	var total time.Duration
+	for _, q := range rec.Queries {
+		total += q.Duration
+	}
+	return total
+}
+
+// snapshot copies the record, which queries may still be added to
+func (rec *Record) snapshot() *Record {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return &Record{
+		ID: rec.ID, Time: rec.Time, Method: rec.Method, Path: rec.Path,
+		Route: rec.Route, Pattern: rec.Pattern, RawQuery: rec.RawQuery,
+		Headers: rec.Headers, Params: rec.Params, Form: rec.Form, Body: rec.Body,
+		Status: rec.Status, Size: rec.Size, Duration: rec.Duration,
+		Template: rec.Template, RenderTime: rec.RenderTime,
+		Queries: append([]RecordedQuery(nil), rec.Queries...),
+		Session: rec.Session,
+		Logs:    append([]string(nil), rec.Logs...),
+	}
+}
+
+type recordKey struct{}
+
+// CurrentRecord returns the record of the request ctx belongs to, or nil
+// when it isn't being recorded
+func CurrentRecord(ctx context.Context) *Record {
+	rec, _ := ctx.Value(recordKey{}).(*Record)
+	return rec
+}
+
+// RecordQuery adds a query to the record of the request ctx belongs to,
+// it has the signature of adapters.QueryObserver
+func RecordQuery(ctx context.Context, query string, args []interface{}, took time.Duration, err error) {
+	rec := CurrentRecord(ctx)
+	if rec == nil {
+		return
+	}
+	q := RecordedQuery{SQL: query, Args: args, Duration: took}
+	if err != nil {
+		q.Error = err.Error()
+	}
+	rec.AddQuery(q)
+}
+
+// Recorder keeps the last requests an app served, for debugging in
+// development: their headers, parameters, template, queries and timings.
+type Recorder struct {
+	Routes  *mux.Router                                           // Routes requests are matched against, for their name and parameters
+	Session func(r *http.Request) (map[string]interface{}, error) // Loads the session of a request
+	Toolbar bool                                                  // Injects the debug toolbar into HTML pages
+
+	mu       sync.Mutex
+	records  []*Record // ring buffer
+	next     int
+	lastID   int64
+	inFlight map[*Record]struct{}
+}
+
+// NewRecorder creates a recorder keeping the last size requests
+func NewRecorder(size int) *Recorder {
+	if size <= 0 {
+		size = DefaultRecorderSize
+	}
+	return &Recorder{records: make([]*Record, 0, size), inFlight: make(map[*Record]struct{})}
+}
+
+// Middleware records the requests, except those to /__rebolo__/
+func (rc *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/__rebolo__/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &Record{
+			Time:     time.Now(),
+			Method:   r.Method,
+			Path:     r.URL.Path,
+			RawQuery: r.URL.RawQuery,
+			Headers:  filterHeaders(r.Header),
+		}
+		rc.matchRoute(r, rec)
+		readBody(r, rec)
+
+		// The toolbar shows the finished record, the page is held until then
+		var page *hotReloadWriter
+		if rc.Toolbar && !websocket.IsUpgrade(r) && !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			page = newHotReloadWriter(w, func() string { return renderToolbar(rec) })
+			w = page
+		}
+		rw := NewResponseWriter(w)
+
+		rc.mu.Lock()
+		rc.inFlight[rec] = struct{}{}
+		rc.mu.Unlock()
+		defer func() {
+			err := recover()
+			rc.mu.Lock()
+			delete(rc.inFlight, rec)
+			rc.mu.Unlock()
+
+			rec.mu.Lock()
+			rec.Duration = time.Since(rec.Time)
+			rec.Status = rw.Status()
+			if err != nil {
+				// Recovery, outside, turns it into a 500
+				rec.Status = http.StatusInternalServerError
+			}
+			rec.Size = rw.Size()
+			rec.mu.Unlock()
+			rec.Session = rc.loadSession(r)
+			rc.add(rec)
+			if err != nil {
+				panic(err)
+			}
+			if page != nil {
+				page.finish()
+			}
+		}()
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), recordKey{}, rec)))
+	})
+}
+
+// matchRoute records the name, path template and variables of the route
+// r is for
+func (rc *Recorder) matchRoute(r *http.Request, rec *Record) {
+	if rc.Routes == nil {
+		return
+	}
+	var match mux.RouteMatch
+	if !rc.Routes.Match(r, &match) || match.Route == nil {
+		return
+	}
+	rec.Route = match.Route.GetName()
+	rec.Pattern, _ = match.Route.GetPathTemplate()
+	rec.Params = match.Vars
+}
+
+// loadSession returns the session values of r, as they came with the
+// request for cookie sessions
+func (rc *Recorder) loadSession(r *http.Request) map[string]string {
+	if rc.Session == nil {
+		return nil
+	}
+	// Stores remember the session on the request, don't touch the handler's
+	values, err := rc.Session(r.Clone(r.Context()))
+	if err != nil || len(values) == 0 {
+		return nil
+	}
+	session := make(map[string]string, len(values))
+	for key, value := range values {
+		session[key] = fmt.Sprint(value)
+		if secretName(key) {
+			session[key] = "[FILTERED]"
+		}
+	}
+	return session
+}
+
+// LogWriter returns a writer for log.SetOutput that writes to w and adds
+// each line to the requests being served. When several are served at
+// once, they all get the line.
+func (rc *Recorder) LogWriter(w io.Writer) io.Writer {
+	return logWriter{rc: rc, w: w}
+}
+
+type logWriter struct {
+	rc *Recorder
+	w  io.Writer
+}
+
+func (lw logWriter) Write(p []byte) (int, error) {
+	line := strings.TrimRight(string(p), "\n")
+	lw.rc.mu.Lock()
+	for rec := range lw.rc.inFlight {
+		rec.mu.Lock()
+		if len(rec.Logs) < recordedLogLines {
+			rec.Logs = append(rec.Logs, line)
+		}
+		rec.mu.Unlock()
+	}
+	lw.rc.mu.Unlock()
+	return lw.w.Write(p)
+}
+
+// add keeps rec, dropping the oldest record when full
+func (rc *Recorder) add(rec *Record) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.lastID++
+	rec.ID = rc.lastID
+	if len(rc.records) < cap(rc.records) {
+		rc.records = append(rc.records, rec)
+		return
+	}
+	rc.records[rc.next] = rec
+	rc.next = (rc.next + 1) % len(rc.records)
+}
+
+// Records returns the recorded requests, newest first
+func (rc *Recorder) Records() []*Record {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	records := make([]*Record, 0, len(rc.records))
+	for i := len(rc.records) - 1; i >= 0; i-- {
+		records = append(records, rc.records[(rc.next+i)%len(rc.records)].snapshot())
+	}
+	return records
+}
+
+// Record returns the request with an id, or nil once it was dropped
+func (rc *Recorder) Record(id int64) *Record {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for _, rec := range rc.records {
+		if rec.ID == id {
+			return rec.snapshot()
+		}
+	}
+	return nil
+}
+
+// Clear drops the recorded requests
+func (rc *Recorder) Clear() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.records = rc.records[:0]
+	rc.next = 0
+}
+
+// ServeHTTP lists the requests at RecorderPath and shows one at
+// RecorderPath/<id>, as JSON with ?format=json. A DELETE clears them.
+func (rc *Recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodDelete {
+		rc.Clear()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var data interface{}
+	page := "list"
+	if id := strings.Trim(strings.TrimPrefix(r.URL.Path, RecorderPath), "/"); id != "" {
+		n, _ := strconv.ParseInt(id, 10, 64)
+		rec := rc.Record(n)
+		if rec == nil {
+			http.Error(w, "request not recorded, or dropped since", http.StatusNotFound)
+			return
+		}
+		data, page = rec, "detail"
+	} else {
+		data = rc.Records()
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(data)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := recorderPages.ExecuteTemplate(w, page, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// readBody keeps the start of text bodies and gives the handler the
+// whole body back
+func readBody(r *http.Request, rec *Record) {
+	// Method override already parsed the form
+	if r.PostForm != nil {
+		if len(r.PostForm) > 0 {
+			rec.Form = filterForm(r.PostForm)
+		}
+		return
+	}
+	if r.Body == nil || r.Body == http.NoBody {
+		return
+	}
+	contentType := r.Header.Get("Content-Type")
+	form := strings.HasPrefix(contentType, "application/x-www-form-urlencoded")
+	if !form && !strings.HasPrefix(contentType, "application/json") && !strings.HasPrefix(contentType, "text/") {
+		return
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(r.Body, recordedBodySize))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+	if form {
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			rec.Form = filterForm(values)
+			return
+		}
+	}
+	rec.Body = string(body)
+}
+
+// filteredHeaders carry credentials
+var filteredHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization", "X-Api-Key", "X-Csrf-Token"}
+
+func filterHeaders(header http.Header) http.Header {
+	filtered := header.Clone()
+	for _, name := range filteredHeaders {
+		if filtered.Get(name) != "" {
+			filtered.Set(name, "[FILTERED]")
+		}
+	}
+	return filtered
+}
+
+func filterForm(form url.Values) url.Values {
+	values := make(url.Values, len(form))
+	for name, v := range form {
+		values[name] = v
+		if secretName(name) {
+			values[name] = []string{"[FILTERED]"}
+		}
+	}
+	return values
+}
+
+// secretName reports whether a form field or session key looks like it
+// holds a credential
+func secretName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, secret := range []string{"password", "token", "secret"} {
+		if strings.Contains(lower, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+var recorderFuncs = template.FuncMap{
+	"ms": func(d time.Duration) string {
+		return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 1, 64) + " ms"
+	},
+}
+
+var recorderPages = template.Must(template.New("recorder").Funcs(recorderFuncs).Parse(`
+{{define "style"}}<style>
+body{font:14px/1.4 system-ui,sans-serif;margin:2rem;color:#222}
+table{border-collapse:collapse;width:100%}
+th,td{text-align:left;padding:.3rem .6rem;border-bottom:1px solid #ddd;vertical-align:top}
+code,pre{font:13px/1.4 ui-monospace,monospace}
+pre{white-space:pre-wrap;margin:0}
+.error{color:#b00}
+a{color:#06c}
+</style>{{end}}
+
+{{define "list"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Requests</title>{{template "style"}}</head><body>
+<h1>Last requests</h1>
+{{if .}}<table>
+<tr><th>Time</th><th>Request</th><th>Status</th><th>Total</th><th>Render</th><th>Queries</th></tr>
+{{range .}}<tr>
+<td>{{.Time.Format "15:04:05"}}</td>
+<td><a href="/__rebolo__/requests/{{.ID}}"><code>{{.Method}} {{.Path}}{{if .RawQuery}}?{{.RawQuery}}{{end}}</code></a></td>
+<td{{if ge .Status 500}} class="error"{{end}}>{{.Status}}</td>
+<td>{{ms .Duration}}</td>
+<td>{{if .Template}}{{ms .RenderTime}}{{end}}</td>
+<td>{{len .Queries}}{{if .Queries}} ({{ms .QueryTime}}){{end}}</td>
+</tr>{{end}}
+</table>{{else}}<p>No requests yet, visit a page of the app.</p>{{end}}
+</body></html>{{end}}
+
+{{define "detail"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Method}} {{.Path}}</title>{{template "style"}}</head><body>
+<p><a href="/__rebolo__/requests">← All requests</a></p>
+<h1><code>{{.Method}} {{.Path}}{{if .RawQuery}}?{{.RawQuery}}{{end}}</code></h1>
+<table>
+<tr><th>Time</th><td>{{.Time.Format "2006-01-02 15:04:05.000"}}</td></tr>
+{{if .Pattern}}<tr><th>Route</th><td><code>{{.Pattern}}</code>{{if .Route}} ({{.Route}}){{end}}</td></tr>{{end}}
+<tr><th>Status</th><td>{{.Status}}, {{.Size}} bytes</td></tr>
+<tr><th>Total</th><td>{{ms .Duration}}</td></tr>
+{{if .Template}}<tr><th>Template</th><td><code>{{.Template}}</code>, {{ms .RenderTime}}</td></tr>{{end}}
+<tr><th>Queries</th><td>{{len .Queries}}, {{ms .QueryTime}}</td></tr>
+</table>
+{{if .Params}}<h2>Route parameters</h2><table>{{range $k, $v := .Params}}<tr><th>{{$k}}</th><td><code>{{$v}}</code></td></tr>{{end}}</table>{{end}}
+{{if .Form}}<h2>Form</h2><table>{{range $k, $v := .Form}}<tr><th>{{$k}}</th><td><code>{{range $v}}{{.}} {{end}}</code></td></tr>{{end}}</table>{{end}}
+{{if .Body}}<h2>Body</h2><pre>{{.Body}}</pre>{{end}}
+{{if .Queries}}<h2>Queries</h2><table>
+{{range .Queries}}<tr><td>{{ms .Duration}}</td><td><pre>{{.SQL}}</pre>{{if .Args}}<code>{{.Args}}</code>{{end}}{{if .Error}}<div class="error">{{.Error}}</div>{{end}}</td></tr>{{end}}
+</table>{{end}}
+{{if .Session}}<h2>Session</h2><table>{{range $k, $v := .Session}}<tr><th>{{$k}}</th><td><code>{{$v}}</code></td></tr>{{end}}</table>{{end}}
+{{if .Logs}}<h2>Log</h2><pre>{{range .Logs}}{{.}}
+{{end}}</pre>{{end}}
+<h2>Headers</h2><table>{{range $k, $v := .Headers}}<tr><th>{{$k}}</th><td><code>{{range $v}}{{.}} {{end}}</code></td></tr>{{end}}</table>
+</body></html>{{end}}
+`))
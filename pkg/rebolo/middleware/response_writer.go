@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter wraps an http.ResponseWriter recording the status and
+// size of the response, for middleware that logs or measures it. Flush,
+// Hijack, Push and ReadFrom reach the underlying writer, so streaming,
+// WebSockets and sendfile keep working through it.
+type ResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int64
+	wroteHeader bool
+}
+
+// NewResponseWriter wraps w, or returns w when it already is a
+// ResponseWriter, so stacked middleware share one wrapper
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	if rw, ok := w.(*ResponseWriter); ok {
+		return rw
+	}
+	return &ResponseWriter{ResponseWriter: w}
+}
+
+// Status returns the status code sent, 200 when the handler wrote nothing
+func (rw *ResponseWriter) Status() int {
+	if rw.status == 0 {
+		return http.StatusOK
+	}
+	return rw.status
+}
+
+// Size returns how many bytes of body were written
+func (rw *ResponseWriter) Size() int64 {
+	return rw.size
+}
+
+// Written reports whether the status line was sent
+func (rw *ResponseWriter) Written() bool {
+	return rw.wroteHeader
+}
+
+// WriteHeader sends the status code. Informational codes like 103 Early
+// Hints go through without counting as the response's status.
+func (rw *ResponseWriter) WriteHeader(code int) {
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		rw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+	rw.status = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *ResponseWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.size += int64(n)
+	return n, err
+}
+
+// ReadFrom copies r into the response, with sendfile when the connection
+// supports it
+func (rw *ResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	var n int64
+	var err error
+	if rf, ok := rw.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(writerOnly{rw.ResponseWriter}, r)
+	}
+	rw.size += n
+	return n, err
+}
+
+// Flush sends what was written so far
+func (rw *ResponseWriter) Flush() {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+// Hijack hands the connection over, e.g. for a WebSocket
+func (rw *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := http.NewResponseController(rw.ResponseWriter).Hijack()
+	if err == nil && !rw.wroteHeader {
+		rw.wroteHeader = true
+		rw.status = http.StatusSwitchingProtocols
+	}
+	return conn, buf, err
+}
+
+// Push starts an HTTP/2 server push, http.ErrNotSupported elsewhere
+func (rw *ResponseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := rw.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer
+func (rw *ResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// writerOnly hides ReadFrom so io.Copy doesn't call back into it
+type writerOnly struct {
+	io.Writer
+}
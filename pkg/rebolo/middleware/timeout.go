@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/errors"
+)
+
+var (
+	errorHandler   errors.ErrorHandler
+	errorHandlerMu sync.RWMutex
+)
+
+// SetErrorHandler sets how middleware answering with an error, like
+// Timeout, render it. Applications set their HandleError, so custom
+// error pages apply.
+func SetErrorHandler(handle errors.ErrorHandler) {
+	errorHandlerMu.Lock()
+	defer errorHandlerMu.Unlock()
+	errorHandler = handle
+}
+
+// HandleError renders err with the error handler, or as plain text when
+// none is set
+func HandleError(w http.ResponseWriter, r *http.Request, err error, code int) {
+	errorHandlerMu.RLock()
+	handle := errorHandler
+	errorHandlerMu.RUnlock()
+	if handle != nil {
+		handle(w, r, err, code)
+		return
+	}
+	http.Error(w, http.StatusText(code), code)
+}
+
+// Timeout bounds requests to d. The handler's context is cancelled when d
+// passes, so queries and calls using it stop, and unless the handler
+// already started its response the client gets a 503 from the error
+// handlers. What the handler writes afterwards is dropped, Write returning
+// http.ErrHandlerTimeout. A response already started is left to finish.
+//
+//	app.Use(middleware.Timeout(10 * time.Second)).Skip("/events")
+func Timeout(d time.Duration) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{w: w, ctx: ctx, header: w.Header().Clone()}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+						return
+					}
+					close(done)
+				}()
+				next.ServeHTTP(tw, r)
+			}()
+
+			finished := false
+			select {
+			case p := <-panicked:
+				// Raised again here, for the recovery middleware
+				panic(p)
+			case <-done:
+				finished = true
+			case <-ctx.Done():
+			}
+
+			tw.mu.Lock()
+			if tw.wroteHeader || (finished && ctx.Err() == nil) {
+				// Answered in time, or too late for an error page
+				tw.writeHeader(http.StatusOK)
+				tw.mu.Unlock()
+				if !finished {
+					select {
+					case p := <-panicked:
+						panic(p)
+					case <-done:
+					}
+				}
+				return
+			}
+			tw.timedOut = true
+			tw.mu.Unlock()
+
+			if ctx.Err() != context.DeadlineExceeded {
+				// The client went away, there is nobody to answer
+				return
+			}
+			log.Printf("⏳ %s %s timed out after %s", r.Method, r.URL.Path, d)
+			HandleError(w, r, errors.ErrServiceUnavailable.Wrap(ctx.Err()), http.StatusServiceUnavailable)
+		})
+	}
+}
+
+// timeoutWriter is the writer of a handler running under Timeout. The
+// handler gets its own headers and its writes are serialized with the
+// timeout's, so only one of them answers.
+type timeoutWriter struct {
+	w           http.ResponseWriter
+	ctx         context.Context
+	header      http.Header
+	mu          sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.writeHeader(code)
+}
+
+// writeHeader sends the handler's headers and code, with tw.mu held.
+// Once the deadline passed, only the timeout answers.
+func (tw *timeoutWriter) writeHeader(code int) {
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	if tw.ctx.Err() != nil {
+		tw.timedOut = true
+		return
+	}
+	header := tw.w.Header()
+	for key := range header {
+		if _, ok := tw.header[key]; !ok {
+			delete(header, key)
+		}
+	}
+	for key, values := range tw.header {
+		header[key] = append([]string(nil), values...)
+	}
+	tw.w.WriteHeader(code)
+	// Informational codes like 103 Early Hints come before the response
+	if code >= 200 || code == http.StatusSwitchingProtocols {
+		tw.wroteHeader = true
+	}
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.writeHeader(http.StatusOK)
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	return tw.w.Write(b)
+}
+
+// Flush sends what was written so far, the response can't time out after
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.writeHeader(http.StatusOK)
+	if !tw.timedOut {
+		http.NewResponseController(tw.w).Flush()
+	}
+}
+
+// Hijack hands the connection over, e.g. for a WebSocket. A hijacked
+// connection counts as answered, the timeout doesn't write onto it.
+func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.ctx.Err() != nil {
+		tw.timedOut = true
+		return nil, nil, http.ErrHandlerTimeout
+	}
+	conn, rw, err := http.NewResponseController(tw.w).Hijack()
+	if err == nil {
+		tw.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.w
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"html/template"
+	"strings"
+)
+
+// renderToolbar returns the debug toolbar of a finished request, injected
+// into its page by Recorder when Toolbar is set
+func renderToolbar(rec *Record) string {
+	var b strings.Builder
+	if err := toolbar.Execute(&b, rec.snapshot()); err != nil {
+		return "<!-- rebolo toolbar: " + template.HTMLEscapeString(err.Error()) + " -->"
+	}
+	return b.String()
+}
+
+// toolbar is collapsed to a tab in the corner and opens on click. Its
+// styles are inline, so it doesn't change how the page looks.
+var toolbar = template.Must(template.New("toolbar").Funcs(recorderFuncs).Parse(`
+<div id="__rebolo_toolbar" style="position:fixed;right:0;bottom:0;z-index:2147483646;max-width:100%;font:12px/1.4 system-ui,sans-serif;color:#eee">
+<details style="background:rgba(24,24,27,.96);border-top-left-radius:6px;box-shadow:0 0 8px rgba(0,0,0,.3)">
+<summary style="cursor:pointer;padding:.35rem .7rem;list-style:none;white-space:nowrap">
+🐞 <b>{{.Status}}</b>
+· {{if .Route}}{{.Route}}{{else if .Pattern}}{{.Pattern}}{{else}}{{.Path}}{{end}}
+· {{ms .Duration}}
+{{if .Template}}· render {{ms .RenderTime}}{{end}}
+· {{len .Queries}} {{if eq (len .Queries) 1}}query{{else}}queries{{end}}{{if .Queries}} ({{ms .QueryTime}}){{end}}
+</summary>
+<div style="max-height:60vh;width:min(48rem,100vw);overflow:auto;padding:.2rem .7rem .7rem">
+<p style="margin:.3rem 0"><code style="font:12px ui-monospace,monospace">{{.Method}} {{.Path}}</code>{{if .Pattern}} → <code style="font:12px ui-monospace,monospace">{{.Pattern}}</code>{{end}}{{if .Template}}, rendered <code style="font:12px ui-monospace,monospace">{{.Template}}</code>{{end}}
+· <a href="/__rebolo__/requests/{{.ID}}" style="color:#7cc4ff">details</a> · <a href="/__rebolo__/requests" style="color:#7cc4ff">all requests</a></p>
+{{if .Params}}<h4 style="margin:.6rem 0 .2rem">Parameters</h4>
+{{range $k, $v := .Params}}<div><b>{{$k}}</b> {{$v}}</div>{{end}}{{end}}
+{{if .Queries}}<h4 style="margin:.6rem 0 .2rem">Queries</h4>
+{{range .Queries}}<div style="font:12px ui-monospace,monospace;white-space:pre-wrap;border-bottom:1px solid #333;padding:.15rem 0">{{ms .Duration}} {{.SQL}}{{if .Args}} {{.Args}}{{end}}{{if .Error}} <span style="color:#f77">{{.Error}}</span>{{end}}</div>{{end}}{{end}}
+<h4 style="margin:.6rem 0 .2rem">Session</h4>
+{{range $k, $v := .Session}}<div><b>{{$k}}</b> {{$v}}</div>{{else}}<div>Empty</div>{{end}}
+{{if .Logs}}<h4 style="margin:.6rem 0 .2rem">Log</h4>
+<pre style="font:12px ui-monospace,monospace;white-space:pre-wrap;margin:0">{{range .Logs}}{{.}}
+{{end}}</pre>{{end}}
+</div>
+</details>
+</div>
+`))
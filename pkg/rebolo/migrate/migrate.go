@@ -0,0 +1,166 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/schema"
+)
+
+// Table records the applied migration versions
+const Table = "schema_migrations"
+
+// Migration is a SQL file in the migrations directory.
+// Files are named <version>_<name>.sql, e.g. 20240101120000_create_posts.sql
+type Migration struct {
+	Version string
+	Name    string
+	Path    string
+}
+
+// Migrator applies pending migrations from a directory
+type Migrator struct {
+	db     *sql.DB
+	driver string
+	dir    string
+}
+
+// New creates a migrator for the given driver and migrations directory
+func New(db *sql.DB, driver, dir string) (*Migrator, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+	return &Migrator{db: db, driver: schema.NormalizeDriver(driver), dir: dir}, nil
+}
+
+// Migrations lists the migration files sorted by version
+func (m *Migrator) Migrations() ([]Migration, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".sql" {
+			continue
+		}
+		base := strings.TrimSuffix(e.Name(), ".sql")
+		version, name, _ := strings.Cut(base, "_")
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    name,
+			Path:    filepath.Join(m.dir, e.Name()),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Pending lists the migrations that have not been applied yet
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := m.Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, mig := range all {
+		if !applied[mig.Version] {
+			pending = append(pending, mig)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration in order, each in its own transaction,
+// and returns the ones applied. It stops at the first failure.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, mig := range pending {
+		if err := m.apply(ctx, mig); err != nil {
+			return done, fmt.Errorf("migration %s failed: %w", filepath.Base(mig.Path), err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, mig Migration) error {
+	content, err := os.ReadFile(mig.Path)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, string(content)); err != nil {
+		return err
+	}
+
+	insert := "INSERT INTO " + Table + " (version, applied_at) VALUES (?, ?)"
+	if m.driver == "postgres" {
+		insert = "INSERT INTO " + Table + " (version, applied_at) VALUES ($1, $2)"
+	}
+	if _, err := tx.ExecContext(ctx, insert, mig.Version, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+Table+" (version VARCHAR(255) PRIMARY KEY, applied_at TIMESTAMP NOT NULL)")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", Table, err)
+	}
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[string]bool, error) {
+	rows, err := m.db.QueryContext(ctx, "SELECT version FROM "+Table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
@@ -0,0 +1,156 @@
+package model
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// Hook methods models implement, with pointer receivers, to run code when
+// they are saved:
+//
+//	func (u *User) BeforeSave(ctx context.Context) error {
+//		if u.Password != "" {
+//			hash, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+//			u.PasswordHash, u.Password = string(hash), ""
+//			return err
+//		}
+//		return nil
+//	}
+type (
+	BeforeCreator interface {
+		BeforeCreate(ctx context.Context) error
+	}
+	AfterCreator interface {
+		AfterCreate(ctx context.Context) error
+	}
+	BeforeUpdater interface {
+		BeforeUpdate(ctx context.Context) error
+	}
+	AfterUpdater interface {
+		AfterUpdate(ctx context.Context) error
+	}
+	BeforeSaver interface {
+		BeforeSave(ctx context.Context) error
+	}
+	AfterSaver interface {
+		AfterSave(ctx context.Context) error
+	}
+)
+
+// Event is a point of a model's save where hooks run
+type Event int
+
+// Save events. The save ones run on both create and update.
+const (
+	BeforeCreate Event = iota
+	AfterCreate
+	BeforeUpdate
+	AfterUpdate
+	BeforeSave
+	AfterSave
+)
+
+type hookFunc func(ctx context.Context, m interface{}) error
+
+var (
+	hooks   = make(map[reflect.Type]map[Event][]hookFunc)
+	hooksMu sync.RWMutex
+)
+
+// On registers fn to run on event for every *T, after T's own hook
+// method. It keeps code that needs the app, like enqueueing jobs, out of
+// the models package:
+//
+//	model.On(model.AfterCreate, func(ctx context.Context, u *models.User) error {
+//		return app.Perform(worker.Job{Handler: "send_welcome_email", Args: worker.Args{"user_id": u.ID}})
+//	})
+func On[T any](event Event, fn func(ctx context.Context, m *T) error) {
+	t := reflect.TypeOf((*T)(nil))
+
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	if hooks[t] == nil {
+		hooks[t] = make(map[Event][]hookFunc)
+	}
+	hooks[t][event] = append(hooks[t][event], func(ctx context.Context, m interface{}) error {
+		return fn(ctx, m.(*T))
+	})
+}
+
+// Create runs insert, the statement saving m, between the create hooks:
+// BeforeSave, BeforeCreate, then Touch for models with Timestamps, and
+// AfterCreate, AfterSave once inserted. A hook error stops the chain and
+// is returned, before insert when it comes from a Before hook.
+func Create(ctx context.Context, m interface{}, insert func() error) error {
+	return save(ctx, m, insert, BeforeCreate, AfterCreate)
+}
+
+// Update runs update between the update hooks, as Create does
+func Update(ctx context.Context, m interface{}, update func() error) error {
+	return save(ctx, m, update, BeforeUpdate, AfterUpdate)
+}
+
+func save(ctx context.Context, m interface{}, write func() error, before, after Event) error {
+	for _, event := range []Event{BeforeSave, before} {
+		if err := run(ctx, m, event); err != nil {
+			return err
+		}
+	}
+	if t, ok := m.(interface{ Touch() }); ok {
+		t.Touch()
+	}
+	if err := write(); err != nil {
+		return err
+	}
+	for _, event := range []Event{after, AfterSave} {
+		if err := run(ctx, m, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run calls the hook method of m for event, then the hooks registered with On
+func run(ctx context.Context, m interface{}, event Event) error {
+	var err error
+	switch event {
+	case BeforeCreate:
+		if h, ok := m.(BeforeCreator); ok {
+			err = h.BeforeCreate(ctx)
+		}
+	case AfterCreate:
+		if h, ok := m.(AfterCreator); ok {
+			err = h.AfterCreate(ctx)
+		}
+	case BeforeUpdate:
+		if h, ok := m.(BeforeUpdater); ok {
+			err = h.BeforeUpdate(ctx)
+		}
+	case AfterUpdate:
+		if h, ok := m.(AfterUpdater); ok {
+			err = h.AfterUpdate(ctx)
+		}
+	case BeforeSave:
+		if h, ok := m.(BeforeSaver); ok {
+			err = h.BeforeSave(ctx)
+		}
+	case AfterSave:
+		if h, ok := m.(AfterSaver); ok {
+			err = h.AfterSave(ctx)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	hooksMu.RLock()
+	registered := hooks[reflect.TypeOf(m)][event]
+	hooksMu.RUnlock()
+	for _, fn := range registered {
+		if err := fn(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// Timestamps are the created_at and updated_at columns, embedded in the
+// models generated with timestamps:
+//
+//	type Post struct {
+//		ID    int64  `json:"id"`
+//		Title string `json:"title"`
+//		model.Timestamps
+//	}
+type Timestamps struct {
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Touch sets UpdatedAt to now, and CreatedAt too when the record is new,
+// before it is saved
+func (t *Timestamps) Touch() {
+	now := time.Now()
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = now
+	}
+	t.UpdatedAt = now
+}
+
+// SoftDeletes is the deleted_at column of models whose rows are kept when
+// deleted. Lists hide them with query.Options.SoftDelete.
+type SoftDeletes struct {
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Trashed reports whether the record was soft deleted
+func (s *SoftDeletes) Trashed() bool {
+	return s.DeletedAt != nil
+}
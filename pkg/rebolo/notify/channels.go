@@ -0,0 +1,217 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/mail"
+)
+
+// client sends the requests of the Slack and Twilio channels
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// MailNotification is a notification sent by email. A message without
+// recipients goes to the recipient's NotificationEmail.
+type MailNotification interface {
+	Mail(to interface{}) (*mail.Message, error)
+}
+
+// MailRecipient is a recipient with an email address
+type MailRecipient interface {
+	NotificationEmail() string
+}
+
+type mailNotifier struct {
+	send func(*mail.Message) error
+}
+
+// Mail is a channel sending email with send, like app.SendMail, which the
+// app uses for the "mail" channel of Default
+func Mail(send func(*mail.Message) error) Notifier {
+	return &mailNotifier{send: send}
+}
+
+func (m *mailNotifier) Build(to interface{}, n Notification) (interface{}, error) {
+	mn, ok := n.(MailNotification)
+	if !ok {
+		return nil, fmt.Errorf("%T has no Mail method", n)
+	}
+	msg, err := mn.Mail(to)
+	if err != nil || msg == nil {
+		return nil, err
+	}
+	if len(msg.To) == 0 {
+		r, ok := to.(MailRecipient)
+		if !ok || r.NotificationEmail() == "" {
+			return nil, fmt.Errorf("no email address for %T, implement notify.MailRecipient", to)
+		}
+		msg.AddTo(r.NotificationEmail())
+	}
+	return msg, nil
+}
+
+func (m *mailNotifier) Deliver(ctx context.Context, payload json.RawMessage) error {
+	msg := mail.NewMessage()
+	if err := json.Unmarshal(payload, msg); err != nil {
+		return err
+	}
+	return m.send(msg)
+}
+
+// SlackMessage is a message posted to a Slack incoming webhook
+type SlackMessage struct {
+	Text   string          `json:"text"`             // Shown in notifications, and when there are no blocks
+	Blocks json.RawMessage `json:"blocks,omitempty"` // Block Kit layout
+}
+
+// SlackNotification is a notification posted to Slack
+type SlackNotification interface {
+	Slack(to interface{}) (*SlackMessage, error)
+}
+
+// SlackRecipient is a recipient with its own incoming webhook, like a
+// team posting to its channel
+type SlackRecipient interface {
+	NotificationSlackWebhook() string
+}
+
+type slackNotifier struct {
+	webhookURL string
+}
+
+// slackPayload is a message and the webhook it's posted to
+type slackPayload struct {
+	URL     string       `json:"url"`
+	Message SlackMessage `json:"message"`
+}
+
+// Slack is a channel posting to the incoming webhook at webhookURL, or
+// the recipient's NotificationSlackWebhook
+func Slack(webhookURL string) Notifier {
+	return &slackNotifier{webhookURL: webhookURL}
+}
+
+func (s *slackNotifier) Build(to interface{}, n Notification) (interface{}, error) {
+	sn, ok := n.(SlackNotification)
+	if !ok {
+		return nil, fmt.Errorf("%T has no Slack method", n)
+	}
+	msg, err := sn.Slack(to)
+	if err != nil || msg == nil {
+		return nil, err
+	}
+	target := s.webhookURL
+	if r, ok := to.(SlackRecipient); ok && r.NotificationSlackWebhook() != "" {
+		target = r.NotificationSlackWebhook()
+	}
+	if target == "" {
+		return nil, fmt.Errorf("no Slack webhook for %T", to)
+	}
+	return slackPayload{URL: target, Message: *msg}, nil
+}
+
+func (s *slackNotifier) Deliver(ctx context.Context, payload json.RawMessage) error {
+	var p slackPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+	body, err := json.Marshal(p.Message)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return do(req, "Slack")
+}
+
+// TwilioURL is the Twilio API the SMS channel calls
+var TwilioURL = "https://api.twilio.com"
+
+// SMSNotification is a notification sent as a text message
+type SMSNotification interface {
+	SMS(to interface{}) (string, error)
+}
+
+// SMSRecipient is a recipient with a phone number, in E.164 format like
+// +573001234567
+type SMSRecipient interface {
+	NotificationPhone() string
+}
+
+type twilioNotifier struct {
+	accountSID string
+	authToken  string
+	from       string
+}
+
+// smsPayload is a text message and the number it goes to
+type smsPayload struct {
+	To   string `json:"to"`
+	Body string `json:"body"`
+}
+
+// Twilio is a channel sending text messages from the number from with
+// Twilio's Messages API
+func Twilio(accountSID, authToken, from string) Notifier {
+	return &twilioNotifier{accountSID: accountSID, authToken: authToken, from: from}
+}
+
+func (t *twilioNotifier) Build(to interface{}, n Notification) (interface{}, error) {
+	sn, ok := n.(SMSNotification)
+	if !ok {
+		return nil, fmt.Errorf("%T has no SMS method", n)
+	}
+	r, ok := to.(SMSRecipient)
+	if !ok {
+		return nil, fmt.Errorf("no phone number for %T, implement notify.SMSRecipient", to)
+	}
+	if r.NotificationPhone() == "" {
+		// Users without a phone don't get texts
+		return nil, nil
+	}
+	body, err := sn.SMS(to)
+	if err != nil || body == "" {
+		return nil, err
+	}
+	return smsPayload{To: r.NotificationPhone(), Body: body}, nil
+}
+
+func (t *twilioNotifier) Deliver(ctx context.Context, payload json.RawMessage) error {
+	var p smsPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+	form := url.Values{"To": {p.To}, "From": {t.from}, "Body": {p.Body}}
+	endpoint := strings.TrimSuffix(TwilioURL, "/") + "/2010-04-01/Accounts/" + url.PathEscape(t.accountSID) + "/Messages.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(t.accountSID, t.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return do(req, "Twilio")
+}
+
+// do sends req, failing on an error status with the start of the body
+func do(req *http.Request, service string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s answered %d: %s", service, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
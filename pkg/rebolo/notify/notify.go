@@ -0,0 +1,177 @@
+// Package notify tells users about things through channels: email, Slack,
+// SMS, or your own Notifier. A notification picks its channels and
+// builds a message for each:
+//
+//	type PasswordResetNotification struct{ URL string }
+//
+//	func (n PasswordResetNotification) Via(to interface{}) []string { return []string{"mail", "sms"} }
+//
+//	func (n PasswordResetNotification) Mail(to interface{}) (*mail.Message, error) {
+//		msg := mail.NewMessage().SetSubject("Reset your password")
+//		return msg, mail.Render(msg, "notifications/password_reset", n)
+//	}
+//
+//	func (n PasswordResetNotification) SMS(to interface{}) (string, error) {
+//		return "Reset your password: " + n.URL, nil
+//	}
+//
+//	notify.Send(user, PasswordResetNotification{URL: url})
+//
+// Messages are built when Send is called and delivered by the app's
+// worker, so a slow SMTP server or API doesn't hold the request.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/worker"
+)
+
+// JobName is the worker job delivering notifications, the app registers it
+const JobName = "notify"
+
+// Notification is something to tell a recipient. Via names the channels
+// it goes through, and for each the notification implements the method
+// the channel's Notifier builds messages with, like Mail for "mail".
+type Notification interface {
+	Via(to interface{}) []string
+}
+
+// Notifier is a channel. Build turns a notification for a recipient into
+// a payload, nil to skip the recipient, and Deliver sends it once it came
+// back from the worker as JSON.
+type Notifier interface {
+	Build(to interface{}, n Notification) (interface{}, error)
+	Deliver(ctx context.Context, payload json.RawMessage) error
+}
+
+// delivery is the args of a JobName job
+type delivery struct {
+	Channel string          `json:"channel"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Dispatcher sends notifications through its channels
+type Dispatcher struct {
+	mu        sync.RWMutex
+	channels  map[string]Notifier
+	performer worker.Performer
+	job       *worker.Definition[delivery]
+}
+
+// New creates a dispatcher without channels
+func New() *Dispatcher {
+	d := &Dispatcher{channels: make(map[string]Notifier)}
+	d.job = worker.Define(JobName, d.deliver)
+	return d
+}
+
+// Default is the dispatcher of the package functions. The app adds the
+// mail channel, Slack and Twilio when configured in config.yml, and
+// delivers with its worker.
+var Default = New()
+
+// Use adds a channel, replacing the one with the same name
+func (d *Dispatcher) Use(name string, n Notifier) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.channels[name] = n
+}
+
+// SetPerformer sets what performs the delivery jobs, the app does it for
+// Default. Without one, notifications are delivered as they're sent.
+func (d *Dispatcher) SetPerformer(p worker.Performer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.performer = p
+}
+
+// Handler delivers the JobName jobs, register it with the worker
+func (d *Dispatcher) Handler() worker.ContextHandler {
+	return d.job.Handler()
+}
+
+// Send builds n's messages for to, see SendContext
+func (d *Dispatcher) Send(to interface{}, n Notification) error {
+	return d.SendContext(context.Background(), to, n)
+}
+
+// SendContext builds n's message for to on each of its channels and
+// queues their delivery. Errors of the channels are returned together,
+// after every channel got the notification.
+func (d *Dispatcher) SendContext(ctx context.Context, to interface{}, n Notification) error {
+	d.mu.RLock()
+	performer := d.performer
+	d.mu.RUnlock()
+
+	var errs []error
+	for _, name := range n.Via(to) {
+		payload, err := d.build(name, to, n)
+		if err != nil || payload == nil {
+			errs = append(errs, err)
+			continue
+		}
+		if performer == nil {
+			errs = append(errs, d.deliver(ctx, *payload))
+			continue
+		}
+		errs = append(errs, d.job.Perform(performer, *payload))
+	}
+	return errors.Join(errs...)
+}
+
+// build builds the payload of channel name, nil when it skips to
+func (d *Dispatcher) build(name string, to interface{}, n Notification) (*delivery, error) {
+	d.mu.RLock()
+	channel := d.channels[name]
+	d.mu.RUnlock()
+	if channel == nil {
+		return nil, fmt.Errorf("notify: no %q channel for %T", name, n)
+	}
+
+	payload, err := channel.Build(to, n)
+	if err != nil || payload == nil {
+		if err != nil {
+			err = fmt.Errorf("notify: building %T for %s: %w", n, name, err)
+		}
+		return nil, err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("notify: encoding %T for %s: %w", n, name, err)
+	}
+	return &delivery{Channel: name, Payload: data}, nil
+}
+
+// deliver sends a built payload through its channel
+func (d *Dispatcher) deliver(ctx context.Context, job delivery) error {
+	d.mu.RLock()
+	channel := d.channels[job.Channel]
+	d.mu.RUnlock()
+	if channel == nil {
+		return fmt.Errorf("notify: no %q channel", job.Channel)
+	}
+	if err := channel.Deliver(ctx, job.Payload); err != nil {
+		return fmt.Errorf("notify: delivering through %s: %w", job.Channel, err)
+	}
+	return nil
+}
+
+// Send sends a notification with the Default dispatcher
+func Send(to interface{}, n Notification) error {
+	return Default.Send(to, n)
+}
+
+// SendContext sends a notification with the Default dispatcher
+func SendContext(ctx context.Context, to interface{}, n Notification) error {
+	return Default.SendContext(ctx, to, n)
+}
+
+// Use adds a channel to the Default dispatcher
+func Use(name string, n Notifier) {
+	Default.Use(name, n)
+}
@@ -0,0 +1,317 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/resource"
+	"github.com/gorilla/mux"
+)
+
+// Paths the spec and Swagger UI are served at by Application.OpenAPI
+const (
+	DefaultPath   = "/openapi.json"
+	SwaggerUIPath = "/swagger"
+)
+
+// Operation describes a route beyond what its path and methods tell:
+//
+//	app.POST("/api/login", login).Describe(openapi.Operation{
+//		Summary:  "Log in",
+//		Request:  LoginRequest{},
+//		Response: Token{},
+//	})
+type Operation struct {
+	Summary     string
+	Description string
+	Tags        []string    // the first path segment after /api by default
+	Request     interface{} // JSON body, a struct value or pointer
+	Response    interface{} // JSON body of the success response
+	Status      int         // status of the success response, 200 by default
+}
+
+// ResourceDoc describes the JSON bodies of a resource, see Documented
+type ResourceDoc struct {
+	Tag      string      // groups the operations, the path segment by default
+	Request  interface{} // struct bound by Create and Update
+	Response interface{} // struct returned by Show, Create and Update
+}
+
+// Documented can be implemented by a resource.Resource so the routes
+// RESTResource registers are documented with their bodies:
+//
+//	func (res *PostsResource) OpenAPI() openapi.ResourceDoc {
+//		return openapi.ResourceDoc{Request: PostRequest{}, Response: models.Post{}}
+//	}
+type Documented interface {
+	OpenAPI() ResourceDoc
+}
+
+// Operation returns the description of one of the resource's actions
+func (d ResourceDoc) Operation(action resource.Action) Operation {
+	op := Operation{}
+	if d.Tag != "" {
+		op.Tags = []string{d.Tag}
+	}
+
+	switch action {
+	case resource.List:
+		op.Summary = "List"
+		if d.Response != nil {
+			t := reflect.TypeOf(d.Response)
+			op.Response = reflect.New(reflect.SliceOf(t)).Elem().Interface()
+		}
+	case resource.Show:
+		op.Summary, op.Response = "Show", d.Response
+	case resource.Create:
+		op.Summary, op.Request, op.Response, op.Status = "Create", d.Request, d.Response, http.StatusCreated
+	case resource.Update:
+		op.Summary, op.Request, op.Response = "Update", d.Request, d.Response
+	case resource.Destroy:
+		op.Summary, op.Status = "Delete", http.StatusNoContent
+	}
+	return op
+}
+
+var (
+	operations   = make(map[*mux.Route]Operation)
+	operationsMu sync.RWMutex
+)
+
+// Describe attaches an operation to a route, see routing.NamedRoute.Describe
+func Describe(route *mux.Route, op Operation) {
+	operationsMu.Lock()
+	defer operationsMu.Unlock()
+	operations[route] = op
+}
+
+func described(route *mux.Route) (Operation, bool) {
+	operationsMu.RLock()
+	defer operationsMu.RUnlock()
+	op, ok := operations[route]
+	return op, ok
+}
+
+// Document is an OpenAPI 3 document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info is the title and version of the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of a path by lowercase method
+type PathItem map[string]*OperationObject
+
+// OperationObject is an operation of the document
+type OperationObject struct {
+	OperationID string              `json:"operationId,omitempty"`
+	Summary     string              `json:"summary,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter is a path parameter, or a query one in documents read by
+// rebolo generate client
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the JSON body of an operation
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the schemas of the structs used by the operations
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+var pathParam = regexp.MustCompile(`\{([^}:]+)(?::([^}]*))?\}`)
+
+// Generate builds the document of the routes registered on router. Routes
+// without methods, like static file prefixes, and the framework's own
+// /__rebolo__ routes are left out.
+func Generate(router *mux.Router, info Info) *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+	}
+	schemas := newSchemaBuilder()
+
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil || strings.HasPrefix(template, "/__rebolo__") {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		// "/posts/{id:[0-9]+}" is "/posts/{id}" with a pattern
+		var params []Parameter
+		path := pathParam.ReplaceAllStringFunc(template, func(m string) string {
+			match := pathParam.FindStringSubmatch(m)
+			schema := &Schema{Type: "string"}
+			if match[2] != "" {
+				schema.Pattern = "^" + match[2] + "$"
+			}
+			params = append(params, Parameter{Name: match[1], In: "path", Required: true, Schema: schema})
+			return "{" + match[1] + "}"
+		})
+		if path == DefaultPath || path == SwaggerUIPath {
+			return nil
+		}
+
+		op, _ := described(route)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(PathItem)
+		}
+		for _, method := range methods {
+			object := operation(op, schemas, params, path)
+			if name := route.GetName(); name != "" {
+				object.OperationID = name
+				if len(methods) > 1 {
+					object.OperationID += method[:1] + strings.ToLower(method[1:])
+				}
+			}
+			doc.Paths[path][strings.ToLower(method)] = object
+		}
+		return nil
+	})
+
+	doc.Components.Schemas = schemas.components
+	return doc
+}
+
+func operation(op Operation, schemas *schemaBuilder, params []Parameter, path string) *OperationObject {
+	object := &OperationObject{
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Parameters:  params,
+		Responses:   make(map[string]Response),
+	}
+	if len(object.Tags) == 0 {
+		if tag := defaultTag(path); tag != "" {
+			object.Tags = []string{tag}
+		}
+	}
+
+	if op.Request != nil {
+		object.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonContent(schemas.schema(reflect.TypeOf(op.Request))),
+		}
+		object.Responses["400"] = Response{Description: "Malformed body"}
+		object.Responses["422"] = Response{Description: "Validation failed"}
+	}
+	if len(params) > 0 {
+		object.Responses["404"] = Response{Description: "Not found"}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Response{Description: http.StatusText(status)}
+	if op.Response != nil && status != http.StatusNoContent {
+		success.Content = jsonContent(schemas.schema(reflect.TypeOf(op.Response)))
+	}
+	object.Responses[strconv.Itoa(status)] = success
+	return object
+}
+
+// defaultTag groups operations by the first path segment after /api
+func defaultTag(path string) string {
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if segment != "" && segment != "api" && !strings.HasPrefix(segment, "{") && !isVersion(segment) {
+			return segment
+		}
+	}
+	return ""
+}
+
+// isVersion reports whether segment is like "v1"
+func isVersion(segment string) bool {
+	if len(segment) < 2 || segment[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(segment[1:])
+	return err == nil
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+// Handler serves the document of router as JSON, generated on each
+// request so routes added after it are included
+func Handler(router *mux.Router, info Info) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Generate(router, info))
+	})
+}
+
+// SwaggerUI serves a Swagger UI page for the document at specURL. The UI
+// is loaded from the unpkg CDN.
+func SwaggerUI(title, specURL string) http.Handler {
+	page := fmt.Sprintf(swaggerPage, html.EscapeString(title), strconv.Quote(specURL))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	})
+}
+
+const swaggerPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <title>%s - API</title>
+    <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+    <div id="swagger-ui"></div>
+    <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+    <script>
+        SwaggerUIBundle({ url: %s, dom_id: "#swagger-ui" });
+    </script>
+</body>
+</html>
+`
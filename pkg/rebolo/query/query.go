@@ -0,0 +1,270 @@
+package query
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/errors"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/geo"
+)
+
+// Options whitelists the columns a list can be sorted and filtered on.
+// Parameters naming other columns are rejected, so the column names that
+// end up in the SQL always come from the code, never from the request.
+type Options struct {
+	Sortable    []string // columns allowed in ?sort=
+	Filterable  []string // columns allowed in ?filter[column]=
+	DefaultSort string   // sort used without ?sort=, e.g. "-created_at"
+	SoftDelete  bool     // hide rows whose deleted_at is set, see Query.Unscoped
+}
+
+// DeletedAt is the column soft deleted rows have set
+const DeletedAt = "deleted_at"
+
+// Order is a column of ORDER BY
+type Order struct {
+	Column string
+	Desc   bool
+}
+
+// Filter is a condition of WHERE
+type Filter struct {
+	Column string
+	Op     string // eq, ne, gt, gte, lt, lte, like, in, null, near or box
+	Value  string
+}
+
+// Query holds the sort and filters of a list request
+type Query struct {
+	Sort    []Order
+	Filters []Filter
+	Scoped  bool // only rows whose deleted_at is NULL, set by Options.SoftDelete
+}
+
+// operators maps the filter operators to SQL
+var operators = map[string]string{
+	"eq":  "=",
+	"ne":  "<>",
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+}
+
+var filterKey = regexp.MustCompile(`^filter\[([^\[\]]+)\](?:\[([a-z]+)\])?$`)
+
+// Parse reads the sort and filter parameters of a list request:
+//
+//	?sort=-created_at,title          ORDER BY created_at DESC, title ASC
+//	?filter[completed]=true          WHERE completed = ?
+//	?filter[views][gte]=10           WHERE views >= ?
+//	?filter[title][like]=go          WHERE title LIKE ? ("%go%")
+//	?filter[status][in]=draft,done   WHERE status IN (?, ?)
+//	?filter[deleted_at][null]=true   WHERE deleted_at IS NULL
+//	?filter[location][near]=4.65,-74.06,2000   within 2km of lat,lng, see geo.Within
+//	?filter[location][box]=-74.2,4.5,-73.9,4.8  in the box west,south,east,north, see geo.InBox
+//
+// Columns missing from opts and unknown operators return a 400 error.
+func Parse(values url.Values, opts Options) (*Query, error) {
+	q := &Query{Scoped: opts.SoftDelete}
+
+	sortParam := values.Get("sort")
+	if sortParam == "" {
+		sortParam = opts.DefaultSort
+	} else if err := allowedSort(sortParam, opts.Sortable); err != nil {
+		return nil, err
+	}
+	for _, field := range strings.Split(sortParam, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		order := Order{Column: strings.TrimLeft(field, "+-"), Desc: strings.HasPrefix(field, "-")}
+		q.Sort = append(q.Sort, order)
+	}
+
+	// Sorted keys keep the generated SQL stable
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		match := filterKey.FindStringSubmatch(key)
+		if match == nil {
+			continue
+		}
+		column, op := match[1], match[2]
+		if op == "" {
+			op = "eq"
+		}
+		if !contains(opts.Filterable, column) {
+			return nil, errors.NewError(http.StatusBadRequest, fmt.Sprintf("cannot filter by %q", column))
+		}
+		if _, ok := operators[op]; !ok && op != "like" && op != "in" && op != "null" && op != "near" && op != "box" {
+			return nil, errors.NewError(http.StatusBadRequest, fmt.Sprintf("unknown filter operator %q", op))
+		}
+		for _, value := range values[key] {
+			if _, _, err := spatial(Filter{Column: column, Op: op, Value: value}); err != nil {
+				return nil, errors.NewError(http.StatusBadRequest, fmt.Sprintf("invalid %s filter on %q: %v", op, column, err))
+			}
+			q.Filters = append(q.Filters, Filter{Column: column, Op: op, Value: value})
+		}
+	}
+
+	return q, nil
+}
+
+// FromRequest parses the query string of r, see Parse
+func FromRequest(r *http.Request, opts Options) (*Query, error) {
+	return Parse(r.URL.Query(), opts)
+}
+
+// Unscoped returns a copy of the query that includes soft deleted rows,
+// e.g. for a trash view:
+//
+//	q.Unscoped().Apply("SELECT id, title FROM posts")
+func (q *Query) Unscoped() *Query {
+	unscoped := *q
+	unscoped.Scoped = false
+	return &unscoped
+}
+
+// Where returns the WHERE clause of the filters and its arguments, with ?
+// placeholders. It is empty without filters and soft delete scope.
+func (q *Query) Where() (string, []interface{}) {
+	if len(q.Filters) == 0 && !q.Scoped {
+		return "", nil
+	}
+
+	conditions := make([]string, 0, len(q.Filters)+1)
+	if q.Scoped {
+		conditions = append(conditions, DeletedAt+" IS NULL")
+	}
+	var args []interface{}
+	for _, f := range q.Filters {
+		switch f.Op {
+		case "like":
+			conditions = append(conditions, f.Column+" LIKE ?")
+			args = append(args, "%"+f.Value+"%")
+		case "in":
+			parts := strings.Split(f.Value, ",")
+			conditions = append(conditions, f.Column+" IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(parts)), ", ")+")")
+			for _, part := range parts {
+				args = append(args, argument(part))
+			}
+		case "near", "box":
+			condition, spatialArgs, _ := spatial(f)
+			conditions = append(conditions, condition)
+			args = append(args, spatialArgs...)
+		case "null":
+			if f.Value == "false" {
+				conditions = append(conditions, f.Column+" IS NOT NULL")
+			} else {
+				conditions = append(conditions, f.Column+" IS NULL")
+			}
+		default:
+			conditions = append(conditions, f.Column+" "+operators[f.Op]+" ?")
+			args = append(args, argument(f.Value))
+		}
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// OrderBy returns the ORDER BY clause of the sort, empty without one
+func (q *Query) OrderBy() string {
+	if len(q.Sort) == 0 {
+		return ""
+	}
+
+	columns := make([]string, len(q.Sort))
+	for i, o := range q.Sort {
+		direction := "ASC"
+		if o.Desc {
+			direction = "DESC"
+		}
+		columns[i] = o.Column + " " + direction
+	}
+	return "ORDER BY " + strings.Join(columns, ", ")
+}
+
+// Apply appends the WHERE and ORDER BY clauses to a SELECT statement:
+//
+//	stmt, args := q.Apply("SELECT id, title FROM posts")
+//	rows, err := db.QueryContext(ctx, stmt, args...)
+func (q *Query) Apply(stmt string) (string, []interface{}) {
+	where, args := q.Where()
+	for _, clause := range []string{where, q.OrderBy()} {
+		if clause != "" {
+			stmt += " " + clause
+		}
+	}
+	return stmt, args
+}
+
+// spatial returns the condition of a near or box filter, checked by Parse
+func spatial(f Filter) (string, []interface{}, error) {
+	switch f.Op {
+	case "near":
+		// lat,lng,meters
+		i := strings.LastIndex(f.Value, ",")
+		if i < 0 {
+			return "", nil, fmt.Errorf("want lat,lng,meters")
+		}
+		center, err := geo.ParsePoint(f.Value[:i])
+		if err != nil {
+			return "", nil, err
+		}
+		meters, err := strconv.ParseFloat(strings.TrimSpace(f.Value[i+1:]), 64)
+		if err != nil || meters < 0 {
+			return "", nil, fmt.Errorf("want lat,lng,meters")
+		}
+		condition, args := geo.Within(f.Column, center, meters)
+		return condition, args, nil
+	case "box":
+		box, err := geo.ParseBox(f.Value)
+		if err != nil {
+			return "", nil, err
+		}
+		condition, args := geo.InBox(f.Column, box)
+		return condition, args, nil
+	}
+	return "", nil, nil
+}
+
+// argument passes "true" and "false" as booleans, other values as strings
+func argument(value string) interface{} {
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return value
+}
+
+// allowedSort checks every column of a sort parameter against the whitelist
+func allowedSort(sortParam string, columns []string) error {
+	for _, field := range strings.Split(sortParam, ",") {
+		column := strings.TrimLeft(strings.TrimSpace(field), "+-")
+		if column != "" && !contains(columns, column) {
+			return errors.NewError(http.StatusBadRequest, fmt.Sprintf("cannot sort by %q", column))
+		}
+	}
+	return nil
+}
+
+func contains(columns []string, column string) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
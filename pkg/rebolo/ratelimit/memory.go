@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Store = &MemoryStore{}
+
+// MemoryStore counts requests in the process. Each instance of the app
+// counts its own requests, use a RedisStore to share the counts.
+type MemoryStore struct {
+	mu        sync.Mutex
+	windows   map[string]*window
+	lastSweep time.Time
+}
+
+// window is a key's count since start
+type window struct {
+	count int
+	reset time.Time
+}
+
+// NewMemoryStore creates a store counting requests in memory
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{windows: make(map[string]*window)}
+}
+
+// Increment counts a request for key
+func (s *MemoryStore) Increment(ctx context.Context, key string, length time.Duration) (int, time.Time, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Forget the keys whose window ended, once a minute
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, w := range s.windows {
+			if !now.Before(w.reset) {
+				delete(s.windows, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	w, ok := s.windows[key]
+	if !ok || !now.Before(w.reset) {
+		w = &window{reset: now.Add(length)}
+		s.windows[key] = w
+	}
+	w.count++
+	return w.count, w.reset, nil
+}
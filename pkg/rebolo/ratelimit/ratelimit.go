@@ -0,0 +1,195 @@
+// Package ratelimit throttles requests per key: a client IP, an API key or
+// a signed in user, each with its own quota. Counts are kept in fixed
+// windows, in memory or in Redis when the app runs on several instances.
+//
+//	keys := apikeys.Enable(app)
+//	app.Use(keys.Middleware) // before the limiter, ByAPIKey reads its key
+//
+//	store, err := ratelimit.NewRedisStore("redis://localhost:6379/0")
+//	app.Use(ratelimit.Middleware(ratelimit.Options{
+//		Key:   ratelimit.FirstKey(ratelimit.ByAPIKey, ratelimit.ByIP),
+//		Quota: ratelimit.PerMinute(60),
+//		Store: store,
+//	})).Skip("/public/*")
+//
+// Responses carry X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset (Unix seconds), and requests over the quota get a 429
+// with Retry-After.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/apikeys"
+)
+
+// Store counts requests per key in fixed windows
+type Store interface {
+	// Increment counts a request for key in its current window, which
+	// starts with the key's first request and lasts window. It returns
+	// the requests counted and when the window ends.
+	Increment(ctx context.Context, key string, window time.Duration) (count int, reset time.Time, err error)
+}
+
+// Quota is how many requests a key can make per window
+type Quota struct {
+	Limit  int
+	Window time.Duration
+}
+
+// PerSecond, PerMinute and PerHour return quotas of n requests
+func PerSecond(n int) Quota { return Quota{Limit: n, Window: time.Second} }
+func PerMinute(n int) Quota { return Quota{Limit: n, Window: time.Minute} }
+func PerHour(n int) Quota   { return Quota{Limit: n, Window: time.Hour} }
+
+// KeyFunc returns the key a request is counted under, empty for requests
+// that aren't limited
+type KeyFunc func(r *http.Request) string
+
+// Options configure the Middleware
+type Options struct {
+	// Key picks who a request is counted for, ByIP by default
+	Key KeyFunc
+	// Quota applies to every key unless QuotaFor returns another one,
+	// for plans with different limits. A zero Limit doesn't limit the key.
+	Quota    Quota
+	QuotaFor func(r *http.Request, key string) Quota
+	// Store keeps the counts, a MemoryStore by default
+	Store Store
+	// Prefix is put before keys in the store, "ratelimit:" by default
+	Prefix string
+	// OnLimit answers requests over the quota, a plain 429 by default
+	OnLimit http.Handler
+}
+
+// Middleware limits requests to the quota of their key. When the store
+// fails requests are let through, an outage of Redis doesn't take the app
+// down.
+func Middleware(opts Options) func(http.Handler) http.Handler {
+	if opts.Key == nil {
+		opts.Key = ByIP
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore()
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = "ratelimit:"
+	}
+	if opts.OnLimit == nil {
+		opts.OnLimit = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := opts.Key(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			quota := opts.Quota
+			if opts.QuotaFor != nil {
+				quota = opts.QuotaFor(r, key)
+			}
+			if quota.Limit <= 0 || quota.Window <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			count, reset, err := opts.Store.Increment(r.Context(), opts.Prefix+key, quota.Window)
+			if err != nil {
+				log.Printf("⚠️  Rate limit store failed, request let through: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Set("X-RateLimit-Limit", strconv.Itoa(quota.Limit))
+			header.Set("X-RateLimit-Remaining", strconv.Itoa(max(quota.Limit-count, 0)))
+			header.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+			if count > quota.Limit {
+				wait := math.Ceil(time.Until(reset).Seconds())
+				header.Set("Retry-After", strconv.Itoa(max(int(wait), 1)))
+				opts.OnLimit.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ByIP counts requests per client IP, the address the request came from.
+// Behind a proxy, use ByHeader with the header it sets.
+func ByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// ByHeader counts requests per value of a header, like X-Real-IP set by
+// a proxy. Requests without it aren't limited.
+func ByHeader(name string) KeyFunc {
+	return func(r *http.Request) string {
+		value := strings.TrimSpace(r.Header.Get(name))
+		if value == "" {
+			return ""
+		}
+		return strings.ToLower(name) + ":" + value
+	}
+}
+
+// ByAPIKey counts requests per API key, the one the apikeys middleware
+// authenticated, so that middleware has to run first. Requests without a
+// valid key get no key, so made up keys fall through to the next key of
+// FirstKey, like ByIP, instead of getting a fresh quota each.
+func ByAPIKey(r *http.Request) string {
+	key := apikeys.Current(r)
+	if key == nil {
+		return ""
+	}
+	return "key:" + key.ID
+}
+
+// ByUser counts requests per signed in user, the session value sessionKey
+// ("user_id" when empty). Anonymous requests aren't limited.
+func ByUser(app *rebolo.Application, sessionKey string) KeyFunc {
+	if sessionKey == "" {
+		sessionKey = "user_id"
+	}
+	return func(r *http.Request) string {
+		sess, err := app.GetSession(r, nil)
+		if err != nil {
+			return ""
+		}
+		id := sess.Get(sessionKey)
+		if id == nil {
+			return ""
+		}
+		return fmt.Sprintf("user:%v", id)
+	}
+}
+
+// FirstKey uses the first of keys returning a key, to limit users by
+// account and anonymous requests by IP
+func FirstKey(keys ...KeyFunc) KeyFunc {
+	return func(r *http.Request) string {
+		for _, key := range keys {
+			if k := key(r); k != "" {
+				return k
+			}
+		}
+		return ""
+	}
+}
@@ -0,0 +1,254 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var _ Store = &RedisStore{}
+
+// incrementScript counts a request and starts the key's window on its
+// first one, atomically, then returns the count and the ms left
+const incrementScript = `local n = redis.call('INCR', KEYS[1])
+if n == 1 then redis.call('PEXPIRE', KEYS[1], ARGV[1]) end
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 then redis.call('PEXPIRE', KEYS[1], ARGV[1]) ttl = tonumber(ARGV[1]) end
+return {n, ttl}`
+
+// RedisStore counts requests in Redis, so every instance of the app shares
+// the counts. It speaks the Redis protocol itself, without a client
+// library, and keeps a few connections open.
+type RedisStore struct {
+	addr     string
+	username string
+	password string
+	db       int
+	tls      bool
+	// Timeout bounds each call to Redis, 1 second by default
+	Timeout time.Duration
+
+	idle chan *redisConn
+}
+
+// NewRedisStore connects to the Redis server at rawURL:
+// redis://[[user]:password@]host[:port][/db], rediss:// for TLS
+func NewRedisStore(rawURL string) (*RedisStore, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("invalid Redis URL %q: the scheme must be redis or rediss", rawURL)
+	}
+
+	s := &RedisStore{
+		addr:    u.Host,
+		tls:     u.Scheme == "rediss",
+		Timeout: time.Second,
+		idle:    make(chan *redisConn, 8),
+	}
+	if u.Port() == "" {
+		s.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		s.username = u.User.Username()
+		s.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if s.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid Redis database %q", db)
+		}
+	}
+
+	// Fail at boot on a wrong address or password rather than on the
+	// first request
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.put(conn)
+	return s, nil
+}
+
+// Increment counts a request for key
+func (s *RedisStore) Increment(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	ms := strconv.FormatInt(window.Milliseconds(), 10)
+	reply, err := s.do(ctx, "EVAL", incrementScript, "1", key, ms)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 {
+		return 0, time.Time{}, fmt.Errorf("unexpected Redis reply %v", reply)
+	}
+	count, _ := values[0].(int64)
+	ttl, _ := values[1].(int64)
+	return int(count), time.Now().Add(time.Duration(ttl) * time.Millisecond), nil
+}
+
+// Close closes the idle connections
+func (s *RedisStore) Close() error {
+	for {
+		select {
+		case conn := <-s.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do runs a command on an idle connection, or a new one
+func (s *RedisStore) do(ctx context.Context, args ...string) (interface{}, error) {
+	var conn *redisConn
+	select {
+	case conn = <-s.idle:
+	default:
+		var err error
+		if conn, err = s.dial(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := conn.do(s.deadline(ctx), args...)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		// The connection may be out of step with the server
+		conn.Close()
+		return nil, err
+	}
+	s.put(conn)
+	return reply, err
+}
+
+func (s *RedisStore) put(conn *redisConn) {
+	select {
+	case s.idle <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+func (s *RedisStore) deadline(ctx context.Context) time.Time {
+	deadline := time.Now().Add(s.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		return d
+	}
+	return deadline
+}
+
+// dial connects, authenticates and selects the database
+func (s *RedisStore) dial(ctx context.Context) (*redisConn, error) {
+	dialer := &net.Dialer{Timeout: s.Timeout}
+	var netConn net.Conn
+	var err error
+	if s.tls {
+		host, _, _ := net.SplitHostPort(s.addr)
+		netConn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", s.addr)
+	} else {
+		netConn, err = dialer.DialContext(ctx, "tcp", s.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connecting to Redis: %w", err)
+	}
+
+	conn := &redisConn{Conn: netConn, r: bufio.NewReader(netConn)}
+	if s.password != "" {
+		args := []string{"AUTH", s.password}
+		if s.username != "" {
+			args = []string{"AUTH", s.username, s.password}
+		}
+		if _, err := conn.do(s.deadline(ctx), args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("authenticating to Redis: %w", err)
+		}
+	}
+	if s.db != 0 {
+		if _, err := conn.do(s.deadline(ctx), "SELECT", strconv.Itoa(s.db)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("selecting Redis database %d: %w", s.db, err)
+		}
+	}
+	return conn, nil
+}
+
+// redisConn is a connection speaking RESP, the Redis protocol
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// redisError is an error reply, the connection is still usable
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// do sends a command and reads its reply: a string, an int64, nil or a
+// []interface{} of them
+func (c *redisConn) do(deadline time.Time, args ...string) (interface{}, error) {
+	c.SetDeadline(deadline)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.Conn, b.String()); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
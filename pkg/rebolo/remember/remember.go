@@ -0,0 +1,277 @@
+package remember
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/schema"
+	"github.com/gorilla/sessions"
+)
+
+// Options configures remember-me logins
+type Options struct {
+	Cookie     string        // cookie holding the token, "remember_token" by default
+	MaxAge     time.Duration // how long a token signs the user in, 30 days by default
+	SessionKey string        // session value holding the user ID, "user_id" by default
+	Table      string        // where tokens are kept, "remember_tokens" by default
+}
+
+// Remember signs users back in from a long-lived cookie once their session
+// is gone. The cookie holds a selector, to find the token, and a validator
+// of which only a SHA-256 hash is stored, so a leaked table can't be used
+// to sign in.
+type Remember struct {
+	app  *rebolo.Application
+	opts Options
+}
+
+// Enable creates the token table if needed and adds the middleware that
+// restores sessions:
+//
+//	rm := remember.Enable(app)
+//	// after checking the password
+//	rm.SignIn(w, r, strconv.Itoa(user.ID), r.FormValue("remember") == "on")
+//
+// The middleware sets the SessionKey value to the user ID as a string.
+func Enable(app *rebolo.Application, opts ...Options) *Remember {
+	rm := &Remember{app: app}
+	if len(opts) > 0 {
+		rm.opts = opts[0]
+	}
+	if rm.opts.Cookie == "" {
+		rm.opts.Cookie = "remember_token"
+	}
+	if rm.opts.MaxAge <= 0 {
+		rm.opts.MaxAge = 30 * 24 * time.Hour
+	}
+	if rm.opts.SessionKey == "" {
+		rm.opts.SessionKey = "user_id"
+	}
+	if rm.opts.Table == "" {
+		rm.opts.Table = "remember_tokens"
+	}
+
+	if err := rm.createTable(context.Background()); err != nil {
+		log.Printf("❌ Remember-me tokens unavailable: %v", err)
+	}
+	app.Use(rm.Middleware)
+	app.OnCleanup("expired remember-me tokens", rm.Cleanup)
+	return rm
+}
+
+// SignIn puts userID in the session, renewed so an ID planted before
+// doesn't follow the user in, and remembers them when remember is set
+func (rm *Remember) SignIn(w http.ResponseWriter, r *http.Request, userID string, remember bool) error {
+	sess, err := rm.app.GetSession(r, w)
+	if err != nil {
+		return err
+	}
+	sess.Set(rm.opts.SessionKey, userID)
+	if err := sess.Renew(); err != nil {
+		return err
+	}
+	if !remember {
+		return nil
+	}
+	return rm.Remember(w, r, userID)
+}
+
+// Remember issues a token for userID and sets its cookie
+func (rm *Remember) Remember(w http.ResponseWriter, r *http.Request, userID string) error {
+	selector, err := randomString(12)
+	if err != nil {
+		return err
+	}
+	validator, err := randomString(32)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	_, err = rm.app.DB().ExecContext(r.Context(), schema.Rebind(rm.app.DatabaseDriver(),
+		"INSERT INTO "+rm.opts.Table+" (selector, validator_hash, user_id, expires_at, created_at) VALUES (?, ?, ?, ?, ?)"),
+		selector, hash(validator), userID, now.Add(rm.opts.MaxAge).Unix(), now.Unix())
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, rm.cookie(selector+":"+validator, int(rm.opts.MaxAge.Seconds())))
+	return nil
+}
+
+// Forget deletes the request's token and its cookie, call it on logout
+func (rm *Remember) Forget(w http.ResponseWriter, r *http.Request) error {
+	http.SetCookie(w, rm.cookie("", -1))
+	selector, _, ok := rm.token(r)
+	if !ok {
+		return nil
+	}
+	_, err := rm.app.DB().ExecContext(r.Context(), schema.Rebind(rm.app.DatabaseDriver(),
+		"DELETE FROM "+rm.opts.Table+" WHERE selector = ?"), selector)
+	return err
+}
+
+// RevokeAll deletes every token of userID, signing them out of every
+// device they were remembered on. Call it when the password changes.
+func (rm *Remember) RevokeAll(ctx context.Context, userID string) error {
+	_, err := rm.app.DB().ExecContext(ctx, schema.Rebind(rm.app.DatabaseDriver(),
+		"DELETE FROM "+rm.opts.Table+" WHERE user_id = ?"), userID)
+	return err
+}
+
+// Cleanup deletes expired tokens and returns how many there were
+func (rm *Remember) Cleanup(ctx context.Context) (int64, error) {
+	result, err := rm.app.DB().ExecContext(ctx, schema.Rebind(rm.app.DatabaseDriver(),
+		"DELETE FROM "+rm.opts.Table+" WHERE expires_at < ?"), time.Now().Unix())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Middleware signs the user back in when the session has no user ID and
+// the request carries a valid token, on a renewed session. The token gets
+// a new validator every time. Invalid or expired tokens lose their
+// cookie.
+func (rm *Remember) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie(rm.opts.Cookie); err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		sess, err := rm.app.GetSession(r, w)
+		if err != nil || sess.Get(rm.opts.SessionKey) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userID, err := rm.verify(w, r)
+		switch {
+		case err == nil:
+			sess.Set(rm.opts.SessionKey, userID)
+			if err := sess.Renew(); err != nil {
+				log.Printf("⚠️  Failed to restore remembered session: %v", err)
+			}
+		case errors.Is(err, errInvalidToken):
+			http.SetCookie(w, rm.cookie("", -1))
+		case errors.Is(err, errTokenRotated):
+			// Another request of the browser got the new validator
+		default:
+			log.Printf("⚠️  Failed to check remember-me token: %v", err)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// errInvalidToken is returned for tokens that are malformed, unknown,
+// expired or don't match
+var errInvalidToken = errors.New("invalid remember-me token")
+
+// errTokenRotated is returned when another request replaced the token's
+// validator between reading and replacing it
+var errTokenRotated = errors.New("remember-me token rotated meanwhile")
+
+// verify returns the user of the request's token, and replaces its
+// validator, so a copied cookie stops working once either copy is used
+func (rm *Remember) verify(w http.ResponseWriter, r *http.Request) (string, error) {
+	selector, validator, ok := rm.token(r)
+	if !ok {
+		return "", errInvalidToken
+	}
+
+	var validatorHash, userID string
+	var expiresAt int64
+	err := rm.app.DB().QueryRowContext(r.Context(), schema.Rebind(rm.app.DatabaseDriver(),
+		"SELECT validator_hash, user_id, expires_at FROM "+rm.opts.Table+" WHERE selector = ?"), selector).
+		Scan(&validatorHash, &userID, &expiresAt)
+	if err == sql.ErrNoRows {
+		return "", errInvalidToken
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hash(validator)), []byte(validatorHash)) != 1 || time.Now().Unix() > expiresAt {
+		// A wrong validator means the selector leaked, drop the token either way
+		if _, err := rm.app.DB().ExecContext(r.Context(), schema.Rebind(rm.app.DatabaseDriver(),
+			"DELETE FROM "+rm.opts.Table+" WHERE selector = ?"), selector); err != nil {
+			log.Printf("⚠️  Failed to revoke remember-me token: %v", err)
+		}
+		return "", errInvalidToken
+	}
+
+	next, err := randomString(32)
+	if err != nil {
+		return "", err
+	}
+	result, err := rm.app.DB().ExecContext(r.Context(), schema.Rebind(rm.app.DatabaseDriver(),
+		"UPDATE "+rm.opts.Table+" SET validator_hash = ? WHERE selector = ? AND validator_hash = ?"),
+		hash(next), selector, validatorHash)
+	if err != nil {
+		return "", err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return "", errTokenRotated
+	}
+	http.SetCookie(w, rm.cookie(selector+":"+next, int(time.Until(time.Unix(expiresAt, 0)).Seconds())))
+	return userID, nil
+}
+
+// token splits the request's cookie into selector and validator
+func (rm *Remember) token(r *http.Request) (selector, validator string, ok bool) {
+	cookie, err := r.Cookie(rm.opts.Cookie)
+	if err != nil {
+		return "", "", false
+	}
+	selector, validator, ok = strings.Cut(cookie.Value, ":")
+	return selector, validator, ok && selector != "" && validator != ""
+}
+
+// cookie returns the token cookie, with the session cookie's attributes
+func (rm *Remember) cookie(value string, maxAge int) *http.Cookie {
+	options := *rm.app.SessionStore().Options()
+	options.MaxAge = maxAge
+	options.HttpOnly = true
+	return sessions.NewCookie(rm.opts.Cookie, value, &options)
+}
+
+func (rm *Remember) createTable(ctx context.Context) error {
+	db := rm.app.DB()
+	if db == nil {
+		return errors.New("no database connection")
+	}
+	_, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+rm.opts.Table+` (
+	selector VARCHAR(32) PRIMARY KEY,
+	validator_hash VARCHAR(64) NOT NULL,
+	user_id VARCHAR(255) NOT NULL,
+	expires_at BIGINT NOT NULL,
+	created_at BIGINT NOT NULL
+)`)
+	return err
+}
+
+func hash(validator string) string {
+	sum := sha256.Sum256([]byte(validator))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomString returns n random bytes, URL safe encoded
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
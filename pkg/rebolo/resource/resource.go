@@ -53,3 +53,54 @@ func (v BaseResource) Destroy(ctx *context.Context) error {
 type Middler interface {
 	Use() []interface{} // Middleware functions
 }
+
+// Action is one of the RESTful actions of a Resource
+type Action string
+
+// The actions of a Resource and the routes they are registered on
+const (
+	List    Action = "list"    // GET    /path
+	Show    Action = "show"    // GET    /path/{id}
+	Create  Action = "create"  // POST   /path
+	Update  Action = "update"  // PUT    /path/{id} (and PATCH)
+	Destroy Action = "destroy" // DELETE /path/{id}
+)
+
+// Option limits the routes registered for a Resource
+type Option func(actions map[Action]bool)
+
+// Only registers just the routes of the given actions
+func Only(actions ...Action) Option {
+	return func(enabled map[Action]bool) {
+		for action := range enabled {
+			enabled[action] = false
+		}
+		for _, action := range actions {
+			enabled[action] = true
+		}
+	}
+}
+
+// Except registers every route but those of the given actions
+func Except(actions ...Action) Option {
+	return func(enabled map[Action]bool) {
+		for _, action := range actions {
+			enabled[action] = false
+		}
+	}
+}
+
+// Actions returns the actions enabled by opts, all of them by default
+func Actions(opts ...Option) map[Action]bool {
+	enabled := map[Action]bool{
+		List:    true,
+		Show:    true,
+		Create:  true,
+		Update:  true,
+		Destroy: true,
+	}
+	for _, opt := range opts {
+		opt(enabled)
+	}
+	return enabled
+}
@@ -3,6 +3,8 @@ package routing
 import (
 	"fmt"
 
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/middleware"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/openapi"
 	"github.com/gorilla/mux"
 )
 
@@ -17,6 +19,32 @@ func (r *NamedRoute) Name(name string) *NamedRoute {
 	return r
 }
 
+// SkipMiddleware excludes this route from middleware added with
+// Application.Use:
+//
+//	logging := app.Use(RequestLogger)
+//	app.GET("/health", health).SkipMiddleware(logging)
+func (r *NamedRoute) SkipMiddleware(configs ...*middleware.MiddlewareConfig) *NamedRoute {
+	pattern, err := r.Route.GetPathTemplate()
+	if err != nil {
+		return r
+	}
+	methods, _ := r.Route.GetMethods()
+	for _, config := range configs {
+		config.SkipRoute(pattern, methods...)
+	}
+	return r
+}
+
+// Describe documents the route in the OpenAPI spec served by
+// Application.OpenAPI:
+//
+//	app.POST("/api/login", login).Describe(openapi.Operation{Request: LoginRequest{}})
+func (r *NamedRoute) Describe(op openapi.Operation) *NamedRoute {
+	openapi.Describe(r.Route, op)
+	return r
+}
+
 // URLFor generates a URL for a named route with the given parameters
 func URLFor(router *mux.Router, name string, params map[string]string) (string, error) {
 	route := router.Get(name)
@@ -51,3 +79,25 @@ func URLForString(router *mux.Router, name string, params map[string]string) str
 	}
 	return url
 }
+
+// RouteInfo describes a registered route
+type RouteInfo struct {
+	Methods []string // empty for routes answering any method, like static files
+	Path    string
+	Name    string
+}
+
+// Routes lists the routes registered on router, in registration order
+func Routes(router *mux.Router) []RouteInfo {
+	var routes []RouteInfo
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, _ := route.GetMethods()
+		routes = append(routes, RouteInfo{Methods: methods, Path: path, Name: route.GetName()})
+		return nil
+	})
+	return routes
+}
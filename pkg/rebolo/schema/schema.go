@@ -0,0 +1,423 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Column describes a table column
+type Column struct {
+	Name       string
+	Type       string
+	Nullable   bool
+	Default    sql.NullString
+	PrimaryKey bool
+}
+
+// Index describes a table index
+type Index struct {
+	Name    string
+	Columns []string
+	Unique  bool
+	Primary bool
+}
+
+// ForeignKey describes a foreign key constraint
+type ForeignKey struct {
+	Column    string
+	RefTable  string
+	RefColumn string
+}
+
+// Table describes a table with its columns, indexes and constraints
+type Table struct {
+	Name        string
+	Columns     []Column
+	Indexes     []Index
+	ForeignKeys []ForeignKey
+}
+
+// Column returns the column with the given name, or nil
+func (t *Table) Column(name string) *Column {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+// NormalizeDriver returns the canonical driver name (postgres, sqlite,
+// mysql), the SQL dialect serverless drivers speak for libsql and neon
+func NormalizeDriver(driver string) string {
+	switch strings.ToLower(driver) {
+	case "postgres", "postgresql", "neon":
+		return "postgres"
+	case "sqlite", "sqlite3", "libsql", "turso":
+		return "sqlite"
+	case "mysql":
+		return "mysql"
+	}
+	return strings.ToLower(driver)
+}
+
+// Rebind turns the ? placeholders of stmt into $1, $2... when driver is
+// postgres, leaving it as is for the others
+func Rebind(driver, stmt string) string {
+	if NormalizeDriver(driver) != "postgres" {
+		return stmt
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range stmt {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// QuoteIdent quotes a table or column name for driver, backticks for mysql
+// and double quotes for the others
+func QuoteIdent(driver, name string) string {
+	if NormalizeDriver(driver) == "mysql" {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Inspector reads the live schema of a database
+type Inspector struct {
+	db     *sql.DB
+	driver string
+}
+
+// NewInspector creates a schema inspector for the given driver
+func NewInspector(db *sql.DB, driver string) (*Inspector, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	driver = NormalizeDriver(driver)
+	switch driver {
+	case "postgres", "sqlite", "mysql":
+	default:
+		return nil, fmt.Errorf("schema inspection not supported for driver: %s", driver)
+	}
+
+	return &Inspector{db: db, driver: driver}, nil
+}
+
+// Driver returns the canonical driver name
+func (i *Inspector) Driver() string {
+	return i.driver
+}
+
+// Tables lists the user tables of the current database
+func (i *Inspector) Tables(ctx context.Context) ([]string, error) {
+	var query string
+	switch i.driver {
+	case "postgres":
+		query = "SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() AND table_type = 'BASE TABLE'"
+	case "mysql":
+		query = "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'"
+	case "sqlite":
+		query = "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'"
+	}
+
+	names, err := i.strings(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// HasTable reports whether a table exists
+func (i *Inspector) HasTable(ctx context.Context, name string) (bool, error) {
+	tables, err := i.Tables(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, t := range tables {
+		if t == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Table reads the full description of a table
+func (i *Inspector) Table(ctx context.Context, name string) (*Table, error) {
+	exists, err := i.HasTable(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("table %s does not exist", name)
+	}
+
+	table := &Table{Name: name}
+
+	switch i.driver {
+	case "sqlite":
+		err = i.sqliteTable(ctx, table)
+	case "postgres":
+		err = i.postgresTable(ctx, table)
+	case "mysql":
+		err = i.mysqlTable(ctx, table)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect table %s: %w", name, err)
+	}
+
+	return table, nil
+}
+
+// sqliteTable reads columns, indexes and foreign keys using PRAGMAs.
+// The table name has already been checked against sqlite_master.
+func (i *Inspector) sqliteTable(ctx context.Context, table *Table) error {
+	quoted := `"` + strings.ReplaceAll(table.Name, `"`, `""`) + `"`
+
+	rows, err := i.db.QueryContext(ctx, "PRAGMA table_info("+quoted+")")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var cid, notNull, pk int
+		var col Column
+		if err := rows.Scan(&cid, &col.Name, &col.Type, &notNull, &col.Default, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		col.Nullable = notNull == 0 && pk == 0
+		col.PrimaryKey = pk > 0
+		table.Columns = append(table.Columns, col)
+	}
+	rows.Close()
+
+	rows, err = i.db.QueryContext(ctx, "PRAGMA index_list("+quoted+")")
+	if err != nil {
+		return err
+	}
+	var indexes []Index
+	for rows.Next() {
+		var seq, unique, partial int
+		var name, origin string
+		if err := rows.Scan(&seq, &name, &unique, &origin, &partial); err != nil {
+			rows.Close()
+			return err
+		}
+		indexes = append(indexes, Index{Name: name, Unique: unique == 1, Primary: origin == "pk"})
+	}
+	rows.Close()
+
+	for _, idx := range indexes {
+		quotedIdx := `"` + strings.ReplaceAll(idx.Name, `"`, `""`) + `"`
+		rows, err := i.db.QueryContext(ctx, "PRAGMA index_info("+quotedIdx+")")
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var seqno, cid int
+			var col sql.NullString
+			if err := rows.Scan(&seqno, &cid, &col); err != nil {
+				rows.Close()
+				return err
+			}
+			idx.Columns = append(idx.Columns, col.String)
+		}
+		rows.Close()
+		table.Indexes = append(table.Indexes, idx)
+	}
+
+	rows, err = i.db.QueryContext(ctx, "PRAGMA foreign_key_list("+quoted+")")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, seq int
+		var fk ForeignKey
+		var to sql.NullString
+		var onUpdate, onDelete, match string
+		if err := rows.Scan(&id, &seq, &fk.RefTable, &fk.Column, &to, &onUpdate, &onDelete, &match); err != nil {
+			return err
+		}
+		fk.RefColumn = to.String
+		table.ForeignKeys = append(table.ForeignKeys, fk)
+	}
+	return rows.Err()
+}
+
+func (i *Inspector) postgresTable(ctx context.Context, table *Table) error {
+	rows, err := i.db.QueryContext(ctx, `
+		SELECT column_name,
+		       CASE WHEN character_maximum_length IS NOT NULL
+		            THEN data_type || '(' || character_maximum_length || ')'
+		            ELSE data_type END,
+		       is_nullable = 'YES',
+		       column_default
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1
+		ORDER BY ordinal_position`, table.Name)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var col Column
+		if err := rows.Scan(&col.Name, &col.Type, &col.Nullable, &col.Default); err != nil {
+			rows.Close()
+			return err
+		}
+		table.Columns = append(table.Columns, col)
+	}
+	rows.Close()
+
+	rows, err = i.db.QueryContext(ctx, `
+		SELECT i.relname, ix.indisunique, ix.indisprimary,
+		       array_to_string(array_agg(a.attname ORDER BY array_position(ix.indkey::int2[], a.attnum)), ',')
+		FROM pg_class t
+		JOIN pg_index ix ON t.oid = ix.indrelid
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(ix.indkey)
+		WHERE t.relname = $1
+		  AND t.relnamespace = (SELECT oid FROM pg_namespace WHERE nspname = current_schema())
+		GROUP BY i.relname, ix.indisunique, ix.indisprimary
+		ORDER BY i.relname`, table.Name)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var idx Index
+		var columns string
+		if err := rows.Scan(&idx.Name, &idx.Unique, &idx.Primary, &columns); err != nil {
+			rows.Close()
+			return err
+		}
+		idx.Columns = strings.Split(columns, ",")
+		if idx.Primary {
+			for _, name := range idx.Columns {
+				if col := table.Column(name); col != nil {
+					col.PrimaryKey = true
+				}
+			}
+		}
+		table.Indexes = append(table.Indexes, idx)
+	}
+	rows.Close()
+
+	rows, err = i.db.QueryContext(ctx, `
+		SELECT kcu.column_name, ccu.table_name, ccu.column_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+		  ON tc.constraint_name = kcu.constraint_name AND tc.table_schema = kcu.table_schema
+		JOIN information_schema.constraint_column_usage ccu
+		  ON ccu.constraint_name = tc.constraint_name AND ccu.table_schema = tc.table_schema
+		WHERE tc.constraint_type = 'FOREIGN KEY'
+		  AND tc.table_schema = current_schema() AND tc.table_name = $1`, table.Name)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var fk ForeignKey
+		if err := rows.Scan(&fk.Column, &fk.RefTable, &fk.RefColumn); err != nil {
+			return err
+		}
+		table.ForeignKeys = append(table.ForeignKeys, fk)
+	}
+	return rows.Err()
+}
+
+func (i *Inspector) mysqlTable(ctx context.Context, table *Table) error {
+	rows, err := i.db.QueryContext(ctx, `
+		SELECT column_name, column_type, is_nullable = 'YES', column_default, column_key = 'PRI'
+		FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = ?
+		ORDER BY ordinal_position`, table.Name)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var col Column
+		if err := rows.Scan(&col.Name, &col.Type, &col.Nullable, &col.Default, &col.PrimaryKey); err != nil {
+			rows.Close()
+			return err
+		}
+		table.Columns = append(table.Columns, col)
+	}
+	rows.Close()
+
+	rows, err = i.db.QueryContext(ctx, `
+		SELECT index_name, non_unique = 0, column_name
+		FROM information_schema.statistics
+		WHERE table_schema = DATABASE() AND table_name = ?
+		ORDER BY index_name, seq_in_index`, table.Name)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var name, column string
+		var unique bool
+		if err := rows.Scan(&name, &unique, &column); err != nil {
+			rows.Close()
+			return err
+		}
+		n := len(table.Indexes)
+		if n > 0 && table.Indexes[n-1].Name == name {
+			table.Indexes[n-1].Columns = append(table.Indexes[n-1].Columns, column)
+			continue
+		}
+		table.Indexes = append(table.Indexes, Index{
+			Name:    name,
+			Columns: []string{column},
+			Unique:  unique,
+			Primary: name == "PRIMARY",
+		})
+	}
+	rows.Close()
+
+	rows, err = i.db.QueryContext(ctx, `
+		SELECT column_name, referenced_table_name, referenced_column_name
+		FROM information_schema.key_column_usage
+		WHERE table_schema = DATABASE() AND table_name = ? AND referenced_table_name IS NOT NULL`, table.Name)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var fk ForeignKey
+		if err := rows.Scan(&fk.Column, &fk.RefTable, &fk.RefColumn); err != nil {
+			return err
+		}
+		table.ForeignKeys = append(table.ForeignKeys, fk)
+	}
+	return rows.Err()
+}
+
+// strings runs a query returning a single string column
+func (i *Inspector) strings(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := i.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
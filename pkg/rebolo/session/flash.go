@@ -3,6 +3,16 @@ package session
 import (
 	"fmt"
 	"html/template"
+	"log"
+	"strings"
+)
+
+// Flash levels
+const (
+	LevelSuccess = "success"
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelInfo    = "info"
 )
 
 // FlashMessage represents a flash message with a type and content
@@ -11,7 +21,10 @@ type FlashMessage struct {
 	Message string
 }
 
-// Flash provides convenient methods for flash messages
+// Flash provides convenient methods for flash messages. Messages are kept
+// in the session as "type:message" strings, which every store can encode,
+// and the session is saved when they are added or read, so they survive
+// the redirect that usually follows.
 type Flash struct {
 	session *Session
 }
@@ -23,57 +36,68 @@ func NewFlash(session *Session) *Flash {
 
 // Add adds a flash message with the specified type
 func (f *Flash) Add(msgType, message string) {
-	f.session.AddFlash(FlashMessage{
-		Type:    msgType,
-		Message: message,
-	})
+	f.session.AddFlash(msgType + ":" + message)
+	f.save()
 }
 
 // Success adds a success flash message
 func (f *Flash) Success(message string) {
-	f.Add("success", message)
+	f.Add(LevelSuccess, message)
 }
 
 // Error adds an error flash message
 func (f *Flash) Error(message string) {
-	f.Add("error", message)
+	f.Add(LevelError, message)
 }
 
 // Warning adds a warning flash message
 func (f *Flash) Warning(message string) {
-	f.Add("warning", message)
+	f.Add(LevelWarning, message)
 }
 
 // Info adds an info flash message
 func (f *Flash) Info(message string) {
-	f.Add("info", message)
+	f.Add(LevelInfo, message)
+}
+
+// Peek returns the flash messages without clearing them
+func (f *Flash) Peek() []FlashMessage {
+	flashes, _ := f.session.Get(flashesKey).([]interface{})
+	return decodeFlashes(flashes)
 }
 
 // Get retrieves all flash messages and clears them
 func (f *Flash) Get() []FlashMessage {
 	flashes := f.session.Flashes()
-	var messages []FlashMessage
-
-	for _, flash := range flashes {
-		if msg, ok := flash.(FlashMessage); ok {
-			messages = append(messages, msg)
-		}
+	if len(flashes) > 0 {
+		f.save()
 	}
-
-	return messages
+	return decodeFlashes(flashes)
 }
 
-// GetByType retrieves flash messages of a specific type
+// GetByType retrieves flash messages of a specific type, clearing only those
 func (f *Flash) GetByType(msgType string) []string {
-	messages := f.Get()
 	var result []string
+	var kept []interface{}
 
-	for _, msg := range messages {
+	flashes, _ := f.session.Get(flashesKey).([]interface{})
+	for i, msg := range decodeFlashes(flashes) {
 		if msg.Type == msgType {
 			result = append(result, msg.Message)
+		} else {
+			kept = append(kept, flashes[i])
 		}
 	}
+	if len(result) == 0 {
+		return nil
+	}
 
+	if len(kept) > 0 {
+		f.session.Set(flashesKey, kept)
+	} else {
+		f.session.Delete(flashesKey)
+	}
+	f.save()
 	return result
 }
 
@@ -87,22 +111,51 @@ func (f *Flash) HTML() template.HTML {
 	html := ""
 	for _, msg := range messages {
 		alertClass := getAlertClass(msg.Type)
-		html += fmt.Sprintf(`<div class="alert alert-%s" role="alert">%s</div>`, alertClass, msg.Message)
+		html += fmt.Sprintf(`<div class="alert alert-%s" role="alert">%s</div>`, alertClass, template.HTMLEscapeString(msg.Message))
 	}
 
 	return template.HTML(html)
 }
 
+// save persists the flashes, logging failures since the request goes on
+func (f *Flash) save() {
+	if f.session.store == nil {
+		return
+	}
+	if err := f.session.Save(); err != nil {
+		log.Printf("⚠️  Failed to save flash messages: %v", err)
+	}
+}
+
+// decodeFlashes turns stored flashes back into messages, skipping values
+// that weren't added through Flash
+func decodeFlashes(flashes []interface{}) []FlashMessage {
+	var messages []FlashMessage
+	for _, flash := range flashes {
+		switch v := flash.(type) {
+		case string:
+			msgType, message, ok := strings.Cut(v, ":")
+			if !ok {
+				msgType, message = LevelInfo, v
+			}
+			messages = append(messages, FlashMessage{Type: msgType, Message: message})
+		case FlashMessage:
+			messages = append(messages, v)
+		}
+	}
+	return messages
+}
+
 // getAlertClass returns Bootstrap-compatible alert classes
 func getAlertClass(msgType string) string {
 	switch msgType {
-	case "success":
+	case LevelSuccess:
 		return "success"
-	case "error":
+	case LevelError:
 		return "danger"
-	case "warning":
+	case LevelWarning:
 		return "warning"
-	case "info":
+	case LevelInfo:
 		return "info"
 	default:
 		return "secondary"
@@ -19,7 +19,7 @@ func GetSession(r *http.Request, w http.ResponseWriter) (*Session, error) {
 func GetFlash(r *http.Request, w http.ResponseWriter) *Flash {
 	session, err := GetSession(r, w)
 	if err != nil {
-		return &Flash{session: &Session{}}
+		return &Flash{session: NewSession(nil, r, w)}
 	}
 	return NewFlash(session)
 }
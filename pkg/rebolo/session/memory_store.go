@@ -0,0 +1,186 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+var (
+	_ Store   = &MemoryStore{}
+	_ Clearer = &MemoryStore{}
+	_ Purger  = &MemoryStore{}
+)
+
+// memoryEntry is a saved session and when it expires
+type memoryEntry struct {
+	values  map[interface{}]interface{}
+	expires time.Time
+}
+
+// MemoryStore keeps sessions in the process, with only a random ID in the
+// cookie. Sessions are lost on restart and aren't shared between
+// instances, use it in tests and as the model of a server-side Store.
+type MemoryStore struct {
+	name    string
+	options *Options
+
+	mu       sync.Mutex
+	sessions map[string]memoryEntry
+}
+
+// NewMemorySessionStore creates a store keeping sessions in memory
+func NewMemorySessionStore(name string) *MemoryStore {
+	return NewMemorySessionStoreWithOptions(name, DefaultOptions())
+}
+
+// NewMemorySessionStoreWithOptions creates a store keeping sessions in
+// memory, with the given cookie attributes
+func NewMemorySessionStoreWithOptions(name string, options Options) *MemoryStore {
+	return &MemoryStore{
+		name:     name,
+		options:  &options,
+		sessions: make(map[string]memoryEntry),
+	}
+}
+
+// Get loads the session whose ID is in the request's cookie. Later calls
+// during the same request return the same session, like the cookie store,
+// see Loaded.
+func (ms *MemoryStore) Get(r *http.Request, w http.ResponseWriter) (*Session, error) {
+	return Loaded(r, ms, func() (*Session, error) {
+		return ms.load(r, w), nil
+	})
+}
+
+func (ms *MemoryStore) load(r *http.Request, w http.ResponseWriter) *Session {
+	session := NewSession(ms, r, w)
+	cookie, err := r.Cookie(ms.name)
+	if err != nil {
+		return session
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	entry, ok := ms.sessions[cookie.Value]
+	if !ok {
+		return session
+	}
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		delete(ms.sessions, cookie.Value)
+		return session
+	}
+	session.Load(cookie.Value, copyValues(entry.values))
+	return session
+}
+
+// Save keeps the session's values and sets the cookie to its ID
+func (ms *MemoryStore) Save(r *http.Request, w http.ResponseWriter, s *Session) error {
+	if s.ID() == "" {
+		id, err := newSessionID()
+		if err != nil {
+			return err
+		}
+		s.SetID(id)
+	}
+
+	entry := memoryEntry{values: copyValues(s.Values())}
+	if ms.options.MaxAge > 0 {
+		entry.expires = time.Now().Add(time.Duration(ms.options.MaxAge) * time.Second)
+	}
+
+	ms.mu.Lock()
+	ms.sessions[s.ID()] = entry
+	ms.mu.Unlock()
+
+	http.SetCookie(w, ms.cookie(s.ID(), ms.options.MaxAge))
+	return nil
+}
+
+// Destroy deletes the session and expires its cookie
+func (ms *MemoryStore) Destroy(r *http.Request, w http.ResponseWriter, s *Session) error {
+	ms.mu.Lock()
+	delete(ms.sessions, s.ID())
+	ms.mu.Unlock()
+
+	s.Clear()
+	http.SetCookie(w, ms.cookie("", -1))
+	return nil
+}
+
+// Renew deletes the session's ID and saves it under a new one
+func (ms *MemoryStore) Renew(r *http.Request, w http.ResponseWriter, s *Session) error {
+	if s.ID() != "" {
+		ms.mu.Lock()
+		delete(ms.sessions, s.ID())
+		ms.mu.Unlock()
+	}
+	s.SetID("")
+	return ms.Save(r, w, s)
+}
+
+// Options returns the cookie attributes
+func (ms *MemoryStore) Options() *Options {
+	return ms.options
+}
+
+// Len returns how many sessions are kept, expired ones included until
+// they are next read
+func (ms *MemoryStore) Len() int {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return len(ms.sessions)
+}
+
+// PurgeExpired deletes the expired sessions
+func (ms *MemoryStore) PurgeExpired(ctx context.Context) (int, error) {
+	now := time.Now()
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	n := 0
+	for id, entry := range ms.sessions {
+		if !entry.expires.IsZero() && now.After(entry.expires) {
+			delete(ms.sessions, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Clear deletes all sessions
+func (ms *MemoryStore) Clear(ctx context.Context) (int, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	n := len(ms.sessions)
+	ms.sessions = make(map[string]memoryEntry)
+	return n, nil
+}
+
+func (ms *MemoryStore) cookie(value string, maxAge int) *http.Cookie {
+	options := *ms.options
+	options.MaxAge = maxAge
+	return sessions.NewCookie(ms.name, value, &options)
+}
+
+// copyValues copies a session's values so requests don't share a map
+func copyValues(values map[interface{}]interface{}) map[interface{}]interface{} {
+	copied := make(map[interface{}]interface{}, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+	return copied
+}
+
+// newSessionID returns a random, URL safe session ID
+func newSessionID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -1,28 +1,134 @@
 package session
 
 import (
+	"context"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gorilla/sessions"
 )
 
-// SessionStore wraps gorilla/sessions Store
+// Options are the attributes of the session cookie
+type Options = sessions.Options
+
+// Store loads and persists sessions. The cookie store keeps the values in
+// the cookie itself, server-side stores (Redis, a database, memcached) keep
+// them under the session's ID and only put the ID in the cookie. Any Store
+// can be given to Application.SetSessionStore.
+type Store interface {
+	// Get returns the request's session, a new one when it has none
+	Get(r *http.Request, w http.ResponseWriter) (*Session, error)
+	// Save persists the session and writes its cookie
+	Save(r *http.Request, w http.ResponseWriter, s *Session) error
+	// Destroy deletes the session and expires its cookie
+	Destroy(r *http.Request, w http.ResponseWriter, s *Session) error
+	// Renew saves the session under a new ID, the old one no longer
+	// loads it
+	Renew(r *http.Request, w http.ResponseWriter, s *Session) error
+	// Options returns the cookie attributes, changes apply to later saves
+	Options() *Options
+}
+
+// Clearer is implemented by server-side stores that can end every
+// session at once, for the db:sessions:clear task
+type Clearer interface {
+	// Clear deletes all sessions and returns how many there were
+	Clear(ctx context.Context) (int, error)
+}
+
+// Purger is implemented by server-side stores that keep expired sessions
+// until they are read again, for the scheduled cleanup
+type Purger interface {
+	// PurgeExpired deletes the expired sessions and returns how many
+	// there were
+	PurgeExpired(ctx context.Context) (int, error)
+}
+
+// requestKey is the context key of the sessions loaded during a request
+type requestKey struct{}
+
+// requestSessions keeps the sessions loaded during a request, by store
+type requestSessions struct {
+	mu       sync.Mutex
+	sessions map[Store]*Session
+}
+
+// Middleware keeps the sessions stores load during a request, so the
+// handler and every middleware get the same one, whatever copy of the
+// request they were given. Applications install it before any middleware
+// reading the session.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), requestKey{}, &requestSessions{})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Loaded returns the session store loaded earlier in the request, or the
+// one load returns, kept for the rest of the request. Server-side stores
+// call it in Get. Requests that didn't go through Middleware, as in
+// tests, load the session on every call.
+func Loaded(r *http.Request, store Store, load func() (*Session, error)) (*Session, error) {
+	held, ok := r.Context().Value(requestKey{}).(*requestSessions)
+	if !ok {
+		return load()
+	}
+	held.mu.Lock()
+	defer held.mu.Unlock()
+	if session, ok := held.sessions[store]; ok {
+		return session, nil
+	}
+	session, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if held.sessions == nil {
+		held.sessions = make(map[Store]*Session)
+	}
+	held.sessions[store] = session
+	return session, nil
+}
+
+// DefaultSecret signs cookie sessions when session.secret isn't set. It's
+// public, anyone can forge sessions signed with it.
+const DefaultSecret = "rebolo-secret-key-change-in-production"
+
+var _ Store = &SessionStore{}
+
+// SessionStore is the cookie Store, it signs the values with
+// gorilla/sessions and keeps them in the cookie
 type SessionStore struct {
-	store sessions.Store
+	store *sessions.CookieStore
 	name  string
 }
 
-// NewCookieSessionStore creates a new cookie-based session store
-func NewCookieSessionStore(name string, keyPairs ...[]byte) *SessionStore {
-	store := sessions.NewCookieStore(keyPairs...)
-
-	// Set secure defaults
-	store.Options = &sessions.Options{
+// DefaultOptions returns the cookie attributes stores start with: a 7 day
+// HttpOnly cookie for the whole site, SameSite=Lax
+func DefaultOptions() Options {
+	return Options{
 		Path:     "/",
 		MaxAge:   86400 * 7, // 7 days
 		HttpOnly: true,
 		SameSite: http.SameSiteLaxMode,
 	}
+}
+
+// NewCookieSessionStore creates a new cookie-based session store
+func NewCookieSessionStore(name string, keyPairs ...[]byte) *SessionStore {
+	return NewCookieSessionStoreWithOptions(name, DefaultOptions(), keyPairs...)
+}
+
+// NewCookieSessionStoreWithOptions creates a cookie-based session store
+// with the given cookie attributes, e.g. Secure and SameSite=Strict:
+//
+//	options := session.DefaultOptions()
+//	options.Secure = true
+//	options.SameSite = http.SameSiteStrictMode
+//	store := session.NewCookieSessionStoreWithOptions("app_session", options, key)
+func NewCookieSessionStoreWithOptions(name string, options Options, keyPairs ...[]byte) *SessionStore {
+	store := sessions.NewCookieStore(keyPairs...)
+	store.Options = &options
 
 	return &SessionStore{
 		store: store,
@@ -30,40 +136,112 @@ func NewCookieSessionStore(name string, keyPairs ...[]byte) *SessionStore {
 	}
 }
 
-// Session represents a user session
-type Session struct {
-	session *sessions.Session
-	r       *http.Request
-	w       http.ResponseWriter
-}
-
 // Get retrieves a session
 func (ss *SessionStore) Get(r *http.Request, w http.ResponseWriter) (*Session, error) {
-	session, err := ss.store.Get(r, ss.name)
+	stored, err := ss.store.Get(r, ss.name)
 	if err != nil {
 		return nil, err
 	}
 
+	// gorilla/sessions returns the same values for the rest of the request,
+	// sharing them lets handlers see what middleware stored
+	session := NewSession(ss, r, w)
+	session.isNew = stored.IsNew
+	session.values = stored.Values
+	return session, nil
+}
+
+// Save writes the session's values to its cookie
+func (ss *SessionStore) Save(r *http.Request, w http.ResponseWriter, s *Session) error {
+	stored := sessions.NewSession(ss.store, ss.name)
+	stored.Values = s.Values()
+	options := *ss.store.Options
+	stored.Options = &options
+	return ss.store.Save(r, w, stored)
+}
+
+// Destroy expires the session's cookie
+func (ss *SessionStore) Destroy(r *http.Request, w http.ResponseWriter, s *Session) error {
+	s.Clear()
+	stored := sessions.NewSession(ss.store, ss.name)
+	options := *ss.store.Options
+	options.MaxAge = -1
+	stored.Options = &options
+	return ss.store.Save(r, w, stored)
+}
+
+// Renew saves the session. Cookie sessions have no ID, a cookie planted
+// before can't see what is saved after.
+func (ss *SessionStore) Renew(r *http.Request, w http.ResponseWriter, s *Session) error {
+	return ss.Save(r, w, s)
+}
+
+// Options returns the cookie attributes
+func (ss *SessionStore) Options() *Options {
+	return ss.store.Options
+}
+
+// flashesKey is where AddFlash keeps flashes when no key is given
+const flashesKey = "_flash"
+
+// renewedKey is when Touch last saved the session, in unix seconds
+const renewedKey = "_renewed_at"
+
+// Session represents a user session
+type Session struct {
+	store  Store
+	id     string
+	isNew  bool
+	values map[interface{}]interface{}
+	r      *http.Request
+	w      http.ResponseWriter
+}
+
+// NewSession creates an empty session for a Store's Get, saved and
+// destroyed through store with r and w
+func NewSession(store Store, r *http.Request, w http.ResponseWriter) *Session {
 	return &Session{
-		session: session,
-		r:       r,
-		w:       w,
-	}, nil
+		store:  store,
+		isNew:  true,
+		values: make(map[interface{}]interface{}),
+		r:      r,
+		w:      w,
+	}
+}
+
+// Load fills the session with what a Store found for the request. It is
+// no longer new once loaded.
+func (s *Session) Load(id string, values map[interface{}]interface{}) {
+	s.id = id
+	s.isNew = false
+	if values != nil {
+		s.values = values
+	}
+}
+
+// SetID sets the ID a Store keeps the session under
+func (s *Session) SetID(id string) {
+	s.id = id
+}
+
+// Values returns every value in the session, for Stores to persist
+func (s *Session) Values() map[interface{}]interface{} {
+	return s.values
 }
 
 // Set stores a value in the session
 func (s *Session) Set(key string, value interface{}) {
-	s.session.Values[key] = value
+	s.values[key] = value
 }
 
 // Get retrieves a value from the session
 func (s *Session) Get(key string) interface{} {
-	return s.session.Values[key]
+	return s.values[key]
 }
 
 // GetString retrieves a string value from the session
 func (s *Session) GetString(key string) string {
-	val, ok := s.session.Values[key].(string)
+	val, ok := s.values[key].(string)
 	if !ok {
 		return ""
 	}
@@ -72,7 +250,7 @@ func (s *Session) GetString(key string) string {
 
 // GetInt retrieves an int value from the session
 func (s *Session) GetInt(key string) int {
-	val, ok := s.session.Values[key].(int)
+	val, ok := s.values[key].(int)
 	if !ok {
 		return 0
 	}
@@ -81,7 +259,7 @@ func (s *Session) GetInt(key string) int {
 
 // GetBool retrieves a bool value from the session
 func (s *Session) GetBool(key string) bool {
-	val, ok := s.session.Values[key].(bool)
+	val, ok := s.values[key].(bool)
 	if !ok {
 		return false
 	}
@@ -90,43 +268,80 @@ func (s *Session) GetBool(key string) bool {
 
 // Delete removes a value from the session
 func (s *Session) Delete(key string) {
-	delete(s.session.Values, key)
+	delete(s.values, key)
 }
 
 // Clear removes all values from the session
 func (s *Session) Clear() {
-	for key := range s.session.Values {
-		delete(s.session.Values, key)
+	for key := range s.values {
+		delete(s.values, key)
 	}
 }
 
 // Save persists the session
 func (s *Session) Save() error {
-	return s.session.Save(s.r, s.w)
+	return s.store.Save(s.r, s.w, s)
 }
 
 // Destroy invalidates the session
 func (s *Session) Destroy() error {
-	s.session.Options.MaxAge = -1
-	return s.session.Save(s.r, s.w)
+	return s.store.Destroy(s.r, s.w, s)
 }
 
-// AddFlash adds a flash message to the session
+// Renew saves the session under a new ID. Call it when a user signs in,
+// so a session ID planted or seen before doesn't follow them in:
+//
+//	sess.Set("user_id", user.ID)
+//	sess.Renew()
+func (s *Session) Renew() error {
+	return s.store.Renew(s.r, s.w, s)
+}
+
+// Touch saves the session again once half of its max age has passed since
+// it was last renewed, so it expires after a period of inactivity rather
+// than a fixed time after sign in. New sessions and browser session cookies
+// are left alone.
+func (s *Session) Touch() error {
+	maxAge := s.store.Options().MaxAge
+	if s.isNew || maxAge <= 0 {
+		return nil
+	}
+
+	renewed, _ := s.values[renewedKey].(int64)
+	if time.Since(time.Unix(renewed, 0)) < time.Duration(maxAge)*time.Second/2 {
+		return nil
+	}
+	s.values[renewedKey] = time.Now().Unix()
+	return s.Save()
+}
+
+// AddFlash adds a flash message to the session, under vars[0] when given
 func (s *Session) AddFlash(value interface{}, vars ...string) {
-	s.session.AddFlash(value, vars...)
+	key := flashesKey
+	if len(vars) > 0 {
+		key = vars[0]
+	}
+	flashes, _ := s.values[key].([]interface{})
+	s.values[key] = append(flashes, value)
 }
 
 // Flashes retrieves and clears flash messages
 func (s *Session) Flashes(vars ...string) []interface{} {
-	return s.session.Flashes(vars...)
+	key := flashesKey
+	if len(vars) > 0 {
+		key = vars[0]
+	}
+	flashes, _ := s.values[key].([]interface{})
+	delete(s.values, key)
+	return flashes
 }
 
-// ID returns the session ID
+// ID returns the session ID, empty for cookie sessions
 func (s *Session) ID() string {
-	return s.session.ID
+	return s.id
 }
 
 // IsNew returns true if the session is new
 func (s *Session) IsNew() bool {
-	return s.session.IsNew
+	return s.isNew
 }
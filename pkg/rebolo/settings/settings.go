@@ -0,0 +1,447 @@
+// Package settings keeps application settings in the database, so
+// operators change runtime knobs like a banner or a limit from the admin
+// panel instead of redeploying config.yml.
+//
+//	s := settings.Enable(app)
+//	s.Define("banner", "", "Text shown above every page")
+//	s.Define("uploads.max_mb", 10, "Largest upload accepted, in MB")
+//
+//	// in a handler, read from memory
+//	limit := s.Int("uploads.max_mb", 10)
+//
+//	s.OnChange("uploads.*", func(name, value string) {
+//		log.Printf("%s is now %s", name, value)
+//	})
+//	err := s.Set(ctx.Context(), "banner", "Maintenance tonight at 22:00")
+//
+// Values are kept in memory. The table is read when enabled and again
+// every Options.Refresh, so changes made in the admin panel or by other
+// instances show up within it, running the OnChange handlers.
+package settings
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/events"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/schema"
+)
+
+// DefaultTable is where settings are kept unless Options.Table says otherwise
+const DefaultTable = "settings"
+
+// DefaultRefresh is how often the table is read again unless
+// Options.Refresh says otherwise
+const DefaultRefresh = 30 * time.Second
+
+// Options configures settings
+type Options struct {
+	Table   string        // where settings are kept, "settings" by default
+	Refresh time.Duration // how often the table is read again, 30s by default, never when negative
+}
+
+// handler is a function registered with OnChange
+type handler struct {
+	pattern string
+	fn      func(name, value string)
+}
+
+// Settings reads and writes the settings table, keeping its values in
+// memory
+type Settings struct {
+	app  *rebolo.Application
+	opts Options
+
+	mu       sync.RWMutex
+	values   map[string]string
+	defaults map[string]interface{} // passed to Define, their type is the setting's
+	handlers []handler
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Enable creates the settings table if needed, loads it and reads it
+// again every Options.Refresh until Stop
+func Enable(app *rebolo.Application, opts ...Options) *Settings {
+	s := &Settings{
+		app:      app,
+		values:   make(map[string]string),
+		defaults: make(map[string]interface{}),
+		stop:     make(chan struct{}),
+	}
+	if len(opts) > 0 {
+		s.opts = opts[0]
+	}
+	if s.opts.Table == "" {
+		s.opts.Table = DefaultTable
+	}
+	if s.opts.Refresh == 0 {
+		s.opts.Refresh = DefaultRefresh
+	}
+
+	ctx := context.Background()
+	if err := s.createTable(ctx); err != nil {
+		log.Printf("❌ Settings unavailable: %v", err)
+		return s
+	}
+	if err := s.Reload(ctx); err != nil {
+		log.Printf("❌ Failed to load settings: %v", err)
+	}
+	if s.opts.Refresh > 0 {
+		go s.refresh()
+	}
+	return s
+}
+
+// CreateTableSQL returns the statement creating the settings table, for a
+// migration instead of the one Enable runs
+func CreateTableSQL(table string) string {
+	return "CREATE TABLE IF NOT EXISTS " + table + ` (
+	name VARCHAR(255) PRIMARY KEY,
+	value TEXT NOT NULL,
+	description VARCHAR(255) NOT NULL DEFAULT '',
+	updated_at TIMESTAMP NOT NULL
+)`
+}
+
+// Define adds a setting with its default value and a description for the
+// admin panel, unless it exists already. The default's type, a string,
+// bool, int, float64 or time.Duration, is the one Set accepts for it.
+func (s *Settings) Define(name string, value interface{}, description string) {
+	s.mu.Lock()
+	s.defaults[name] = value
+	_, exists := s.values[name]
+	s.mu.Unlock()
+	if exists {
+		return
+	}
+
+	encoded, err := encode(value)
+	if err != nil {
+		log.Printf("❌ Setting %s: %v", name, err)
+		return
+	}
+	db := s.app.DB()
+	if db == nil {
+		return
+	}
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, schema.Rebind(s.app.DatabaseDriver(), "INSERT INTO "+s.opts.Table+
+		" (name, value, description, updated_at) VALUES (?, ?, ?, ?)"), name, encoded, description, time.Now()); err != nil {
+		// Another instance may have just defined it
+		var found string
+		if db.QueryRowContext(ctx, schema.Rebind(s.app.DatabaseDriver(), "SELECT value FROM "+s.opts.Table+" WHERE name = ?"), name).Scan(&found) != nil {
+			log.Printf("❌ Failed to define setting %s: %v", name, err)
+			return
+		}
+		encoded = found
+	}
+	s.apply(map[string]*string{name: &encoded})
+}
+
+// Get returns the value of a setting and whether it is set
+func (s *Settings) Get(name string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.values[name]
+	return value, ok
+}
+
+// String returns a setting, or def when it isn't set
+func (s *Settings) String(name, def string) string {
+	if value, ok := s.Get(name); ok {
+		return value
+	}
+	return def
+}
+
+// Int returns a setting as an int, or def when it isn't set or isn't one
+func (s *Settings) Int(name string, def int) int {
+	if value, ok := s.Get(name); ok {
+		if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
+// Float returns a setting as a float64, or def when it isn't set or isn't one
+func (s *Settings) Float(name string, def float64) float64 {
+	if value, ok := s.Get(name); ok {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+// Bool returns a setting as a bool, "true", "1" or "t" among others, or
+// def when it isn't set or isn't one
+func (s *Settings) Bool(name string, def bool) bool {
+	if value, ok := s.Get(name); ok {
+		if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// Duration returns a setting like "90s" or "2h" as a time.Duration, or
+// def when it isn't set or isn't one
+func (s *Settings) Duration(name string, def time.Duration) time.Duration {
+	if value, ok := s.Get(name); ok {
+		if d, err := time.ParseDuration(strings.TrimSpace(value)); err == nil {
+			return d
+		}
+	}
+	return def
+}
+
+// Decode decodes a setting holding JSON into v, set with a struct, map or
+// slice. It returns sql.ErrNoRows when the setting isn't set.
+func (s *Settings) Decode(name string, v interface{}) error {
+	value, ok := s.Get(name)
+	if !ok {
+		return sql.ErrNoRows
+	}
+	return json.Unmarshal([]byte(value), v)
+}
+
+// All returns the values of every setting
+func (s *Settings) All() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	all := make(map[string]string, len(s.values))
+	for name, value := range s.values {
+		all[name] = value
+	}
+	return all
+}
+
+// Set stores a setting and runs the OnChange handlers when its value
+// changed. Strings are stored as they are, numbers, bools and durations
+// in their text form and other values as JSON. A setting passed to Define
+// only takes values of its default's type.
+func (s *Settings) Set(ctx context.Context, name string, value interface{}) error {
+	encoded, err := encode(value)
+	if err != nil {
+		return fmt.Errorf("setting %s: %w", name, err)
+	}
+	if err := s.check(name, encoded); err != nil {
+		return err
+	}
+	db := s.app.DB()
+	if db == nil {
+		return errors.New("no database connection")
+	}
+
+	now := time.Now()
+	res, err := db.ExecContext(ctx, schema.Rebind(s.app.DatabaseDriver(), "UPDATE "+s.opts.Table+" SET value = ?, updated_at = ? WHERE name = ?"), encoded, now, name)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		if _, err := db.ExecContext(ctx, schema.Rebind(s.app.DatabaseDriver(), "INSERT INTO "+s.opts.Table+
+			" (name, value, description, updated_at) VALUES (?, ?, ?, ?)"), name, encoded, "", now); err != nil {
+			return err
+		}
+	}
+	s.apply(map[string]*string{name: &encoded})
+	return nil
+}
+
+// Delete removes a setting, the getters return their default for it
+func (s *Settings) Delete(ctx context.Context, name string) error {
+	db := s.app.DB()
+	if db == nil {
+		return errors.New("no database connection")
+	}
+	if _, err := db.ExecContext(ctx, schema.Rebind(s.app.DatabaseDriver(), "DELETE FROM "+s.opts.Table+" WHERE name = ?"), name); err != nil {
+		return err
+	}
+	s.apply(map[string]*string{name: nil})
+	return nil
+}
+
+// OnChange runs fn when a setting matching pattern changes, like
+// "banner", "uploads.*" or "*" for all of them. fn gets the new value,
+// empty when the setting was deleted. Changes made elsewhere are noticed
+// when the table is read again.
+func (s *Settings) OnChange(pattern string, fn func(name, value string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, handler{pattern: pattern, fn: fn})
+}
+
+// Reload reads the table again, Enable does it every Options.Refresh
+func (s *Settings) Reload(ctx context.Context) error {
+	db := s.app.DB()
+	if db == nil {
+		return errors.New("no database connection")
+	}
+	rows, err := db.QueryContext(ctx, "SELECT name, value FROM "+s.opts.Table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	loaded := make(map[string]string)
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return err
+		}
+		loaded[name] = value
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	changes := make(map[string]*string)
+	s.mu.RLock()
+	for name, value := range loaded {
+		if old, ok := s.values[name]; !ok || old != value {
+			value := value
+			changes[name] = &value
+		}
+	}
+	for name := range s.values {
+		if _, ok := loaded[name]; !ok {
+			changes[name] = nil
+		}
+	}
+	s.mu.RUnlock()
+
+	for name, value := range changes {
+		if value == nil {
+			continue
+		}
+		if err := s.check(name, *value); err != nil {
+			log.Printf("⚠️  %v, its default is used", err)
+		}
+	}
+	s.apply(changes)
+	return nil
+}
+
+// Stop stops reading the table again
+func (s *Settings) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+func (s *Settings) refresh() {
+	ticker := time.NewTicker(s.opts.Refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), s.opts.Refresh)
+			if err := s.Reload(ctx); err != nil {
+				log.Printf("❌ Failed to reload settings: %v", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// apply stores changed values, nil for deleted settings, and runs the
+// handlers of those that actually changed
+func (s *Settings) apply(changes map[string]*string) {
+	type change struct{ name, value string }
+	var changed []change
+
+	s.mu.Lock()
+	for name, value := range changes {
+		old, existed := s.values[name]
+		switch {
+		case value == nil && existed:
+			delete(s.values, name)
+			changed = append(changed, change{name, ""})
+		case value != nil && (!existed || old != *value):
+			s.values[name] = *value
+			changed = append(changed, change{name, *value})
+		}
+	}
+	handlers := s.handlers
+	s.mu.Unlock()
+
+	for _, c := range changed {
+		for _, h := range handlers {
+			if events.Match(h.pattern, c.name) {
+				h.fn(c.name, c.value)
+			}
+		}
+	}
+}
+
+// check reports whether value suits the type of the setting's default
+func (s *Settings) check(name, value string) error {
+	s.mu.RLock()
+	def, ok := s.defaults[name]
+	s.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	value = strings.TrimSpace(value)
+	var err error
+	switch def.(type) {
+	case bool:
+		_, err = strconv.ParseBool(value)
+	case int, int64:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case float64:
+		_, err = strconv.ParseFloat(value, 64)
+	case time.Duration:
+		_, err = time.ParseDuration(value)
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("setting %s: %q is not a valid %T", name, value, def)
+	}
+	return nil
+}
+
+// encode returns the text form of a setting's value
+func encode(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case time.Duration:
+		return v.String(), nil
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (s *Settings) createTable(ctx context.Context) error {
+	db := s.app.DB()
+	if db == nil {
+		return errors.New("no database connection")
+	}
+	_, err := db.ExecContext(ctx, CreateTableSQL(s.opts.Table))
+	return err
+}
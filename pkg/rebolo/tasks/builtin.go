@@ -0,0 +1,161 @@
+package tasks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/routing"
+)
+
+// Module is the framework's module path, whose version the version task
+// reports
+const Module = "github.com/Palaciodiego008/rebololang"
+
+// BuildAssets builds the asset pipeline for production. The rebolo CLI
+// sets it, assets:precompile fails where it's nil.
+var BuildAssets func() error
+
+// errNoApp is returned by the tasks needing an app when there's none
+var errNoApp = errors.New("this task needs the app, run it from the app's directory")
+
+var defaultsOnce sync.Once
+
+// DefaultTasks registers default tasks, once however many times it's
+// called
+func DefaultTasks() {
+	defaultsOnce.Do(registerDefaults)
+}
+
+func registerDefaults() {
+	Register("secret", "Generate a cryptographically secure secret key", func(args []string) error {
+		// Generate 64 random bytes
+		b := make([]byte, 64)
+		_, err := rand.Read(b)
+		if err != nil {
+			return err
+		}
+
+		// Print as base64
+		fmt.Println(base64.URLEncoding.EncodeToString(b))
+		return nil
+	})
+
+	Register("version", "Print the framework and Go versions", func(args []string) error {
+		fmt.Printf("rebolo %s (%s, %s/%s)\n", FrameworkVersion(), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+		return nil
+	})
+
+	Register("routes", "Print the app's routes", func(args []string) error {
+		app, ok := GetApp().(interface{ Routes() []routing.RouteInfo })
+		if !ok {
+			return errNoApp
+		}
+		printRoutes(app.Routes())
+		return nil
+	}).NeedsApp = true
+
+	Register("middleware", "Print the app's middleware, outermost first", func(args []string) error {
+		app, ok := GetApp().(interface{ MiddlewareNames() []string })
+		if !ok {
+			return errNoApp
+		}
+		for _, name := range app.MiddlewareNames() {
+			fmt.Println(name)
+		}
+		return nil
+	}).NeedsApp = true
+
+	Register("db:sessions:clear", "End every session kept by a server-side session store", func(args []string) error {
+		app, ok := GetApp().(interface {
+			ClearSessions(ctx context.Context) (int, error)
+		})
+		if !ok {
+			return errNoApp
+		}
+		n, err := app.ClearSessions(context.Background())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🧹 %d sessions cleared\n", n)
+		return nil
+	}).NeedsApp = true
+
+	Register("cleanup", "Delete expired sessions, old temporary files and what OnCleanup functions purge", func(args []string) error {
+		app, ok := GetApp().(interface {
+			Cleanup(ctx context.Context) error
+		})
+		if !ok {
+			return errNoApp
+		}
+		return app.Cleanup(context.Background())
+	}).NeedsApp = true
+
+	Register("cache:clear", "Clear the caches the app registered with OnCacheClear", func(args []string) error {
+		app, ok := GetApp().(interface {
+			ClearCache(ctx context.Context) error
+		})
+		if !ok {
+			return errNoApp
+		}
+		return app.ClearCache(context.Background())
+	}).NeedsApp = true
+
+	Register("assets:precompile", "Build the assets for production", func(args []string) error {
+		if BuildAssets == nil {
+			return errors.New("run it with the rebolo CLI: rebolo task assets:precompile")
+		}
+		return BuildAssets()
+	})
+}
+
+// FrameworkVersion returns the version of the framework the binary was
+// built with, "(devel)" for local builds
+func FrameworkVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(unknown)"
+	}
+	if info.Main.Path == Module {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == Module {
+			if dep.Replace != nil {
+				return dep.Version + " => " + dep.Replace.Path
+			}
+			return dep.Version
+		}
+	}
+	return "(unknown)"
+}
+
+// printRoutes prints routes in aligned columns
+func printRoutes(routes []routing.RouteInfo) {
+	if len(routes) == 0 {
+		fmt.Println("No routes registered")
+		return
+	}
+
+	methodWidth, pathWidth := len("METHOD"), len("PATH")
+	methods := make([]string, len(routes))
+	for i, route := range routes {
+		methods[i] = strings.Join(route.Methods, ",")
+		if methods[i] == "" {
+			methods[i] = "ANY"
+		}
+		methodWidth = max(methodWidth, len(methods[i]))
+		pathWidth = max(pathWidth, len(route.Path))
+	}
+
+	fmt.Printf("%-*s  %-*s  %s\n", methodWidth, "METHOD", pathWidth, "PATH", "NAME")
+	for i, route := range routes {
+		fmt.Printf("%-*s  %-*s  %s\n", methodWidth, methods[i], pathWidth, route.Path, route.Name)
+	}
+}
@@ -1,12 +1,14 @@
 package tasks
 
 import (
-	"crypto/rand"
-	"encoding/base64"
+	"errors"
+	"flag"
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
 // Task represents a runnable task
@@ -14,6 +16,49 @@ type Task struct {
 	Name        string
 	Description string
 	Handler     func(args []string) error
+	// Deps are the tasks run before this one, once each
+	Deps []string
+	// NeedsApp marks tasks using the app, see GetApp. rebolo task runs
+	// them in the app, built and started as app task <name>.
+	NeedsApp bool
+
+	flags *flag.FlagSet
+}
+
+// ExitError ends a task with an exit code, see Exit
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// Exit makes a task fail with code as the exit status of rebolo task,
+// instead of 1
+func Exit(code int, err error) error {
+	return &ExitError{Code: code, Err: err}
+}
+
+// ExitCode is the exit status for a task's error: 0 without one, the code
+// given to Exit, or 1
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exit *ExitError
+	if errors.As(err, &exit) {
+		return exit.Code
+	}
+	return 1
 }
 
 var (
@@ -22,8 +67,9 @@ var (
 	app     interface{} // Reference to Application for tasks that need it
 )
 
-// Register registers a new task
-func Register(name, description string, handler func(args []string) error) {
+// Register registers a new task. Names with colons group tasks in
+// namespaces, like db:cleanup.
+func Register(name, description string, handler func(args []string) error) *Task {
 	tasksMu.Lock()
 	defer tasksMu.Unlock()
 
@@ -31,11 +77,50 @@ func Register(name, description string, handler func(args []string) error) {
 		panic(fmt.Sprintf("task %s already registered", name))
 	}
 
-	tasks[name] = &Task{
+	task := &Task{
 		Name:        name,
 		Description: description,
 		Handler:     handler,
 	}
+	tasks[name] = task
+	return task
+}
+
+// DependsOn adds tasks that run before this one
+func (t *Task) DependsOn(names ...string) *Task {
+	t.Deps = append(t.Deps, names...)
+	return t
+}
+
+// Flags returns the task's flags, parsed from its arguments before it runs;
+// the handler gets the arguments left. Tasks without flags get all of them.
+//
+//	var days int
+//	tasks.Register("db:cleanup", "Delete old sessions", func(args []string) error {
+//		return deleteSessions(days)
+//	}).Flags().IntVar(&days, "days", 30, "delete sessions older than this")
+func (t *Task) Flags() *flag.FlagSet {
+	if t.flags == nil {
+		t.flags = flag.NewFlagSet(t.Name, flag.ContinueOnError)
+		t.flags.Usage = func() {
+			fmt.Fprintf(t.flags.Output(), "Usage: rebolo task %s [flags] [args...]\n\n%s\n\n", t.Name, t.Description)
+			t.flags.PrintDefaults()
+		}
+	}
+	return t.flags
+}
+
+// Namespace registers tasks under a prefix
+type Namespace string
+
+// Register registers the task ns:name
+func (ns Namespace) Register(name, description string, handler func(args []string) error) *Task {
+	return Register(string(ns)+":"+name, description, handler)
+}
+
+// Namespace returns the namespace ns:name
+func (ns Namespace) Namespace(name string) Namespace {
+	return ns + Namespace(":"+name)
 }
 
 // List returns all registered tasks sorted by name
@@ -68,14 +153,65 @@ func Get(name string) (*Task, error) {
 	return task, nil
 }
 
-// Run executes a task by name with the given arguments
+// Run executes a task by name with the given arguments, after its
+// dependencies. Each task runs once, however many tasks depend on it.
 func Run(name string, args []string) error {
+	r := &runner{done: map[string]bool{}, running: map[string]bool{}}
+	return r.run(name, args, nil)
+}
+
+// runner runs a task and its dependencies
+type runner struct {
+	done    map[string]bool
+	running map[string]bool
+}
+
+func (r *runner) run(name string, args []string, path []string) error {
+	if r.done[name] {
+		return nil
+	}
+	path = append(path, name)
+	if r.running[name] {
+		return fmt.Errorf("task dependency cycle: %s", strings.Join(path, " -> "))
+	}
 	task, err := Get(name)
 	if err != nil {
+		if len(path) > 1 {
+			return fmt.Errorf("%s, needed by %s", err, path[len(path)-2])
+		}
 		return err
 	}
 
-	return task.Handler(args)
+	if task.flags != nil {
+		if err := task.flags.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil
+			}
+			return Exit(2, err)
+		}
+		args = task.flags.Args()
+	}
+
+	r.running[name] = true
+	for _, dep := range task.Deps {
+		if err := r.run(dep, nil, path); err != nil {
+			return err
+		}
+	}
+	r.running[name] = false
+
+	// Reported on stderr, the output of tasks like secret can be piped
+	fmt.Fprintf(os.Stderr, "▶️  %s\n", name)
+	start := time.Now()
+	err = task.Handler(args)
+	took := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %s failed after %v\n", name, took)
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	fmt.Fprintf(os.Stderr, "✅ %s done in %v\n", name, took)
+	r.done[name] = true
+	return nil
 }
 
 // SetApp sets the application reference for tasks that need it
@@ -90,15 +226,20 @@ func GetApp() interface{} {
 
 // PrintList prints all available tasks
 func PrintList() {
-	tasks := List()
+	printTasks(List())
+}
 
+// printTasks prints tasks grouped by namespace, those without one first
+func printTasks(tasks []*Task) {
 	if len(tasks) == 0 {
 		fmt.Println("No tasks available")
 		return
 	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return namespaceOf(tasks[i].Name) < namespaceOf(tasks[j].Name)
+	})
 
 	fmt.Println("Available tasks:")
-	fmt.Println()
 
 	maxNameLen := 0
 	for _, task := range tasks {
@@ -107,17 +248,35 @@ func PrintList() {
 		}
 	}
 
+	namespace := "-"
 	for _, task := range tasks {
+		if ns := namespaceOf(task.Name); ns != namespace {
+			fmt.Println()
+			namespace = ns
+		}
 		padding := strings.Repeat(" ", maxNameLen-len(task.Name))
 		desc := task.Description
 		if desc == "" {
 			desc = "No description"
 		}
+		if len(task.Deps) > 0 {
+			desc += fmt.Sprintf(" (after %s)", strings.Join(task.Deps, ", "))
+		}
 		fmt.Printf("  %s%s  %s\n", task.Name, padding, desc)
 	}
 }
 
-// RunFromArgs runs a task from command line arguments
+// namespaceOf returns the top namespace of a task, empty for tasks without
+// one
+func namespaceOf(name string) string {
+	if i := strings.Index(name, ":"); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// RunFromArgs runs a task from command line arguments. A namespace lists
+// its tasks, and a task's -h flag its flags.
 func RunFromArgs(args []string) error {
 	if len(args) == 0 {
 		PrintList()
@@ -127,21 +286,18 @@ func RunFromArgs(args []string) error {
 	taskName := args[0]
 	taskArgs := args[1:]
 
-	return Run(taskName, taskArgs)
-}
-
-// DefaultTasks registers default tasks
-func DefaultTasks() {
-	Register("secret", "Generate a cryptographically secure secret key", func(args []string) error {
-		// Generate 64 random bytes
-		b := make([]byte, 64)
-		_, err := rand.Read(b)
-		if err != nil {
-			return err
+	if _, err := Get(taskName); err != nil {
+		var inNamespace []*Task
+		for _, task := range List() {
+			if strings.HasPrefix(task.Name, taskName+":") {
+				inNamespace = append(inNamespace, task)
+			}
 		}
+		if len(inNamespace) > 0 {
+			printTasks(inNamespace)
+			return nil
+		}
+	}
 
-		// Print as base64
-		fmt.Println(base64.URLEncoding.EncodeToString(b))
-		return nil
-	})
+	return Run(taskName, taskArgs)
 }
@@ -0,0 +1,389 @@
+// Package test runs handlers and full applications in Go tests.
+//
+//	func TestTodos(t *testing.T) {
+//		ta := test.NewApp(t)
+//		ta.GET("/todos", listTodos)
+//		ta.HTMLGet("/todos").AssertStatus(200).AssertContains("Todo")
+//	}
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/adapters"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/ports"
+)
+
+var databaseCounter int64
+
+type options struct {
+	config   ports.ConfigData
+	truncate bool
+}
+
+// Option customizes the application created by NewApp
+type Option func(*options)
+
+// WithDatabase runs the app against a real database instead of in-memory SQLite
+func WithDatabase(driver, url string) Option {
+	return func(o *options) {
+		o.config.Database.Driver = driver
+		o.config.Database.URL = url
+	}
+}
+
+// WithTruncation empties every table when the test ends. Use it with
+// WithDatabase so tests against a shared database don't leak rows.
+func WithTruncation() Option {
+	return func(o *options) {
+		o.truncate = true
+	}
+}
+
+// WithConfig applies arbitrary configuration changes
+func WithConfig(fn func(*ports.ConfigData)) Option {
+	return func(o *options) {
+		fn(&o.config)
+	}
+}
+
+// App is an Application under test. Requests go through the full middleware
+// stack and cookies set by responses are sent with the following requests.
+type App struct {
+	*rebolo.Application
+	T testing.TB
+
+	driver  string
+	db      *DB
+	cookies map[string]*http.Cookie
+	server  *httptest.Server
+}
+
+// NewApp creates an Application in the test environment backed by a private
+// in-memory SQLite database. The working directory is moved to the app root
+// (the nearest directory with a go.mod) so config.yml and views/ are found.
+// It uses t.Chdir and t.Setenv, so tests using it cannot run in parallel.
+func NewApp(t testing.TB, opts ...Option) *App {
+	t.Helper()
+
+	if root, ok := findAppRoot(); ok {
+		t.Chdir(root)
+	}
+	t.Setenv("REBOLO_ENV", "test")
+
+	config, err := adapters.NewYAMLConfig().Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	n := atomic.AddInt64(&databaseCounter, 1)
+	config.App.Env = "test"
+	config.Assets.HotReload = false
+	config.Errors.Sentry.DSN = ""
+	config.Database.Driver = "sqlite"
+	config.Database.URL = fmt.Sprintf("file:rebolo_test_%d?mode=memory&cache=shared", n)
+	config.Database.Debug = false
+	config.Database.SQLite.WAL = false
+	config.Database.SQLite.CheckpointInterval = ""
+
+	o := &options{config: config}
+	for _, opt := range opts {
+		opt(o)
+	}
+	config = o.config
+
+	app := rebolo.NewWithConfig(config)
+
+	// An in-memory database lives as long as its connection, keep exactly one
+	if db := app.DB(); db != nil && config.Database.Driver == "sqlite" {
+		db.SetMaxOpenConns(1)
+	}
+
+	ta := &App{
+		Application: app,
+		T:           t,
+		driver:      config.Database.Driver,
+		cookies:     make(map[string]*http.Cookie),
+	}
+
+	t.Cleanup(func() {
+		if ta.server != nil {
+			ta.server.Close()
+		}
+		if o.truncate && ta.db != nil {
+			ta.db.Truncate()
+		}
+		app.Shutdown()
+		if database := app.Database(); database != nil {
+			database.Close()
+		}
+	})
+
+	return ta
+}
+
+// TestDB returns the app's database wrapped with test helpers
+// (fixtures, factories, truncation)
+func (ta *App) TestDB() *DB {
+	ta.T.Helper()
+
+	if ta.db == nil {
+		if ta.DB() == nil {
+			ta.T.Fatalf("no database connected")
+		}
+		ta.db = NewDB(ta.T, ta.DB(), ta.driver)
+	}
+	return ta.db
+}
+
+// findAppRoot walks up from the working directory looking for go.mod
+func findAppRoot() (string, bool) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", false
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// Server starts a real HTTP server for the app, closed when the test ends
+func (ta *App) Server() *httptest.Server {
+	if ta.server == nil {
+		ta.server = httptest.NewServer(ta.Handler())
+	}
+	return ta.server
+}
+
+// Request starts building a request
+func (ta *App) Request(method, path string) *Request {
+	return &Request{
+		app:     ta,
+		method:  method,
+		path:    path,
+		headers: make(http.Header),
+	}
+}
+
+// HTMLGet performs a GET request as a browser would
+func (ta *App) HTMLGet(path string) *Response {
+	return ta.Request(http.MethodGet, path).HTML().Do()
+}
+
+// HTMLPost submits a form
+func (ta *App) HTMLPost(path string, form map[string]string) *Response {
+	return ta.Request(http.MethodPost, path).HTML().WithForm(form).Do()
+}
+
+// HTMLPut submits a form with method override, as generated forms do
+func (ta *App) HTMLPut(path string, form map[string]string) *Response {
+	return ta.HTMLPost(path, withMethod(form, http.MethodPut))
+}
+
+// HTMLPatch submits a form with method override
+func (ta *App) HTMLPatch(path string, form map[string]string) *Response {
+	return ta.HTMLPost(path, withMethod(form, http.MethodPatch))
+}
+
+// HTMLDelete submits a delete form with method override
+func (ta *App) HTMLDelete(path string) *Response {
+	return ta.HTMLPost(path, withMethod(nil, http.MethodDelete))
+}
+
+// JSONGet performs a GET request as an API client
+func (ta *App) JSONGet(path string) *Response {
+	return ta.Request(http.MethodGet, path).JSON().Do()
+}
+
+// JSONPost sends body encoded as JSON
+func (ta *App) JSONPost(path string, body interface{}) *Response {
+	return ta.Request(http.MethodPost, path).WithJSON(body).Do()
+}
+
+// JSONPut sends body encoded as JSON
+func (ta *App) JSONPut(path string, body interface{}) *Response {
+	return ta.Request(http.MethodPut, path).WithJSON(body).Do()
+}
+
+// JSONPatch sends body encoded as JSON
+func (ta *App) JSONPatch(path string, body interface{}) *Response {
+	return ta.Request(http.MethodPatch, path).WithJSON(body).Do()
+}
+
+// JSONDelete performs a DELETE request as an API client
+func (ta *App) JSONDelete(path string) *Response {
+	return ta.Request(http.MethodDelete, path).JSON().Do()
+}
+
+// Cookie returns a cookie stored from previous responses, or nil
+func (ta *App) Cookie(name string) *http.Cookie {
+	return ta.cookies[name]
+}
+
+// SetCookie stores a cookie that is sent with the following requests
+func (ta *App) SetCookie(cookie *http.Cookie) {
+	ta.cookies[cookie.Name] = cookie
+}
+
+// ClearCookies forgets all cookies, starting a new browser session
+func (ta *App) ClearCookies() {
+	ta.cookies = make(map[string]*http.Cookie)
+}
+
+// SetSession stores a session value for the following requests,
+// e.g. ta.SetSession("user_id", 1) to sign a user in
+func (ta *App) SetSession(key string, value interface{}) {
+	ta.T.Helper()
+
+	req := ta.cookieRequest()
+	rec := httptest.NewRecorder()
+
+	sess, err := ta.GetSession(req, rec)
+	if err != nil {
+		ta.T.Fatalf("failed to load session: %v", err)
+	}
+	sess.Set(key, value)
+	if err := sess.Save(); err != nil {
+		ta.T.Fatalf("failed to save session: %v", err)
+	}
+
+	ta.storeCookies(rec.Result().Cookies())
+}
+
+// SessionValue reads a value from the current session
+func (ta *App) SessionValue(key string) interface{} {
+	ta.T.Helper()
+
+	sess, err := ta.GetSession(ta.cookieRequest(), httptest.NewRecorder())
+	if err != nil {
+		ta.T.Fatalf("failed to load session: %v", err)
+	}
+	return sess.Get(key)
+}
+
+// cookieRequest builds an empty request carrying the stored cookies
+func (ta *App) cookieRequest() *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range ta.cookies {
+		req.AddCookie(c)
+	}
+	return req
+}
+
+// storeCookies keeps cookies from a response, dropping expired ones
+func (ta *App) storeCookies(cookies []*http.Cookie) {
+	for _, c := range cookies {
+		if c.MaxAge < 0 {
+			delete(ta.cookies, c.Name)
+			continue
+		}
+		ta.cookies[c.Name] = c
+	}
+}
+
+func withMethod(form map[string]string, method string) map[string]string {
+	values := make(map[string]string, len(form)+1)
+	for k, v := range form {
+		values[k] = v
+	}
+	values["_method"] = method
+	return values
+}
+
+// Request is a request being built against the app
+type Request struct {
+	app     *App
+	method  string
+	path    string
+	body    io.Reader
+	headers http.Header
+}
+
+// HTML marks the request as coming from a browser
+func (r *Request) HTML() *Request {
+	r.headers.Set("Accept", "text/html,application/xhtml+xml")
+	return r
+}
+
+// JSON marks the request as coming from an API client
+func (r *Request) JSON() *Request {
+	r.headers.Set("Accept", "application/json")
+	return r
+}
+
+// WithHeader sets a request header
+func (r *Request) WithHeader(key, value string) *Request {
+	r.headers.Set(key, value)
+	return r
+}
+
+// WithForm sets a URL encoded form body
+func (r *Request) WithForm(data map[string]string) *Request {
+	form := url.Values{}
+	for k, v := range data {
+		form.Set(k, v)
+	}
+	r.body = strings.NewReader(form.Encode())
+	r.headers.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+// WithJSON sets a JSON body
+func (r *Request) WithJSON(data interface{}) *Request {
+	r.app.T.Helper()
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		r.app.T.Fatalf("failed to encode JSON body: %v", err)
+	}
+	r.body = bytes.NewReader(body)
+	r.headers.Set("Content-Type", "application/json")
+	return r.JSON()
+}
+
+// WithBody sets a raw body
+func (r *Request) WithBody(body io.Reader) *Request {
+	r.body = body
+	return r
+}
+
+// Do sends the request through the app's full handler chain
+func (r *Request) Do() *Response {
+	req := httptest.NewRequest(r.method, r.path, r.body)
+	for key, values := range r.headers {
+		req.Header[key] = values
+	}
+	for _, c := range r.app.cookies {
+		req.AddCookie(c)
+	}
+
+	rec := httptest.NewRecorder()
+	r.app.Handler().ServeHTTP(rec, req)
+
+	r.app.storeCookies(rec.Result().Cookies())
+
+	return &Response{
+		ResponseRecorder: rec,
+		Request:          req,
+		t:                r.app.T,
+	}
+}
@@ -0,0 +1,273 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/migrate"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/schema"
+)
+
+// MigrationsTable is never truncated so the schema version survives cleanup
+const MigrationsTable = migrate.Table
+
+// DB wraps a database connection with helpers that keep tests from
+// leaking state into each other
+type DB struct {
+	*sql.DB
+	T      testing.TB
+	driver string
+
+	inspector *schema.Inspector
+}
+
+// NewDB wraps db for use in a test
+func NewDB(t testing.TB, db *sql.DB, driver string) *DB {
+	t.Helper()
+
+	inspector, err := schema.NewInspector(db, driver)
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+
+	return &DB{DB: db, T: t, driver: inspector.Driver(), inspector: inspector}
+}
+
+// Driver returns the canonical driver name
+func (d *DB) Driver() string {
+	return d.driver
+}
+
+// Tx begins a transaction that is rolled back when the test ends.
+// Pass it to code that accepts a transaction or query interface.
+// The in-memory SQLite database of NewApp has a single connection,
+// so only use the transaction until it is rolled back.
+func (d *DB) Tx() *sql.Tx {
+	d.T.Helper()
+
+	tx, err := d.BeginTx(context.Background(), nil)
+	if err != nil {
+		d.T.Fatalf("failed to begin transaction: %v", err)
+	}
+	d.T.Cleanup(func() {
+		tx.Rollback()
+	})
+	return tx
+}
+
+// Truncate empties the given tables, or every table except the migrations table
+func (d *DB) Truncate(tables ...string) {
+	d.T.Helper()
+
+	ctx := context.Background()
+	if len(tables) == 0 {
+		all, err := d.inspector.Tables(ctx)
+		if err != nil {
+			d.T.Fatalf("failed to list tables: %v", err)
+		}
+		for _, t := range all {
+			if t != MigrationsTable {
+				tables = append(tables, t)
+			}
+		}
+	} else {
+		d.checkTables(ctx, tables)
+	}
+	if len(tables) == 0 {
+		return
+	}
+
+	var statements []string
+	switch d.driver {
+	case "postgres":
+		quoted := make([]string, len(tables))
+		for i, t := range tables {
+			quoted[i] = schema.QuoteIdent(d.driver, t)
+		}
+		statements = []string{"TRUNCATE TABLE " + strings.Join(quoted, ", ") + " RESTART IDENTITY CASCADE"}
+	case "mysql":
+		statements = append(statements, "SET FOREIGN_KEY_CHECKS = 0")
+		for _, t := range tables {
+			statements = append(statements, "TRUNCATE TABLE "+schema.QuoteIdent(d.driver, t))
+		}
+		statements = append(statements, "SET FOREIGN_KEY_CHECKS = 1")
+	case "sqlite":
+		statements = append(statements, "PRAGMA foreign_keys = OFF")
+		for _, t := range tables {
+			statements = append(statements, "DELETE FROM "+schema.QuoteIdent(d.driver, t))
+		}
+		if d.hasTable(ctx, "sqlite_sequence") {
+			statements = append(statements, "DELETE FROM sqlite_sequence")
+		}
+		statements = append(statements, "PRAGMA foreign_keys = ON")
+	}
+
+	// Foreign key checks are per connection, so use a dedicated one
+	conn, err := d.Conn(ctx)
+	if err != nil {
+		d.T.Fatalf("failed to get connection: %v", err)
+	}
+	defer conn.Close()
+
+	for _, stmt := range statements {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			d.T.Fatalf("failed to truncate tables: %v", err)
+		}
+	}
+}
+
+// TruncateOnCleanup empties every table when the test ends
+func (d *DB) TruncateOnCleanup() {
+	d.T.Cleanup(func() {
+		d.Truncate()
+	})
+}
+
+// Insert inserts a row and returns its id (0 if the table has no id column)
+func (d *DB) Insert(table string, row Row) int64 {
+	d.T.Helper()
+
+	id, err := d.insert(context.Background(), table, row)
+	if err != nil {
+		d.T.Fatalf("failed to insert into %s: %v", table, err)
+	}
+	return id
+}
+
+func (d *DB) insert(ctx context.Context, table string, row Row) (int64, error) {
+	info, err := d.inspector.Table(ctx, table)
+	if err != nil {
+		return 0, err
+	}
+
+	columns := make([]string, 0, len(row))
+	for col := range row {
+		if info.Column(col) == nil {
+			return 0, fmt.Errorf("unknown column %s", col)
+		}
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for i, col := range columns {
+		quoted[i] = schema.QuoteIdent(d.driver, col)
+		placeholders[i] = d.placeholder(i + 1)
+		args[i] = row[col]
+	}
+
+	query := "INSERT INTO " + schema.QuoteIdent(d.driver, table)
+	if len(columns) == 0 {
+		query += " DEFAULT VALUES"
+		if d.driver == "mysql" {
+			query = "INSERT INTO " + schema.QuoteIdent(d.driver, table) + " () VALUES ()"
+		}
+	} else {
+		query += " (" + strings.Join(quoted, ", ") + ") VALUES (" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	hasID := info.Column("id") != nil
+	if d.driver == "postgres" {
+		if !hasID {
+			_, err := d.ExecContext(ctx, query, args...)
+			return 0, err
+		}
+		var id int64
+		err := d.QueryRowContext(ctx, query+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := d.ExecContext(ctx, query, args...)
+	if err != nil || !hasID {
+		return 0, err
+	}
+	if v, ok := row["id"]; ok {
+		if id, ok := toInt64(v); ok {
+			return id, nil
+		}
+	}
+	return result.LastInsertId()
+}
+
+// Count returns the number of rows in a table
+func (d *DB) Count(table string) int {
+	d.T.Helper()
+
+	ctx := context.Background()
+	d.checkTables(ctx, []string{table})
+
+	var n int
+	if err := d.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+schema.QuoteIdent(d.driver, table)).Scan(&n); err != nil {
+		d.T.Fatalf("failed to count %s: %v", table, err)
+	}
+	return n
+}
+
+// checkTables fails the test if a table does not exist; it also keeps
+// user input out of the SQL
+func (d *DB) checkTables(ctx context.Context, tables []string) {
+	d.T.Helper()
+	for _, t := range tables {
+		if !d.hasTable(ctx, t) {
+			d.T.Fatalf("table %s does not exist", t)
+		}
+	}
+}
+
+func (d *DB) hasTable(ctx context.Context, table string) bool {
+	if table == "sqlite_sequence" && d.driver == "sqlite" {
+		var n int
+		d.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE name = 'sqlite_sequence'").Scan(&n)
+		return n > 0
+	}
+	ok, err := d.inspector.HasTable(ctx, table)
+	return err == nil && ok
+}
+
+// resetSequences moves Postgres id sequences past rows inserted with explicit ids
+func (d *DB) resetSequences(ctx context.Context, tables []string) error {
+	if d.driver != "postgres" {
+		return nil
+	}
+	for _, t := range tables {
+		info, err := d.inspector.Table(ctx, t)
+		if err != nil {
+			return err
+		}
+		if info.Column("id") == nil {
+			continue
+		}
+		query := fmt.Sprintf("SELECT setval(pg_get_serial_sequence($1, 'id'), COALESCE((SELECT MAX(id) FROM %s), 0) + 1, false)", schema.QuoteIdent(d.driver, t))
+		if _, err := d.ExecContext(ctx, query, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DB) placeholder(n int) string {
+	if d.driver == "postgres" {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	case int32:
+		return int64(n), true
+	case uint64:
+		return int64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,286 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Row is a set of column values
+type Row map[string]interface{}
+
+// Fixtures holds the rows loaded from fixture files, by table and row name
+type Fixtures map[string]map[string]Row
+
+// ID returns the id of a named fixture row, or 0 if unknown
+func (f Fixtures) ID(table, name string) int64 {
+	row, ok := f[table][name]
+	if !ok {
+		return 0
+	}
+	id, _ := toInt64(row["id"])
+	return id
+}
+
+// Row returns a named fixture row
+func (f Fixtures) Row(table, name string) Row {
+	return f[table][name]
+}
+
+// LoadFixtures inserts the rows of every <table>.yml file in dir, or only
+// of the given tables. A file holds either a list of rows or named rows:
+//
+//	alice:
+//	  email: alice@example.com
+//	bob:
+//	  email: bob@example.com
+//
+// Tables are loaded in foreign key order. Rows without an id get the one
+// assigned by the database, so Fixtures.ID works for every named row.
+func (d *DB) LoadFixtures(dir string, tables ...string) Fixtures {
+	d.T.Helper()
+
+	ctx := context.Background()
+	files, err := fixtureFiles(dir)
+	if err != nil {
+		d.T.Fatalf("failed to read fixtures: %v", err)
+	}
+
+	if len(tables) == 0 {
+		for table := range files {
+			tables = append(tables, table)
+		}
+	}
+	d.checkTables(ctx, tables)
+
+	ordered, err := d.dependencyOrder(ctx, tables)
+	if err != nil {
+		d.T.Fatalf("failed to order fixtures: %v", err)
+	}
+
+	fixtures := make(Fixtures)
+	for _, table := range ordered {
+		path, ok := files[table]
+		if !ok {
+			d.T.Fatalf("no fixture file for table %s in %s", table, dir)
+		}
+
+		rows, err := readFixtureFile(path)
+		if err != nil {
+			d.T.Fatalf("failed to read %s: %v", path, err)
+		}
+
+		fixtures[table] = make(map[string]Row, len(rows))
+		for _, named := range rows {
+			id, err := d.insert(ctx, table, named.row)
+			if err != nil {
+				d.T.Fatalf("failed to load fixture %s/%s: %v", table, named.name, err)
+			}
+			if _, ok := named.row["id"]; !ok && id != 0 {
+				named.row["id"] = id
+			}
+			fixtures[table][named.name] = named.row
+		}
+	}
+
+	if err := d.resetSequences(ctx, ordered); err != nil {
+		d.T.Fatalf("failed to reset sequences: %v", err)
+	}
+
+	return fixtures
+}
+
+// fixtureFiles maps table names to fixture files in dir
+func fixtureFiles(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string)
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+		files[strings.TrimSuffix(e.Name(), ext)] = filepath.Join(dir, e.Name())
+	}
+	return files, nil
+}
+
+type namedRow struct {
+	name string
+	row  Row
+}
+
+// readFixtureFile parses a list of rows or a map of named rows
+func readFixtureFile(path string) ([]namedRow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Row
+	if err := yaml.Unmarshal(data, &list); err == nil {
+		rows := make([]namedRow, len(list))
+		for i, row := range list {
+			rows[i] = namedRow{name: strconv.Itoa(i), row: row}
+		}
+		return rows, nil
+	}
+
+	var named map[string]Row
+	if err := yaml.Unmarshal(data, &named); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := make([]namedRow, len(names))
+	for i, name := range names {
+		rows[i] = namedRow{name: name, row: named[name]}
+	}
+	return rows, nil
+}
+
+// dependencyOrder sorts tables so referenced tables come first
+func (d *DB) dependencyOrder(ctx context.Context, tables []string) ([]string, error) {
+	wanted := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		wanted[t] = true
+	}
+
+	deps := make(map[string][]string, len(tables))
+	for _, t := range tables {
+		info, err := d.inspector.Table(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		for _, fk := range info.ForeignKeys {
+			if wanted[fk.RefTable] && fk.RefTable != t {
+				deps[t] = append(deps[t], fk.RefTable)
+			}
+		}
+	}
+
+	sorted := append([]string{}, tables...)
+	sort.Strings(sorted)
+
+	var ordered []string
+	state := make(map[string]int) // 1 visiting, 2 done
+	var visit func(string) error
+	visit = func(t string) error {
+		switch state[t] {
+		case 1:
+			return fmt.Errorf("circular foreign keys involving %s", t)
+		case 2:
+			return nil
+		}
+		state[t] = 1
+		for _, dep := range deps[t] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[t] = 2
+		ordered = append(ordered, t)
+		return nil
+	}
+
+	for _, t := range sorted {
+		if err := visit(t); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// Factory builds rows for a table from default attributes. String values
+// may contain {{n}}, replaced by a per-factory sequence number, and values
+// of type func(n int) interface{} are called with it.
+type Factory struct {
+	Table      string
+	Attributes Row
+
+	mu sync.Mutex
+	n  int
+}
+
+// NewFactory creates a factory for table
+func NewFactory(table string, attributes Row) *Factory {
+	return &Factory{Table: table, Attributes: attributes}
+}
+
+// Build returns a row with the defaults and overrides applied, without inserting it
+func (f *Factory) Build(overrides Row) Row {
+	f.mu.Lock()
+	f.n++
+	n := f.n
+	f.mu.Unlock()
+
+	row := make(Row, len(f.Attributes)+len(overrides))
+	for k, v := range f.Attributes {
+		row[k] = sequenceValue(v, n)
+	}
+	for k, v := range overrides {
+		row[k] = sequenceValue(v, n)
+	}
+	return row
+}
+
+func sequenceValue(v interface{}, n int) interface{} {
+	switch value := v.(type) {
+	case string:
+		return strings.ReplaceAll(value, "{{n}}", strconv.Itoa(n))
+	case func(int) interface{}:
+		return value(n)
+	}
+	return v
+}
+
+// Create inserts a row built by the factory and returns its id
+func (d *DB) Create(f *Factory, overrides Row) int64 {
+	d.T.Helper()
+	return d.Insert(f.Table, f.Build(overrides))
+}
+
+// LoadFactories reads factory definitions from a YAML file:
+//
+//	user:
+//	  table: users
+//	  attributes:
+//	    email: user{{n}}@example.com
+func LoadFactories(path string) (map[string]*Factory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs map[string]struct {
+		Table      string `yaml:"table"`
+		Attributes Row    `yaml:"attributes"`
+	}
+	if err := yaml.Unmarshal(data, &defs); err != nil {
+		return nil, err
+	}
+
+	factories := make(map[string]*Factory, len(defs))
+	for name, def := range defs {
+		if def.Table == "" {
+			return nil, fmt.Errorf("factory %s has no table", name)
+		}
+		factories[name] = NewFactory(def.Table, def.Attributes)
+	}
+	return factories, nil
+}
@@ -0,0 +1,166 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Response is the result of a request, with chainable assertions.
+// Failed assertions mark the test as failed and keep going.
+type Response struct {
+	*httptest.ResponseRecorder
+	Request *http.Request
+	t       testing.TB
+}
+
+// Status returns the HTTP status code
+func (r *Response) Status() int {
+	return r.Code
+}
+
+// Body returns the response body as string
+func (r *Response) Body() string {
+	return r.ResponseRecorder.Body.String()
+}
+
+// Location returns the redirect target
+func (r *Response) Location() string {
+	return r.Header().Get("Location")
+}
+
+// AssertStatus checks the status code
+func (r *Response) AssertStatus(code int) *Response {
+	r.t.Helper()
+	if r.Code != code {
+		r.t.Errorf("%s %s: expected status %d, got %d\n%s", r.Request.Method, r.Request.URL.Path, code, r.Code, snippet(r.Body()))
+	}
+	return r
+}
+
+// AssertContains checks that the body contains s
+func (r *Response) AssertContains(s string) *Response {
+	r.t.Helper()
+	if !strings.Contains(r.Body(), s) {
+		r.t.Errorf("%s %s: expected body to contain %q\n%s", r.Request.Method, r.Request.URL.Path, s, snippet(r.Body()))
+	}
+	return r
+}
+
+// AssertNotContains checks that the body does not contain s
+func (r *Response) AssertNotContains(s string) *Response {
+	r.t.Helper()
+	if strings.Contains(r.Body(), s) {
+		r.t.Errorf("%s %s: expected body not to contain %q", r.Request.Method, r.Request.URL.Path, s)
+	}
+	return r
+}
+
+// AssertHeader checks a response header value
+func (r *Response) AssertHeader(key, value string) *Response {
+	r.t.Helper()
+	if got := r.Header().Get(key); got != value {
+		r.t.Errorf("%s %s: expected header %s to be %q, got %q", r.Request.Method, r.Request.URL.Path, key, value, got)
+	}
+	return r
+}
+
+// AssertRedirect checks for a 3xx response to location
+func (r *Response) AssertRedirect(location string) *Response {
+	r.t.Helper()
+	if r.Code < 300 || r.Code >= 400 {
+		r.t.Errorf("%s %s: expected a redirect, got status %d", r.Request.Method, r.Request.URL.Path, r.Code)
+		return r
+	}
+	if got := r.Location(); got != location {
+		r.t.Errorf("%s %s: expected redirect to %q, got %q", r.Request.Method, r.Request.URL.Path, location, got)
+	}
+	return r
+}
+
+// BindJSON decodes the body into v, failing the test if it is not valid JSON
+func (r *Response) BindJSON(v interface{}) *Response {
+	r.t.Helper()
+	if err := json.Unmarshal(r.ResponseRecorder.Body.Bytes(), v); err != nil {
+		r.t.Fatalf("%s %s: invalid JSON response: %v\n%s", r.Request.Method, r.Request.URL.Path, err, snippet(r.Body()))
+	}
+	return r
+}
+
+// AssertJSON checks that the body is JSON equal to expected.
+// Structs, maps and slices are compared by their JSON encoding.
+func (r *Response) AssertJSON(expected interface{}) *Response {
+	r.t.Helper()
+
+	var got interface{}
+	r.BindJSON(&got)
+
+	want, err := normalizeJSON(expected)
+	if err != nil {
+		r.t.Fatalf("failed to encode expected JSON: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		r.t.Errorf("%s %s: JSON mismatch\nexpected: %s\n     got: %s", r.Request.Method, r.Request.URL.Path, mustJSON(want), mustJSON(got))
+	}
+	return r
+}
+
+// AssertJSONField checks a field of a JSON object. Nested fields use dots: "user.name".
+func (r *Response) AssertJSONField(path string, expected interface{}) *Response {
+	r.t.Helper()
+
+	var got interface{}
+	r.BindJSON(&got)
+
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := got.(map[string]interface{})
+		if !ok {
+			r.t.Errorf("%s %s: JSON field %s not found", r.Request.Method, r.Request.URL.Path, path)
+			return r
+		}
+		if got, ok = obj[key]; !ok {
+			r.t.Errorf("%s %s: JSON field %s not found", r.Request.Method, r.Request.URL.Path, path)
+			return r
+		}
+	}
+
+	want, err := normalizeJSON(expected)
+	if err != nil {
+		r.t.Fatalf("failed to encode expected JSON: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		r.t.Errorf("%s %s: expected JSON field %s to be %s, got %s", r.Request.Method, r.Request.URL.Path, path, mustJSON(want), mustJSON(got))
+	}
+	return r
+}
+
+// normalizeJSON round-trips v through encoding/json so it compares
+// equal to a decoded response body
+func normalizeJSON(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
+
+func mustJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// snippet shortens a body for failure messages
+func snippet(body string) string {
+	const max = 500
+	if len(body) > max {
+		return body[:max] + "..."
+	}
+	return body
+}
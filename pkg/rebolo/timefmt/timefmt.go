@@ -0,0 +1,206 @@
+package timefmt
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Formatter formats times in a timezone with the conventions of a locale
+type Formatter struct {
+	Location *time.Location
+	Locale   string // "en", "es", "pt", "fr" or "de", English otherwise
+}
+
+var (
+	defaultFormatter = Formatter{Location: time.UTC, Locale: "en"}
+	mu               sync.RWMutex
+)
+
+// SetDefault sets the timezone (an IANA name like "America/Bogota") and
+// locale used when a request doesn't ask for its own, from app.timezone
+// and app.locale in config.yml. Empty values keep the current ones.
+func SetDefault(timezone, locale string) error {
+	f := Default()
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return fmt.Errorf("unknown timezone %q: %w", timezone, err)
+		}
+		f.Location = loc
+	}
+	if locale != "" {
+		f.Locale = locale
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	defaultFormatter = f
+	return nil
+}
+
+// Default returns the formatter configured with SetDefault
+func Default() Formatter {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultFormatter
+}
+
+// New returns a formatter for timezone and locale, falling back to the
+// default for empty or unknown values
+func New(timezone, locale string) Formatter {
+	f := Default()
+	if loc, err := time.LoadLocation(timezone); timezone != "" && err == nil {
+		f.Location = loc
+	}
+	if locale != "" {
+		f.Locale = locale
+	}
+	return f
+}
+
+// In converts t to the formatter's timezone
+func (f Formatter) In(t time.Time) time.Time {
+	if f.Location == nil {
+		return t
+	}
+	return t.In(f.Location)
+}
+
+// Date formats the day of t, e.g. "March 5, 2025" or "5 de marzo de 2025"
+func (f Formatter) Date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	t = f.In(t)
+	l := lookup(f.Locale)
+	return l.date(t.Day(), l.months[t.Month()-1], t.Year())
+}
+
+// Time formats the time of day of t, "3:04 PM" in English and "15:04" elsewhere
+func (f Formatter) Time(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return f.In(t).Format(lookup(f.Locale).clock)
+}
+
+// DateTime formats the day and time of t
+func (f Formatter) DateTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return f.Date(t) + " " + f.Time(t)
+}
+
+// Ago describes how long ago t was, e.g. "5 minutes ago" or "hace 5 minutos".
+// Times older than a month are shown as a date.
+func (f Formatter) Ago(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	l := lookup(f.Locale)
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return l.now
+	case d < time.Hour:
+		return l.ago(int(d/time.Minute), l.units[0])
+	case d < 24*time.Hour:
+		return l.ago(int(d/time.Hour), l.units[1])
+	case d < 30*24*time.Hour:
+		return l.ago(int(d/(24*time.Hour)), l.units[2])
+	}
+	return f.Date(t)
+}
+
+// FuncMap returns template helpers using the default formatter:
+//
+//	{{localDate .CreatedAt}}  {{localTime .CreatedAt}}  {{timeAgo .UpdatedAt}}
+//	{{localTime .CreatedAt "Europe/Madrid"}}
+func FuncMap() template.FuncMap {
+	formatter := func(zone []string) Formatter {
+		if len(zone) > 0 {
+			return New(zone[0], "")
+		}
+		return Default()
+	}
+	return template.FuncMap{
+		"localDate": func(t time.Time, zone ...string) string { return formatter(zone).Date(t) },
+		"localTime": func(t time.Time, zone ...string) string { return formatter(zone).DateTime(t) },
+		"timeAgo":   func(t time.Time) string { return Default().Ago(t) },
+	}
+}
+
+type locale struct {
+	months [12]string
+	date   func(day int, month string, year int) string
+	clock  string
+	now    string
+	units  [3][2]string // minute, hour, day: singular and plural
+	ago    func(n int, unit [2]string) string
+}
+
+func lookup(name string) locale {
+	// "es-CO" and "es_CO" use "es"
+	name = strings.ToLower(name)
+	if i := strings.IndexAny(name, "-_"); i > 0 {
+		name = name[:i]
+	}
+	if l, ok := locales[name]; ok {
+		return l
+	}
+	return locales["en"]
+}
+
+func plural(n int, unit [2]string) string {
+	if n == 1 {
+		return unit[0]
+	}
+	return unit[1]
+}
+
+var locales = map[string]locale{
+	"en": {
+		months: [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		date:   func(d int, m string, y int) string { return fmt.Sprintf("%s %d, %d", m, d, y) },
+		clock:  "3:04 PM",
+		now:    "just now",
+		units:  [3][2]string{{"minute", "minutes"}, {"hour", "hours"}, {"day", "days"}},
+		ago:    func(n int, u [2]string) string { return fmt.Sprintf("%d %s ago", n, plural(n, u)) },
+	},
+	"es": {
+		months: [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		date:   func(d int, m string, y int) string { return fmt.Sprintf("%d de %s de %d", d, m, y) },
+		clock:  "15:04",
+		now:    "justo ahora",
+		units:  [3][2]string{{"minuto", "minutos"}, {"hora", "horas"}, {"día", "días"}},
+		ago:    func(n int, u [2]string) string { return fmt.Sprintf("hace %d %s", n, plural(n, u)) },
+	},
+	"pt": {
+		months: [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		date:   func(d int, m string, y int) string { return fmt.Sprintf("%d de %s de %d", d, m, y) },
+		clock:  "15:04",
+		now:    "agora mesmo",
+		units:  [3][2]string{{"minuto", "minutos"}, {"hora", "horas"}, {"dia", "dias"}},
+		ago:    func(n int, u [2]string) string { return fmt.Sprintf("há %d %s", n, plural(n, u)) },
+	},
+	"fr": {
+		months: [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		date:   func(d int, m string, y int) string { return fmt.Sprintf("%d %s %d", d, m, y) },
+		clock:  "15:04",
+		now:    "à l'instant",
+		units:  [3][2]string{{"minute", "minutes"}, {"heure", "heures"}, {"jour", "jours"}},
+		ago:    func(n int, u [2]string) string { return fmt.Sprintf("il y a %d %s", n, plural(n, u)) },
+	},
+	"de": {
+		months: [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		date:   func(d int, m string, y int) string { return fmt.Sprintf("%d. %s %d", d, m, y) },
+		clock:  "15:04",
+		now:    "gerade eben",
+		units:  [3][2]string{{"Minute", "Minuten"}, {"Stunde", "Stunden"}, {"Tag", "Tagen"}},
+		ago:    func(n int, u [2]string) string { return fmt.Sprintf("vor %d %s", n, plural(n, u)) },
+	},
+}
@@ -2,11 +2,19 @@ package rebolo
 
 // Re-export types from sub-packages for convenience
 import (
+	stdcontext "context"
+	stderrors "errors"
+	"fmt"
+	"log"
 	"net/http"
 
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/adapters"
 	"github.com/Palaciodiego008/rebololang/pkg/rebolo/context"
 	"github.com/Palaciodiego008/rebololang/pkg/rebolo/errors"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/form"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/graphql"
 	"github.com/Palaciodiego008/rebololang/pkg/rebolo/middleware"
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/openapi"
 	"github.com/Palaciodiego008/rebololang/pkg/rebolo/session"
 	"github.com/Palaciodiego008/rebololang/pkg/rebolo/testing"
 	"github.com/Palaciodiego008/rebololang/pkg/rebolo/validation"
@@ -19,34 +27,92 @@ type (
 	ContextHandler   = context.ContextHandler
 	Session          = session.Session
 	SessionStore     = session.SessionStore
+	SessionOptions   = session.Options
+	MemoryStore      = session.MemoryStore
 	Flash            = session.Flash
 	FlashMessage     = session.FlashMessage
 	ErrorHandler     = errors.ErrorHandler
 	ErrorHandlers    = errors.ErrorHandlers
+	ErrorReporter    = errors.Reporter
+	HTTPError        = errors.HTTPError
+	SafeError        = errors.SafeError
+	PanicError       = errors.PanicError
 	MiddlewareFunc   = middleware.MiddlewareFunc
 	MiddlewareConfig = middleware.MiddlewareConfig
 	MiddlewareStack  = middleware.MiddlewareStack
+	ResponseWriter   = middleware.ResponseWriter
+	CORSOptions      = middleware.CORSOptions
+	CORSRoute        = middleware.CORSRoute
+	StaticOptions    = adapters.StaticOptions
 	FileWatcher      = watcher.FileWatcher
 	TestApp          = testing.TestApp
 	ValidationError  = validation.ValidationError
 	ValidationErrors = validation.ValidationErrors
 	File             = validation.File
+	Form             = form.Form
+	OpenAPIInfo      = openapi.Info
+	OpenAPIOperation = openapi.Operation
 )
 
+// ErrorReporterFunc is invoked for recovered panics and 5xx responses
+type ErrorReporterFunc func(ctx *Context, err error, code int)
+
 // Function aliases for convenience
 var (
-	NewContext            = context.NewContext
-	NewCookieSessionStore = session.NewCookieSessionStore
-	NewFlash              = session.NewFlash
-	GetSession            = session.GetSession
-	GetFlash              = session.GetFlash
-	NewErrorHandlers      = errors.NewErrorHandlers
-	NewMiddlewareStack    = middleware.NewMiddlewareStack
-	CORSMiddleware        = middleware.CORSMiddleware
-	ValidateStruct        = validation.ValidateStruct
-	ValidationErrorsToMap = validation.ValidationErrorsToMap
-	Bind                  = validation.Bind
-	BindAndValidate       = validation.BindAndValidate
+	NewContext                       = context.NewContext
+	WithValue                        = context.WithValue
+	Value                            = context.Value
+	IsHTMX                           = context.IsHTMX
+	NewCookieSessionStore            = session.NewCookieSessionStore
+	NewMemorySessionStore            = session.NewMemorySessionStore
+	NewCookieSessionStoreWithOptions = session.NewCookieSessionStoreWithOptions
+	DefaultSessionOptions            = session.DefaultOptions
+	NewFlash                         = session.NewFlash
+	GetSession                       = session.GetSession
+	GetFlash                         = session.GetFlash
+	NewErrorHandlers                 = errors.NewErrorHandlers
+	NewSentryReporter                = errors.NewSentryReporter
+	NewError                         = errors.NewError
+	NewSafeError                     = errors.NewSafeError
+	NewMiddlewareStack               = middleware.NewMiddlewareStack
+	NewResponseWriter                = middleware.NewResponseWriter
+	CORS                             = middleware.CORS
+	CORSMiddleware                   = middleware.CORSMiddleware
+	CSRFMiddleware                   = middleware.CSRFMiddleware
+	TimeoutMiddleware                = middleware.Timeout
+	CSRFToken                        = middleware.CSRFToken
+	EarlyHintsMiddleware             = middleware.EarlyHintsMiddleware
+	NewForm                          = form.New
+	GraphQLContext                   = graphql.FromContext
+	ValidateStruct                   = validation.ValidateStruct
+	ValidationErrorsToMap            = validation.ValidationErrorsToMap
+	Bind                             = validation.Bind
+	BindAndValidate                  = validation.BindAndValidate
+	WithPrimary                      = adapters.WithPrimary
+)
+
+// Flash levels, for Context.AddFlash
+const (
+	FlashSuccess = session.LevelSuccess
+	FlashError   = session.LevelError
+	FlashWarning = session.LevelWarning
+	FlashInfo    = session.LevelInfo
+)
+
+// Common HTTP errors, return them from a ContextHandler
+var (
+	ErrBadRequest         = errors.ErrBadRequest
+	ErrUnauthorized       = errors.ErrUnauthorized
+	ErrForbidden          = errors.ErrForbidden
+	ErrNotFound           = errors.ErrNotFound
+	ErrMethodNotAllowed   = errors.ErrMethodNotAllowed
+	ErrConflict           = errors.ErrConflict
+	ErrUnprocessable      = errors.ErrUnprocessable
+	ErrTooManyRequests    = errors.ErrTooManyRequests
+	ErrInternal           = errors.ErrInternal
+	ErrNotImplemented     = errors.ErrNotImplemented
+	ErrServiceUnavailable = errors.ErrServiceUnavailable
+	ErrGatewayTimeout     = errors.ErrGatewayTimeout
 )
 
 // NewTestApp creates a new test app wrapping an application
@@ -60,8 +126,63 @@ func (a *Application) ContextMiddleware(handler ContextHandler) http.HandlerFunc
 		ctx := NewContext(w, r, a)
 
 		if err := handler(ctx); err != nil {
-			// Use custom error handler
-			a.InternalErrorHandler(w, r, err)
+			a.handleContextError(ctx, err)
+		}
+	}
+}
+
+// handleContextError maps an error returned by a ContextHandler to a response.
+// HTTPErrors keep their status code, validation errors become 422, timeouts
+// 503 and anything else is a 500. JSON clients get a JSON body, browsers get
+// the error pages. Nothing is written for requests the client cancelled.
+func (a *Application) handleContextError(ctx *Context, err error) {
+	if stderrors.Is(err, stdcontext.DeadlineExceeded) {
+		log.Printf("⏳ %s %s timed out: %v", ctx.Request.Method, ctx.Request.URL.Path, err)
+		err = errors.ErrServiceUnavailable.Wrap(err)
+		if !ctx.WantsJSON() {
+			a.HandleError(ctx.Response, ctx.Request, err, http.StatusServiceUnavailable)
+			return
+		}
+		a.reportError(ctx.Response, ctx.Request, err, http.StatusServiceUnavailable)
+		a.RenderError(ctx.Response, errors.PublicMessage(err, http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	if stderrors.Is(err, stdcontext.Canceled) {
+		// The client went away, there is nobody to answer
+		log.Printf("⚠️  %s %s cancelled: %v", ctx.Request.Method, ctx.Request.URL.Path, err)
+		return
+	}
+
+	code := errors.StatusCode(err)
+
+	var validationErrs ValidationErrors
+	isValidation := stderrors.As(err, &validationErrs)
+	if isValidation {
+		code = http.StatusUnprocessableEntity
+	}
+
+	if !ctx.WantsJSON() {
+		if code >= 500 {
+			a.InternalErrorHandler(ctx.Response, ctx.Request, err)
+			return
 		}
+		a.HandleError(ctx.Response, ctx.Request, err, code)
+		return
 	}
+
+	if code >= 500 {
+		log.Printf("❌ Internal Server Error: %v", err)
+		a.reportError(ctx.Response, ctx.Request, err, code)
+	}
+
+	if isValidation {
+		ctx.JSON(code, map[string]interface{}{
+			"error":  "validation failed",
+			"status": fmt.Sprintf("%d", code),
+			"errors": validationErrs,
+		})
+		return
+	}
+
+	a.RenderError(ctx.Response, errors.PublicMessage(err, code), code)
 }
@@ -0,0 +1,384 @@
+package uploads
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by Stat for objects that aren't in the bucket
+var ErrNotFound = errors.New("object not found")
+
+const (
+	algorithm       = "AWS4-HMAC-SHA256"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	emptySHA256     = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// Bucket is an S3 bucket, or one of an S3 compatible service like
+// Cloudflare R2, MinIO or DigitalOcean Spaces. Requests are signed with
+// AWS Signature Version 4.
+type Bucket struct {
+	Name            string
+	Region          string // "us-east-1" by default, "auto" for R2
+	Endpoint        string // of S3 compatible services, "https://s3.<region>.amazonaws.com" by default
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // of temporary credentials
+	PathStyle       bool   // put the bucket in the path instead of the host, as MinIO needs
+	PublicURL       string // where objects are served from, like a CDN, the bucket's URL by default
+	Client          *http.Client
+}
+
+// S3FromEnv returns the bucket set by the S3_BUCKET, S3_ENDPOINT,
+// S3_PUBLIC_URL, S3_PATH_STYLE, AWS_REGION, AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables
+func S3FromEnv() *Bucket {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	pathStyle, _ := strconv.ParseBool(os.Getenv("S3_PATH_STYLE"))
+	return &Bucket{
+		Name:            os.Getenv("S3_BUCKET"),
+		Region:          region,
+		Endpoint:        os.Getenv("S3_ENDPOINT"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		PathStyle:       pathStyle,
+		PublicURL:       os.Getenv("S3_PUBLIC_URL"),
+	}
+}
+
+// Presigned is what a client needs to upload a file itself: the request
+// to make, and for POST the form fields to send before the file
+type Presigned struct {
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// Object is what Stat tells about an object
+type Object struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// PresignPut returns a URL a client can PUT the object to until expires
+// passes. The request must send the given Content-Type and exactly size
+// bytes, both being signed.
+func (b *Bucket) PresignPut(key, contentType string, size int64, expires time.Duration) (*Presigned, error) {
+	headers := map[string]string{"Content-Type": contentType, "Content-Length": strconv.FormatInt(size, 10)}
+	u, err := b.presign(http.MethodPut, key, headers, expires, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	// Browsers set Content-Length themselves and refuse to be told
+	return &Presigned{
+		Method:    http.MethodPut,
+		URL:       u,
+		Headers:   map[string]string{"Content-Type": contentType},
+		ExpiresAt: time.Now().Add(expires).UTC(),
+	}, nil
+}
+
+// PresignGet returns a URL downloading the object until expires passes,
+// for buckets that aren't public
+func (b *Bucket) PresignGet(key string, expires time.Duration) (string, error) {
+	return b.presign(http.MethodGet, key, nil, expires, time.Now())
+}
+
+// PresignPost returns a form a browser can POST the object with until
+// expires passes, a multipart form with the fields then a "file" field.
+// The storage rejects files with another Content-Type or over maxSize
+// bytes.
+func (b *Bucket) PresignPost(key, contentType string, maxSize int64, expires time.Duration) (*Presigned, error) {
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	date, scope, credential := b.scope(now)
+
+	fields := map[string]string{
+		"key":              key,
+		"Content-Type":     contentType,
+		"x-amz-algorithm":  algorithm,
+		"x-amz-credential": credential,
+		"x-amz-date":       date,
+	}
+	conditions := []interface{}{
+		map[string]string{"bucket": b.Name},
+		[]interface{}{"content-length-range", 0, maxSize},
+	}
+	if b.SessionToken != "" {
+		fields["x-amz-security-token"] = b.SessionToken
+	}
+	for name, value := range fields {
+		conditions = append(conditions, map[string]string{name: value})
+	}
+	policy, err := json.Marshal(map[string]interface{}{
+		"expiration": now.Add(expires).Format("2006-01-02T15:04:05Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	fields["policy"] = base64.StdEncoding.EncodeToString(policy)
+	fields["x-amz-signature"] = hex.EncodeToString(hmacSHA256(b.signingKey(scope), fields["policy"]))
+
+	return &Presigned{
+		Method:    http.MethodPost,
+		URL:       b.bucketURL(),
+		Fields:    fields,
+		ExpiresAt: now.Add(expires),
+	}, nil
+}
+
+// Stat returns what the storage knows of the object, ErrNotFound when it
+// isn't there
+func (b *Bucket) Stat(ctx context.Context, key string) (*Object, error) {
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, b.objectURL(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	b.sign(req, time.Now())
+
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("stat %s: storage answered %s", key, resp.Status)
+	}
+
+	obj := &Object{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+	}
+	obj.LastModified, _ = http.ParseTime(resp.Header.Get("Last-Modified"))
+	return obj, nil
+}
+
+// URL returns where the object is served from
+func (b *Bucket) URL(key string) string {
+	if b.PublicURL != "" {
+		return strings.TrimRight(b.PublicURL, "/") + "/" + escapePath(key)
+	}
+	return b.objectURL(key)
+}
+
+func (b *Bucket) check() error {
+	if b.Name == "" || b.AccessKeyID == "" || b.SecretAccessKey == "" {
+		return errors.New("uploads: the bucket needs a name, an access key ID and a secret access key")
+	}
+	return nil
+}
+
+func (b *Bucket) region() string {
+	if b.Region == "" {
+		return "us-east-1"
+	}
+	return b.Region
+}
+
+func (b *Bucket) endpoint() *url.URL {
+	endpoint := b.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + b.region() + ".amazonaws.com"
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return &url.URL{Scheme: "https", Host: endpoint}
+	}
+	return u
+}
+
+// bucketURL returns the URL of the bucket, with a trailing slash
+func (b *Bucket) bucketURL() string {
+	u := b.endpoint()
+	if b.PathStyle {
+		return u.Scheme + "://" + u.Host + "/" + b.Name + "/"
+	}
+	return u.Scheme + "://" + b.Name + "." + u.Host + "/"
+}
+
+func (b *Bucket) objectURL(key string) string {
+	return b.bucketURL() + escapePath(key)
+}
+
+// scope returns the request date, the credential scope and the credential
+func (b *Bucket) scope(now time.Time) (date, scope, credential string) {
+	date = now.UTC().Format("20060102T150405Z")
+	scope = date[:8] + "/" + b.region() + "/s3/aws4_request"
+	return date, scope, b.AccessKeyID + "/" + scope
+}
+
+func (b *Bucket) signingKey(scope string) []byte {
+	parts := strings.Split(scope, "/")
+	key := hmacSHA256([]byte("AWS4"+b.SecretAccessKey), parts[0])
+	for _, part := range parts[1:] {
+		key = hmacSHA256(key, part)
+	}
+	return key
+}
+
+// presign returns the URL of a request signed in its query string, the
+// headers being part of the signature
+func (b *Bucket) presign(method, key string, headers map[string]string, expires time.Duration, now time.Time) (string, error) {
+	if err := b.check(); err != nil {
+		return "", err
+	}
+	if expires <= 0 || expires > 7*24*time.Hour {
+		return "", errors.New("uploads: presigned URLs expire within 7 days")
+	}
+	u, err := url.Parse(b.objectURL(key))
+	if err != nil {
+		return "", err
+	}
+	date, scope, credential := b.scope(now)
+
+	signed := map[string]string{"host": u.Host}
+	for name, value := range headers {
+		signed[strings.ToLower(name)] = value
+	}
+	names, canonicalHeaders := canonical(signed)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", algorithm)
+	query.Set("X-Amz-Credential", credential)
+	query.Set("X-Amz-Date", date)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", names)
+	if b.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", b.SessionToken)
+	}
+	rawQuery := canonicalQuery(query)
+
+	request := strings.Join([]string{method, u.EscapedPath(), rawQuery, canonicalHeaders, names, unsignedPayload}, "\n")
+	signature := hex.EncodeToString(hmacSHA256(b.signingKey(scope), stringToSign(date, scope, request)))
+	u.RawQuery = rawQuery + "&X-Amz-Signature=" + signature
+	return u.String(), nil
+}
+
+// sign signs req in its Authorization header, its body being empty
+func (b *Bucket) sign(req *http.Request, now time.Time) {
+	date, scope, credential := b.scope(now)
+	req.Header.Set("X-Amz-Date", date)
+	req.Header.Set("X-Amz-Content-Sha256", emptySHA256)
+	if b.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", b.SessionToken)
+	}
+
+	signed := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		if strings.HasPrefix(strings.ToLower(name), "x-amz-") {
+			signed[strings.ToLower(name)] = req.Header.Get(name)
+		}
+	}
+	names, canonicalHeaders := canonical(signed)
+
+	request := strings.Join([]string{req.Method, req.URL.EscapedPath(), canonicalQuery(req.URL.Query()), canonicalHeaders, names, emptySHA256}, "\n")
+	signature := hex.EncodeToString(hmacSHA256(b.signingKey(scope), stringToSign(date, scope, request)))
+	req.Header.Set("Authorization", algorithm+" Credential="+credential+", SignedHeaders="+names+", Signature="+signature)
+}
+
+func stringToSign(date, scope, request string) string {
+	sum := sha256.Sum256([]byte(request))
+	return algorithm + "\n" + date + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+}
+
+// canonical returns the signed header names, and the headers as they are
+// signed
+func canonical(headers map[string]string) (names, canonicalHeaders string) {
+	keys := make([]string, 0, len(headers))
+	for name := range headers {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, name := range keys {
+		b.WriteString(name + ":" + strings.Join(strings.Fields(headers[name]), " ") + "\n")
+	}
+	return strings.Join(keys, ";"), b.String()
+}
+
+// canonicalQuery returns the query sorted by name, escaped the way
+// signatures need
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for name := range query {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, name := range keys {
+		for _, value := range query[name] {
+			pairs = append(pairs, escape(name)+"="+escape(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// escape percent-encodes everything but the unreserved characters of
+// RFC 3986
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// escapePath escapes each segment of a key, keeping the slashes
+func escapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = escape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,291 @@
+// Package uploads lets clients send files straight to an S3 compatible
+// bucket, so large uploads never go through the application. The client
+// asks for a presigned URL, uploads the file to it, then tells the
+// application the upload is complete to record it:
+//
+//	uploads.Mount(app, "/uploads", uploads.Options{
+//		Bucket:       uploads.S3FromEnv(),
+//		MaxSize:      500 << 20,
+//		ContentTypes: []string{"video/*"},
+//		OnComplete: func(c *rebolo.Context, u uploads.Upload) (interface{}, error) {
+//			return videos.Create(c.Context(), currentUser(c).ID, u.Key, u.Size)
+//		},
+//	})
+//
+// From the browser:
+//
+//	POST /uploads/presign   {"filename": "talk.mp4", "content_type": "video/mp4", "size": 73400320}
+//	PUT  <url>              the file, with the returned headers
+//	POST /uploads/complete  {"key": "<key>"}
+//
+// Keys are issued to the session and only its own can be completed, and
+// the object is checked in the bucket before OnComplete runs.
+package uploads
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo"
+)
+
+// pendingKey is the session value holding the keys presigned and not yet
+// completed, one "key\tfilename" per line
+const pendingKey = "uploads_pending"
+
+// maxPending is how many uploads a session may have pending, the oldest
+// being forgotten
+const maxPending = 20
+
+// Options configures uploads
+type Options struct {
+	Bucket       *Bucket
+	Prefix       string        // put before every key, "uploads/" by default
+	MaxSize      int64         // largest file in bytes, 100 MB by default
+	ContentTypes []string      // accepted types like "application/pdf" or "image/*", any when empty
+	Expires      time.Duration // how long presigned URLs work, 15 minutes by default
+	// Authorize, when set, decides who may upload, others get a 403. The
+	// routes are open otherwise, OnComplete being where an upload is tied
+	// to a user.
+	Authorize func(r *http.Request) bool
+	// OnComplete records the upload, e.g. inserts its row, once the
+	// object is in the bucket. What it returns is sent as JSON, the
+	// Upload when OnComplete isn't set.
+	OnComplete func(c *rebolo.Context, u Upload) (interface{}, error)
+}
+
+// Upload is a file uploaded to the bucket
+type Upload struct {
+	Key         string `json:"key"`
+	Filename    string `json:"filename"` // as the client named it
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	ETag        string `json:"etag"`
+	URL         string `json:"url"`
+}
+
+// Uploads issues presigned uploads and completes them
+type Uploads struct {
+	app    *rebolo.Application
+	prefix string
+	opts   Options
+}
+
+type presignRequest struct {
+	Filename    string `json:"filename" form:"filename"`
+	ContentType string `json:"content_type" form:"content_type"`
+	Size        int64  `json:"size" form:"size"`
+	Method      string `json:"method" form:"method"` // "PUT", the default, or "POST" for a form upload
+}
+
+type presignResponse struct {
+	Key string `json:"key"`
+	*Presigned
+}
+
+type completeRequest struct {
+	Key string `json:"key" form:"key"`
+}
+
+// Mount serves POST <prefix>/presign and POST <prefix>/complete. It
+// panics without a bucket.
+func Mount(app *rebolo.Application, prefix string, opts Options) *Uploads {
+	if opts.Bucket == nil {
+		panic("uploads: Options.Bucket is required")
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = "uploads/"
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 100 << 20
+	}
+	if opts.Expires <= 0 {
+		opts.Expires = 15 * time.Minute
+	}
+	u := &Uploads{app: app, prefix: strings.TrimRight(prefix, "/"), opts: opts}
+
+	app.POST(u.prefix+"/presign", app.ContextMiddleware(u.presign))
+	app.POST(u.prefix+"/complete", app.ContextMiddleware(u.complete))
+
+	log.Printf("📤 Direct uploads to %s at %s", opts.Bucket.Name, u.prefix)
+	return u
+}
+
+func (u *Uploads) presign(c *rebolo.Context) error {
+	if u.opts.Authorize != nil && !u.opts.Authorize(c.Request) {
+		return rebolo.ErrForbidden
+	}
+	var req presignRequest
+	if err := c.Bind(&req); err != nil {
+		return rebolo.ErrBadRequest.Wrap(err)
+	}
+	req.ContentType = strings.TrimSpace(req.ContentType)
+	if req.ContentType == "" {
+		req.ContentType = "application/octet-stream"
+	}
+	switch {
+	case req.Size <= 0:
+		return rebolo.ErrBadRequest.WithMessage("size is required")
+	case req.Size > u.opts.MaxSize:
+		return rebolo.ErrBadRequest.WithMessage("file is larger than " + strconv.FormatInt(u.opts.MaxSize, 10) + " bytes")
+	case !u.accepts(req.ContentType):
+		return rebolo.ErrBadRequest.WithMessage(req.ContentType + " files aren't accepted")
+	}
+
+	key := u.opts.Prefix + time.Now().UTC().Format("2006/01/02/") + randomID() + "/" + safeName(req.Filename)
+	var (
+		presigned *Presigned
+		err       error
+	)
+	switch strings.ToUpper(req.Method) {
+	case "", http.MethodPut:
+		presigned, err = u.opts.Bucket.PresignPut(key, req.ContentType, req.Size, u.opts.Expires)
+	case http.MethodPost:
+		presigned, err = u.opts.Bucket.PresignPost(key, req.ContentType, u.opts.MaxSize, u.opts.Expires)
+	default:
+		return rebolo.ErrBadRequest.WithMessage("method must be PUT or POST")
+	}
+	if err != nil {
+		return err
+	}
+
+	sess, err := c.Session()
+	if err != nil {
+		return err
+	}
+	pending := append(pendingUploads(sess.GetString(pendingKey)), key+"\t"+clientName(req.Filename))
+	if len(pending) > maxPending {
+		pending = pending[len(pending)-maxPending:]
+	}
+	sess.Set(pendingKey, strings.Join(pending, "\n"))
+	if err := sess.Save(); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, presignResponse{Key: key, Presigned: presigned})
+}
+
+func (u *Uploads) complete(c *rebolo.Context) error {
+	if u.opts.Authorize != nil && !u.opts.Authorize(c.Request) {
+		return rebolo.ErrForbidden
+	}
+	var req completeRequest
+	if err := c.Bind(&req); err != nil {
+		return rebolo.ErrBadRequest.Wrap(err)
+	}
+	sess, err := c.Session()
+	if err != nil {
+		return err
+	}
+	pending := pendingUploads(sess.GetString(pendingKey))
+	index, filename := -1, ""
+	for i, p := range pending {
+		if key, name, _ := strings.Cut(p, "\t"); key == req.Key && req.Key != "" {
+			index, filename = i, name
+		}
+	}
+	if index < 0 {
+		return rebolo.ErrNotFound.WithMessage("no pending upload with this key")
+	}
+
+	obj, err := u.opts.Bucket.Stat(c.Context(), req.Key)
+	if errors.Is(err, ErrNotFound) {
+		return rebolo.ErrUnprocessable.WithMessage("the file hasn't been uploaded")
+	}
+	if err != nil {
+		return err
+	}
+	if obj.Size > u.opts.MaxSize || !u.accepts(obj.ContentType) {
+		// Only a client going around the presigned request gets here
+		log.Printf("⚠️  Upload %s of %d bytes of %s rejected", obj.Key, obj.Size, obj.ContentType)
+		return rebolo.ErrUnprocessable.WithMessage("the uploaded file isn't accepted")
+	}
+
+	upload := Upload{
+		Key:         obj.Key,
+		Filename:    filename,
+		ContentType: obj.ContentType,
+		Size:        obj.Size,
+		ETag:        obj.ETag,
+		URL:         u.opts.Bucket.URL(obj.Key),
+	}
+	var result interface{} = upload
+	if u.opts.OnComplete != nil {
+		if result, err = u.opts.OnComplete(c, upload); err != nil {
+			return err
+		}
+	}
+
+	sess.Set(pendingKey, strings.Join(append(pending[:index], pending[index+1:]...), "\n"))
+	if err := sess.Save(); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, result)
+}
+
+// accepts reports whether contentType is one of Options.ContentTypes
+func (u *Uploads) accepts(contentType string) bool {
+	if len(u.opts.ContentTypes) == 0 {
+		return true
+	}
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	for _, accepted := range u.opts.ContentTypes {
+		accepted = strings.ToLower(accepted)
+		if accepted == contentType || strings.HasSuffix(accepted, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(accepted, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+func pendingUploads(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// safeName returns the base of filename with only letters, digits, dots,
+// dashes and underscores, to be part of a key
+func safeName(filename string) string {
+	name := path.Base(strings.ReplaceAll(filename, `\`, "/"))
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '.', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('-')
+		}
+	}
+	name = strings.Trim(b.String(), ".-")
+	if len(name) > 100 {
+		name = name[len(name)-100:]
+	}
+	if name == "" {
+		return "file"
+	}
+	return name
+}
+
+// clientName returns filename without its directories and the
+// characters separating pending uploads
+func clientName(filename string) string {
+	name := path.Base(strings.ReplaceAll(filename, `\`, "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	return strings.Join(strings.Fields(name), " ")
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,97 @@
+package validation
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// binder parses a request value into a value of its type
+type binder func(value string) (reflect.Value, error)
+
+var (
+	bindersMu sync.RWMutex
+	binders   = map[reflect.Type]binder{}
+)
+
+// RegisterBinder makes Bind parse fields of type T with fn, for types
+// that aren't strings, numbers or booleans:
+//
+//	validation.RegisterBinder(func(s string) (Status, error) {
+//		return ParseStatus(s)
+//	})
+//
+// Types implementing encoding.TextUnmarshaler, like most UUID packages,
+// bind without registering. time.Time and time.Duration are registered
+// already.
+func RegisterBinder[T any](fn func(value string) (T, error)) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	bindersMu.Lock()
+	defer bindersMu.Unlock()
+	binders[typ] = func(value string) (reflect.Value, error) {
+		v, err := fn(value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(&v).Elem(), nil
+	}
+}
+
+// TimeLayouts are the layouts time.Time fields are parsed with, in order.
+// They cover RFC 3339 and what date and datetime-local inputs send.
+var TimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+func init() {
+	RegisterBinder(parseTime)
+	RegisterBinder(time.ParseDuration)
+}
+
+// parseTime parses value with the first of TimeLayouts that fits
+func parseTime(value string) (time.Time, error) {
+	for _, layout := range TimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as a time", value)
+}
+
+// bindCustom sets field with its registered binder or its UnmarshalText
+// method. It reports false when the type has neither.
+func bindCustom(field reflect.Value, value string) (bool, error) {
+	bindersMu.RLock()
+	bind, ok := binders[field.Type()]
+	bindersMu.RUnlock()
+	if ok {
+		v, err := bind(value)
+		if err != nil {
+			return true, err
+		}
+		field.Set(v)
+		return true, nil
+	}
+
+	if field.CanAddr() {
+		if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return true, u.UnmarshalText([]byte(value))
+		}
+	}
+	return false, nil
+}
+
+// isCustom reports whether a type binds through bindCustom
+func isCustom(typ reflect.Type) bool {
+	bindersMu.RLock()
+	_, ok := binders[typ]
+	bindersMu.RUnlock()
+	return ok || reflect.PointerTo(typ).Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem())
+}
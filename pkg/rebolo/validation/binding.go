@@ -3,19 +3,38 @@ package validation
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"reflect"
 	"strconv"
 	"strings"
+
+	"github.com/gorilla/mux"
 )
 
 // Bind binds request data to a struct
-// Supports form data, JSON, and query parameters
+// Supports form data, JSON, and query parameters. Fields tagged
+// `query:"page"` or `param:"id"` are then set from the query string and
+// the route's path parameters, whatever the body was:
+//
+//	type ListPosts struct {
+//		AuthorID int      `param:"author_id"`
+//		Page     int      `query:"page"`
+//		Tags     []string `query:"tag"` // ?tag=go&tag=web
+//	}
 func Bind(r *http.Request, v interface{}) error {
 	if v == nil {
 		return errors.New("bind target cannot be nil")
 	}
 
+	if err := bindBody(r, v); err != nil {
+		return err
+	}
+	return bindURL(r, v)
+}
+
+// bindBody binds the request's JSON, multipart or form body
+func bindBody(r *http.Request, v interface{}) error {
 	// Check if it's JSON request
 	contentType := r.Header.Get("Content-Type")
 	if strings.Contains(contentType, "application/json") {
@@ -35,6 +54,99 @@ func Bind(r *http.Request, v interface{}) error {
 	return bindForm(r, v)
 }
 
+// bindURL sets the fields tagged query or param. Path parameters are
+// set last, so the ID in the URL wins over one sent in the body. Values
+// that don't parse are returned as ValidationErrors.
+func bindURL(r *http.Request, v interface{}) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return nil // JSON may bind into maps and slices
+	}
+	val = val.Elem()
+	typ := val.Type()
+
+	query := r.URL.Query()
+	params := mux.Vars(r)
+
+	var errs ValidationErrors
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Field(i)
+		typeField := typ.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+
+		if name := typeField.Tag.Get("query"); name != "" && name != "-" {
+			if values, ok := query[name]; ok {
+				if bad, err := setValues(field, values); err != nil {
+					errs = append(errs, parseError(name, bad, field))
+				}
+			}
+		}
+		if name := typeField.Tag.Get("param"); name != "" && name != "-" {
+			if value, ok := params[name]; ok {
+				if err := setField(field, value); err != nil {
+					errs = append(errs, parseError(name, value, field))
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// setValues sets a field from every value of a query parameter, slices
+// getting all of them and other fields the first. It returns the value
+// that didn't parse.
+func setValues(field reflect.Value, values []string) (string, error) {
+	if field.Kind() != reflect.Slice || isCustom(field.Type()) {
+		return values[0], setField(field, values[0])
+	}
+	slice := reflect.MakeSlice(field.Type(), 0, len(values))
+	for _, value := range values {
+		part := reflect.New(field.Type()).Elem()
+		if err := setField(part, value); err != nil {
+			return value, err
+		}
+		slice = reflect.AppendSlice(slice, part)
+	}
+	field.Set(slice)
+	return "", nil
+}
+
+// parseError describes a query or path parameter of the wrong type
+func parseError(name, value string, field reflect.Value) ValidationError {
+	typ := field.Type()
+	for (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Ptr) && !isCustom(typ) {
+		typ = typ.Elem()
+	}
+
+	expected := "text"
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		expected = "whole number"
+	case reflect.Float32, reflect.Float64:
+		expected = "number"
+	case reflect.Bool:
+		expected = "boolean"
+	}
+	if isCustom(typ) {
+		// time.Duration is an int64, but "whole number" would mislead
+		expected = "valid " + strings.ToLower(typ.Name())
+	}
+
+	return ValidationError{
+		Field:   name,
+		Tag:     "type",
+		Value:   value,
+		Message: fmt.Sprintf("%s must be a %s", name, expected),
+	}
+}
+
 // bindMultipart binds multipart form data (including files) to struct
 func bindMultipart(r *http.Request, v interface{}) error {
 	// Parse multipart form (32MB max memory)
@@ -63,13 +175,7 @@ func bindMultipart(r *http.Request, v interface{}) error {
 			continue
 		}
 
-		// Get form tag or use lowercase field name
-		tag := typeField.Tag.Get("form")
-		if tag == "" {
-			tag = strings.ToLower(typeField.Name)
-		}
-
-		// Skip if tag is "-"
+		tag := formName(typeField)
 		if tag == "-" {
 			continue
 		}
@@ -128,13 +234,7 @@ func bindForm(r *http.Request, v interface{}) error {
 			continue
 		}
 
-		// Get form tag or use lowercase field name
-		tag := typeField.Tag.Get("form")
-		if tag == "" {
-			tag = strings.ToLower(typeField.Name)
-		}
-
-		// Skip if tag is "-"
+		tag := formName(typeField)
 		if tag == "-" {
 			continue
 		}
@@ -154,8 +254,26 @@ func bindForm(r *http.Request, v interface{}) error {
 	return nil
 }
 
-// setField sets a struct field value from string
+// formName returns the form field a struct field binds from: its form
+// tag or lowercase name. Fields only tagged query or param, or tagged "-",
+// return "-".
+func formName(typeField reflect.StructField) string {
+	if tag := typeField.Tag.Get("form"); tag != "" {
+		return tag
+	}
+	if typeField.Tag.Get("query") != "" || typeField.Tag.Get("param") != "" {
+		return "-"
+	}
+	return strings.ToLower(typeField.Name)
+}
+
+// setField sets a struct field value from string, with the binder
+// registered for its type if any (see RegisterBinder)
 func setField(field reflect.Value, value string) error {
+	if ok, err := bindCustom(field, value); ok {
+		return err
+	}
+
 	switch field.Kind() {
 	case reflect.String:
 		field.SetString(value)
@@ -189,8 +307,31 @@ func setField(field reflect.Value, value string) error {
 		}
 		field.SetBool(boolVal)
 
+	case reflect.Ptr:
+		elem := reflect.New(field.Type().Elem())
+		if err := setField(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+
+	case reflect.Slice:
+		// Comma separated values, e.g. "go,web"
+		slice := reflect.MakeSlice(field.Type(), 0, strings.Count(value, ",")+1)
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setField(elem, part); err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, elem)
+		}
+		field.Set(slice)
+
 	default:
-		return errors.New("unsupported field type: " + field.Kind().String())
+		return errors.New("unsupported field type: " + field.Type().String() + ", see RegisterBinder")
 	}
 
 	return nil
@@ -0,0 +1,146 @@
+package watcher
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFile lists patterns of files whose changes are ignored, one per
+// line, next to config.yml
+const IgnoreFile = ".rebolignore"
+
+// DefaultIgnore are always ignored: editor swap and backup files, and
+// test files and artifacts, which don't change the running app
+var DefaultIgnore = []string{
+	"*~", "*.swp", "*.swo", "*.swx", ".#*", "#*#", "4913", "*.tmp",
+	"*_test.go", "*.test", "coverage.out", "testdata/",
+}
+
+// Ignore matches paths against gitignore-style patterns:
+//
+//	*.log        a file or directory with a matching name, anywhere
+//	tmp/         a directory with a matching name and everything in it
+//	public/build a path from the root (patterns with a slash), and everything in it
+//	!keep.log    re-includes what an earlier pattern ignored
+//
+// Names are matched with path.Match. Later patterns win.
+type Ignore struct {
+	root     string
+	patterns []ignorePattern
+}
+
+type ignorePattern struct {
+	glob    string
+	negate  bool
+	dirOnly bool
+	rooted  bool // matched against the path from the root, not names
+}
+
+// NewIgnore matches paths under root against patterns
+func NewIgnore(root string, patterns ...string) *Ignore {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	i := &Ignore{root: root}
+	i.Add(patterns...)
+	return i
+}
+
+// LoadIgnore returns the DefaultIgnore patterns, those in root's
+// .rebolignore and patterns, in that order
+func LoadIgnore(root string, patterns ...string) (*Ignore, error) {
+	i := NewIgnore(root, DefaultIgnore...)
+
+	file, err := os.Open(filepath.Join(root, IgnoreFile))
+	if err != nil && !os.IsNotExist(err) {
+		return i, err
+	}
+	if err == nil {
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			i.Add(scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return i, err
+		}
+	}
+
+	i.Add(patterns...)
+	return i, nil
+}
+
+// Add appends patterns, skipping blank lines and # comments
+func (i *Ignore) Add(patterns ...string) {
+	for _, line := range patterns {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p := ignorePattern{}
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			p.rooted = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		p.glob = line
+		i.patterns = append(i.patterns, p)
+	}
+}
+
+// Match reports whether path, absolute or relative to the working
+// directory, is ignored. isDir tells whether path is a directory.
+func (i *Ignore) Match(name string, isDir bool) bool {
+	if i == nil || len(i.patterns) == 0 {
+		return false
+	}
+	if abs, err := filepath.Abs(name); err == nil {
+		if rel, err := filepath.Rel(i.root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+	}
+	parts := strings.Split(filepath.ToSlash(name), "/")
+
+	ignored := false
+	for _, p := range i.patterns {
+		if p.matches(parts, isDir) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// matches checks every directory leading to the path too, a pattern
+// matching a directory matches everything in it
+func (p ignorePattern) matches(parts []string, isDir bool) bool {
+	for n := range parts {
+		// Only the last part can be a file
+		dir := n < len(parts)-1 || isDir
+		if p.dirOnly && !dir {
+			continue
+		}
+		var ok bool
+		if p.rooted {
+			ok, _ = path.Match(p.glob, path.Join(parts[:n+1]...))
+		} else {
+			ok, _ = path.Match(p.glob, parts[n])
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"log"
 	"os"
+	pathpkg "path"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/assets"
 	"github.com/fsnotify/fsnotify"
 )
 
@@ -36,6 +38,9 @@ type FileWatcher struct {
 	debounce    map[string]time.Time
 	debounceMu  sync.Mutex
 	watchDirs   []string
+	ignored     []string // patterns added with Ignore, on top of .rebolignore
+	ignore      *Ignore
+	handlers    []changeHandler
 	stats       WatcherStats
 	statsMu     sync.RWMutex
 }
@@ -43,10 +48,16 @@ type FileWatcher struct {
 // FileChangeEvent represents a file change notification
 type FileChangeEvent struct {
 	Path      string
-	EventType string // "template", "asset", "code"
+	EventType string // "template", "asset", "code", "build"
 	Timestamp time.Time
 }
 
+// changeHandler is a function added with OnChange
+type changeHandler struct {
+	glob string
+	fn   func(FileChangeEvent)
+}
+
 // NewFileWatcher creates a new file watcher
 func NewFileWatcher(app AppInterface, watchDirs []string) *FileWatcher {
 	fw := &FileWatcher{
@@ -60,16 +71,66 @@ func NewFileWatcher(app AppInterface, watchDirs []string) *FileWatcher {
 	return fw
 }
 
+// Ignore skips changes to paths matching patterns, see Ignore for the
+// syntax. The DefaultIgnore patterns and those in .rebolignore always
+// apply. Call it before Start.
+func (fw *FileWatcher) Ignore(patterns ...string) {
+	fw.ignored = append(fw.ignored, patterns...)
+}
+
+// OnChange calls fn with changes to files matching glob, a path from the
+// working directory where * matches within a name and ** any number of
+// directories: "locales/*.yml", "rules/**/*.json". The directory the glob
+// starts from is watched too. fn runs on the watcher's goroutine, one
+// change at a time, so it should start a goroutine for slow work. It can
+// be called before or after Start.
+func (fw *FileWatcher) OnChange(glob string, fn func(FileChangeEvent)) {
+	glob = filepath.ToSlash(filepath.Clean(glob))
+	dir := globDir(glob)
+
+	fw.mu.Lock()
+	fw.handlers = append(fw.handlers, changeHandler{glob: glob, fn: fn})
+	started := fw.watcher != nil
+	watched := false
+	for _, d := range fw.watchDirs {
+		if rel, err := filepath.Rel(d, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			watched = true
+			break
+		}
+	}
+	if !watched {
+		fw.watchDirs = append(fw.watchDirs, dir)
+	}
+	fw.mu.Unlock()
+
+	if started && !watched {
+		if err := fw.addRecursive(dir); err != nil {
+			log.Printf("⚠️  Failed to watch %s: %v", dir, err)
+			return
+		}
+		log.Printf("👁️  Watching: %s", dir)
+	}
+}
+
 // Start starts the file watcher
 func (fw *FileWatcher) Start() error {
+	ignore, err := LoadIgnore(".", fw.ignored...)
+	if err != nil {
+		log.Printf("⚠️  Failed to read %s: %v", IgnoreFile, err)
+	}
+	fw.ignore = ignore
+
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return err
 	}
+	fw.mu.Lock()
 	fw.watcher = watcher
+	watchDirs := fw.watchDirs
+	fw.mu.Unlock()
 
 	// Add directories to watch
-	for _, dir := range fw.watchDirs {
+	for _, dir := range watchDirs {
 		if err := fw.addRecursive(dir); err != nil {
 			log.Printf("⚠️  Failed to watch %s: %v", dir, err)
 			continue
@@ -95,9 +156,10 @@ func (fw *FileWatcher) addRecursive(dir string) error {
 		if err != nil {
 			return err
 		}
-		// Skip hidden directories and node_modules
+		// Skip hidden directories, node_modules and ignored ones
 		if info != nil && info.IsDir() {
-			if strings.HasPrefix(info.Name(), ".") || info.Name() == "node_modules" {
+			name := info.Name()
+			if (strings.HasPrefix(name, ".") && name != ".") || name == "node_modules" || fw.ignore.Match(path, true) {
 				return filepath.SkipDir
 			}
 			return fw.watcher.Add(path)
@@ -126,11 +188,32 @@ func (fw *FileWatcher) processEvents() {
 
 // handleEvent processes a single file system event
 func (fw *FileWatcher) handleEvent(event fsnotify.Event) {
+	// Permission changes don't change content
+	if event.Op == fsnotify.Chmod {
+		return
+	}
+
+	if fw.ignore.Match(event.Name, false) {
+		return
+	}
+
+	// Watch directories created after startup
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := fw.addRecursive(event.Name); err != nil {
+				log.Printf("⚠️  Failed to watch %s: %v", event.Name, err)
+			}
+			return
+		}
+	}
+
 	// Debounce: ignore rapid successive events for the same file
 	if !fw.shouldProcess(event.Name) {
 		return
 	}
 
+	fw.runHandlers(event.Name)
+
 	ext := filepath.Ext(event.Name)
 	var eventType string
 
@@ -144,6 +227,12 @@ func (fw *FileWatcher) handleEvent(event fsnotify.Event) {
 	case ".go":
 		eventType = "code"
 		log.Printf("🔄 Code changed: %s (restart required)", event.Name)
+	case ".json":
+		// rebolo dev recorded an asset build
+		if filepath.Base(event.Name) != filepath.Base(assets.StatusFile) {
+			return
+		}
+		eventType = "build"
 	default:
 		return // Ignore other file types
 	}
@@ -176,6 +265,78 @@ func (fw *FileWatcher) handleEvent(event fsnotify.Event) {
 	fw.notifySubscribers(changeEvent)
 }
 
+// runHandlers calls the OnChange functions whose glob matches path
+func (fw *FileWatcher) runHandlers(path string) {
+	fw.mu.RLock()
+	handlers := fw.handlers
+	fw.mu.RUnlock()
+	if len(handlers) == 0 {
+		return
+	}
+
+	name := path
+	if abs, err := filepath.Abs(path); err == nil {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, abs); err == nil {
+				name = rel
+			}
+		}
+	}
+	name = filepath.ToSlash(name)
+
+	event := FileChangeEvent{Path: path, EventType: "file", Timestamp: time.Now()}
+	for _, h := range handlers {
+		if !matchGlob(strings.Split(h.glob, "/"), strings.Split(name, "/")) {
+			continue
+		}
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					log.Printf("❌ OnChange %s panicked: %v", h.glob, err)
+				}
+			}()
+			h.fn(event)
+		}()
+	}
+}
+
+// matchGlob matches the parts of a path against those of a glob, where a
+// ** part matches any number of directories
+func matchGlob(glob, parts []string) bool {
+	if len(glob) == 0 {
+		return len(parts) == 0
+	}
+	if glob[0] == "**" {
+		for n := 0; n <= len(parts); n++ {
+			if matchGlob(glob[1:], parts[n:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := pathpkg.Match(glob[0], parts[0]); !ok {
+		return false
+	}
+	return matchGlob(glob[1:], parts[1:])
+}
+
+// globDir returns the directory a glob starts from, the parts before the
+// first one with a wildcard
+func globDir(glob string) string {
+	parts := strings.Split(glob, "/")
+	n := 0
+	for n < len(parts)-1 && !strings.ContainsAny(parts[n], "*?[\\") {
+		n++
+	}
+	if n == 0 {
+		return "."
+	}
+	return filepath.FromSlash(strings.Join(parts[:n], "/"))
+}
+
 // shouldProcess implements debouncing to avoid processing the same file too frequently
 func (fw *FileWatcher) shouldProcess(path string) bool {
 	fw.debounceMu.Lock()
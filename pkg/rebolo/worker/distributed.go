@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Palaciodiego008/rebololang/pkg/rebolo/broker"
+)
+
+// Distributed performs jobs through a message broker: Perform publishes
+// them on "jobs.<group>.<queue>" and every instance of the app takes its
+// share, running them on its Simple worker with its pools and queues.
+// A job is acknowledged once the instance taking it has room for it.
+//
+// PerformIn and PerformAt wait in the process that schedules the job, so
+// jobs scheduled for later are lost if it stops before.
+type Distributed struct {
+	*Simple
+	broker broker.Broker
+	group  string
+}
+
+// NewDistributed performs the jobs of local through b, group names the
+// app, whose instances share its jobs
+func NewDistributed(local *Simple, b broker.Broker, group string) *Distributed {
+	return &Distributed{Simple: local, broker: b, group: group}
+}
+
+// Start starts the local worker and takes jobs from the broker
+func (d *Distributed) Start(ctx context.Context) error {
+	if err := d.Simple.Start(ctx); err != nil {
+		return err
+	}
+	return d.broker.Subscribe("jobs."+d.group+".>", d.group, func(ctx context.Context, msg broker.Message) error {
+		var job Job
+		if err := json.Unmarshal(msg.Data, &job); err != nil {
+			return fmt.Errorf("decoding job on %s: %w", msg.Subject, err)
+		}
+		return d.Simple.PerformContext(ctx, job)
+	})
+}
+
+// Perform publishes a job for an instance to perform
+func (d *Distributed) Perform(job Job) error {
+	return d.PerformContext(context.Background(), job)
+}
+
+// PerformContext publishes a job unless ctx is already done
+func (d *Distributed) PerformContext(ctx context.Context, job Job) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("not performing job %s: %w", job, err)
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("encoding job %s: %w", job.Handler, err)
+	}
+	queue := job.Queue
+	if queue == "" {
+		queue = QueueDefault
+	}
+	return d.broker.Publish(ctx, "jobs."+d.group+"."+queue, data)
+}
+
+// PerformAt publishes a job at a particular time
+func (d *Distributed) PerformAt(job Job, t time.Time) error {
+	return d.PerformIn(job, time.Until(t))
+}
+
+// PerformIn publishes a job after waiting for d
+func (d *Distributed) PerformIn(job Job, wait time.Duration) error {
+	time.AfterFunc(wait, func() {
+		if err := d.Perform(job); err != nil {
+			d.logger.Println("ERROR:", err)
+		}
+	})
+	return nil
+}
@@ -1,6 +1,9 @@
 package worker
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Args are the arguments passed into a job
 type Args map[string]interface{}
@@ -18,6 +21,37 @@ type Job struct {
 	Args Args
 	// Handler that will be run by the worker
 	Handler string
+	// Unique drops copies of the job performed again within a window
+	Unique *Uniqueness `json:",omitempty"`
+}
+
+// Uniqueness makes a job run once however many times it's performed
+// within TTL, see Unique
+type Uniqueness struct {
+	Key string
+	TTL time.Duration
+}
+
+// Unique runs a job once per ttl: copies performed before ttl passes
+// since the first are dropped. Jobs are copies when they have the same
+// handler and key, an empty key compares their args.
+//
+//	app.Perform(worker.Job{
+//		Handler: "refresh_cache",
+//		Args:    worker.Args{"post_id": id},
+//		Unique:  worker.Unique("", time.Minute),
+//	})
+func Unique(key string, ttl time.Duration) *Uniqueness {
+	return &Uniqueness{Key: key, TTL: ttl}
+}
+
+// uniqueKey identifies the copies of a job
+func (j Job) uniqueKey() string {
+	if j.Unique.Key != "" {
+		return j.Handler + ":" + j.Unique.Key
+	}
+	// Maps are marshalled with sorted keys
+	return j.Handler + ":" + j.Args.String()
 }
 
 func (j Job) String() string {
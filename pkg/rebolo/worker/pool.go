@@ -0,0 +1,137 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is returned by Perform for a job whose handler has no free
+// slot, when its pool rejects jobs instead of blocking
+var ErrQueueFull = errors.New("worker queue is full")
+
+// PoolOptions limit how many jobs of a handler run and wait at once
+type PoolOptions struct {
+	// Concurrency is how many jobs run at once, 0 for no limit
+	Concurrency int
+	// QueueSize is how many more jobs wait for one of them to finish.
+	// Past that Perform blocks until there's room, or fails with
+	// ErrQueueFull when Reject is set.
+	QueueSize int
+	Reject    bool
+}
+
+// PoolStats are the metrics of a handler's jobs
+type PoolStats struct {
+	Running   int
+	Queued    int   // waiting for a free slot
+	Processed int64 // finished, failed ones included
+	Failed    int64
+	Rejected  int64
+	// TotalTime is the time spent running jobs, MaxTime the longest job
+	TotalTime time.Duration
+	MaxTime   time.Duration
+}
+
+// AvgTime is the average time a job ran for
+func (s PoolStats) AvgTime() time.Duration {
+	if s.Processed == 0 {
+		return 0
+	}
+	return s.TotalTime / time.Duration(s.Processed)
+}
+
+// pool runs the jobs of a handler, slots holds a token per job running or
+// queued and running one per job running
+type pool struct {
+	opts    PoolOptions
+	slots   chan struct{}
+	running chan struct{}
+
+	mu    sync.Mutex
+	stats PoolStats
+}
+
+func newPool(opts PoolOptions) *pool {
+	p := &pool{opts: opts}
+	if opts.Concurrency > 0 {
+		p.slots = make(chan struct{}, opts.Concurrency+opts.QueueSize)
+		p.running = make(chan struct{}, opts.Concurrency)
+	}
+	return p
+}
+
+// acquire takes a slot for a job, waiting for one unless the pool
+// rejects jobs
+func (p *pool) acquire(ctx context.Context) error {
+	if p.slots == nil {
+		return nil
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if p.opts.Reject {
+		p.mu.Lock()
+		p.stats.Rejected++
+		p.mu.Unlock()
+		return ErrQueueFull
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for a free worker: %w", ctx.Err())
+	}
+}
+
+// release frees the slot of a job that won't run
+func (p *pool) release() {
+	if p.slots != nil {
+		<-p.slots
+	}
+}
+
+// begin waits until a job holding a slot may start
+func (p *pool) begin() {
+	if p.slots != nil {
+		p.running <- struct{}{}
+	}
+	p.mu.Lock()
+	p.stats.Running++
+	p.mu.Unlock()
+}
+
+// end records a job that ran for took and frees its slot
+func (p *pool) end(took time.Duration, err error) {
+	p.mu.Lock()
+	p.stats.Running--
+	p.stats.Processed++
+	if err != nil {
+		p.stats.Failed++
+	}
+	p.stats.TotalTime += took
+	if took > p.stats.MaxTime {
+		p.stats.MaxTime = took
+	}
+	p.mu.Unlock()
+
+	if p.slots != nil {
+		<-p.running
+		<-p.slots
+	}
+}
+
+func (p *pool) snapshot() PoolStats {
+	p.mu.Lock()
+	stats := p.stats
+	p.mu.Unlock()
+	if p.slots != nil {
+		stats.Running = len(p.running)
+		stats.Queued = max(len(p.slots)-stats.Running, 0)
+	}
+	return stats
+}
@@ -0,0 +1,132 @@
+package worker
+
+import (
+	"context"
+	"sync"
+)
+
+// Queues jobs are performed on, Job.Queue picks one. Jobs without a queue
+// go to QueueDefault.
+const (
+	QueueCritical = "critical"
+	QueueDefault  = "default"
+	QueueLow      = "low"
+)
+
+// Priorities of the built-in queues
+const (
+	PriorityLow      = -10
+	PriorityDefault  = 0
+	PriorityCritical = 10
+)
+
+// QueueOptions configure a named queue: its pool limits how many of its
+// jobs run and wait at once, whatever their handler, and when the worker
+// is at its concurrency the waiting jobs of the highest priority queue
+// start first
+type QueueOptions struct {
+	PoolOptions
+	Priority int
+}
+
+// queue is a named queue and its pool
+type queue struct {
+	name     string
+	priority int
+	pool     *pool
+}
+
+// DefaultPriority is the priority of the queue name unless SetQueue sets
+// one: those of the built-in queues, PriorityDefault for others
+func DefaultPriority(name string) int {
+	switch name {
+	case QueueCritical:
+		return PriorityCritical
+	case QueueLow:
+		return PriorityLow
+	}
+	return PriorityDefault
+}
+
+// prioritySem limits how many jobs run at once across queues, a free slot
+// goes to the waiting job with the highest priority, in order of arrival
+type prioritySem struct {
+	mu      sync.Mutex
+	limit   int
+	running int
+	waiting []*waiter
+}
+
+type waiter struct {
+	priority int
+	ready    chan struct{}
+}
+
+// acquire waits for a slot, limit 0 means no limit
+func (s *prioritySem) acquire(ctx context.Context, priority int) error {
+	s.mu.Lock()
+	if s.limit <= 0 || (s.running < s.limit && len(s.waiting) == 0) {
+		s.running++
+		s.mu.Unlock()
+		return nil
+	}
+	w := &waiter{priority: priority, ready: make(chan struct{})}
+	// After the waiters of the same or a higher priority
+	i := len(s.waiting)
+	for i > 0 && s.waiting[i-1].priority < priority {
+		i--
+	}
+	s.waiting = append(s.waiting, nil)
+	copy(s.waiting[i+1:], s.waiting[i:])
+	s.waiting[i] = w
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, other := range s.waiting {
+			if other == w {
+				s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+				return ctx.Err()
+			}
+		}
+		// Handed a slot meanwhile, pass it on
+		s.next()
+		return ctx.Err()
+	}
+}
+
+// release frees a slot
+func (s *prioritySem) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next()
+}
+
+// next gives the slot of a finished job to the first waiter. Call with mu
+// held.
+func (s *prioritySem) next() {
+	if len(s.waiting) > 0 && (s.limit <= 0 || s.running <= s.limit) {
+		w := s.waiting[0]
+		s.waiting = s.waiting[1:]
+		close(w.ready)
+		return
+	}
+	s.running--
+}
+
+func (s *prioritySem) setLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = limit
+	// Start the jobs a higher limit has room for
+	for len(s.waiting) > 0 && (limit <= 0 || s.running < limit) {
+		s.running++
+		w := s.waiting[0]
+		s.waiting = s.waiting[1:]
+		close(w.ready)
+	}
+}
@@ -26,7 +26,10 @@ func NewSimpleWithContext(ctx context.Context) *Simple {
 		logger:   log.New(log.Writer(), "[Worker] ", log.LstdFlags),
 		ctx:      ctx,
 		cancel:   cancel,
-		handlers: map[string]Handler{},
+		handlers: map[string]ContextHandler{},
+		pools:    map[string]*pool{},
+		unique:   map[string]time.Time{},
+		queues:   map[string]*queue{},
 		moot:     &sync.Mutex{},
 		started:  false,
 	}
@@ -38,7 +41,11 @@ type Simple struct {
 	logger   *log.Logger
 	ctx      context.Context
 	cancel   context.CancelFunc
-	handlers map[string]Handler
+	handlers map[string]ContextHandler
+	pools    map[string]*pool
+	unique   map[string]time.Time // when the Unique jobs performed expire
+	queues   map[string]*queue
+	sem      prioritySem // Concurrency across queues
 	moot     *sync.Mutex
 	wg       sync.WaitGroup
 	started  bool
@@ -46,6 +53,17 @@ type Simple struct {
 
 // Register Handler with the worker
 func (w *Simple) Register(name string, h Handler) error {
+	if h == nil {
+		return fmt.Errorf("name or handler cannot be empty/nil")
+	}
+	return w.RegisterContext(name, func(_ context.Context, args Args) error {
+		return h(args)
+	})
+}
+
+// RegisterContext registers a ContextHandler with the worker. Its context
+// is cancelled by Stop, so long jobs can wind down their work.
+func (w *Simple) RegisterContext(name string, h ContextHandler) error {
 	if name == "" || h == nil {
 		return fmt.Errorf("name or handler cannot be empty/nil")
 	}
@@ -56,9 +74,83 @@ func (w *Simple) Register(name string, h Handler) error {
 		return fmt.Errorf("handler already mapped for name %s", name)
 	}
 	w.handlers[name] = h
+	if _, ok := w.pools[name]; !ok {
+		w.pools[name] = newPool(PoolOptions{})
+	}
 	return nil
 }
 
+// SetPool limits how many jobs of the handler name run and wait at once,
+// by default each job runs in its own goroutine as soon as it's performed
+func (w *Simple) SetPool(name string, opts PoolOptions) error {
+	if opts.Concurrency < 0 || opts.QueueSize < 0 {
+		return fmt.Errorf("invalid pool for %s: negative concurrency or queue size", name)
+	}
+
+	w.moot.Lock()
+	defer w.moot.Unlock()
+	w.pools[name] = newPool(opts)
+	return nil
+}
+
+// SetQueue configures the queue name. The critical, default and low
+// queues exist without it, with their priorities and no limits, other
+// queues get the default priority.
+func (w *Simple) SetQueue(name string, opts QueueOptions) error {
+	if opts.Concurrency < 0 || opts.QueueSize < 0 {
+		return fmt.Errorf("invalid queue %s: negative concurrency or queue size", name)
+	}
+
+	w.moot.Lock()
+	defer w.moot.Unlock()
+	w.queues[name] = &queue{name: name, priority: opts.Priority, pool: newPool(opts.PoolOptions)}
+	return nil
+}
+
+// SetConcurrency limits how many jobs run at once across queues, 0 for no
+// limit. Jobs waiting for a slot start by the priority of their queue.
+func (w *Simple) SetConcurrency(n int) {
+	w.sem.setLimit(n)
+}
+
+// QueueStats returns the metrics of each queue's jobs
+func (w *Simple) QueueStats() map[string]PoolStats {
+	w.moot.Lock()
+	defer w.moot.Unlock()
+
+	stats := make(map[string]PoolStats, len(w.queues))
+	for name, q := range w.queues {
+		stats[name] = q.pool.snapshot()
+	}
+	return stats
+}
+
+// queue returns the queue of job, call with moot held
+func (w *Simple) queue(job Job) *queue {
+	name := job.Queue
+	if name == "" {
+		name = QueueDefault
+	}
+	q, ok := w.queues[name]
+	if !ok {
+		q = &queue{name: name, priority: DefaultPriority(name), pool: newPool(PoolOptions{})}
+		w.queues[name] = q
+	}
+	return q
+}
+
+// Stats returns the metrics of each handler's jobs
+func (w *Simple) Stats() map[string]PoolStats {
+	w.moot.Lock()
+	defer w.moot.Unlock()
+
+	stats := make(map[string]PoolStats, len(w.pools))
+	for name, p := range w.pools {
+		stats[name] = p.snapshot()
+	}
+	return stats
+}
+
 // Start the worker
 func (w *Simple) Start(ctx context.Context) error {
 	w.logger.Println("starting Simple background worker")
@@ -86,47 +178,149 @@ func (w *Simple) Stop() error {
 	return nil
 }
 
-// Perform a job as soon as possible using a goroutine.
+// Perform a job as soon as possible using a goroutine. When the handler's
+// pool is full it blocks until there's room, or fails with ErrQueueFull.
 func (w *Simple) Perform(job Job) error {
+	return w.PerformContext(context.Background(), job)
+}
+
+// PerformContext performs a job unless ctx is already done, so a request
+// that was cancelled or timed out doesn't leave jobs behind. The job runs
+// with the worker's context, it isn't cancelled when the request ends.
+func (w *Simple) PerformContext(ctx context.Context, job Job) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("not performing job %s: %w", job, err)
+	}
+	if !w.claim(job) {
+		return nil
+	}
+	if err := w.perform(ctx, job); err != nil {
+		w.release(job)
+		return err
+	}
+	return nil
+}
+
+// claim reports whether job should be performed, false for copies of a
+// Unique job within its TTL
+func (w *Simple) claim(job Job) bool {
+	if job.Unique == nil {
+		return true
+	}
+	key := job.uniqueKey()
+	now := time.Now()
+
 	w.moot.Lock()
 	defer w.moot.Unlock()
+	for k, expires := range w.unique {
+		if !now.Before(expires) {
+			delete(w.unique, k)
+		}
+	}
+	if _, ok := w.unique[key]; ok {
+		w.logger.Printf("skipping duplicate job %s", job)
+		return false
+	}
+	w.unique[key] = now.Add(job.Unique.TTL)
+	return true
+}
+
+// release lets a Unique job that couldn't be performed be performed again
+func (w *Simple) release(job Job) {
+	if job.Unique == nil {
+		return
+	}
+	w.moot.Lock()
+	delete(w.unique, job.uniqueKey())
+	w.moot.Unlock()
+}
+
+// perform runs job, ctx stops waiting for room in a full pool
+func (w *Simple) perform(ctx context.Context, job Job) error {
+	w.moot.Lock()
 
 	if !w.started {
+		w.moot.Unlock()
 		return fmt.Errorf("worker is not yet started")
 	}
 
 	// Perform should not allow a job submission if the worker is not running
 	if err := w.ctx.Err(); err != nil {
+		w.moot.Unlock()
 		return fmt.Errorf("worker is not ready to perform a job: %v", err)
 	}
 
 	w.logger.Printf("performing job %s", job)
 
 	if job.Handler == "" {
+		w.moot.Unlock()
 		err := fmt.Errorf("no handler name given: %s", job)
 		w.logger.Println("ERROR:", err)
 		return err
 	}
 
-	if h, ok := w.handlers[job.Handler]; ok {
-		w.wg.Add(1)
-		go func() {
-			defer w.wg.Done()
-			err := safeRun(func() error {
-				return h(job.Args)
-			})
+	h, ok := w.handlers[job.Handler]
+	if !ok {
+		w.moot.Unlock()
+		err := fmt.Errorf("no handler mapped for name %s", job.Handler)
+		w.logger.Println("ERROR:", err)
+		return err
+	}
+	p := w.pools[job.Handler]
+	q := w.queue(job)
+	workerCtx := w.ctx
+	// Counted before waiting for room, so Stop waits for it
+	w.wg.Add(1)
+	w.moot.Unlock()
 
-			if err != nil {
-				w.logger.Println("ERROR:", err)
-			}
-			w.logger.Printf("completed job %s", job)
-		}()
-		return nil
+	// Stop cancels the wait too, without holding the lock Stop needs
+	waitCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-workerCtx.Done():
+			cancel()
+		case <-waitCtx.Done():
+		}
+	}()
+	err := q.pool.acquire(waitCtx)
+	if err == nil {
+		if err = p.acquire(waitCtx); err != nil {
+			q.pool.release()
+		}
+	}
+	if err != nil {
+		w.wg.Done()
+		err = fmt.Errorf("not performing job %s: %w", job, err)
+		w.logger.Println("ERROR:", err)
+		return err
 	}
 
-	err := fmt.Errorf("no handler mapped for name %s", job.Handler)
-	w.logger.Println("ERROR:", err)
-	return err
+	go func() {
+		defer w.wg.Done()
+		q.pool.begin()
+		p.begin()
+		var took time.Duration
+		err := w.sem.acquire(workerCtx, q.priority)
+		if err != nil {
+			err = fmt.Errorf("job %s not started: %w", job, err)
+		} else {
+			start := time.Now()
+			err = safeRun(func() error {
+				return h(workerCtx, job.Args)
+			})
+			took = time.Since(start)
+			w.sem.release()
+		}
+		p.end(took, err)
+		q.pool.end(took, err)
+
+		if err != nil {
+			w.logger.Println("ERROR:", err)
+		}
+		w.logger.Printf("completed job %s", job)
+	}()
+	return nil
 }
 
 // safeRun the function safely knowing that if it panics
@@ -157,6 +351,10 @@ func (w *Simple) PerformIn(job Job, d time.Duration) error {
 	if err := w.ctx.Err(); err != nil {
 		return fmt.Errorf("worker is not ready to perform a job: %v", err)
 	}
+	// Copies are dropped from when the job is scheduled
+	if !w.claim(job) {
+		return nil
+	}
 
 	w.wg.Add(1) // waiting job also should be counted
 	go func() {
@@ -177,7 +375,9 @@ func (w *Simple) PerformIn(job Job, d time.Duration) error {
 
 		select {
 		case <-time.After(d):
-			w.Perform(job)
+			if err := w.perform(context.Background(), job); err != nil {
+				w.release(job)
+			}
 		case <-w.ctx.Done():
 			w.cancel()
 		}
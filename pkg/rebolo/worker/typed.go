@@ -0,0 +1,95 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Definition is a job whose args are a Go type, checked at compile time
+// where it's performed and stored as JSON in the job's Args, so they
+// survive persistent backends
+//
+//	type EmailArgs struct {
+//		To      string `json:"to"`
+//		Subject string `json:"subject"`
+//	}
+//
+//	var SendEmail = worker.Define("send_email", func(ctx context.Context, args EmailArgs) error {
+//		return sendWelcome(ctx, args.To, args.Subject)
+//	})
+//
+//	SendEmail.Register(app.RegisterWorkerContext)
+//	SendEmail.Perform(app, EmailArgs{To: "ada@example.com", Subject: "Welcome"})
+type Definition[T any] struct {
+	name string
+	fn   func(context.Context, T) error
+}
+
+// Define defines the job name, run by fn with its args decoded into a T.
+// T must encode to a JSON object, usually it's a struct.
+func Define[T any](name string, fn func(ctx context.Context, args T) error) *Definition[T] {
+	return &Definition[T]{name: name, fn: fn}
+}
+
+// Performer performs jobs, it's a Worker or the app
+type Performer interface {
+	Perform(Job) error
+}
+
+// Name is the handler name of the job
+func (d *Definition[T]) Name() string {
+	return d.name
+}
+
+// Register registers the job's handler with register, the RegisterContext
+// method of a worker or the app's RegisterWorkerContext
+func (d *Definition[T]) Register(register func(string, ContextHandler) error) error {
+	return register(d.name, d.Handler())
+}
+
+// Handler decodes a job's Args and runs the job with them
+func (d *Definition[T]) Handler() ContextHandler {
+	return func(ctx context.Context, args Args) error {
+		v, err := d.Decode(args)
+		if err != nil {
+			return err
+		}
+		return d.fn(ctx, v)
+	}
+}
+
+// Job returns the job to perform with args
+func (d *Definition[T]) Job(args T) (Job, error) {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return Job{}, fmt.Errorf("encoding args of job %s: %w", d.name, err)
+	}
+	var encoded Args
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return Job{}, fmt.Errorf("args of job %s must encode to a JSON object: %w", d.name, err)
+	}
+	return Job{Handler: d.name, Args: encoded}, nil
+}
+
+// Perform performs the job with args on p
+func (d *Definition[T]) Perform(p Performer, args T) error {
+	job, err := d.Job(args)
+	if err != nil {
+		return err
+	}
+	return p.Perform(job)
+}
+
+// Decode decodes a job's Args into a T
+func (d *Definition[T]) Decode(args Args) (T, error) {
+	var v T
+	data, err := json.Marshal(args)
+	if err != nil {
+		return v, fmt.Errorf("decoding args of job %s: %w", d.name, err)
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, fmt.Errorf("decoding args of job %s: %w", d.name, err)
+	}
+	return v, nil
+}
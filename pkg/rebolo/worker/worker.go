@@ -9,6 +9,10 @@ import (
 // a slice of arguments
 type Handler func(Args) error
 
+// ContextHandler is a Handler that also gets the worker's context, done
+// when the worker stops, to pass to the queries and calls it makes
+type ContextHandler func(context.Context, Args) error
+
 // Worker interface that needs to be implemented to be considered
 // a "worker"
 type Worker interface {